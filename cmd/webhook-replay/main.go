@@ -0,0 +1,120 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+// webhook-replay lists the webhook deliveries in the dead-letter bucket
+// of the bot and replays them through its replay API.
+//
+//	webhook-replay -url https://bot.internal/webhooks/admin -failed
+//	webhook-replay -url https://bot.internal/webhooks/admin <delivery-id>...
+//	webhook-replay -url https://bot.internal/webhooks/admin -all
+//
+// The API token is read from WEBHOOK_REPLAY_TOKEN.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/puerco/mattermod-refactor/pkg/webhook"
+	"github.com/sirupsen/logrus"
+)
+
+const tokenEnvVar = "WEBHOOK_REPLAY_TOKEN"
+
+func main() {
+	apiURL := flag.String("url", "", "address where the replay API is mounted")
+	listFailed := flag.Bool("failed", false, "list the deliveries in the dead-letter bucket")
+	replayAll := flag.Bool("all", false, "replay all the deliveries in the dead-letter bucket")
+	flag.Parse()
+
+	token := os.Getenv(tokenEnvVar)
+	if *apiURL == "" || token == "" {
+		logrus.Fatalf("The API address (-url) and the %s environment variable are required", tokenEnvVar)
+	}
+	c := &client{url: strings.TrimSuffix(*apiURL, "/"), token: token, http: &http.Client{Timeout: 10 * time.Minute}}
+
+	ids := flag.Args()
+	if *listFailed || *replayAll {
+		failed, err := c.failed()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if *listFailed {
+			for _, d := range failed {
+				fmt.Printf("%s\t%s\t%s\t%s\n", d.ID, d.Event, d.ReceivedAt.Format(time.RFC3339), d.Error)
+			}
+			return
+		}
+		for _, d := range failed {
+			ids = append(ids, d.ID)
+		}
+	}
+
+	errs := 0
+	for _, id := range ids {
+		if err := c.replay(id); err != nil {
+			logrus.Errorf("Replaying delivery %s: %v", id, err)
+			errs++
+			continue
+		}
+		logrus.Infof("Replayed delivery %s", id)
+	}
+	if errs > 0 {
+		os.Exit(1)
+	}
+}
+
+// client talks to the replay API
+type client struct {
+	url   string
+	token string
+	http  *http.Client
+}
+
+// do sends a request to the API and returns the body of the response
+func (c *client) do(method, path string) ([]byte, error) {
+	req, err := http.NewRequest(method, c.url+path, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "building request")
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "calling %s", path)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading response of %s", path)
+	}
+	if resp.StatusCode >= 300 {
+		return nil, errors.Errorf("%s returned %s: %s", path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+// failed returns the deliveries in the dead-letter bucket
+func (c *client) failed() ([]webhook.FailedDelivery, error) {
+	body, err := c.do(http.MethodGet, "/failed")
+	if err != nil {
+		return nil, err
+	}
+	failed := []webhook.FailedDelivery{}
+	if err := json.Unmarshal(body, &failed); err != nil {
+		return nil, errors.Wrap(err, "decoding failed deliveries")
+	}
+	return failed, nil
+}
+
+// replay replays a delivery
+func (c *client) replay(id string) error {
+	_, err := c.do(http.MethodPost, "/replay/"+id)
+	return err
+}
@@ -19,9 +19,6 @@ const (
 	gitCommand      = "git"
 	rebaseMagic     = ".git/rebase-apply"
 	newBranchSlug   = "automated-cherry-pick-of-"
-	REBASE          = "rebase"
-	MERGE           = "merge"
-	SQUASH          = "squash"
 	prTitleTemplate = "Automated cherry pick of #%d on %s"
 	prBodyTemplate  = `Automated cherry pick of #%d on %s
 
@@ -52,6 +49,9 @@ func NewCherryPickerWithOptions(opts Options) *CherryPicker {
 	if opts.RepoPath == "" {
 		opts.RepoPath = defaultCherryPickerOpts.RepoPath
 	}
+	if opts.Mode == "" {
+		opts.Mode = defaultCherryPickerOpts.Mode
+	}
 	return &CherryPicker{
 		options: opts,
 		state:   State{},
@@ -59,18 +59,33 @@ func NewCherryPickerWithOptions(opts Options) *CherryPicker {
 	}
 }
 
+// Mode is how the cherry-picks are made
+type Mode string
+
+const (
+	// ModeGit cherry-picks with git in the local repository
+	ModeGit Mode = "git"
+
+	// ModeAPI builds the cherry-picked commits with the git data API of
+	// GitHub, no local repository or git binary is needed
+	ModeAPI Mode = "api"
+)
+
 type Options struct {
 	RepoPath  string // Local path to the repository
 	RepoOwner string // Org of the repo we are using
 	RepoName  string // Name of the repository
 	ForkOwner string
 	Remote    string
+	Mode      Mode           // Defaults to ModeGit
+	GitHub    *github.GitHub // Client to talk to GitHub, defaults to github.New()
 }
 
 var defaultCherryPickerOpts = Options{
 	RepoPath:  ".",
 	Remote:    "origin",
 	ForkOwner: "",
+	Mode:      ModeGit,
 }
 
 type State struct {
@@ -86,11 +101,21 @@ type cherryPickerImplementation interface {
 	cherrypickCommits(*State, *Options, string, []string) error
 	cherrypickMergeCommit(*State, *Options, string, []string, int) error
 	pushFeatureBranch(*State, *Options, string) error
+	cherrypickWithAPI(context.Context, *State, *Options, string, *github.PullRequest) (string, error)
 }
 
 // Initialize checks the environment and populates the state
 func (impl *defaultCPImplementation) initialize(ctx context.Context, state *State, opts *Options) error {
-	state.github = github.New()
+	state.github = opts.GitHub
+	if state.github == nil {
+		state.github = github.New()
+	}
+	state.repo = state.github.NewRepository(opts.RepoOwner, opts.RepoName)
+
+	// The API mode does not touch the local repository
+	if opts.Mode == ModeAPI {
+		return nil
+	}
 
 	// Check the repository path exists
 	if util.Exists(filepath.Join(opts.RepoPath, rebaseMagic)) {
@@ -125,23 +150,54 @@ func (cp *CherryPicker) CreateCherryPickPRWithContext(ctx context.Context, prNum
 		return errors.Wrapf(err, "getting pull request %d", prNumber)
 	}
 
+	var featureBranch string
+	if cp.options.Mode == ModeAPI {
+		featureBranch, err = cp.impl.cherrypickWithAPI(ctx, &cp.state, &cp.options, branch, pr)
+	} else {
+		featureBranch, err = cp.cherrypickLocal(ctx, pr, branch)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Create the pull request
+	pullrequest, err := cp.state.repo.CreatePullRequest(
+		ctx, featureBranch, branch,
+		fmt.Sprintf(prTitleTemplate, prNumber, branch),
+		fmt.Sprintf(prBodyTemplate, prNumber, branch, prNumber, branch, pr.Username),
+		&github.NewPullRequestOptions{MaintainerCanModify: true},
+	)
+	if err != nil {
+		return errors.Wrap(err, "creating pull request in github")
+	}
+
+	logrus.Info(fmt.Sprintf("Successfully created pull request #%d", pullrequest.Number))
+
+	return nil
+}
+
+// cherrypickLocal cherry-picks the pull request with git in the local
+// repository and pushes the result. It returns the new branch.
+func (cp *CherryPicker) cherrypickLocal(
+	ctx context.Context, pr *github.PullRequest, branch string,
+) (featureBranch string, err error) {
 	// Next step: Find out how the PR was merged
 	mergeMode, err := pr.GetMergeMode(ctx)
 	if err != nil {
-		return errors.Wrapf(err, "getting merge mode for PR #%d", pr.Number)
+		return "", errors.Wrapf(err, "getting merge mode for PR #%d", pr.Number)
 	}
 
 	// Create the CP branch
-	featureBranch, err := cp.impl.createBranch(&cp.state, &cp.options, branch, pr)
+	featureBranch, err = cp.impl.createBranch(&cp.state, &cp.options, branch, pr)
 	if err != nil {
-		return errors.Wrap(err, "creating the feature branch")
+		return "", errors.Wrap(err, "creating the feature branch")
 	}
 
 	var cpError error
 
 	// The easiest case: PR was squashed. In this case we only need to CP
 	// the sha returned in merge_commit_sha
-	if mergeMode == SQUASH {
+	if mergeMode == github.MergeModeSquash {
 		cpError = cp.impl.cherrypickCommits(
 			&cp.state, &cp.options, branch, []string{pr.MergeCommitSHA},
 		)
@@ -150,27 +206,36 @@ func (cp *CherryPicker) CreateCherryPickPRWithContext(ctx context.Context, prNum
 	// Next, if the PR resulted in a merge commit, we only need to cherry-pick
 	// the `merge_commit_sha` but we have to find out which parent's tree we want
 	// to generate the diff from:
-	if mergeMode == MERGE {
+	if mergeMode == github.MergeModeMerge {
 		parent, err2 := pr.PatchTreeID(ctx)
 		if err2 != nil {
-			return errors.Wrap(err2, "searching for parent patch tree")
+			return "", errors.Wrap(err2, "searching for parent patch tree")
 		}
 		cpError = cp.impl.cherrypickMergeCommit(
 			&cp.state, &cp.options, branch, []string{pr.MergeCommitSHA}, parent,
 		)
 	}
 
+	// PRs merged by a merge queue do not have the PR commits in their
+	// history, we pick the changes the merge commit brought to the branch
+	// it was merged into, its first parent.
+	if mergeMode == github.MergeModeQueue {
+		cpError = cp.impl.cherrypickMergeCommit(
+			&cp.state, &cp.options, branch, []string{pr.MergeCommitSHA}, 1,
+		)
+	}
+
 	// Last case. We are dealing with a rebase. In this case we have to take the
 	// merge commit and go back in the git log to find the previous trees and
 	// CP the commits where they merged
-	if mergeMode == REBASE {
+	if mergeMode == github.MergeModeRebase {
 		rebaseCommits, err2 := pr.GetRebaseCommits(ctx)
 		if err2 != nil {
-			return errors.Wrapf(err2, "while getting commits in rebase from PR #%d", pr.Number)
+			return "", errors.Wrapf(err2, "while getting commits in rebase from PR #%d", pr.Number)
 		}
 
 		if len(rebaseCommits) == 0 {
-			return errors.Errorf("empty commit list while searching from commits from PR#%d", pr.Number)
+			return "", errors.Errorf("empty commit list while searching from commits from PR#%d", pr.Number)
 		}
 
 		cpError = cp.impl.cherrypickCommits(
@@ -179,27 +244,13 @@ func (cp *CherryPicker) CreateCherryPickPRWithContext(ctx context.Context, prNum
 	}
 
 	if cpError != nil {
-		return errors.Errorf("while cherrypicking pull request %d of type %s", pr.Number, mergeMode)
+		return "", errors.Errorf("while cherrypicking pull request %d of type %s", pr.Number, mergeMode)
 	}
 
 	if err = cp.impl.pushFeatureBranch(&cp.state, &cp.options, featureBranch); err != nil {
-		return errors.Wrap(err, "pushing branch to git remote")
-	}
-
-	// Create the pull request
-	pullrequest, err := cp.state.repo.CreatePullRequest(
-		ctx, featureBranch, branch,
-		fmt.Sprintf(prTitleTemplate, prNumber, branch),
-		fmt.Sprintf(prBodyTemplate, prNumber, branch, prNumber, branch, pr.Username),
-		&github.NewPullRequestOptions{MaintainerCanModify: true},
-	)
-	if err != nil {
-		return errors.Wrap(err, "creating pull request in github")
+		return "", errors.Wrap(err, "pushing branch to git remote")
 	}
-
-	logrus.Info(fmt.Sprintf("Successfully created pull request #%d", pullrequest.Number))
-
-	return nil
+	return featureBranch, nil
 }
 
 type defaultCPImplementation struct{}
@@ -285,3 +336,16 @@ func (impl *defaultCPImplementation) pushFeatureBranch(
 	logrus.Info(fmt.Sprintf("Successfully pushed %s to remote %s", featureBranch, opts.Remote))
 	return nil
 }
+
+// cherrypickWithAPI records the cherry-pick in a new branch created
+// through the git data API and returns the branch
+func (impl *defaultCPImplementation) cherrypickWithAPI(
+	ctx context.Context, state *State, opts *Options, branch string, pr *github.PullRequest,
+) (string, error) {
+	featureBranch, sha, err := pr.CherryPick(ctx, branch)
+	if err != nil {
+		return "", errors.Wrapf(err, "cherry-picking pull request %d with the API", pr.Number)
+	}
+	logrus.Infof("Cherry-picked #%d to %s in branch %s (%s)", pr.Number, branch, featureBranch, sha)
+	return featureBranch, nil
+}
@@ -1,12 +1,14 @@
 package cherrypicker
 
 import (
+	"context"
 	"os"
 	"strings"
 	"testing"
 
 	git "github.com/go-git/go-git/v5"
 	"github.com/puerco/mattermod-refactor/pkg/github"
+	"github.com/puerco/mattermod-refactor/pkg/github/githubtest"
 
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
@@ -64,6 +66,36 @@ func TestCreateBranch(t *testing.T) {
 	require.True(t, found, "checking if new branch was created")
 }
 
+func TestCreateCherryPickPRWithAPI(t *testing.T) {
+	// A squashed PR to cherry-pick to release-7.1, no local repo is used
+	fake := githubtest.New()
+	fake.AddRepository("mattermost", "mattermost-server")
+	fake.AddCommit("main", "main-tree")
+	fake.AddCommit("pr-1", "pr-tree", "main")
+	fake.AddCommit("squashed", "merged-tree", "main")
+	fake.AddCommit("release", "release-tree")
+	fake.AddTree("main-tree", map[string]string{"a.go": "a1"})
+	fake.AddTree("merged-tree", map[string]string{"a.go": "a2"})
+	fake.AddTree("release-tree", map[string]string{"a.go": "a1", "b.go": "b0"})
+	fake.AddBranch("release-7.1", "release")
+	fake.AddPullRequest("mattermost", "mattermost-server", 10, "squashed", "pr-1")
+
+	cp := NewCherryPickerWithOptions(Options{
+		RepoPath:  "/nonexistent",
+		RepoOwner: "mattermost",
+		RepoName:  "mattermost-server",
+		Mode:      ModeAPI,
+		GitHub:    fake.GitHub(nil),
+	})
+	require.Nil(t, cp.CreateCherryPickPRWithContext(context.Background(), 10, "release-7.1"))
+
+	require.Len(t, fake.PullRequests.Created, 1)
+	require.Equal(t, "cherry-pick-10-release-7.1", fake.PullRequests.Created[0].GetHead())
+	require.Equal(t, "release-7.1", fake.PullRequests.Created[0].GetBase())
+	require.Equal(t, "Automated cherry pick of #10 on release-7.1", fake.PullRequests.Created[0].GetTitle())
+	require.NotEmpty(t, fake.Branch("cherry-pick-10-release-7.1"))
+}
+
 /*
 func TestGetPRMergeMode(t *testing.T) {
 	impl := defaultCPImplementation{}
@@ -0,0 +1,286 @@
+package cherrypicker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/puerco/mattermod-refactor/pkg/github"
+	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/release-utils/util"
+)
+
+// engineRemote is the name of the remote configured in the worktrees
+const engineRemote = "origin"
+
+// EngineOptions configure a cherry-pick engine
+type EngineOptions struct {
+	// URL of the repository to fetch from. Credentials can be part of
+	// it, they are kept in the git config of the worktree and not
+	// passed in the command line.
+	URL string
+
+	// PushURL of the repository receiving the branches, eg a fork.
+	// Defaults to URL.
+	PushURL string
+
+	// WorkDir is the directory of a worktree reused between picks. When
+	// empty, each pick runs in a temporary clone removed afterwards.
+	WorkDir string
+
+	// Depth of the target branch history to fetch, defaults to 1. The
+	// commits to pick are fetched with their parents.
+	Depth int
+
+	// Name and email of the committer of the picks
+	UserName  string
+	UserEmail string
+
+	// Logger receives the log messages of the engine. When nil, they
+	// go to the standard logrus logger.
+	Logger github.Logger
+}
+
+var defaultEngineOptions = EngineOptions{
+	Depth:     1,
+	UserName:  "Mattermod",
+	UserEmail: "mattermod@mattermost.com",
+}
+
+// Pick describes commits to apply onto a branch
+type Pick struct {
+	Commits []string // SHAs to cherry-pick, in order
+	Parent  int      // Mainline parent number when picking merge commits, 0 otherwise
+	Branch  string   // Branch to apply the commits onto
+	Target  string   // Branch pushed with the result
+}
+
+// PickResult is the outcome of a successful pick
+type PickResult struct {
+	Branch string // Branch pushed
+	SHA    string // Head of the pushed branch
+}
+
+// Engine cherry-picks commits in a shallow local clone and pushes the
+// result. Picks are serialized, as they share the worktree.
+type Engine struct {
+	impl    engineImplementation
+	options EngineOptions
+	mtx     sync.Mutex
+}
+
+// NewEngine returns a cherry-pick engine for a repository
+func NewEngine(opts EngineOptions) (*Engine, error) {
+	if opts.URL == "" {
+		return nil, errors.New("the engine needs the URL of the repository")
+	}
+	if opts.PushURL == "" {
+		opts.PushURL = opts.URL
+	}
+	if opts.Depth <= 0 {
+		opts.Depth = defaultEngineOptions.Depth
+	}
+	if opts.UserName == "" {
+		opts.UserName = defaultEngineOptions.UserName
+	}
+	if opts.UserEmail == "" {
+		opts.UserEmail = defaultEngineOptions.UserEmail
+	}
+	if opts.Logger == nil {
+		opts.Logger = github.NewLogrusLogger(logrus.StandardLogger())
+	}
+	return &Engine{options: opts, impl: &defaultEngineImplementation{}}, nil
+}
+
+// engineImplementation runs the git steps of a pick. The git processes
+// are killed when the context is done.
+type engineImplementation interface {
+	prepare(ctx context.Context, dir string, opts *EngineOptions) error
+	fetch(ctx context.Context, dir string, opts *EngineOptions, pick *Pick) error
+	checkout(ctx context.Context, dir string, pick *Pick) error
+	apply(ctx context.Context, dir string, opts *EngineOptions, pick *Pick, commit string) error
+	push(ctx context.Context, dir string, pick *Pick) (string, error)
+}
+
+// CherryPick applies the commits of pick onto its branch and pushes the
+// result to the target branch, replacing it. When a commit does not
+// apply cleanly it returns a *github.CherryPickConflictError with the
+// conflicting paths, which matches github.ErrCherryPickConflict.
+func (e *Engine) CherryPick(ctx context.Context, pick *Pick) (*PickResult, error) {
+	if pick == nil || len(pick.Commits) == 0 || pick.Branch == "" || pick.Target == "" {
+		return nil, errors.New("a pick needs commits, a branch and a target branch")
+	}
+	if pick.Parent < 0 {
+		return nil, errors.Errorf("invalid mainline parent %d", pick.Parent)
+	}
+
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	dir := e.options.WorkDir
+	if dir == "" {
+		tmp, err := os.MkdirTemp("", "cherry-pick-")
+		if err != nil {
+			return nil, errors.Wrap(err, "creating temporary worktree")
+		}
+		defer os.RemoveAll(tmp)
+		dir = tmp
+	}
+
+	if err := e.impl.prepare(ctx, dir, &e.options); err != nil {
+		return nil, errors.Wrap(err, "preparing worktree")
+	}
+	if err := e.impl.fetch(ctx, dir, &e.options, pick); err != nil {
+		return nil, errors.Wrap(err, "fetching commits")
+	}
+	if err := e.impl.checkout(ctx, dir, pick); err != nil {
+		return nil, errors.Wrapf(err, "checking out %s", pick.Branch)
+	}
+	for _, commit := range pick.Commits {
+		if err := e.impl.apply(ctx, dir, &e.options, pick, commit); err != nil {
+			return nil, err
+		}
+	}
+	sha, err := e.impl.push(ctx, dir, pick)
+	if err != nil {
+		return nil, errors.Wrapf(err, "pushing %s", pick.Target)
+	}
+	e.options.Logger.Infof("Pushed %d commits picked onto %s to %s (%s)", len(pick.Commits), pick.Branch, pick.Target, sha)
+	return &PickResult{Branch: pick.Target, SHA: sha}, nil
+}
+
+type defaultEngineImplementation struct{}
+
+// runGit runs git in the worktree and returns its output without the
+// trailing newline. The process is killed when ctx is done. Errors of
+// failed commands carry what git wrote to stderr.
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, gitCommand, args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", errors.Wrapf(ctx.Err(), "running git %s", args[0])
+		}
+		return "", errors.Wrapf(err, "running git %s: %s", args[0], strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimRight(stdout.String(), "\n"), nil
+}
+
+// prepare initializes the worktree, or cleans it when reused
+func (impl *defaultEngineImplementation) prepare(ctx context.Context, dir string, opts *EngineOptions) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return errors.Wrapf(err, "creating %s", dir)
+	}
+	if !util.Exists(filepath.Join(dir, ".git")) {
+		if _, err := runGit(ctx, dir, "init", "--quiet"); err != nil {
+			return errors.Wrap(err, "initializing repository")
+		}
+	}
+
+	// Leftovers of a failed pick are discarded
+	if util.Exists(filepath.Join(dir, ".git", "CHERRY_PICK_HEAD")) {
+		if _, err := runGit(ctx, dir, "cherry-pick", "--abort"); err != nil {
+			return errors.Wrap(err, "aborting previous cherry-pick")
+		}
+	}
+
+	for key, value := range map[string]string{
+		"remote." + engineRemote + ".url":     opts.URL,
+		"remote." + engineRemote + ".pushurl": opts.PushURL,
+		"user.name":                           opts.UserName,
+		"user.email":                          opts.UserEmail,
+	} {
+		if _, err := runGit(ctx, dir, "config", key, value); err != nil {
+			return errors.Wrapf(err, "setting %s", key)
+		}
+	}
+	return nil
+}
+
+// fetch gets the tip of the branch and the commits with their parents,
+// which are needed to compute the changes they bring
+func (impl *defaultEngineImplementation) fetch(ctx context.Context, dir string, opts *EngineOptions, pick *Pick) error {
+	if _, err := runGit(
+		ctx, dir, "fetch", "--quiet", "--no-tags", fmt.Sprintf("--depth=%d", opts.Depth), engineRemote,
+		fmt.Sprintf("+refs/heads/%s:refs/remotes/%s/%s", pick.Branch, engineRemote, pick.Branch),
+	); err != nil {
+		return errors.Wrapf(err, "fetching branch %s", pick.Branch)
+	}
+	if _, err := runGit(
+		ctx, dir, append([]string{"fetch", "--quiet", "--no-tags", "--depth=2", engineRemote}, pick.Commits...)...,
+	); err != nil {
+		return errors.Wrap(err, "fetching commits to pick")
+	}
+	return nil
+}
+
+// checkout creates the target branch from the tip of the branch
+func (impl *defaultEngineImplementation) checkout(ctx context.Context, dir string, pick *Pick) error {
+	if _, err := runGit(
+		ctx, dir, "checkout", "--quiet", "--force", "-B", pick.Target, engineRemote+"/"+pick.Branch,
+	); err != nil {
+		return err
+	}
+	_, err := runGit(ctx, dir, "clean", "-fdx", "--quiet")
+	return errors.Wrap(err, "cleaning worktree")
+}
+
+// apply cherry-picks a commit. Conflicts are aborted and returned
+// with the paths that could not be merged.
+func (impl *defaultEngineImplementation) apply(ctx context.Context, dir string, opts *EngineOptions, pick *Pick, commit string) error {
+	args := []string{"cherry-pick", "-x"}
+	if pick.Parent > 0 {
+		args = append(args, "-m", fmt.Sprintf("%d", pick.Parent))
+	}
+	_, pickErr := runGit(ctx, dir, append(args, commit)...)
+	if pickErr == nil {
+		return nil
+	}
+	exitErr := &exec.ExitError{}
+	if !errors.As(pickErr, &exitErr) {
+		return errors.Wrapf(pickErr, "cherry-picking %s", commit)
+	}
+
+	output, err := runGit(ctx, dir, "diff", "--name-only", "--diff-filter=U")
+	if err != nil {
+		return errors.Wrap(err, "listing conflicting paths")
+	}
+	paths := []string{}
+	for _, path := range strings.Split(output, "\n") {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) == 0 {
+		return errors.Wrapf(pickErr, "cherry-picking %s onto %s", commit, pick.Branch)
+	}
+	sort.Strings(paths)
+
+	if _, err := runGit(ctx, dir, "cherry-pick", "--abort"); err != nil {
+		opts.Logger.Warnf("Unable to abort conflicting cherry-pick of %s: %v", commit, err)
+	}
+	return &github.CherryPickConflictError{Commit: commit, Branch: pick.Branch, Paths: paths}
+}
+
+// push force-pushes the target branch and returns its head
+func (impl *defaultEngineImplementation) push(ctx context.Context, dir string, pick *Pick) (string, error) {
+	sha, err := runGit(ctx, dir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", errors.Wrap(err, "reading head of the worktree")
+	}
+	if _, err := runGit(
+		ctx, dir, "push", "--quiet", "--force", engineRemote, "HEAD:refs/heads/"+pick.Target,
+	); err != nil {
+		return "", err
+	}
+	return sha, nil
+}
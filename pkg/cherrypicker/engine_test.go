@@ -0,0 +1,89 @@
+package cherrypicker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/puerco/mattermod-refactor/pkg/github"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/release-utils/command"
+)
+
+// commitFile writes a file in the repository and commits it,
+// returning the SHA of the commit
+func commitFile(t *testing.T, dir, name, content string) string {
+	require.Nil(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	require.Nil(t, command.NewWithWorkDir(dir, gitCommand, "add", name).RunSilentSuccess())
+	require.Nil(t, command.NewWithWorkDir(dir, gitCommand, "commit", "-m", "Update "+name).RunSilentSuccess())
+	output, err := command.NewWithWorkDir(dir, gitCommand, "rev-parse", "HEAD").RunSilentSuccessOutput()
+	require.Nil(t, err)
+	return output.OutputTrimNL()
+}
+
+func TestEngineCherryPick(t *testing.T) {
+	// The release branch diverged from main in a.txt
+	origin := createTestRepo(t)
+	defer os.RemoveAll(origin)
+	commitFile(t, origin, "a.txt", "base\n")
+	commitFile(t, origin, "b.txt", "base\n")
+	require.Nil(t, command.NewWithWorkDir(origin, gitCommand, "checkout", "-q", "-b", "release").RunSilentSuccess())
+	commitFile(t, origin, "a.txt", "release\n")
+	require.Nil(t, command.NewWithWorkDir(origin, gitCommand, "checkout", "-q", "main").RunSilentSuccess())
+	fix := commitFile(t, origin, "b.txt", "fixed\n")
+	conflicting := commitFile(t, origin, "a.txt", "main\n")
+
+	_, err := NewEngine(EngineOptions{})
+	require.NotNil(t, err)
+
+	engine, err := NewEngine(EngineOptions{URL: "file://" + origin, WorkDir: t.TempDir()})
+	require.Nil(t, err)
+	ctx := context.Background()
+
+	_, err = engine.CherryPick(ctx, &Pick{Commits: []string{fix}, Branch: "release"})
+	require.NotNil(t, err)
+
+	// Conflicts are reported with the paths
+	_, err = engine.CherryPick(ctx, &Pick{Commits: []string{fix, conflicting}, Branch: "release", Target: "cp-2"})
+	require.True(t, errors.Is(err, github.ErrCherryPickConflict))
+	require.Equal(t, []string{"a.txt"}, github.ConflictPaths(err))
+
+	// The worktree is reused after a failed pick
+	result, err := engine.CherryPick(ctx, &Pick{Commits: []string{fix}, Branch: "release", Target: "cp-1"})
+	require.Nil(t, err)
+	require.Equal(t, "cp-1", result.Branch)
+
+	output, err := command.NewWithWorkDir(origin, gitCommand, "rev-parse", "cp-1").RunSilentSuccessOutput()
+	require.Nil(t, err)
+	require.Equal(t, result.SHA, output.OutputTrimNL())
+	for file, content := range map[string]string{"a.txt": "release\n", "b.txt": "fixed\n"} {
+		output, err = command.NewWithWorkDir(origin, gitCommand, "show", "cp-1:"+file).RunSilentSuccessOutput()
+		require.Nil(t, err)
+		require.Equal(t, content, output.Output())
+	}
+}
+
+func TestEngineTemporaryWorktree(t *testing.T) {
+	origin := createTestRepo(t)
+	defer os.RemoveAll(origin)
+	commitFile(t, origin, "a.txt", "base\n")
+	require.Nil(t, command.NewWithWorkDir(origin, gitCommand, "branch", "release").RunSilentSuccess())
+	fix := commitFile(t, origin, "a.txt", "fixed\n")
+
+	engine, err := NewEngine(EngineOptions{URL: "file://" + origin})
+	require.Nil(t, err)
+
+	// Git does not run once the context is canceled
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = engine.CherryPick(canceled, &Pick{Commits: []string{fix}, Branch: "release", Target: "cp"})
+	require.True(t, errors.Is(err, context.Canceled))
+
+	result, err := engine.CherryPick(context.Background(), &Pick{Commits: []string{fix}, Branch: "release", Target: "cp"})
+	require.Nil(t, err)
+	output, err := command.NewWithWorkDir(origin, gitCommand, "show", result.SHA+":a.txt").RunSilentSuccessOutput()
+	require.Nil(t, err)
+	require.Equal(t, "fixed\n", output.Output())
+}
@@ -0,0 +1,230 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+// Package commands runs the bot commands written in comments of pull
+// requests and issues, eg /cherry-pick release-7.8, /retest or /hold.
+package commands
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/puerco/mattermod-refactor/pkg/github"
+	"github.com/sirupsen/logrus"
+)
+
+// Handler runs a command
+type Handler func(ctx context.Context, req *Request) error
+
+// Definition describes a command and how to run it
+type Definition struct {
+	Name        string // Name of the command without the slash, eg cherry-pick
+	Usage       string // Arguments shown in the help, eg "<branch>"
+	Description string // One line explaining what the command does
+
+	// Permission is the access to the repository the commenter needs.
+	// Defaults to write access.
+	Permission github.PermissionLevel
+
+	// MinArgs and MaxArgs bound the number of arguments. A negative
+	// MaxArgs allows any number of them.
+	MinArgs int
+	MaxArgs int
+
+	Run Handler
+}
+
+// Request is a command to run with the context where it was written
+type Request struct {
+	*Command
+	Sender string // Login of the commenter
+	Owner  string // Repository of the pull request or issue
+	Repo   string
+	Number int
+
+	// PullRequest is set when the comment is on a pull request, with only
+	// its repository, number, title and state. Issue is set otherwise.
+	PullRequest *github.PullRequest
+	Issue       *github.Issue
+
+	Event *github.WebhookEvent
+}
+
+// IsPullRequest returns true if the command was written on a pull request
+func (req *Request) IsPullRequest() bool {
+	return req.PullRequest != nil
+}
+
+// Reply comments on the pull request or issue of the command
+func (req *Request) Reply(ctx context.Context, body string) error {
+	var err error
+	if req.PullRequest != nil {
+		_, err = req.PullRequest.CommentOnPR(ctx, body)
+	} else {
+		_, err = req.Issue.Comment(ctx, body)
+	}
+	return errors.Wrapf(err, "replying to /%s", req.Name)
+}
+
+// Options configure a router
+type Options struct {
+	// GitHub is used to check the permissions of the commenters.
+	// Defaults to a client created with github.New().
+	GitHub *github.GitHub
+
+	// Logger receives the log messages of the router. When nil,
+	// the standard logrus logger is used.
+	Logger github.Logger
+
+	// Quiet disables the replies to commands that are denied or
+	// have wrong arguments. They are only logged.
+	Quiet bool
+}
+
+// Router runs the commands found in comments with their registered
+// handlers. Commands that are not registered are ignored, as they
+// may be meant for other bots. It is safe for concurrent use.
+type Router struct {
+	options  Options
+	mtx      sync.RWMutex
+	commands map[string]*Definition
+}
+
+// NewRouter returns a router without commands
+func NewRouter(opts Options) *Router {
+	if opts.GitHub == nil {
+		opts.GitHub = github.New()
+	}
+	if opts.Logger == nil {
+		opts.Logger = github.NewLogrusLogger(logrus.StandardLogger())
+	}
+	return &Router{options: opts, commands: map[string]*Definition{}}
+}
+
+// Register adds a command to the router
+func (r *Router) Register(def Definition) error {
+	def.Name = strings.ToLower(strings.TrimPrefix(def.Name, "/"))
+	if !commandRegex.MatchString("/"+def.Name) || def.Run == nil {
+		return errors.Errorf("invalid command %q, commands need a name and a handler", def.Name)
+	}
+	if def.Permission == "" {
+		def.Permission = github.PermissionWrite
+	}
+	if def.MaxArgs >= 0 && def.MaxArgs < def.MinArgs {
+		return errors.Errorf("command %s accepts at most %d arguments but requires %d", def.Name, def.MaxArgs, def.MinArgs)
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if _, ok := r.commands[def.Name]; ok {
+		return errors.Errorf("command %s is already registered", def.Name)
+	}
+	r.commands[def.Name] = &def
+	return nil
+}
+
+// Help returns the usage of the registered commands as markdown
+func (r *Router) Help() string {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	names := []string{}
+	for name := range r.commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	for _, name := range names {
+		def := r.commands[name]
+		sb.WriteString(fmt.Sprintf("- `%s`: %s\n", usage(def), def.Description))
+	}
+	return sb.String()
+}
+
+// usage returns how to write a command
+func usage(def *Definition) string {
+	return strings.TrimSpace("/" + def.Name + " " + def.Usage)
+}
+
+// Handle runs the commands in the comment of an issue_comment event. It
+// has the signature of webhook handlers, to be registered with
+// On("issue_comment.created", router.Handle). Commands run in the order
+// they were written. Errors of their handlers are returned together
+// once all commands ran.
+func (r *Router) Handle(ctx context.Context, event *github.WebhookEvent) error {
+	if event.Type != "issue_comment" || event.Sender == "" {
+		return nil
+	}
+	failed := []string{}
+	for _, cmd := range Parse(event.Comment) {
+		req, err := newRequest(cmd, event)
+		if err != nil {
+			return err
+		}
+		if err := r.run(ctx, req); err != nil {
+			r.options.Logger.Errorf("Command %s from %s on %s/%s#%d failed: %v", cmd, req.Sender, req.Owner, req.Repo, req.Number, err)
+			failed = append(failed, fmt.Sprintf("/%s: %v", cmd.Name, err))
+		}
+	}
+	if len(failed) > 0 {
+		return errors.Errorf("%d commands failed: %s", len(failed), strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// newRequest builds the request of a command written in a comment
+func newRequest(cmd *Command, event *github.WebhookEvent) (*Request, error) {
+	req := &Request{Command: cmd, Sender: event.Sender, Event: event}
+	switch {
+	case event.PullRequest != nil:
+		req.PullRequest = event.PullRequest
+		req.Owner, req.Repo, req.Number = event.PullRequest.RepoOwner, event.PullRequest.RepoName, event.PullRequest.Number
+	case event.Issue != nil:
+		req.Issue = event.Issue
+		req.Owner, req.Repo, req.Number = event.Issue.Owner, event.Issue.Repo, event.Issue.Number
+	default:
+		return nil, errors.New("comment event has no pull request or issue")
+	}
+	return req, nil
+}
+
+// run checks the arguments of a command and the permissions of
+// the commenter, then calls its handler
+func (r *Router) run(ctx context.Context, req *Request) error {
+	r.mtx.RLock()
+	def, ok := r.commands[req.Name]
+	r.mtx.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	if len(req.Args) < def.MinArgs || (def.MaxArgs >= 0 && len(req.Args) > def.MaxArgs) {
+		return r.reject(ctx, req, fmt.Sprintf("Usage: `%s`", usage(def)))
+	}
+
+	level, err := r.options.GitHub.NewRepository(req.Owner, req.Repo).GetPermissionLevel(ctx, req.Sender)
+	if err != nil {
+		return errors.Wrapf(err, "checking the permissions of %s", req.Sender)
+	}
+	if !level.AtLeast(def.Permission) {
+		return r.reject(ctx, req, fmt.Sprintf(
+			"@%s you need %s access to the repository to run `/%s`", req.Sender, def.Permission, def.Name,
+		))
+	}
+
+	return def.Run(ctx, req)
+}
+
+// reject tells the commenter why a command was not run. It is
+// not an error, the delivery does not need to be retried.
+func (r *Router) reject(ctx context.Context, req *Request, reason string) error {
+	r.options.Logger.Infof("Not running %s from %s on %s/%s#%d: %s", req.Command, req.Sender, req.Owner, req.Repo, req.Number, reason)
+	if r.options.Quiet {
+		return nil
+	}
+	return req.Reply(ctx, reason)
+}
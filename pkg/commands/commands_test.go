@@ -0,0 +1,78 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/puerco/mattermod-refactor/pkg/github"
+	"github.com/puerco/mattermod-refactor/pkg/github/githubtest"
+	"github.com/stretchr/testify/require"
+)
+
+// commentPayload is an issue_comment event on pull request #1234
+const commentPayload = `{
+	"action": "created",
+	"issue": {"number": 1234, "state": "open", "pull_request": {"url": "https://api.github.com/repos/mattermost/mattermost-server/pulls/1234"}},
+	"comment": {"body": "/cherry-pick release-7.8\n/hold\n/retest\n/cherry-pick\n/unknown"},
+	"sender": {"login": "alice"},
+	"repository": {"name": "mattermost-server", "owner": {"login": "mattermost"}}
+}`
+
+func TestRouter(t *testing.T) {
+	fake := githubtest.New()
+	fake.Repositories.Permissions = map[string]string{"alice": "triage"}
+	gh := fake.GitHub(nil)
+	router := NewRouter(Options{GitHub: gh})
+
+	// A GitHub client is created when none is passed
+	require.NotNil(t, NewRouter(Options{}).options.GitHub)
+
+	ran := []string{}
+	record := func(ctx context.Context, req *Request) error {
+		require.True(t, req.IsPullRequest())
+		require.Equal(t, "mattermost", req.Owner)
+		require.Equal(t, 1234, req.Number)
+		ran = append(ran, req.String())
+		return nil
+	}
+	require.Nil(t, router.Register(Definition{
+		Name: "cherry-pick", Usage: "<branch>", Description: "Cherry-picks the PR",
+		Permission: github.PermissionTriage, MinArgs: 1, MaxArgs: 1, Run: record,
+	}))
+	require.Nil(t, router.Register(Definition{Name: "/Hold", MaxArgs: 0, Run: record}))
+	require.Nil(t, router.Register(Definition{Name: "retest", Permission: github.PermissionRead, MaxArgs: -1, Run: func(ctx context.Context, req *Request) error {
+		return errors.New("CI is down")
+	}}))
+
+	// Invalid and duplicate commands are rejected
+	require.NotNil(t, router.Register(Definition{Name: "hold", Run: record}))
+	require.NotNil(t, router.Register(Definition{Name: "bad name", Run: record}))
+	require.NotNil(t, router.Register(Definition{Name: "nothing"}))
+	require.NotNil(t, router.Register(Definition{Name: "args", MinArgs: 2, MaxArgs: 1, Run: record}))
+
+	require.Equal(t, "- `/cherry-pick <branch>`: Cherry-picks the PR\n- `/hold`: \n- `/retest`: \n", router.Help())
+
+	event, err := gh.ParseWebhook([]byte(commentPayload), "issue_comment")
+	require.Nil(t, err)
+	err = router.Handle(context.Background(), event)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "CI is down")
+
+	// Commands with missing permissions or arguments get a reply
+	require.Equal(t, []string{"/cherry-pick release-7.8"}, ran)
+	replies := fake.Issues.Comments[1234]
+	require.Len(t, replies, 2)
+	require.Equal(t, "@alice you need write access to the repository to run `/hold`", replies[0].GetBody())
+	require.Equal(t, "Usage: `/cherry-pick <branch>`", replies[1].GetBody())
+
+	// Commenters without access can't run anything
+	router.options.Quiet = true
+	require.Nil(t, router.Handle(context.Background(), &github.WebhookEvent{
+		Type: "issue_comment", Sender: "mallory", Comment: "/retest", PullRequest: event.PullRequest,
+	}))
+	require.Len(t, fake.Issues.Comments[1234], 2)
+}
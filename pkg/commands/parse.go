@@ -0,0 +1,48 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"regexp"
+	"strings"
+)
+
+// commandRegex matches the name of a command at the start of a line
+var commandRegex = regexp.MustCompile(`^/([A-Za-z][A-Za-z0-9-]*)$`)
+
+// Command is a bot command found in a comment, eg /cherry-pick release-7.8
+type Command struct {
+	Name string   // Name of the command, lowercase and without the slash
+	Args []string // Arguments, split on whitespace
+}
+
+// String returns the command as written in a comment
+func (c *Command) String() string {
+	return strings.Join(append([]string{"/" + c.Name}, c.Args...), " ")
+}
+
+// Parse returns the commands in a comment body, one per line. Lines have
+// to start with the command. Quotes and code blocks are skipped, so
+// commands copied from other comments or in examples are not run.
+func Parse(body string) []*Command {
+	commands := []*Command{}
+	inCode := false
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~") {
+			inCode = !inCode
+			continue
+		}
+		if inCode || !strings.HasPrefix(line, "/") {
+			continue
+		}
+		fields := strings.Fields(line)
+		m := commandRegex.FindStringSubmatch(fields[0])
+		if m == nil {
+			continue
+		}
+		commands = append(commands, &Command{Name: strings.ToLower(m[1]), Args: fields[1:]})
+	}
+	return commands
+}
@@ -0,0 +1,30 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	body := "LGTM, backporting\r\n" +
+		"/cherry-pick release-7.8\r\n" +
+		"  /Retest  \n" +
+		"> /hold\n" +
+		"Run /hold to stop the merge\n" +
+		"```\n/merge\n```\n" +
+		"/path/to/file\n" +
+		"/\n" +
+		"/cherry-pick release-7.7 release-7.6"
+
+	commands := Parse(body)
+	require.Len(t, commands, 3)
+	require.Equal(t, &Command{Name: "cherry-pick", Args: []string{"release-7.8"}}, commands[0])
+	require.Equal(t, &Command{Name: "retest", Args: []string{}}, commands[1])
+	require.Equal(t, "/cherry-pick release-7.7 release-7.6", commands[2].String())
+
+	require.Empty(t, Parse(""))
+}
@@ -0,0 +1,30 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+
+	gogithub "github.com/google/go-github/v39/github"
+)
+
+// addAssignees assigns users to the pull request and returns
+// the assignees after the change
+func (impl *defaultPRImplementation) addAssignees(ctx context.Context, pr *PullRequest, logins []string) ([]string, error) {
+	if impl.getOptions().DryRun {
+		impl.log(pr).Infof("[dry-run] Would assign %v to PR #%d", logins, pr.Number)
+		return appendMissing(pr.Assignees, logins), nil
+	}
+
+	var ghIssue *gogithub.Issue
+	err := impl.doWithRetry(ctx, "issues.AddAssignees", func() (resp *gogithub.Response, err error) {
+		ghIssue, resp, err = impl.GitHubClient().Issues.AddAssignees(ctx, pr.RepoOwner, pr.RepoName, pr.Number, logins)
+		return resp, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	impl.log(pr).Infof("Assigned %v to PR #%d", logins, pr.Number)
+	return userLogins(ghIssue.Assignees), nil
+}
@@ -0,0 +1,251 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bradleyfalzon/ghinstallation/v2"
+	"github.com/pkg/errors"
+)
+
+// AppAuth holds the credentials to authenticate as a GitHub App installation
+type AppAuth struct {
+	AppID          int64  // ID of the GitHub App
+	InstallationID int64  // ID of the app installation in the org or repository
+	PrivateKey     []byte // PEM encoded private key of the app
+}
+
+// NewWithAppAuth returns a GitHub object authenticated as a GitHub App
+// installation. Installation tokens are minted from the app credentials
+// and refreshed transparently before they expire. Nil options take the
+// package defaults, the options of the caller are not modified.
+func NewWithAppAuth(auth *AppAuth, opts *Options) (*GitHub, error) {
+	o := copyOptions(opts)
+	transport, err := newAppTransport(auth, &o)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating GitHub App transport")
+	}
+	o.Transport = transport
+	return NewWithOptions(&o), nil
+}
+
+// newAppTransport returns a transport that authenticates requests
+// with the installation tokens of a GitHub App
+func newAppTransport(auth *AppAuth, opts *Options) (http.RoundTripper, error) {
+	if auth == nil {
+		return nil, errors.New("GitHub App credentials not specified")
+	}
+	base := opts.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	transport, err := ghinstallation.New(base, auth.AppID, auth.InstallationID, auth.PrivateKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing GitHub App credentials")
+	}
+
+	// Tokens of apps installed in GitHub Enterprise are minted by the server
+	if opts.EnterpriseURL != nil {
+		transport.BaseURL = strings.TrimSuffix(enterpriseAPIURL(opts.EnterpriseURL, "/api/v3/").String(), "/")
+	}
+	return transport, nil
+}
+
+// NewWithTokens returns a GitHub object that spreads its requests among
+// several tokens, so their rate limits add up. See TokenPool. As in
+// NewWithAppAuth, the pool is set in a copy of the options.
+func NewWithTokens(tokens []string, opts *Options) (*GitHub, error) {
+	o := copyOptions(opts)
+	pool, err := NewTokenPool(tokens, o.Transport)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating token pool")
+	}
+	o.Transport = pool
+	return NewWithOptions(&o), nil
+}
+
+// TokenQuota is the rate limit of a token as last reported by GitHub
+type TokenQuota struct {
+	Remaining int       // Calls left, -1 until known
+	Reset     time.Time // When the limit resets
+}
+
+// pooledToken is a token of the pool with its quota by resource
+type pooledToken struct {
+	token  string
+	quotas map[string]TokenQuota
+}
+
+// TokenPool is a transport that authenticates each request with the next
+// token of a pool. The quota of each token is tracked from the responses
+// and tokens that ran out are skipped until their limit resets. When all
+// of them are exhausted, the one resetting first is used.
+type TokenPool struct {
+	base http.RoundTripper
+
+	mtx    sync.Mutex
+	tokens []*pooledToken
+	next   int
+}
+
+// NewTokenPool returns a pool rotating among the tokens. Requests are
+// sent through base, or the default transport when it is nil.
+func NewTokenPool(tokens []string, base http.RoundTripper) (*TokenPool, error) {
+	if len(tokens) == 0 {
+		return nil, errors.New("no tokens specified")
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	pool := &TokenPool{base: base, tokens: []*pooledToken{}}
+	for _, token := range tokens {
+		if token == "" {
+			return nil, errors.New("tokens cannot be empty")
+		}
+		pool.tokens = append(pool.tokens, &pooledToken{token: token, quotas: map[string]TokenQuota{}})
+	}
+	return pool, nil
+}
+
+func (tp *TokenPool) RoundTrip(req *http.Request) (*http.Response, error) {
+	resource := RateResourceCore
+	if strings.HasSuffix(req.URL.Path, "/graphql") {
+		resource = RateResourceGraphQL
+	}
+	if strings.HasPrefix(strings.TrimPrefix(req.URL.Path, "/api/v3"), "/search/") {
+		resource = RateResourceSearch
+	}
+
+	for attempt := 1; ; attempt++ {
+		pt, available := tp.pick(resource)
+		treq := req.Clone(req.Context())
+		treq.Header.Set("Authorization", "token "+pt.token)
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			treq.Body = body
+		}
+		resp, err := tp.base.RoundTrip(treq)
+		if err != nil {
+			return nil, err
+		}
+		tp.record(pt, resp)
+
+		// A token that ran out is replaced by the next one with calls left
+		if available && attempt < len(tp.tokens) && isRateLimited(resp) && (req.Body == nil || req.GetBody != nil) {
+			if _, more := tp.pick(resource); more {
+				resp.Body.Close()
+				continue
+			}
+		}
+		tp.setPoolQuota(resource, resp)
+		return resp, nil
+	}
+}
+
+// isRateLimited returns true if the response rejects a call because
+// the token used ran out of calls
+func isRateLimited(resp *http.Response) bool {
+	return (resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests) &&
+		resp.Header.Get("X-Ratelimit-Remaining") == "0"
+}
+
+// pick returns the next token with calls left for the resource. When
+// all are exhausted, it returns the one resetting first and false.
+func (tp *TokenPool) pick(resource string) (*pooledToken, bool) {
+	tp.mtx.Lock()
+	defer tp.mtx.Unlock()
+
+	now := time.Now()
+	var soonest *pooledToken
+	for i := 0; i < len(tp.tokens); i++ {
+		pt := tp.tokens[(tp.next+i)%len(tp.tokens)]
+		quota, known := pt.quotas[resource]
+		if !known || quota.Remaining > 0 || !now.Before(quota.Reset) {
+			tp.next = (tp.next + i + 1) % len(tp.tokens)
+			return pt, true
+		}
+		if soonest == nil || quota.Reset.Before(soonest.quotas[resource].Reset) {
+			soonest = pt
+		}
+	}
+	return soonest, false
+}
+
+// record stores the quota reported in a response for the token used
+func (tp *TokenPool) record(pt *pooledToken, resp *http.Response) {
+	remaining, err := strconv.Atoi(resp.Header.Get("X-Ratelimit-Remaining"))
+	if err != nil {
+		return
+	}
+	reset, err := strconv.ParseInt(resp.Header.Get("X-Ratelimit-Reset"), 10, 64)
+	if err != nil {
+		return
+	}
+	resource := resp.Header.Get("X-Ratelimit-Resource")
+	if resource == "" {
+		resource = RateResourceCore
+	}
+
+	tp.mtx.Lock()
+	defer tp.mtx.Unlock()
+	pt.quotas[resource] = TokenQuota{Remaining: remaining, Reset: time.Unix(reset, 0)}
+}
+
+// setPoolQuota replaces the rate limit headers of the response with the
+// quota of the whole pool. Otherwise go-github would stop sending
+// requests as soon as one of the tokens runs out. Tokens not used yet
+// are counted with the full limit reported in the response.
+func (tp *TokenPool) setPoolQuota(resource string, resp *http.Response) {
+	limit, err := strconv.Atoi(resp.Header.Get("X-Ratelimit-Limit"))
+	if err != nil || resp.Header.Get("X-Ratelimit-Remaining") == "" {
+		return
+	}
+
+	tp.mtx.Lock()
+	defer tp.mtx.Unlock()
+	now := time.Now()
+	remaining := 0
+	var reset time.Time
+	for _, pt := range tp.tokens {
+		quota, known := pt.quotas[resource]
+		switch {
+		case !known || !now.Before(quota.Reset):
+			remaining += limit
+		case quota.Remaining > 0:
+			remaining += quota.Remaining
+		}
+		if known && (reset.IsZero() || quota.Reset.Before(reset)) {
+			reset = quota.Reset
+		}
+	}
+	resp.Header.Set("X-Ratelimit-Limit", strconv.Itoa(limit*len(tp.tokens)))
+	resp.Header.Set("X-Ratelimit-Remaining", strconv.Itoa(remaining))
+	if !reset.IsZero() {
+		resp.Header.Set("X-Ratelimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+	}
+}
+
+// Quotas returns the quota of each token for a resource, in the order
+// the tokens were passed to the pool
+func (tp *TokenPool) Quotas(resource string) []TokenQuota {
+	tp.mtx.Lock()
+	defer tp.mtx.Unlock()
+	quotas := []TokenQuota{}
+	for _, pt := range tp.tokens {
+		quota, ok := pt.quotas[resource]
+		if !ok {
+			quota = TokenQuota{Remaining: -1}
+		}
+		quotas = append(quotas, quota)
+	}
+	return quotas
+}
@@ -0,0 +1,149 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWithAppAuth(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.Nil(t, err)
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	tokensMinted := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/app/installations/99/access_tokens", func(w http.ResponseWriter, r *http.Request) {
+		// Tokens are requested with the app JWT
+		require.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "Bearer "))
+		tokensMinted++
+		fmt.Fprintf(w, `{"token":"installation-token","expires_at":%q}`, time.Now().Add(time.Hour).Format(time.RFC3339))
+	})
+	mux.HandleFunc("/api/v3/repos/mattermost/mattermost-server", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "token installation-token", r.Header.Get("Authorization"))
+		w.Write([]byte(`{"name":"mattermost-server","owner":{"login":"mattermost"}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	require.Nil(t, err)
+
+	// Invalid keys are rejected when creating the client
+	_, err = NewWithAppAuth(&AppAuth{AppID: 1, InstallationID: 99, PrivateKey: []byte("bad")}, &Options{})
+	require.NotNil(t, err)
+
+	// Nil options take the defaults
+	gh, err := NewWithAppAuth(&AppAuth{AppID: 1, InstallationID: 99, PrivateKey: pemKey}, nil)
+	require.Nil(t, err)
+	require.Equal(t, defaultOptions.Retry, gh.options.Retry)
+	require.Nil(t, defaultOptions.Transport)
+
+	// The transport is set in a copy of the options
+	opts := &Options{EnterpriseURL: serverURL}
+	gh, err = NewWithAppAuth(&AppAuth{AppID: 1, InstallationID: 99, PrivateKey: pemKey}, opts)
+	require.Nil(t, err)
+	require.Nil(t, opts.Transport)
+
+	impl := gh.NewRepository("mattermost", "mattermost-server").impl.(*defaultRepoImplementation)
+	for i := 0; i < 2; i++ {
+		_, _, err = impl.GitHubClient().Repositories.Get(context.Background(), "mattermost", "mattermost-server")
+		require.Nil(t, err)
+	}
+
+	// The installation token is reused until it expires
+	require.Equal(t, 1, tokensMinted)
+}
+
+func TestNewWithTokens(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Unix()
+	remaining := map[string]int{"token-a": 2, "token-b": 5000}
+	used := []string{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/repos/mattermost/mattermost-server", func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "token ")
+		used = append(used, token)
+		remaining[token]--
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", fmt.Sprintf("%d", remaining[token]))
+		w.Header().Set("X-RateLimit-Reset", fmt.Sprintf("%d", reset))
+		w.Header().Set("X-RateLimit-Resource", "core")
+		w.Write([]byte(`{"name":"mattermost-server","owner":{"login":"mattermost"}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	require.Nil(t, err)
+
+	_, err = NewWithTokens([]string{}, &Options{})
+	require.NotNil(t, err)
+
+	// Nil options take the defaults
+	gh, err := NewWithTokens([]string{"token-a"}, nil)
+	require.Nil(t, err)
+	require.Equal(t, defaultOptions.Retry, gh.options.Retry)
+	require.Nil(t, defaultOptions.Transport)
+
+	// The pool is set in a copy, reusing the options does not nest pools
+	opts := &Options{EnterpriseURL: serverURL}
+	_, err = NewWithTokens([]string{"token-a", "token-b"}, opts)
+	require.Nil(t, err)
+	require.Nil(t, opts.Transport)
+	gh, err = NewWithTokens([]string{"token-a", "token-b"}, opts)
+	require.Nil(t, err)
+	pool := gh.options.Transport.(*TokenPool)
+	impl := gh.NewRepository("mattermost", "mattermost-server").impl.(*defaultRepoImplementation)
+	var resp *gogithub.Response
+	for i := 0; i < 5; i++ {
+		_, resp, err = impl.GitHubClient().Repositories.Get(context.Background(), "mattermost", "mattermost-server")
+		require.Nil(t, err)
+	}
+
+	// Tokens rotate until one runs out, then it is skipped
+	require.Equal(t, []string{"token-a", "token-b", "token-a", "token-b", "token-b"}, used)
+	quotas := pool.Quotas(RateResourceCore)
+	require.Equal(t, 0, quotas[0].Remaining)
+	require.Equal(t, 4997, quotas[1].Remaining)
+	require.Equal(t, -1, pool.Quotas(RateResourceGraphQL)[0].Remaining)
+
+	// The client sees the quota of the whole pool
+	require.Equal(t, 4997, resp.Rate.Remaining)
+	require.Equal(t, 10000, resp.Rate.Limit)
+
+	// A token found exhausted is replaced in the same call
+	remaining = map[string]int{"token-a": 0, "token-c": 10}
+	used = []string{}
+	mux.HandleFunc("/api/v3/repos/mattermost/other", func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "token ")
+		used = append(used, token)
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", fmt.Sprintf("%d", remaining[token]))
+		w.Header().Set("X-RateLimit-Reset", fmt.Sprintf("%d", reset))
+		if remaining[token] == 0 {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message":"API rate limit exceeded"}`))
+			return
+		}
+		w.Write([]byte(`{"name":"other","owner":{"login":"mattermost"}}`))
+	})
+	gh, err = NewWithTokens([]string{"token-a", "token-c"}, &Options{EnterpriseURL: serverURL})
+	require.Nil(t, err)
+	impl = gh.NewRepository("mattermost", "other").impl.(*defaultRepoImplementation)
+	_, _, err = impl.GitHubClient().Repositories.Get(context.Background(), "mattermost", "other")
+	require.Nil(t, err)
+	require.Equal(t, []string{"token-a", "token-c"}, used)
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"strings"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+)
+
+const enableAutoMergeMutation = `mutation($pullRequestId: ID!, $mergeMethod: PullRequestMergeMethod!) {
+  enablePullRequestAutoMerge(input: {pullRequestId: $pullRequestId, mergeMethod: $mergeMethod}) {
+    clientMutationId
+  }
+}`
+
+// autoMergeMethods maps the merge modes to the GraphQL merge methods
+var autoMergeMethods = map[MergeMode]string{
+	MergeModeMerge:  "MERGE",
+	MergeModeSquash: "SQUASH",
+	MergeModeRebase: "REBASE",
+}
+
+// enableAutoMerge turns on auto-merge for the pull request
+func (impl *defaultPRImplementation) enableAutoMerge(ctx context.Context, pr *PullRequest, mode MergeMode) error {
+	method, ok := autoMergeMethods[mode]
+	if !ok {
+		return errors.Errorf("merge mode %s cannot be used for auto-merge", mode)
+	}
+
+	// The mutation needs the GraphQL ID of the PR
+	if err := impl.loadNodeID(ctx, pr); err != nil {
+		return err
+	}
+
+	if impl.getOptions().DryRun {
+		impl.log(pr).Infof("[dry-run] Would enable auto-merge (%s) on PR #%d", mode, pr.Number)
+		return nil
+	}
+
+	err := impl.doWithRetry(ctx, "graphql.Do", func() (*gogithub.Response, error) {
+		return impl.GitHubClient().GraphQL.Do(ctx, enableAutoMergeMutation, map[string]interface{}{
+			"pullRequestId": pr.NodeID,
+			"mergeMethod":   method,
+		}, nil)
+	})
+	if err != nil {
+		if isAutoMergeDisabled(err) {
+			return errors.Wrapf(ErrAutoMergeDisabled, "enabling auto-merge on PR #%d", pr.Number)
+		}
+		return errors.Wrapf(err, "enabling auto-merge on PR #%d", pr.Number)
+	}
+	impl.log(pr).Infof("Enabled auto-merge (%s) on PR #%d", mode, pr.Number)
+	return nil
+}
+
+// isAutoMergeDisabled checks if GitHub rejected the mutation
+// because auto-merge is not allowed in the repository
+func isAutoMergeDisabled(err error) bool {
+	gqlErrors := GraphQLErrors{}
+	if !errors.As(err, &gqlErrors) {
+		return false
+	}
+	for _, e := range gqlErrors {
+		if strings.Contains(strings.ToLower(e.Message), "auto merge is not allowed") {
+			return true
+		}
+	}
+	return false
+}
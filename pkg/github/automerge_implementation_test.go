@@ -0,0 +1,70 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnableAutoMerge(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	impl := &defaultPRImplementation{githubAPIUser: gau}
+	pr := &PullRequest{
+		impl: impl, NodeID: "PR_node", RepoOwner: "mattermost", RepoName: "mattermost-server", Number: 1,
+	}
+
+	require.Nil(t, pr.EnableAutoMerge(context.Background(), MergeModeSquash))
+	require.Len(t, fakes.graphql.Calls, 1)
+	require.Equal(t, "PR_node", fakes.graphql.Calls[0].Variables["pullRequestId"])
+	require.Equal(t, "SQUASH", fakes.graphql.Calls[0].Variables["mergeMethod"])
+
+	// Merge modes without a merge method are rejected
+	require.NotNil(t, pr.EnableAutoMerge(context.Background(), MergeModeQueue))
+	require.Len(t, fakes.graphql.Calls, 1)
+
+	// Repositories without auto-merge return a specific error
+	fakes.graphql.Stub = func(string, map[string]interface{}) (string, error) {
+		return "", GraphQLErrors{{
+			Type: "UNPROCESSABLE", Message: "Pull request Auto merge is not allowed for this repository",
+		}}
+	}
+	err := pr.EnableAutoMerge(context.Background(), MergeModeMerge)
+	require.True(t, errors.Is(err, ErrAutoMergeDisabled))
+}
+
+func TestGraphQLClient(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		request := struct {
+			Variables map[string]interface{} `json:"variables"`
+		}{}
+		require.Nil(t, json.NewDecoder(r.Body).Decode(&request))
+		if request.Variables["fail"] == true {
+			w.Write([]byte(`{"data":null,"errors":[{"type":"NOT_FOUND","message":"Could not resolve"}]}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"viewer":{"login":"mattermod"}}}`))
+	})
+	gau := newTestAPIUser(t, mux)
+
+	result := struct {
+		Viewer struct{ Login string } `json:"viewer"`
+	}{}
+	_, err := gau.GitHubClient().GraphQL.Do(context.Background(), "query { viewer { login } }", nil, &result)
+	require.Nil(t, err)
+	require.Equal(t, "mattermod", result.Viewer.Login)
+
+	_, err = gau.GitHubClient().GraphQL.Do(
+		context.Background(), "query { viewer { login } }", map[string]interface{}{"fail": true}, nil,
+	)
+	gqlErrors := GraphQLErrors{}
+	require.True(t, errors.As(err, &gqlErrors))
+	require.Equal(t, "NOT_FOUND", gqlErrors[0].Type)
+}
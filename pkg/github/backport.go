@@ -0,0 +1,408 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Steps of a backport, in the order they run
+const (
+	BackportStepMergedCheck = "merged-check" // The source PR must be merged
+	BackportStepMergeMode   = "merge-mode"   // Detect how the source PR was merged
+	BackportStepCherryPick  = "cherry-pick"  // Record the changes in a branch off the target
+	BackportStepPullRequest = "pull-request" // Open the backport PR from the branch
+	BackportStepMetadata    = "metadata"     // Copy the labels, milestone and assignees to the backport PR
+	BackportStepReviewers   = "reviewers"    // Ask the approvers of the source PR to review the backport
+	BackportStepComment     = "comment"      // Report the result on the source PR
+)
+
+// backportBranchPrefix starts the name of the branches of backports,
+// eg backport-1234-release-7.8
+const backportBranchPrefix = "backport"
+
+// backportCommentMarker tags the comments reporting the backports of a
+// pull request so they are updated when the backport is run again
+const backportCommentMarker = "backport-%s"
+
+// backportSummaryMarker tags the comment summing up
+// the backports of a pull request to several branches
+const backportSummaryMarker = "backport-summary"
+
+// BackportOptions control the steps run by Backport. Every step can be
+// skipped to adapt the flow to the conventions of each team.
+type BackportOptions struct {
+	// CherryPick controls how the target branch is handled
+	CherryPick *CherryPickOptions
+
+	// PullRequest controls the backport pull request and the
+	// metadata copied to it
+	PullRequest BackportPROptions
+
+	SkipMergedCheck bool // Do not check that the source PR is merged
+	SkipCherryPick  bool // Use the cherry-pick branch as it is, it must exist
+	SkipPullRequest bool // Do not open the backport PR
+	SkipMetadata    bool // Do not copy labels, milestone or assignees to the backport PR
+	SkipReviewers   bool // Do not request reviews from the approvers of the source PR
+	SkipComment     bool // Do not report the result on the source PR
+}
+
+// BackportStep is the outcome of a step of the backport
+type BackportStep struct {
+	Name    string // One of the BackportStep constants
+	Skipped bool   // The step was skipped by the options or was not needed
+	Err     error  // Error that stopped the backport, nil on success
+}
+
+// BackportResult describes what a backport did
+type BackportResult struct {
+	Target      string          // Branch the PR was backported to
+	Err         error           // Error that stopped the backport, nil on success
+	MergeMode   MergeMode       // How the source PR was merged
+	Branch      string          // Branch where the cherry-pick was recorded
+	SHA         string          // Head of the cherry-pick branch
+	PullRequest *PullRequest    // Backport PR, nil if it was not opened
+	Reviewers   []string        // Approvers of the source PR asked to review the backport
+	CommentID   int64           // ID of the comment posted on the source PR
+	Conflict    *ConflictReport // How to backport by hand when the cherry-pick conflicted
+	Steps       []BackportStep  // Steps run, in order
+}
+
+// Step returns the outcome of the named step or nil if it did not run
+func (br *BackportResult) Step(name string) *BackportStep {
+	for i := range br.Steps {
+		if br.Steps[i].Name == name {
+			return &br.Steps[i]
+		}
+	}
+	return nil
+}
+
+// record runs a step and stores its outcome in the result
+func (br *BackportResult) record(name string, skip bool, fn func() error) error {
+	if skip {
+		br.Steps = append(br.Steps, BackportStep{Name: name, Skipped: true})
+		return nil
+	}
+	err := fn()
+	br.Steps = append(br.Steps, BackportStep{Name: name, Err: err})
+	return err
+}
+
+// Backport cherry-picks the pull request to the target branch and opens a
+// pull request with the changes. It checks the PR is merged, detects its
+// merge mode, cherry-picks it to the backport-<pr>-<target> branch, opens
+// the backport PR, copies the labels, milestone and assignees to it, asks
+// the approvers of the PR to review it and reports the result in a
+// comment on the PR.
+//
+// The backport stops at the first step that fails, its error is returned
+// along with the result. Failed cherry-picks are still reported in the
+// comment, listing the conflicting files.
+func (pr *PullRequest) Backport(
+	ctx context.Context, targetBranch string, opts *BackportOptions,
+) (_ *BackportResult, err error) {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	if opts == nil {
+		opts = &BackportOptions{}
+	}
+	result := &BackportResult{Target: targetBranch, Steps: []BackportStep{}}
+	defer func() { result.Err = err }()
+
+	err = result.record(BackportStepMergedCheck, opts.SkipMergedCheck, func() error {
+		if !pr.IsMerged() {
+			return errors.Wrapf(ErrNotMerged, "backporting PR #%d", pr.Number)
+		}
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	err = result.record(BackportStepMergeMode, opts.SkipCherryPick, func() (err error) {
+		result.MergeMode, err = pr.GetMergeMode(ctx)
+		return err
+	})
+	if err != nil {
+		return result, err
+	}
+
+	cherryPickOpts := CherryPickOptions{BranchPrefix: backportBranchPrefix}
+	if opts.CherryPick != nil {
+		cherryPickOpts = *opts.CherryPick
+		if cherryPickOpts.BranchPrefix == "" {
+			cherryPickOpts.BranchPrefix = backportBranchPrefix
+		}
+	}
+	branch := cherryPickBranch(pr, targetBranch, &cherryPickOpts)
+	result.Branch = branch
+	err = result.record(BackportStepCherryPick, opts.SkipCherryPick, func() (err error) {
+		result.Branch, result.SHA, err = pr.CherryPickWithOptions(ctx, targetBranch, &cherryPickOpts)
+		return err
+	})
+	if err != nil {
+		// Let the PR know which files need manual attention and how
+		// to finish the backport
+		if len(ConflictPaths(err)) > 0 {
+			var rerr error
+			if result.Conflict, rerr = pr.ConflictReport(ctx, err, branch); rerr != nil {
+				pr.impl.log(pr).Warnf("Could not build the conflict report: %v", rerr)
+			}
+		}
+		result.record(BackportStepComment, opts.SkipComment, func() (cerr error) { // nolint: errcheck
+			pr.impl.log(pr).Warnf("Cherry-pick of PR #%d to %s failed: %v", pr.Number, targetBranch, err)
+			result.CommentID, cerr = pr.UpdateOrCreateComment(
+				ctx, fmt.Sprintf(backportCommentMarker, targetBranch), backportFailureComment(targetBranch, err, result.Conflict),
+			)
+			return cerr
+		})
+		return result, err
+	}
+
+	err = result.record(BackportStepPullRequest, opts.SkipPullRequest, func() (err error) {
+		prOpts := opts.PullRequest
+		prOpts.SkipLabels, prOpts.SkipMilestone, prOpts.SkipAssignees = true, true, true
+		result.PullRequest, err = pr.OpenBackportPR(ctx, targetBranch, result.Branch, &prOpts)
+		return err
+	})
+	if err != nil {
+		return result, err
+	}
+
+	// There is no PR to update when it was skipped or in dry-run mode
+	err = result.record(BackportStepMetadata, opts.SkipMetadata || result.PullRequest == nil, func() error {
+		return pr.copyBackportMetadata(ctx, result.PullRequest, &opts.PullRequest)
+	})
+	if err != nil {
+		return result, err
+	}
+
+	err = result.record(BackportStepReviewers, opts.SkipReviewers || result.PullRequest == nil, func() (err error) {
+		result.Reviewers, err = pr.requestBackportReviews(ctx, result.PullRequest)
+		return err
+	})
+	if err != nil {
+		return result, err
+	}
+
+	err = result.record(BackportStepComment, opts.SkipComment, func() (err error) {
+		result.CommentID, err = pr.UpdateOrCreateComment(
+			ctx, fmt.Sprintf(backportCommentMarker, targetBranch), backportSuccessComment(targetBranch, result),
+		)
+		return err
+	})
+	return result, err
+}
+
+// Labels set on pull requests according to the result of CanBackport
+const (
+	BackportCleanLabel     = "backport-clean"
+	BackportConflictsLabel = "backport-conflicts"
+)
+
+// BackportCheck is the outcome of checking if a pull request can be
+// cherry-picked to a branch
+type BackportCheck struct {
+	Target    string   // Branch checked
+	Clean     bool     // The cherry-pick applies without conflicts
+	Applied   bool     // The changes are already in the branch
+	Commit    string   // Commit that does not apply, when not clean
+	Conflicts []string // Conflicting paths, sorted
+}
+
+// Label returns the label summing up the check
+func (bc *BackportCheck) Label() string {
+	if bc.Clean {
+		return BackportCleanLabel
+	}
+	return BackportConflictsLabel
+}
+
+// CanBackport checks if the merged pull request can be cherry-picked to
+// the target branch without conflicts. The changes are replayed in memory
+// with the same three-way check of the cherry-pick, nothing is written to
+// the repository.
+func (pr *PullRequest) CanBackport(
+	ctx context.Context, targetBranch string, opts *CherryPickOptions,
+) (*BackportCheck, error) {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	if !pr.IsMerged() {
+		return nil, errors.Wrapf(ErrNotMerged, "checking backport of PR #%d", pr.Number)
+	}
+	if opts == nil {
+		opts = &CherryPickOptions{}
+	}
+	if opts.CreateIfMissing && opts.BaseRef == "" {
+		return nil, errors.New("a base ref is required to check missing target branches")
+	}
+	return pr.impl.checkCherryPick(ctx, pr, targetBranch, opts)
+}
+
+// BackportToBranches backports the pull request to each of the targets,
+// in order. A failure in one target does not stop the rest. Instead of a
+// comment per target, a single comment summing up the successes and
+// failures is posted on the PR, updated when the backports run again.
+// The results are returned in the order of the targets, an error is
+// returned only when the summary cannot be posted.
+func (pr *PullRequest) BackportToBranches(
+	ctx context.Context, targets []string, opts *BackportOptions,
+) ([]*BackportResult, error) {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	if len(targets) == 0 {
+		return nil, errors.New("no target branches to backport to")
+	}
+	if opts == nil {
+		opts = &BackportOptions{}
+	}
+	targetOpts := *opts
+	targetOpts.SkipComment = true
+
+	results := []*BackportResult{}
+	for _, target := range targets {
+		result, err := pr.Backport(ctx, target, &targetOpts)
+		if err != nil {
+			pr.impl.log(pr).Warnf("Backport of PR #%d to %s failed: %v", pr.Number, target, err)
+		}
+		results = append(results, result)
+	}
+
+	if opts.SkipComment {
+		return results, nil
+	}
+	if _, err := pr.UpdateOrCreateComment(ctx, backportSummaryMarker, backportSummaryComment(results)); err != nil {
+		return results, errors.Wrap(err, "posting backport summary")
+	}
+	return results, nil
+}
+
+// copyBackportMetadata copies the labels, milestone and
+// assignees of the pull request to its backport
+func (pr *PullRequest) copyBackportMetadata(ctx context.Context, backport *PullRequest, opts *BackportPROptions) error {
+	if !opts.SkipAssignees {
+		if err := backport.AddAssignees(ctx, pr.Assignees...); err != nil {
+			return err
+		}
+	}
+	if !opts.SkipLabels {
+		if _, err := pr.GetLabels(ctx); err != nil {
+			return err
+		}
+		if err := backport.AddLabels(ctx, backportLabels(pr, opts)...); err != nil {
+			return err
+		}
+	}
+	if !opts.SkipMilestone {
+		title, err := pr.GetMilestone(ctx)
+		if err != nil {
+			return err
+		}
+		if title != "" {
+			return backport.SetMilestone(ctx, title)
+		}
+	}
+	return nil
+}
+
+// requestBackportReviews asks the approvers of the pull request to review
+// its backport and returns them. The author of the backport is skipped,
+// GitHub does not allow requesting their review.
+func (pr *PullRequest) requestBackportReviews(ctx context.Context, backport *PullRequest) ([]string, error) {
+	approvers, err := pr.GetApprovers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reviewers := []string{}
+	for _, login := range approvers {
+		if !strings.EqualFold(login, backport.Username) {
+			reviewers = append(reviewers, login)
+		}
+	}
+	if err := backport.RequestReviewers(ctx, reviewers, nil); err != nil {
+		return nil, err
+	}
+	return reviewers, nil
+}
+
+// backportSuccessComment is the comment reporting a backport
+func backportSuccessComment(targetBranch string, result *BackportResult) string {
+	if result.PullRequest != nil {
+		return fmt.Sprintf("Cherry-picked to `%s` in #%d.", targetBranch, result.PullRequest.Number)
+	}
+	return fmt.Sprintf("Cherry-picked to `%s` in branch `%s`.", targetBranch, result.Branch)
+}
+
+// backportSummaryComment is the comment reporting the backports to
+// several branches, one line per branch
+func backportSummaryComment(results []*BackportResult) string {
+	var sb strings.Builder
+	sb.WriteString("Backport results:\n")
+	for _, result := range results {
+		sb.WriteString(fmt.Sprintf("\n- `%s`: ", result.Target))
+		paths := ConflictPaths(result.Err)
+		switch {
+		case len(paths) > 0:
+			sb.WriteString(fmt.Sprintf("conflicts in `%s`, please backport manually", strings.Join(paths, "`, `")))
+		case result.Err != nil:
+			sb.WriteString("failed: " + failureReason(result.Err))
+		case result.PullRequest != nil:
+			sb.WriteString(fmt.Sprintf("opened #%d", result.PullRequest.Number))
+		default:
+			sb.WriteString(fmt.Sprintf("cherry-picked in branch `%s`", result.Branch))
+		}
+	}
+	for _, result := range results {
+		if result.Conflict == nil {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf(
+			"\n\n<details><summary>Backporting to <code>%s</code> manually</summary>\n\n%s\n</details>",
+			result.Target, result.Conflict.commandsMarkdown(),
+		))
+	}
+	return sb.String()
+}
+
+// backportFailureComment is the comment reporting a failed cherry-pick
+func backportFailureComment(targetBranch string, err error, report *ConflictReport) string {
+	if report != nil {
+		return report.Markdown()
+	}
+	paths := ConflictPaths(err)
+	if len(paths) == 0 {
+		return fmt.Sprintf("Could not cherry-pick to `%s`: %s", targetBranch, failureReason(err))
+	}
+	return fmt.Sprintf(
+		"Could not cherry-pick to `%s`, these files conflict:\n\n- `%s`\n\nPlease backport this PR manually.",
+		targetBranch, strings.Join(paths, "`\n- `"),
+	)
+}
+
+// failureReason returns a short description of an error for the
+// comments posted on pull requests. The errors themselves are only
+// logged, they can include API URLs and internal details.
+func failureReason(err error) string {
+	switch {
+	case errors.Is(err, ErrCherryPickConflict):
+		return "conflicts"
+	case errors.Is(err, ErrTargetBranchMissing):
+		return "branch missing"
+	case errors.Is(err, ErrNotMerged), errors.Is(err, ErrNoMergeCommit):
+		return "not merged"
+	case errors.Is(err, ErrStaleMergeCommit), errors.Is(err, ErrPatchTreeNotFound):
+		return "merge commit not found"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timed out"
+	}
+	return "unexpected error, see the logs"
+}
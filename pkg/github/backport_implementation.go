@@ -0,0 +1,122 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"fmt"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+)
+
+const (
+	backportTitleTemplate = "[%s] %s (#%d)"
+	backportBodyTemplate  = "Automated cherry-pick of #%d\n\n%s"
+)
+
+// openBackportPR creates the pull request for a cherry-pick branch
+func (impl *defaultPRImplementation) openBackportPR(
+	ctx context.Context, pr *PullRequest, targetBranch, cherryBranch string, opts *BackportPROptions,
+) (*PullRequest, error) {
+	// If the backport PR was already opened, we return it
+	existing, err := impl.findOpenPullRequest(ctx, pr.RepoOwner, pr.RepoName, cherryBranch, targetBranch)
+	if err != nil {
+		return nil, errors.Wrap(err, "searching for existing backport pull request")
+	}
+	if existing != nil {
+		impl.log(pr).Infof("Backport of PR #%d to %s already open as #%d", pr.Number, targetBranch, existing.Number)
+		return existing, nil
+	}
+
+	if impl.getOptions().DryRun {
+		impl.log(pr).Infof("[dry-run] Would open backport PR of #%d from %s to %s", pr.Number, cherryBranch, targetBranch)
+		return nil, nil
+	}
+
+	var ghpr *gogithub.PullRequest
+	err = impl.doWithRetry(ctx, "pulls.Create", func() (resp *gogithub.Response, err error) {
+		ghpr, resp, err = impl.GitHubClient().PullRequests.Create(
+			ctx, pr.RepoOwner, pr.RepoName, &gogithub.NewPullRequest{
+				Title:               gogithub.String(fmt.Sprintf(backportTitleTemplate, targetBranch, pr.Title, pr.Number)),
+				Body:                gogithub.String(fmt.Sprintf(backportBodyTemplate, pr.Number, pr.Body)),
+				Head:                gogithub.String(cherryBranch),
+				Base:                gogithub.String(targetBranch),
+				MaintainerCanModify: gogithub.Bool(true),
+			},
+		)
+		return resp, err
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating backport pull request for #%d", pr.Number)
+	}
+	backport := impl.NewPullRequest(ghpr)
+
+	// Copy the labels and milestone of the original PR
+	labels := backportLabels(pr, opts)
+	request := &gogithub.IssueRequest{}
+	if len(labels) > 0 && !opts.SkipLabels {
+		request.Labels = &labels
+	}
+	if pr.MilestoneNumber != nil && *pr.MilestoneNumber != 0 && !opts.SkipMilestone {
+		request.Milestone = gogithub.Int(int(*pr.MilestoneNumber))
+	}
+	if len(pr.Assignees) > 0 && !opts.SkipAssignees {
+		assignees := append([]string{}, pr.Assignees...)
+		request.Assignees = &assignees
+	}
+	if request.Labels != nil || request.Milestone != nil || request.Assignees != nil {
+		err = impl.doWithRetry(ctx, "issues.Edit", func() (resp *gogithub.Response, err error) {
+			_, resp, err = impl.GitHubClient().Issues.Edit(ctx, pr.RepoOwner, pr.RepoName, backport.Number, request)
+			return resp, err
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "copying labels, milestone and assignees to backport PR #%d", backport.Number)
+		}
+		if request.Labels != nil {
+			backport.Labels = labels
+		}
+		if request.Milestone != nil {
+			backport.MilestoneNumber = pr.MilestoneNumber
+			backport.MilestoneTitle = pr.MilestoneTitle
+		}
+		if request.Assignees != nil {
+			backport.Assignees = *request.Assignees
+		}
+	}
+
+	impl.log(pr).Infof("Opened backport PR #%d for #%d on %s", backport.Number, pr.Number, targetBranch)
+	return backport, nil
+}
+
+// backportLabels returns the labels of the pull request
+// to copy to its backports, without the trigger label
+func backportLabels(pr *PullRequest, opts *BackportPROptions) []string {
+	labels := []string{}
+	for _, label := range pr.Labels {
+		if label != opts.TriggerLabel {
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
+
+// findOpenPullRequest returns the open pull request from the head branch
+// of the repository to base, or nil if there is none
+func (gau *githubAPIUser) findOpenPullRequest(ctx context.Context, owner, repo, head, base string) (*PullRequest, error) {
+	var existing []*gogithub.PullRequest
+	err := gau.doWithRetry(ctx, "pulls.List", func() (resp *gogithub.Response, err error) {
+		existing, resp, err = gau.GitHubClient().PullRequests.List(
+			ctx, owner, repo, &gogithub.PullRequestListOptions{State: "open", Head: owner + ":" + head, Base: base},
+		)
+		return resp, err
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing pull requests from %s to %s", head, base)
+	}
+	if len(existing) == 0 {
+		return nil, nil
+	}
+	return gau.NewPullRequest(existing[0]), nil
+}
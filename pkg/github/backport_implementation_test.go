@@ -0,0 +1,73 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenBackportPR(t *testing.T) {
+	var created *gogithub.NewPullRequest
+	var edited *gogithub.IssueRequest
+	existing := "[]"
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/mattermost/mattermost-server/pulls", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Write([]byte(existing))
+			return
+		}
+		created = &gogithub.NewPullRequest{}
+		require.Nil(t, json.NewDecoder(r.Body).Decode(created))
+		w.Write([]byte(`{"number":200,"title":"backport"}`))
+	})
+	mux.HandleFunc("/repos/mattermost/mattermost-server/issues/200", func(w http.ResponseWriter, r *http.Request) {
+		edited = &gogithub.IssueRequest{}
+		require.Nil(t, json.NewDecoder(r.Body).Decode(edited))
+		w.Write([]byte(`{"number":200}`))
+	})
+
+	impl := &defaultPRImplementation{githubAPIUser: newTestAPIUser(t, mux)}
+	pr := &PullRequest{
+		RepoOwner:       "mattermost",
+		RepoName:        "mattermost-server",
+		Number:          100,
+		Title:           "Fix the thing",
+		Body:            "Original description",
+		Labels:          []string{"CherryPick/Approved", "Bug"},
+		MilestoneNumber: gogithub.Int64(7),
+		Assignees:       []string{"alice"},
+	}
+
+	backport, err := impl.openBackportPR(
+		context.Background(), pr, "release-7.1", "cherry-pick-100-release-7.1",
+		&BackportPROptions{TriggerLabel: "CherryPick/Approved"},
+	)
+	require.Nil(t, err)
+	require.Equal(t, 200, backport.Number)
+	require.Equal(t, "[release-7.1] Fix the thing (#100)", created.GetTitle())
+	require.Equal(t, "Automated cherry-pick of #100\n\nOriginal description", created.GetBody())
+	require.Equal(t, "release-7.1", created.GetBase())
+	require.Equal(t, "cherry-pick-100-release-7.1", created.GetHead())
+	require.Equal(t, []string{"Bug"}, *edited.Labels)
+	require.Equal(t, 7, edited.GetMilestone())
+	require.Equal(t, []string{"alice"}, *edited.Assignees)
+	require.Equal(t, []string{"alice"}, backport.Assignees)
+
+	// When the PR is already open, it is returned without creating a new one
+	created = nil
+	existing = `[{"number":150}]`
+	backport, err = impl.openBackportPR(
+		context.Background(), pr, "release-7.1", "cherry-pick-100-release-7.1", &BackportPROptions{},
+	)
+	require.Nil(t, err)
+	require.Equal(t, 150, backport.Number)
+	require.Nil(t, created)
+}
@@ -0,0 +1,284 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackport(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	impl := &defaultPRImplementation{githubAPIUser: gau}
+
+	// A PR with one commit, squashed on merge, touching a.go
+	fakes.pulls.Commits[1] = []*gogithub.RepositoryCommit{
+		fakes.addCommit("pr-1", "tree-1", "main-old"),
+	}
+	fakes.pulls.PullRequests[1] = &gogithub.PullRequest{Number: gogithub.Int(1)}
+	fakes.addCommit("main-old", "tree-base")
+	fakes.addCommit("squashed", "tree-merged", "main-old")
+	fakes.git.Trees["tree-base"] = &gogithub.Tree{Entries: []*gogithub.TreeEntry{testEntry("a.go", "a1")}}
+	fakes.git.Trees["tree-merged"] = &gogithub.Tree{Entries: []*gogithub.TreeEntry{testEntry("a.go", "a2")}}
+	fakes.addCommit("release-head", "tree-release")
+	fakes.git.Refs["heads/release-7.1"] = &gogithub.Reference{
+		Ref: gogithub.String("refs/heads/release-7.1"), Object: &gogithub.GitObject{SHA: gogithub.String("release-head")},
+	}
+	fakes.git.Trees["tree-release"] = &gogithub.Tree{
+		SHA: gogithub.String("tree-release"), Entries: []*gogithub.TreeEntry{testEntry("a.go", "a1")},
+	}
+	fakes.issues.Milestones = []*gogithub.Milestone{
+		{Number: gogithub.Int(3), Title: gogithub.String("v7.1"), State: gogithub.String("open")},
+	}
+	// Bob and Carol approved, Carol after requesting changes
+	review := func(login, state string, minute int) *gogithub.PullRequestReview {
+		submitted := time.Date(2021, 10, 1, 10, minute, 0, 0, time.UTC)
+		return &gogithub.PullRequestReview{
+			User: &gogithub.User{Login: gogithub.String(login)}, State: gogithub.String(state), SubmittedAt: &submitted,
+		}
+	}
+	fakes.pulls.Reviews = map[int][]*gogithub.PullRequestReview{1: {
+		review("bob", "APPROVED", 1),
+		review("carol", "CHANGES_REQUESTED", 2),
+		review("carol", "APPROVED", 3),
+		review("dave", "COMMENTED", 4),
+	}}
+	fakes.pulls.Files = map[int][]*gogithub.CommitFile{1: {
+		{Filename: gogithub.String("a.go"), Patch: gogithub.String("@@ -1 +1 @@\n-a1\n+a2")},
+	}}
+	// The backport PR, to record its assignees
+	fakes.issues.Issues = map[string]*gogithub.Issue{"mattermost/mattermost-server#2": {Number: gogithub.Int(2)}}
+
+	newPR := func() *PullRequest {
+		return &PullRequest{
+			impl:           impl,
+			RepoOwner:      "mattermost",
+			RepoName:       "mattermost-server",
+			Number:         1,
+			Title:          "Fix the thing",
+			Merged:         gogithub.Bool(true),
+			MergeCommitSHA: "squashed",
+			Labels:         []string{"CherryPick/Approved", "Bug"},
+			labelsLoaded:   true,
+			MilestoneTitle: gogithub.String("v7.1"),
+			Assignees:      []string{"alice"},
+		}
+	}
+
+	// Unmerged PRs are not backported
+	pr := newPR()
+	pr.Merged = gogithub.Bool(false)
+	result, err := pr.Backport(context.Background(), "release-7.1", nil)
+	require.True(t, errors.Is(err, ErrNotMerged))
+	require.Len(t, result.Steps, 1)
+	require.Equal(t, BackportStepMergedCheck, result.Steps[0].Name)
+
+	// The whole flow runs and is reported on the source PR
+	pr = newPR()
+	result, err = pr.Backport(context.Background(), "release-7.1", &BackportOptions{
+		PullRequest: BackportPROptions{TriggerLabel: "CherryPick/Approved"},
+	})
+	require.Nil(t, err)
+	require.Equal(t, MergeModeSquash, result.MergeMode)
+	require.Equal(t, "backport-1-release-7.1", result.Branch)
+	require.Len(t, result.Steps, 7)
+	for _, step := range result.Steps {
+		require.False(t, step.Skipped, step.Name)
+		require.Nil(t, step.Err, step.Name)
+	}
+	require.NotNil(t, result.PullRequest)
+	backport := result.PullRequest.Number
+	require.Equal(t, []string{"Bug"}, fakes.issues.Labels[backport])
+	require.Equal(t, "v7.1", fakes.issues.IssueMilestones[backport].GetTitle())
+	require.Equal(t, []string{"alice"}, result.PullRequest.Assignees)
+	require.Equal(t, []string{"bob", "carol"}, result.Reviewers)
+	require.Len(t, fakes.pulls.Requested, 1)
+	require.Equal(t, []string{"bob", "carol"}, fakes.pulls.Requested[0].Reviewers)
+	require.Len(t, fakes.issues.Comments[1], 1)
+	require.Equal(t, result.CommentID, fakes.issues.Comments[1][0].GetID())
+	require.Contains(t, fakes.issues.Comments[1][0].GetBody(), "Cherry-picked to `release-7.1` in #2.")
+
+	// Skipped steps are recorded but not run
+	pr = newPR()
+	result, err = pr.Backport(context.Background(), "release-7.1", &BackportOptions{
+		SkipCherryPick: true, SkipPullRequest: true, SkipComment: true,
+	})
+	require.Nil(t, err)
+	require.Equal(t, "backport-1-release-7.1", result.Branch)
+	require.True(t, result.Step(BackportStepCherryPick).Skipped)
+	require.True(t, result.Step(BackportStepMetadata).Skipped)
+	require.True(t, result.Step(BackportStepReviewers).Skipped)
+	require.True(t, result.Step(BackportStepComment).Skipped)
+	require.Len(t, fakes.git.CreatedCommits, 1)
+
+	// Conflicts stop the backport and are reported in the comment
+	fakes.git.Trees["tree-release"].Entries[0] = testEntry("a.go", "a0")
+	pr = newPR()
+	result, err = pr.Backport(context.Background(), "release-7.1", nil)
+	require.True(t, errors.Is(err, ErrCherryPickConflict))
+	require.NotNil(t, result.Step(BackportStepCherryPick).Err)
+	require.Nil(t, result.Step(BackportStepPullRequest))
+	require.Nil(t, result.Step(BackportStepComment).Err)
+	require.NotNil(t, result.Conflict)
+	require.Equal(t, "backport-1-release-7.1", result.Conflict.Branch)
+	require.Len(t, fakes.issues.Comments[1], 1)
+	body := fakes.issues.Comments[1][0].GetBody()
+	require.Contains(t, body, "- `a.go`")
+	require.Contains(t, body, "git cherry-pick -x squashed")
+	require.Contains(t, body, "-a1\n+a2")
+}
+
+func TestBackportToBranches(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	impl := &defaultPRImplementation{githubAPIUser: gau}
+
+	// A squashed PR touching a.go, which conflicts in release-7.0
+	fakes.pulls.Commits[1] = []*gogithub.RepositoryCommit{fakes.addCommit("pr-1", "tree-1", "main-old")}
+	fakes.pulls.PullRequests[1] = &gogithub.PullRequest{Number: gogithub.Int(1)}
+	fakes.addCommit("main-old", "tree-base")
+	fakes.addCommit("squashed", "tree-merged", "main-old")
+	fakes.git.Trees["tree-base"] = &gogithub.Tree{Entries: []*gogithub.TreeEntry{testEntry("a.go", "a1")}}
+	fakes.git.Trees["tree-merged"] = &gogithub.Tree{Entries: []*gogithub.TreeEntry{testEntry("a.go", "a2")}}
+	for branch, blob := range map[string]string{"release-7.1": "a1", "release-7.0": "a0"} {
+		fakes.addCommit(branch+"-head", branch+"-tree")
+		fakes.git.Refs["heads/"+branch] = &gogithub.Reference{
+			Ref: gogithub.String("refs/heads/" + branch), Object: &gogithub.GitObject{SHA: gogithub.String(branch + "-head")},
+		}
+		fakes.git.Trees[branch+"-tree"] = &gogithub.Tree{
+			SHA: gogithub.String(branch + "-tree"), Entries: []*gogithub.TreeEntry{testEntry("a.go", blob)},
+		}
+	}
+	fakes.pulls.Files = map[int][]*gogithub.CommitFile{1: {{Filename: gogithub.String("a.go")}}}
+
+	pr := &PullRequest{
+		impl:           impl,
+		RepoOwner:      "mattermost",
+		RepoName:       "mattermost-server",
+		Number:         1,
+		Merged:         gogithub.Bool(true),
+		MergeCommitSHA: "squashed",
+		Labels:         []string{},
+		labelsLoaded:   true,
+		MilestoneTitle: gogithub.String(""),
+	}
+	_, err := pr.BackportToBranches(context.Background(), nil, nil)
+	require.NotNil(t, err)
+
+	// Failures do not stop the other targets
+	targets := []string{"release-7.1", "release-7.0", "release-6.9"}
+	results, err := pr.BackportToBranches(context.Background(), targets, nil)
+	require.Nil(t, err)
+	require.Len(t, results, 3)
+	for i, result := range results {
+		require.Equal(t, targets[i], result.Target)
+	}
+	require.Nil(t, results[0].Err)
+	require.Equal(t, "backport-1-release-7.1", results[0].Branch)
+	require.True(t, errors.Is(results[1].Err, ErrCherryPickConflict))
+	require.True(t, errors.Is(results[2].Err, ErrTargetBranchMissing))
+
+	// A single comment sums up all the targets
+	require.Len(t, fakes.issues.Comments[1], 1)
+	require.Equal(t, "<!-- mattermod:backport-summary -->\nBackport results:\n\n"+
+		"- `release-7.1`: opened #2\n"+
+		"- `release-7.0`: conflicts in `a.go`, please backport manually\n"+
+		"- `release-6.9`: failed: branch missing\n\n"+
+		"<details><summary>Backporting to <code>release-7.0</code> manually</summary>\n\n"+
+		"```sh\n"+strings.Join(results[1].Conflict.Commands(), "\n")+"\n```\n\n</details>",
+		fakes.issues.Comments[1][0].GetBody(),
+	)
+
+	// Running again updates the summary
+	_, err = pr.BackportToBranches(context.Background(), targets[1:], nil)
+	require.Nil(t, err)
+	require.Len(t, fakes.issues.Comments[1], 1)
+	require.NotContains(t, fakes.issues.Comments[1][0].GetBody(), "release-7.1")
+}
+
+func TestCanBackport(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	impl := &defaultPRImplementation{githubAPIUser: gau}
+
+	// A squashed PR changing a.go, which conflicts in release-7.0
+	// and is already in release-7.2
+	fakes.pulls.Commits[1] = []*gogithub.RepositoryCommit{fakes.addCommit("pr-1", "tree-1", "main-old")}
+	fakes.pulls.PullRequests[1] = &gogithub.PullRequest{Number: gogithub.Int(1)}
+	fakes.addCommit("main-old", "tree-base")
+	fakes.addCommit("squashed", "tree-merged", "main-old")
+	fakes.git.Trees["tree-base"] = &gogithub.Tree{Entries: []*gogithub.TreeEntry{testEntry("a.go", "a1")}}
+	fakes.git.Trees["tree-merged"] = &gogithub.Tree{Entries: []*gogithub.TreeEntry{testEntry("a.go", "a2")}}
+	for branch, blob := range map[string]string{"release-7.2": "a2", "release-7.1": "a1", "release-7.0": "a0"} {
+		fakes.addCommit(branch+"-head", branch+"-tree")
+		fakes.git.Refs["heads/"+branch] = &gogithub.Reference{
+			Ref: gogithub.String("refs/heads/" + branch), Object: &gogithub.GitObject{SHA: gogithub.String(branch + "-head")},
+		}
+		fakes.git.Trees[branch+"-tree"] = &gogithub.Tree{
+			SHA: gogithub.String(branch + "-tree"), Entries: []*gogithub.TreeEntry{testEntry("a.go", blob)},
+		}
+	}
+
+	pr := &PullRequest{
+		impl:           impl,
+		RepoOwner:      "mattermost",
+		RepoName:       "mattermost-server",
+		Number:         1,
+		Merged:         gogithub.Bool(false),
+		MergeCommitSHA: "squashed",
+	}
+	_, err := pr.CanBackport(context.Background(), "release-7.1", nil)
+	require.True(t, errors.Is(err, ErrNotMerged))
+
+	pr.Merged = gogithub.Bool(true)
+	check, err := pr.CanBackport(context.Background(), "release-7.1", nil)
+	require.Nil(t, err)
+	require.True(t, check.Clean)
+	require.False(t, check.Applied)
+	require.Equal(t, BackportCleanLabel, check.Label())
+
+	check, err = pr.CanBackport(context.Background(), "release-7.2", nil)
+	require.Nil(t, err)
+	require.True(t, check.Clean)
+	require.True(t, check.Applied)
+
+	check, err = pr.CanBackport(context.Background(), "release-7.0", nil)
+	require.Nil(t, err)
+	require.False(t, check.Clean)
+	require.Equal(t, "squashed", check.Commit)
+	require.Equal(t, []string{"a.go"}, check.Conflicts)
+	require.Equal(t, BackportConflictsLabel, check.Label())
+
+	// Missing branches are checked against the ref they would be created from
+	_, err = pr.CanBackport(context.Background(), "release-6.9", nil)
+	require.True(t, errors.Is(err, ErrTargetBranchMissing))
+	check, err = pr.CanBackport(context.Background(), "release-6.9", &CherryPickOptions{
+		CreateIfMissing: true, BaseRef: "release-7.0",
+	})
+	require.Nil(t, err)
+	require.False(t, check.Clean)
+
+	// Nothing was written
+	require.Empty(t, fakes.git.CreatedTrees)
+	require.Empty(t, fakes.git.CreatedCommits)
+	require.Len(t, fakes.git.Refs, 3)
+}
+
+func TestFailureReason(t *testing.T) {
+	for _, tc := range []struct {
+		Err      error
+		Expected string
+	}{
+		{Err: &CherryPickConflictError{Paths: []string{"a.go"}}, Expected: "conflicts"},
+		{Err: errors.Wrap(ErrTargetBranchMissing, "https://api.github.com/repos/mattermost/x/git/ref/heads/y"), Expected: "branch missing"},
+		{Err: errors.Wrap(context.DeadlineExceeded, "reading commits"), Expected: "timed out"},
+		{Err: errors.New("GET https://api.github.com/repos/mattermost/x: 500"), Expected: "unexpected error, see the logs"},
+	} {
+		require.Equal(t, tc.Expected, failureReason(tc.Err))
+	}
+}
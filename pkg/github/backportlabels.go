@@ -0,0 +1,89 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// BackportLabelsPath is the file where repositories configure
+// the labels requesting backports
+const BackportLabelsPath = ".github/backport-labels.json"
+
+// BackportLabels maps the labels of pull requests to the branches
+// they have to be backported to. Labels are matched ignoring case,
+// like GitHub does.
+type BackportLabels struct {
+	// Prefixes of the labels requesting backports, followed by the
+	// target branch, eg CherryPick/release-7.8 or backport/v2.x
+	Prefixes []string `json:"prefixes"`
+
+	// Branches maps labels to their target branches, overriding the
+	// prefixes. Labels mapped to an empty branch are not targets, eg
+	// the CherryPick/Approved label tracking the state of the backport.
+	// The labels are kept in lower case, see ParseBackportLabels.
+	Branches map[string]string `json:"branches"`
+}
+
+// DefaultBackportLabels returns the labels used when
+// repositories do not configure their own
+func DefaultBackportLabels() *BackportLabels {
+	return &BackportLabels{
+		Prefixes: []string{"CherryPick/", "backport/"},
+		Branches: map[string]string{
+			"cherrypick/approved":  "",
+			"cherrypick/candidate": "",
+			"cherrypick/done":      "",
+			"cherrypick/rejected":  "",
+		},
+	}
+}
+
+// ParseBackportLabels reads the JSON configuration of the backport
+// labels. The prefixes replace the default ones when set, the branches
+// are added to the default ones with their labels in lower case.
+func ParseBackportLabels(data []byte) (*BackportLabels, error) {
+	config := &BackportLabels{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, errors.Wrap(err, "parsing backport labels configuration")
+	}
+	defaults := DefaultBackportLabels()
+	if config.Prefixes == nil {
+		config.Prefixes = defaults.Prefixes
+	}
+	for label, branch := range config.Branches {
+		defaults.Branches[strings.ToLower(label)] = branch
+	}
+	config.Branches = defaults.Branches
+	return config, nil
+}
+
+// Branch returns the branch a label requests a backport to,
+// or an empty string if the label is not a backport label
+func (bl *BackportLabels) Branch(label string) string {
+	if branch, ok := bl.Branches[strings.ToLower(label)]; ok {
+		return branch
+	}
+	for _, prefix := range bl.Prefixes {
+		if len(label) > len(prefix) && strings.EqualFold(label[:len(prefix)], prefix) {
+			return strings.TrimSpace(label[len(prefix):])
+		}
+	}
+	return ""
+}
+
+// Targets returns the branches requested by the labels, in the
+// order of the labels and without duplicates
+func (bl *BackportLabels) Targets(labels []string) []string {
+	targets := []string{}
+	for _, label := range labels {
+		if branch := bl.Branch(label); branch != "" && !containsString(targets, branch) {
+			targets = append(targets, branch)
+		}
+	}
+	return targets
+}
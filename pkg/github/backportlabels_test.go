@@ -0,0 +1,110 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"testing"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackportLabels(t *testing.T) {
+	labels := []string{
+		"CherryPick/Approved", "cherrypick/release-7.8", "Bug", "backport/v2.x", "CherryPick/release-7.8", "CherryPick/",
+	}
+	require.Equal(t, []string{"release-7.8", "v2.x"}, DefaultBackportLabels().Targets(labels))
+
+	// Overrides win over the prefixes and are added to the defaults
+	config, err := ParseBackportLabels([]byte(`{"branches": {"CherryPick/release-7.8": "release-7.8.1", "backport/v2.x": ""}}`))
+	require.Nil(t, err)
+	require.Equal(t, DefaultBackportLabels().Prefixes, config.Prefixes)
+	require.Equal(t, []string{"release-7.8.1"}, config.Targets(labels))
+
+	// Overrides replace the defaults whatever their case
+	config, err = ParseBackportLabels([]byte(`{"branches": {"cherrypick/approved": "release-x"}}`))
+	require.Nil(t, err)
+	require.Len(t, config.Branches, 4)
+	require.Equal(t, "release-x", config.Branch("CherryPick/Approved"))
+
+	config, err = ParseBackportLabels([]byte(`{"prefixes": ["Backport to "]}`))
+	require.Nil(t, err)
+	require.Equal(t, []string{"release-7.7"}, config.Targets([]string{"backport to release-7.7", "CherryPick/release-7.8"}))
+
+	_, err = ParseBackportLabels([]byte(`{"prefixes": "CherryPick/"}`))
+	require.NotNil(t, err)
+}
+
+func TestBackportTargets(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	pr := &PullRequest{
+		impl:         &defaultPRImplementation{githubAPIUser: gau},
+		RepoOwner:    "mattermost",
+		RepoName:     "mattermost-server",
+		Number:       1,
+		BaseRef:      "master",
+		Merged:       gogithub.Bool(true),
+		Labels:       []string{"CherryPick/Approved", "CherryPick/release-7.8", "Backport release-7.7"},
+		labelsLoaded: true,
+	}
+	ctx := context.Background()
+
+	// Repositories without configuration use the default labels
+	targets, err := pr.BackportTargets(ctx, nil)
+	require.Nil(t, err)
+	require.Equal(t, []string{"release-7.8"}, targets)
+
+	fakes.repos.Files = map[string]map[string][]byte{
+		"master": {BackportLabelsPath: []byte(`{"prefixes": ["Backport "]}`)},
+	}
+	targets, err = pr.BackportTargets(ctx, nil)
+	require.Nil(t, err)
+	require.Equal(t, []string{"release-7.7"}, targets)
+
+	pr.Merged = gogithub.Bool(false)
+	_, err = pr.BackportTargets(ctx, DefaultBackportLabels())
+	require.True(t, errors.Is(err, ErrNotMerged))
+}
+
+func TestBackportMilestoneTargets(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	pr := &PullRequest{
+		impl:      &defaultPRImplementation{githubAPIUser: gau},
+		RepoOwner: "mattermost",
+		RepoName:  "mattermost-server",
+		Number:    1,
+		BaseRef:   "master",
+		Merged:    gogithub.Bool(true),
+		Body:      "Fixes #10\nFixes #11\nFixes #12\nFixes #13\nFixes mattermost/focalboard#20",
+	}
+	fakes.pulls.Commits[1] = []*gogithub.RepositoryCommit{}
+	issue := func(number int, milestone string) *gogithub.Issue {
+		return &gogithub.Issue{Number: gogithub.Int(number), Milestone: &gogithub.Milestone{Title: gogithub.String(milestone)}}
+	}
+	fakes.issues.Issues = map[string]*gogithub.Issue{
+		"mattermost/mattermost-server#10": issue(10, "v7.8.1"),
+		"mattermost/mattermost-server#11": issue(11, "v7.9.0"),
+		"mattermost/mattermost-server#12": issue(12, "v7.8.2"),
+		"mattermost/mattermost-server#13": issue(13, "Backlog"),
+		"mattermost/focalboard#20":        issue(20, "v0.15.0"),
+	}
+	ctx := context.Background()
+
+	targets, err := pr.BackportMilestoneTargets(ctx, nil)
+	require.Nil(t, err)
+	require.Equal(t, []string{"release-7.8", "release-7.9"}, targets)
+
+	// Resolvers can map the milestones to any branch
+	targets, err = pr.BackportMilestoneTargets(ctx, func(milestone string) (string, bool) {
+		return "master", milestone == "v7.8.1"
+	})
+	require.Nil(t, err)
+	require.Empty(t, targets)
+
+	pr.Merged = gogithub.Bool(false)
+	_, err = pr.BackportMilestoneTargets(ctx, nil)
+	require.True(t, errors.Is(err, ErrNotMerged))
+}
@@ -0,0 +1,211 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	gogithub "github.com/google/go-github/v39/github"
+)
+
+// RepositoryCache keeps the repositories read from the GitHub API for a
+// limited time to avoid fetching the same data repeatedly. It is safe
+// for concurrent use.
+type RepositoryCache struct {
+	mtx     sync.RWMutex
+	ttl     time.Duration
+	entries map[string]repositoryCacheEntry
+}
+
+type repositoryCacheEntry struct {
+	repo    *gogithub.Repository
+	expires time.Time
+}
+
+// NewRepositoryCache returns a cache that keeps repositories for ttl
+func NewRepositoryCache(ttl time.Duration) *RepositoryCache {
+	return &RepositoryCache{
+		ttl:     ttl,
+		entries: map[string]repositoryCacheEntry{},
+	}
+}
+
+// Get returns a cached repository if it has not expired
+func (rc *RepositoryCache) Get(owner, name string) (*gogithub.Repository, bool) {
+	rc.mtx.RLock()
+	defer rc.mtx.RUnlock()
+	entry, ok := rc.entries[owner+"/"+name]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+	return entry.repo, true
+}
+
+// Set stores a repository in the cache
+func (rc *RepositoryCache) Set(owner, name string, repo *gogithub.Repository) {
+	rc.mtx.Lock()
+	defer rc.mtx.Unlock()
+	rc.entries[owner+"/"+name] = repositoryCacheEntry{
+		repo:    repo,
+		expires: time.Now().Add(rc.ttl),
+	}
+}
+
+// Delete removes a repository from the cache
+func (rc *RepositoryCache) Delete(owner, name string) {
+	rc.mtx.Lock()
+	defer rc.mtx.Unlock()
+	delete(rc.entries, owner+"/"+name)
+}
+
+// Flush removes all repositories from the cache
+func (rc *RepositoryCache) Flush() {
+	rc.mtx.Lock()
+	defer rc.mtx.Unlock()
+	rc.entries = map[string]repositoryCacheEntry{}
+}
+
+// MilestoneCache keeps the open milestones of repositories for a limited
+// time, so milestones are listed once per repository when processing a
+// batch of pull requests. It is safe for concurrent use.
+type MilestoneCache struct {
+	mtx     sync.RWMutex
+	ttl     time.Duration
+	entries map[string]milestoneCacheEntry
+}
+
+type milestoneCacheEntry struct {
+	milestones map[string]int // Milestone numbers by title
+	expires    time.Time
+}
+
+// NewMilestoneCache returns a cache that keeps milestones for ttl
+func NewMilestoneCache(ttl time.Duration) *MilestoneCache {
+	return &MilestoneCache{
+		ttl:     ttl,
+		entries: map[string]milestoneCacheEntry{},
+	}
+}
+
+// Get returns the cached milestone numbers of a repository
+// by title if they have not expired
+func (mc *MilestoneCache) Get(owner, name string) (map[string]int, bool) {
+	mc.mtx.RLock()
+	defer mc.mtx.RUnlock()
+	entry, ok := mc.entries[owner+"/"+name]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+	return entry.milestones, true
+}
+
+// Set stores the milestone numbers of a repository by title
+func (mc *MilestoneCache) Set(owner, name string, milestones map[string]int) {
+	mc.mtx.Lock()
+	defer mc.mtx.Unlock()
+	mc.entries[owner+"/"+name] = milestoneCacheEntry{
+		milestones: milestones,
+		expires:    time.Now().Add(mc.ttl),
+	}
+}
+
+// Delete removes the milestones of a repository from the cache
+func (mc *MilestoneCache) Delete(owner, name string) {
+	mc.mtx.Lock()
+	defer mc.mtx.Unlock()
+	delete(mc.entries, owner+"/"+name)
+}
+
+// Flush removes all milestones from the cache
+func (mc *MilestoneCache) Flush() {
+	mc.mtx.Lock()
+	defer mc.mtx.Unlock()
+	mc.entries = map[string]milestoneCacheEntry{}
+}
+
+// MembershipCache keeps the results of organization and team membership
+// checks for a limited time, so trust checks made on every webhook event
+// do not call the API each time. It is safe for concurrent use.
+type MembershipCache struct {
+	mtx     sync.RWMutex
+	ttl     time.Duration
+	members map[string]membershipCacheEntry // Checks by "group:login"
+	lists   map[string]memberListCacheEntry // Member lists by group
+}
+
+type membershipCacheEntry struct {
+	member  bool
+	expires time.Time
+}
+
+type memberListCacheEntry struct {
+	logins  []string
+	expires time.Time
+}
+
+// NewMembershipCache returns a cache that keeps memberships for ttl
+func NewMembershipCache(ttl time.Duration) *MembershipCache {
+	return &MembershipCache{
+		ttl:     ttl,
+		members: map[string]membershipCacheEntry{},
+		lists:   map[string]memberListCacheEntry{},
+	}
+}
+
+// IsMember returns the cached membership of a user in a group, which
+// is an organization or an "org/team-slug", if it has not expired
+func (mc *MembershipCache) IsMember(group, login string) (member, ok bool) {
+	mc.mtx.RLock()
+	defer mc.mtx.RUnlock()
+	key := strings.ToLower(group + ":" + login)
+	if entry, found := mc.members[key]; found && time.Now().Before(entry.expires) {
+		return entry.member, true
+	}
+	// A list of the group members answers the question too
+	if entry, found := mc.lists[strings.ToLower(group)]; found && time.Now().Before(entry.expires) {
+		return containsFold(entry.logins, login), true
+	}
+	return false, false
+}
+
+// SetMember stores the membership of a user in a group
+func (mc *MembershipCache) SetMember(group, login string, member bool) {
+	mc.mtx.Lock()
+	defer mc.mtx.Unlock()
+	mc.members[strings.ToLower(group+":"+login)] = membershipCacheEntry{
+		member:  member,
+		expires: time.Now().Add(mc.ttl),
+	}
+}
+
+// Members returns the cached logins of the members of a group
+func (mc *MembershipCache) Members(group string) ([]string, bool) {
+	mc.mtx.RLock()
+	defer mc.mtx.RUnlock()
+	entry, ok := mc.lists[strings.ToLower(group)]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+	return entry.logins, true
+}
+
+// SetMembers stores the logins of the members of a group
+func (mc *MembershipCache) SetMembers(group string, logins []string) {
+	mc.mtx.Lock()
+	defer mc.mtx.Unlock()
+	mc.lists[strings.ToLower(group)] = memberListCacheEntry{
+		logins:  logins,
+		expires: time.Now().Add(mc.ttl),
+	}
+}
+
+// Flush removes all memberships from the cache
+func (mc *MembershipCache) Flush() {
+	mc.mtx.Lock()
+	defer mc.mtx.Unlock()
+	mc.members = map[string]membershipCacheEntry{}
+	mc.lists = map[string]memberListCacheEntry{}
+}
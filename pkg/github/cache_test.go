@@ -0,0 +1,49 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepositoryCache(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	gau.options.RepositoryCache = NewRepositoryCache(time.Hour)
+	impl := &defaultPRImplementation{githubAPIUser: gau}
+	ctx := context.Background()
+
+	load := func() {
+		pr := &PullRequest{RepoOwner: "mattermost", RepoName: "mattermost-server", Number: 1}
+		require.Nil(t, impl.loadRepository(ctx, pr))
+		require.Equal(t, "mattermost-server", pr.Repository.Name)
+	}
+
+	// Repeated lookups are served from the cache
+	load()
+	load()
+	load()
+	require.Equal(t, 1, fakes.repos.GetCalls)
+
+	// Flushing forces a new lookup
+	gau.options.RepositoryCache.Flush()
+	load()
+	require.Equal(t, 2, fakes.repos.GetCalls)
+
+	// Expired entries are fetched again
+	gau.options.RepositoryCache = NewRepositoryCache(time.Nanosecond)
+	load()
+	time.Sleep(time.Millisecond)
+	load()
+	require.Equal(t, 4, fakes.repos.GetCalls)
+
+	// Without a cache, every lookup hits the API
+	gau.options.RepositoryCache = nil
+	load()
+	load()
+	require.Equal(t, 6, fakes.repos.GetCalls)
+}
@@ -0,0 +1,191 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"time"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+)
+
+// maxAnnotations is the number of annotations GitHub
+// accepts in each request creating or updating a check run
+const maxAnnotations = 50
+
+// createCheckRun creates a check run on a commit and returns its ID
+func (di *defaultRepoImplementation) createCheckRun(
+	ctx context.Context, owner, repo, sha string, run *CheckRun,
+) (int64, error) {
+	if di.getOptions().DryRun {
+		di.getLogger().Infof("[dry-run] Would create check run %s (%s) on %s", run.Name, run.Status, sha)
+		return 0, nil
+	}
+
+	first, rest := splitAnnotations(run.Annotations)
+	opts := gogithub.CreateCheckRunOptions{
+		Name:    run.Name,
+		HeadSHA: sha,
+		Output:  checkRunOutput(run, first),
+	}
+	if run.Status != "" {
+		opts.Status = gogithub.String(run.Status)
+	}
+	if run.Conclusion != "" {
+		opts.Conclusion = gogithub.String(run.Conclusion)
+		opts.CompletedAt = &gogithub.Timestamp{Time: time.Now()}
+	}
+	if run.DetailsURL != "" {
+		opts.DetailsURL = gogithub.String(run.DetailsURL)
+	}
+
+	var created *gogithub.CheckRun
+	err := di.doWithRetry(ctx, "checks.CreateCheckRun", func() (resp *gogithub.Response, err error) {
+		created, resp, err = di.GitHubClient().Checks.CreateCheckRun(ctx, owner, repo, opts)
+		return resp, err
+	})
+	if err != nil {
+		return 0, errors.Wrapf(err, "creating check run %s on %s", run.Name, sha)
+	}
+	if err := di.addAnnotations(ctx, owner, repo, created.GetID(), run, rest); err != nil {
+		return 0, err
+	}
+	di.getLogger().Infof("Created check run %s (%d) on %s", run.Name, created.GetID(), sha)
+	return created.GetID(), nil
+}
+
+// updateCheckRun changes the status, conclusion or output of a check run
+func (di *defaultRepoImplementation) updateCheckRun(
+	ctx context.Context, owner, repo string, id int64, run *CheckRun,
+) error {
+	if di.getOptions().DryRun {
+		di.getLogger().Infof("[dry-run] Would update check run %s (%d) to %s", run.Name, id, run.Status)
+		return nil
+	}
+
+	first, rest := splitAnnotations(run.Annotations)
+	opts := gogithub.UpdateCheckRunOptions{
+		Name:   run.Name,
+		Output: checkRunOutput(run, first),
+	}
+	if run.Status != "" {
+		opts.Status = gogithub.String(run.Status)
+	}
+	if run.Conclusion != "" {
+		opts.Conclusion = gogithub.String(run.Conclusion)
+		opts.CompletedAt = &gogithub.Timestamp{Time: time.Now()}
+	}
+	if run.DetailsURL != "" {
+		opts.DetailsURL = gogithub.String(run.DetailsURL)
+	}
+
+	err := di.doWithRetry(ctx, "checks.UpdateCheckRun", func() (resp *gogithub.Response, err error) {
+		_, resp, err = di.GitHubClient().Checks.UpdateCheckRun(ctx, owner, repo, id, opts)
+		return resp, err
+	})
+	if err != nil {
+		return errors.Wrapf(err, "updating check run %s (%d)", run.Name, id)
+	}
+	if err := di.addAnnotations(ctx, owner, repo, id, run, rest); err != nil {
+		return err
+	}
+	di.getLogger().Infof("Updated check run %s (%d) to %s", run.Name, id, run.Status)
+	return nil
+}
+
+// addAnnotations sends the annotations that did not fit in the request
+// creating or updating the run. GitHub appends them to the existing ones.
+func (di *defaultRepoImplementation) addAnnotations(
+	ctx context.Context, owner, repo string, id int64, run *CheckRun, annotations []*CheckAnnotation,
+) error {
+	for len(annotations) > 0 {
+		var batch []*CheckAnnotation
+		batch, annotations = splitAnnotations(annotations)
+		opts := gogithub.UpdateCheckRunOptions{Name: run.Name, Output: checkRunOutput(run, batch)}
+		err := di.doWithRetry(ctx, "checks.UpdateCheckRun", func() (resp *gogithub.Response, err error) {
+			_, resp, err = di.GitHubClient().Checks.UpdateCheckRun(ctx, owner, repo, id, opts)
+			return resp, err
+		})
+		if err != nil {
+			return errors.Wrapf(err, "adding annotations to check run %s (%d)", run.Name, id)
+		}
+	}
+	return nil
+}
+
+// setCommitStatus reports a status on a commit
+func (di *defaultRepoImplementation) setCommitStatus(
+	ctx context.Context, owner, repo, sha string, status *CommitStatus,
+) error {
+	if di.getOptions().DryRun {
+		di.getLogger().Infof("[dry-run] Would set status %s of %s to %s", status.Context, sha, status.State)
+		return nil
+	}
+
+	repoStatus := &gogithub.RepoStatus{
+		State:   gogithub.String(status.State),
+		Context: gogithub.String(status.Context),
+	}
+	if status.Description != "" {
+		repoStatus.Description = gogithub.String(status.Description)
+	}
+	if status.TargetURL != "" {
+		repoStatus.TargetURL = gogithub.String(status.TargetURL)
+	}
+	err := di.doWithRetry(ctx, "repos.CreateStatus", func() (resp *gogithub.Response, err error) {
+		_, resp, err = di.GitHubClient().Repositories.CreateStatus(ctx, owner, repo, sha, repoStatus)
+		return resp, err
+	})
+	if err != nil {
+		return errors.Wrapf(err, "setting status %s of %s", status.Context, sha)
+	}
+	di.getLogger().Infof("Set status %s of %s to %s", status.Context, sha, status.State)
+	return nil
+}
+
+// splitAnnotations returns the annotations that fit in
+// a request to GitHub and the ones left over
+func splitAnnotations(annotations []*CheckAnnotation) (batch, rest []*CheckAnnotation) {
+	if len(annotations) <= maxAnnotations {
+		return annotations, nil
+	}
+	return annotations[:maxAnnotations], annotations[maxAnnotations:]
+}
+
+// checkRunOutput builds the output of a check run with the
+// annotations. Runs without output data return nil.
+func checkRunOutput(run *CheckRun, annotations []*CheckAnnotation) *gogithub.CheckRunOutput {
+	if run.Title == "" && run.Summary == "" && run.Text == "" && len(annotations) == 0 {
+		return nil
+	}
+	title := run.Title
+	if title == "" {
+		title = run.Name
+	}
+	output := &gogithub.CheckRunOutput{
+		Title:   gogithub.String(title),
+		Summary: gogithub.String(run.Summary),
+	}
+	if run.Text != "" {
+		output.Text = gogithub.String(run.Text)
+	}
+	for _, a := range annotations {
+		annotation := &gogithub.CheckRunAnnotation{
+			Path:            gogithub.String(a.Path),
+			StartLine:       gogithub.Int(a.StartLine),
+			EndLine:         gogithub.Int(a.EndLine),
+			AnnotationLevel: gogithub.String(a.Level),
+			Message:         gogithub.String(a.Message),
+		}
+		if a.EndLine == 0 {
+			annotation.EndLine = annotation.StartLine
+		}
+		if a.Title != "" {
+			annotation.Title = gogithub.String(a.Title)
+		}
+		output.Annotations = append(output.Annotations, annotation)
+	}
+	return output
+}
@@ -0,0 +1,613 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+)
+
+// cherryPickBranchPrefix starts the name of the branches where the
+// cherry-picked commits are recorded, followed by the PR number and
+// the target branch
+const cherryPickBranchPrefix = "cherry-pick"
+
+// dryRunSHA is returned in place of the SHAs of objects not
+// created because the package runs in dry-run mode
+const dryRunSHA = "0000000000000000000000000000000000000000"
+
+// cherryPickStep is a commit to be replayed on the target branch. The
+// changes applied are the diff between the from commit and source.
+type cherryPickStep struct {
+	from   string
+	source *Commit
+}
+
+// treeFiles maps the paths of files in a git tree to their entries
+type treeFiles map[string]*gogithub.TreeEntry
+
+// cherryPick replays the changes of the pull request on top of the target
+// branch using the git data API. No local clone of the repository is needed:
+// the trees of the commits are compared to compute the changes and a new
+// tree and commit are created for each step.
+func (impl *defaultPRImplementation) cherryPick(
+	ctx context.Context, pr *PullRequest, targetBranch string, opts *CherryPickOptions,
+) (branch, sha string, err error) {
+	start := time.Now()
+	defer func() { impl.observeCherryPick(start, err) }()
+
+	repo, err := pr.GetRepository(ctx)
+	if err != nil {
+		return "", "", errors.Wrap(err, "unable to cherry-pick")
+	}
+
+	if impl.getOptions().SignOff && impl.getOptions().Committer == nil {
+		return "", "", errors.New("a committer identity is required to sign off commits")
+	}
+
+	// Check the target branch before doing any work
+	source := fmt.Sprintf("PR #%d", pr.Number)
+	targetRef, err := impl.checkTargetBranch(ctx, impl.log(pr), source, repo, targetBranch, opts)
+	if err != nil {
+		return "", "", err
+	}
+
+	steps, err := impl.prepareCherryPick(ctx, pr, repo, opts)
+	if err != nil {
+		return "", "", err
+	}
+
+	return impl.applyCherryPick(
+		ctx, impl.log(pr), source, repo, steps, targetBranch, targetRef, cherryPickBranch(pr, targetBranch, opts),
+	)
+}
+
+// cherryPickBranch returns the branch where the cherry-pick of
+// the pull request to the target branch is recorded
+func cherryPickBranch(pr *PullRequest, targetBranch string, opts *CherryPickOptions) string {
+	prefix := cherryPickBranchPrefix
+	if opts != nil && opts.BranchPrefix != "" {
+		prefix = opts.BranchPrefix
+	}
+	return fmt.Sprintf("%s-%d-%s", prefix, pr.Number, targetBranch)
+}
+
+// cherryPickToBranches cherry-picks the pull request to each of the
+// targets. The commits to replay are computed once for all of them. A
+// failure in one target does not stop nor undo the others.
+func (impl *defaultPRImplementation) cherryPickToBranches(
+	ctx context.Context, pr *PullRequest, targets []string, opts *CherryPickOptions,
+) (map[string]CherryPickResult, error) {
+	repo, err := pr.GetRepository(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to cherry-pick")
+	}
+
+	if impl.getOptions().SignOff && impl.getOptions().Committer == nil {
+		return nil, errors.New("a committer identity is required to sign off commits")
+	}
+
+	steps, err := impl.prepareCherryPick(ctx, pr, repo, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	source := fmt.Sprintf("PR #%d", pr.Number)
+	results := map[string]CherryPickResult{}
+	for _, targetBranch := range targets {
+		start := time.Now()
+		targetRef, err := impl.checkTargetBranch(ctx, impl.log(pr), source, repo, targetBranch, opts)
+		if err != nil {
+			impl.observeCherryPick(start, err)
+			results[targetBranch] = CherryPickResult{Err: err}
+			continue
+		}
+		branch, sha, err := impl.applyCherryPick(
+			ctx, impl.log(pr), source, repo, steps, targetBranch, targetRef, cherryPickBranch(pr, targetBranch, opts),
+		)
+		if err != nil {
+			impl.log(pr).Warnf("Cherry-pick of PR #%d to %s failed: %v", pr.Number, targetBranch, err)
+		}
+		impl.observeCherryPick(start, err)
+		results[targetBranch] = CherryPickResult{Branch: branch, SHA: sha, Err: err}
+	}
+	return results, nil
+}
+
+// checkCherryPick replays the changes of the pull request on the tree of
+// the target branch in memory. No objects, branches or refs are created.
+func (impl *defaultPRImplementation) checkCherryPick(
+	ctx context.Context, pr *PullRequest, targetBranch string, opts *CherryPickOptions,
+) (*BackportCheck, error) {
+	repo, err := pr.GetRepository(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to check cherry-pick")
+	}
+
+	// A missing target branch would be created from the base ref
+	targetRef := "heads/" + targetBranch
+	exists, err := repo.BranchExists(ctx, targetBranch)
+	if err != nil {
+		return nil, errors.Wrapf(err, "checking target branch %s", targetBranch)
+	}
+	if !exists {
+		if !opts.CreateIfMissing {
+			return nil, errors.Wrapf(ErrTargetBranchMissing, "checking cherry-pick of PR #%d to %s", pr.Number, targetBranch)
+		}
+		targetRef = qualifyRef(opts.BaseRef)
+	}
+
+	steps, err := impl.prepareCherryPick(ctx, pr, repo, opts)
+	if err != nil {
+		return nil, err
+	}
+	_, _, targetFiles, err := impl.readBranch(ctx, repo, targetBranch, targetRef)
+	if err != nil {
+		return nil, err
+	}
+
+	check := &BackportCheck{Target: targetBranch, Conflicts: []string{}}
+	changed := 0
+	for _, step := range steps {
+		changes, err := impl.stepChanges(ctx, repo, step, targetBranch, targetFiles)
+		if err != nil {
+			conflictErr := &CherryPickConflictError{}
+			if !errors.As(err, &conflictErr) {
+				return nil, err
+			}
+			check.Commit, check.Conflicts = conflictErr.Commit, conflictErr.Paths
+			impl.log(pr).Infof("PR #%d conflicts with %s in %d files", pr.Number, targetBranch, len(check.Conflicts))
+			return check, nil
+		}
+		applyTreeChanges(targetFiles, changes)
+		changed += len(changes)
+	}
+	check.Clean = true
+	check.Applied = changed == 0
+	impl.log(pr).Infof("PR #%d can be cherry-picked cleanly to %s", pr.Number, targetBranch)
+	return check, nil
+}
+
+// observeCherryPick reports the outcome and duration
+// of a cherry-pick to a branch to the metrics
+func (gau *githubAPIUser) observeCherryPick(start time.Time, err error) {
+	gau.getMetrics().ObserveCherryPickDuration(time.Since(start))
+	gau.getMetrics().IncCherryPick(cherryPickOutcome(err))
+}
+
+// checkTargetBranch verifies the target branch exists, creating it if the
+// options allow it. It returns the ref to read the branch head from.
+// Source describes what is cherry-picked in logs and errors.
+func (gau *githubAPIUser) checkTargetBranch(
+	ctx context.Context, log Logger, source string, repo *Repository, targetBranch string, opts *CherryPickOptions,
+) (targetRef string, err error) {
+	exists, err := repo.BranchExists(ctx, targetBranch)
+	if err != nil {
+		return "", errors.Wrapf(err, "checking target branch %s", targetBranch)
+	}
+	if exists {
+		return "heads/" + targetBranch, nil
+	}
+
+	if !opts.CreateIfMissing {
+		return "", errors.Wrapf(ErrTargetBranchMissing, "cherry-picking %s to %s", source, targetBranch)
+	}
+	if gau.getOptions().DryRun {
+		// The new branch would point to the base, we read it instead
+		log.Infof("[dry-run] Would create target branch %s from %s", targetBranch, opts.BaseRef)
+		return qualifyRef(opts.BaseRef), nil
+	}
+	if err := repo.CreateBranch(ctx, targetBranch, opts.BaseRef); err != nil {
+		return "", errors.Wrapf(err, "creating missing target branch %s", targetBranch)
+	}
+	log.Infof("Created target branch %s from %s", targetBranch, opts.BaseRef)
+	return "heads/" + targetBranch, nil
+}
+
+// prepareCherryPick determines how the pull request was merged
+// and returns the commits to replay on the target branches
+func (impl *defaultPRImplementation) prepareCherryPick(
+	ctx context.Context, pr *PullRequest, repo *Repository, opts *CherryPickOptions,
+) ([]cherryPickStep, error) {
+	mode, err := pr.GetMergeMode(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting merge mode of PR #%d", pr.Number)
+	}
+
+	steps, err := impl.cherryPickSteps(ctx, pr, repo, mode)
+	if err != nil {
+		return nil, errors.Wrap(err, "computing the commits to cherry-pick")
+	}
+	if opts.Squash && len(steps) > 1 {
+		impl.log(pr).Infof("Squashing the %d commits of PR #%d into one", len(steps), pr.Number)
+		return squashCherryPickSteps(pr, steps)
+	}
+	return steps, nil
+}
+
+// squashCherryPickSteps combines the steps of a rebased pull request into
+// one replaying all their changes. The rebased commits form a chain, so
+// the changes are the diff between the parent of the first one and the
+// last one. The commit gets the squash message of the pull request.
+func squashCherryPickSteps(pr *PullRequest, steps []cherryPickStep) ([]cherryPickStep, error) {
+	commits := []*Commit{}
+	for i, step := range steps {
+		if i > 0 && step.from != steps[i-1].source.SHA {
+			return nil, errors.Errorf(
+				"unable to squash, commit %s does not follow %s", step.source.SHA, steps[i-1].source.SHA,
+			)
+		}
+		commits = append(commits, step.source)
+	}
+
+	title, body := squashMessage(pr, commits)
+	last := commits[len(commits)-1]
+	squashed := &Commit{
+		SHA:       last.SHA,
+		TreeSHA:   last.TreeSHA,
+		Message:   strings.TrimSpace(title + "\n\n" + body),
+		Author:    commits[0].Author,
+		Committer: last.Committer,
+	}
+	return []cherryPickStep{{from: steps[0].from, source: squashed}}, nil
+}
+
+// applyCherryPick replays the steps on top of the target branch and
+// records the result in branch
+func (gau *githubAPIUser) applyCherryPick(
+	ctx context.Context, log Logger, source string, repo *Repository,
+	steps []cherryPickStep, targetBranch, targetRef, branch string,
+) (_, sha string, err error) {
+	headSHA, treeSHA, targetFiles, err := gau.readBranch(ctx, repo, targetBranch, targetRef)
+	if err != nil {
+		return "", "", err
+	}
+
+	for _, step := range steps {
+		changes, err := gau.stepChanges(ctx, repo, step, targetBranch, targetFiles)
+		if err != nil {
+			return "", "", err
+		}
+		if len(changes) == 0 {
+			log.Infof("Skipping commit %s, its changes are already in %s", step.source.SHA, targetBranch)
+			continue
+		}
+
+		if gau.getOptions().DryRun {
+			log.Infof(
+				"[dry-run] Would cherry-pick %s to %s changing %d paths", step.source.SHA, targetBranch, len(changes),
+			)
+			applyTreeChanges(targetFiles, changes)
+			headSHA = dryRunSHA
+			continue
+		}
+
+		newTreeSHA, err := gau.createTree(ctx, repo.Owner, repo.Name, treeSHA, changes)
+		if err != nil {
+			return "", "", errors.Wrapf(err, "creating tree to cherry-pick %s", step.source.SHA)
+		}
+
+		newCommitSHA, err := gau.createCommit(
+			ctx, repo.Owner, repo.Name, buildCherryPickCommit(step.source, newTreeSHA, headSHA, gau.getOptions()),
+		)
+		if err != nil {
+			return "", "", errors.Wrapf(err, "creating commit to cherry-pick %s", step.source.SHA)
+		}
+
+		log.Infof("Cherry-picked %s as %s", step.source.SHA, newCommitSHA)
+		applyTreeChanges(targetFiles, changes)
+		treeSHA = newTreeSHA
+		headSHA = newCommitSHA
+	}
+
+	if gau.getOptions().DryRun {
+		log.Infof("[dry-run] Would record cherry-pick of %s to %s in branch %s", source, targetBranch, branch)
+		return branch, headSHA, nil
+	}
+	if err := gau.writeBranch(ctx, repo, branch, headSHA); err != nil {
+		return "", "", errors.Wrapf(err, "writing cherry-pick branch %s", branch)
+	}
+
+	log.Infof("Cherry-pick of %s to %s recorded in branch %s", source, targetBranch, branch)
+	return branch, headSHA, nil
+}
+
+// readBranch returns the head commit of the target branch, its tree and
+// the files in it
+func (gau *githubAPIUser) readBranch(
+	ctx context.Context, repo *Repository, targetBranch, targetRef string,
+) (headSHA, treeSHA string, files treeFiles, err error) {
+	var ref *gogithub.Reference
+	err = gau.doWithRetry(ctx, "git.GetRef", func() (resp *gogithub.Response, err error) {
+		ref, resp, err = gau.GitHubClient().Git.GetRef(ctx, repo.Owner, repo.Name, targetRef)
+		return resp, err
+	})
+	if err != nil {
+		return "", "", nil, errors.Wrapf(err, "reading head of branch %s", targetBranch)
+	}
+	headSHA = ref.GetObject().GetSHA()
+
+	headCommit, err := repo.GetCommit(ctx, headSHA)
+	if err != nil {
+		return "", "", nil, errors.Wrapf(err, "fetching head commit of %s", targetBranch)
+	}
+
+	files, err = gau.readTree(ctx, repo, headCommit.TreeSHA)
+	if err != nil {
+		return "", "", nil, errors.Wrapf(err, "reading tree of branch %s", targetBranch)
+	}
+	return headSHA, headCommit.TreeSHA, files, nil
+}
+
+// stepChanges computes the changes a step makes to the target files.
+// Conflicts return a *CherryPickConflictError.
+func (gau *githubAPIUser) stepChanges(
+	ctx context.Context, repo *Repository, step cherryPickStep, targetBranch string, targetFiles treeFiles,
+) ([]*gogithub.TreeEntry, error) {
+	fromCommit, err := repo.GetCommit(ctx, step.from)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fetching commit %s", step.from)
+	}
+	fromFiles, err := gau.readTree(ctx, repo, fromCommit.TreeSHA)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading tree of commit %s", step.from)
+	}
+	toFiles, err := gau.readTree(ctx, repo, step.source.TreeSHA)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading tree of commit %s", step.source.SHA)
+	}
+
+	changes, conflicts := computeTreeChanges(fromFiles, toFiles, targetFiles)
+	if len(conflicts) > 0 {
+		return nil, &CherryPickConflictError{Commit: step.source.SHA, Branch: targetBranch, Paths: conflicts}
+	}
+	return changes, nil
+}
+
+// cherryPickSteps returns the list of commits that need to be replayed
+// on the target branch, according to the way the PR was merged
+func (impl *defaultPRImplementation) cherryPickSteps(
+	ctx context.Context, pr *PullRequest, repo *Repository, mode MergeMode,
+) ([]cherryPickStep, error) {
+	switch mode {
+	case MergeModeSquash, MergeModeQueue:
+		// A squashed PR is a single commit, we apply its changes. Merge
+		// queue commits are diffed against the branch they were merged
+		// into, their first parent.
+		mergeCommit, err := pr.GetMergeCommit(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "fetching merge commit")
+		}
+		if len(mergeCommit.Parents) == 0 {
+			return nil, errors.Errorf("merge commit %s has no parents", pr.MergeCommitSHA)
+		}
+		return []cherryPickStep{{from: mergeCommit.Parents[0].SHA, source: mergeCommit}}, nil
+
+	case MergeModeMerge:
+		// For merge commits, the changes are the diff between the mainline
+		// parent and the merge commit. The mainline is the parent that is
+		// not the patch tree.
+		mergeCommit, err := pr.GetMergeCommit(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "fetching merge commit")
+		}
+		patchParent, err := impl.findPatchTree(ctx, pr)
+		if err != nil {
+			return nil, errors.Wrap(err, "searching for parent patch tree")
+		}
+		mainline := 0
+		if patchParent == 0 {
+			mainline = 1
+		}
+		if len(mergeCommit.Parents) <= mainline {
+			return nil, errors.Errorf("merge commit %s does not have a mainline parent", pr.MergeCommitSHA)
+		}
+		return []cherryPickStep{{from: mergeCommit.Parents[mainline].SHA, source: mergeCommit}}, nil
+
+	case MergeModeRebase:
+		// Rebased PRs are replayed one commit at a time, oldest first
+		commitSHAs, err := pr.GetRebaseCommits(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "getting rebase commits")
+		}
+		steps := []cherryPickStep{}
+		for i := len(commitSHAs) - 1; i >= 0; i-- {
+			commit, err := repo.GetCommit(ctx, commitSHAs[i])
+			if err != nil {
+				return nil, errors.Wrapf(err, "fetching rebased commit %s", commitSHAs[i])
+			}
+			if len(commit.Parents) == 0 {
+				return nil, errors.Errorf("rebased commit %s has no parents", commitSHAs[i])
+			}
+			steps = append(steps, cherryPickStep{from: commit.Parents[0].SHA, source: commit})
+		}
+		return steps, nil
+
+	default:
+		return nil, errors.Errorf("unable to cherry-pick PR #%d, merge mode is %s", pr.Number, mode)
+	}
+}
+
+// readTree fetches a tree recursively and returns its files indexed by path
+func (gau *githubAPIUser) readTree(
+	ctx context.Context, repo *Repository, treeSHA string,
+) (treeFiles, error) {
+	entries, err := gau.getTree(ctx, repo.Owner, repo.Name, treeSHA)
+	if err != nil {
+		return nil, err
+	}
+
+	files := treeFiles{}
+	for _, entry := range entries {
+		// Directories are implied by the file paths
+		if entry.GetType() == "tree" {
+			continue
+		}
+		files[entry.GetPath()] = entry
+	}
+	return files, nil
+}
+
+// writeBranch points branch to sha, creating the branch if needed
+func (gau *githubAPIUser) writeBranch(
+	ctx context.Context, repo *Repository, branch, sha string,
+) error {
+	ref := &gogithub.Reference{
+		Ref:    gogithub.String("refs/heads/" + branch),
+		Object: &gogithub.GitObject{SHA: gogithub.String(sha)},
+	}
+
+	err := gau.doWithRetry(ctx, "git.GetRef", func() (resp *gogithub.Response, err error) {
+		_, resp, err = gau.GitHubClient().Git.GetRef(ctx, repo.Owner, repo.Name, "heads/"+branch)
+		return resp, err
+	})
+	if err != nil {
+		if !isNotFound(err) {
+			return errors.Wrapf(err, "checking if branch %s exists", branch)
+		}
+		return gau.doWithRetry(ctx, "git.CreateRef", func() (resp *gogithub.Response, err error) {
+			_, resp, err = gau.GitHubClient().Git.CreateRef(ctx, repo.Owner, repo.Name, ref)
+			return resp, err
+		})
+	}
+
+	return gau.doWithRetry(ctx, "git.UpdateRef", func() (resp *gogithub.Response, err error) {
+		_, resp, err = gau.GitHubClient().Git.UpdateRef(ctx, repo.Owner, repo.Name, ref, true)
+		return resp, err
+	})
+}
+
+// buildCherryPickCommit returns the commit object to be created for a
+// cherry-pick, preserving the metadata of the original commit
+func buildCherryPickCommit(source *Commit, treeSHA, parentSHA string, opts *Options) *gogithub.Commit {
+	message := source.Message
+	if opts.SignOff {
+		message = appendSignOff(message, opts.Committer)
+	}
+	commit := &gogithub.Commit{
+		Message:    gogithub.String(message),
+		Tree:       &gogithub.Tree{SHA: gogithub.String(treeSHA)},
+		Parents:    []*gogithub.Commit{{SHA: gogithub.String(parentSHA)}},
+		SigningKey: opts.SigningKey,
+	}
+	if source.Author != nil {
+		commit.Author = &gogithub.CommitAuthor{
+			Name:  gogithub.String(source.Author.Name),
+			Email: gogithub.String(source.Author.Email),
+			Date:  &source.Author.Date,
+		}
+	}
+	if opts.Committer != nil {
+		now := time.Now()
+		commit.Committer = &gogithub.CommitAuthor{
+			Name:  gogithub.String(opts.Committer.Name),
+			Email: gogithub.String(opts.Committer.Email),
+			Date:  &now,
+		}
+	} else if source.Committer != nil {
+		commit.Committer = &gogithub.CommitAuthor{
+			Name:  gogithub.String(source.Committer.Name),
+			Email: gogithub.String(source.Committer.Email),
+			Date:  &source.Committer.Date,
+		}
+	}
+	return commit
+}
+
+// appendSignOff adds a Signed-off-by trailer to a commit message
+// unless the same sign-off is already there
+func appendSignOff(message string, identity *CommitAuthor) string {
+	trailer := fmt.Sprintf("Signed-off-by: %s <%s>", identity.Name, identity.Email)
+	if strings.Contains(message, trailer) {
+		return message
+	}
+	message = strings.TrimRight(message, "\n")
+	lines := strings.Split(message, "\n")
+	if !strings.HasPrefix(lines[len(lines)-1], "Signed-off-by: ") {
+		message += "\n"
+	}
+	return message + "\n" + trailer
+}
+
+// computeTreeChanges compares the files in the from and to trees and
+// returns the tree entries needed to apply the same changes to target.
+// Paths modified between from and to which also diverged in the target
+// are returned as conflicts.
+func computeTreeChanges(from, to, target treeFiles) (changes []*gogithub.TreeEntry, conflicts []string) {
+	paths := map[string]struct{}{}
+	for path := range from {
+		paths[path] = struct{}{}
+	}
+	for path := range to {
+		paths[path] = struct{}{}
+	}
+
+	sortedPaths := []string{}
+	for path := range paths {
+		sortedPaths = append(sortedPaths, path)
+	}
+	sort.Strings(sortedPaths)
+
+	changes = []*gogithub.TreeEntry{}
+	conflicts = []string{}
+	for _, path := range sortedPaths {
+		if sameEntry(from[path], to[path]) {
+			continue
+		}
+
+		// Change is already present in the target
+		if sameEntry(target[path], to[path]) {
+			continue
+		}
+
+		// Target diverged from the original file, we can't apply
+		if !sameEntry(target[path], from[path]) {
+			conflicts = append(conflicts, path)
+			continue
+		}
+
+		if to[path] == nil {
+			// File was deleted, a nil SHA removes it from the tree
+			changes = append(changes, &gogithub.TreeEntry{
+				Path: gogithub.String(path),
+				Mode: from[path].Mode,
+				Type: from[path].Type,
+			})
+			continue
+		}
+
+		changes = append(changes, &gogithub.TreeEntry{
+			Path: gogithub.String(path),
+			Mode: to[path].Mode,
+			Type: to[path].Type,
+			SHA:  to[path].SHA,
+		})
+	}
+	return changes, conflicts
+}
+
+// applyTreeChanges updates files with the entries of a new tree
+func applyTreeChanges(files treeFiles, changes []*gogithub.TreeEntry) {
+	for _, entry := range changes {
+		if entry.SHA == nil {
+			delete(files, entry.GetPath())
+			continue
+		}
+		files[entry.GetPath()] = entry
+	}
+}
+
+// sameEntry returns true if two tree entries point to the same content
+func sameEntry(a, b *gogithub.TreeEntry) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.GetSHA() == b.GetSHA() && a.GetMode() == b.GetMode()
+}
@@ -0,0 +1,361 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func testEntry(path, sha string) *gogithub.TreeEntry {
+	return &gogithub.TreeEntry{
+		Path: gogithub.String(path),
+		Mode: gogithub.String("100644"),
+		Type: gogithub.String("blob"),
+		SHA:  gogithub.String(sha),
+	}
+}
+
+func TestComputeTreeChanges(t *testing.T) {
+	from := treeFiles{
+		"README.md":   testEntry("README.md", "readme-1"),
+		"main.go":     testEntry("main.go", "main-1"),
+		"removed.go":  testEntry("removed.go", "removed-1"),
+		"conflict.go": testEntry("conflict.go", "conflict-1"),
+		"applied.go":  testEntry("applied.go", "applied-1"),
+	}
+	to := treeFiles{
+		"README.md":   testEntry("README.md", "readme-1"),
+		"main.go":     testEntry("main.go", "main-2"),
+		"added.go":    testEntry("added.go", "added-1"),
+		"conflict.go": testEntry("conflict.go", "conflict-2"),
+		"applied.go":  testEntry("applied.go", "applied-2"),
+	}
+	target := treeFiles{
+		"README.md":   testEntry("README.md", "readme-0"),
+		"main.go":     testEntry("main.go", "main-1"),
+		"removed.go":  testEntry("removed.go", "removed-1"),
+		"conflict.go": testEntry("conflict.go", "conflict-3"),
+		"applied.go":  testEntry("applied.go", "applied-2"),
+	}
+
+	changes, conflicts := computeTreeChanges(from, to, target)
+	require.Equal(t, []string{"conflict.go"}, conflicts)
+	require.Len(t, changes, 3)
+
+	// Changes are sorted by path
+	require.Equal(t, "added.go", changes[0].GetPath())
+	require.Equal(t, "added-1", changes[0].GetSHA())
+	require.Equal(t, "main.go", changes[1].GetPath())
+	require.Equal(t, "main-2", changes[1].GetSHA())
+	require.Equal(t, "removed.go", changes[2].GetPath())
+	require.Nil(t, changes[2].SHA, "deleted files must have a nil SHA")
+
+	// Applying the changes must leave the target like the new tree
+	applyTreeChanges(target, changes)
+	require.NotContains(t, target, "removed.go")
+	require.Equal(t, "added-1", target["added.go"].GetSHA())
+	require.Equal(t, "main-2", target["main.go"].GetSHA())
+}
+
+func TestCherryPick(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	impl := &defaultPRImplementation{githubAPIUser: gau}
+
+	// A PR with two commits, squashed on merge
+	fakes.pulls.Commits[1] = []*gogithub.RepositoryCommit{
+		fakes.addCommit("pr-1", "tree-1", "main-old"),
+		fakes.addCommit("pr-2", "tree-2", "pr-1"),
+	}
+	fakes.addCommit("main-old", "tree-base")
+	squashed := fakes.addCommit("squashed", "tree-merged", "main-old")
+	squashed.Commit.Author = &gogithub.CommitAuthor{
+		Name: gogithub.String("Jane Doe"), Email: gogithub.String("jane@example.com"),
+	}
+	fakes.git.Trees["tree-base"] = &gogithub.Tree{Entries: []*gogithub.TreeEntry{
+		testEntry("a.go", "a1"), testEntry("b.go", "b1"),
+	}}
+	fakes.git.Trees["tree-merged"] = &gogithub.Tree{Entries: []*gogithub.TreeEntry{
+		testEntry("a.go", "a2"), testEntry("b.go", "b1"), testEntry("c.go", "c1"),
+	}}
+
+	// The release branch diverged in b.go, which the PR did not touch
+	fakes.addCommit("release-head", "tree-release")
+	fakes.git.Refs["heads/release-7.1"] = &gogithub.Reference{
+		Ref: gogithub.String("refs/heads/release-7.1"), Object: &gogithub.GitObject{SHA: gogithub.String("release-head")},
+	}
+	fakes.git.Trees["tree-release"] = &gogithub.Tree{
+		SHA: gogithub.String("tree-release"),
+		Entries: []*gogithub.TreeEntry{
+			testEntry("a.go", "a1"), testEntry("b.go", "b0"),
+		},
+	}
+
+	pr := &PullRequest{
+		impl:           impl,
+		RepoOwner:      "mattermost",
+		RepoName:       "mattermost-server",
+		Number:         1,
+		MergeCommitSHA: "squashed",
+	}
+	branch, sha, err := impl.cherryPick(context.Background(), pr, "release-7.1", &CherryPickOptions{})
+	require.Nil(t, err)
+	require.Equal(t, "cherry-pick-1-release-7.1", branch)
+	require.Equal(t, "created-commit-1", sha)
+
+	// The new tree applies the PR changes on top of the release branch
+	require.Len(t, fakes.git.CreatedTrees, 1)
+	files := treeFiles{}
+	for _, e := range fakes.git.CreatedTrees[0].Entries {
+		files[e.GetPath()] = e
+	}
+	require.Equal(t, "a2", files["a.go"].GetSHA())
+	require.Equal(t, "b0", files["b.go"].GetSHA())
+	require.Equal(t, "c1", files["c.go"].GetSHA())
+
+	// The commit keeps the original author and sits on the release branch
+	require.Len(t, fakes.git.CreatedCommits, 1)
+	commit := fakes.git.CreatedCommits[0]
+	require.Equal(t, "Jane Doe", commit.GetAuthor().GetName())
+	require.Equal(t, "release-head", commit.Parents[0].GetSHA())
+	require.Equal(t, "created-commit-1", fakes.git.Refs["heads/cherry-pick-1-release-7.1"].GetObject().GetSHA())
+
+	// A change in the release branch to a file in the patch is a conflict
+	fakes.git.Trees["tree-release"].Entries[0] = testEntry("a.go", "a0")
+	_, _, err = impl.cherryPick(context.Background(), pr, "release-7.1", &CherryPickOptions{})
+	require.NotNil(t, err)
+	require.True(t, errors.Is(err, ErrCherryPickConflict))
+	require.Equal(t, []string{"a.go"}, ConflictPaths(err))
+	require.Nil(t, ConflictPaths(errors.Wrap(ErrTargetBranchMissing, "test")))
+
+	// A missing target branch is reported before computing anything
+	_, _, err = pr.CherryPick(context.Background(), "release-7.2")
+	require.True(t, errors.Is(err, ErrTargetBranchMissing))
+	require.Len(t, fakes.git.CreatedTrees, 1)
+
+	// Unless it can be created from a base ref
+	fakes.git.Trees["tree-release"].Entries[0] = testEntry("a.go", "a1")
+	branch, _, err = pr.CherryPickWithOptions(
+		context.Background(), "release-7.2", &CherryPickOptions{CreateIfMissing: true, BaseRef: "release-7.1"},
+	)
+	require.Nil(t, err)
+	require.Equal(t, "cherry-pick-1-release-7.2", branch)
+	require.Equal(t, "release-head", fakes.git.Refs["heads/release-7.2"].GetObject().GetSHA())
+}
+
+func TestCherryPickToBranches(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	impl := &defaultPRImplementation{githubAPIUser: gau}
+
+	// A PR merged with a merge commit, the patch tree is the second parent
+	fakes.pulls.Commits[1] = []*gogithub.RepositoryCommit{fakes.addCommit("pr-1", "tree-pr", "main-old")}
+	fakes.addCommit("main-old", "tree-base")
+	fakes.addCommit("merge", "tree-pr", "main-old", "pr-1")
+	fakes.git.Trees["tree-base"] = &gogithub.Tree{Entries: []*gogithub.TreeEntry{
+		testEntry("a.go", "a1"), testEntry("b.go", "b1"),
+	}}
+	fakes.git.Trees["tree-pr"] = &gogithub.Tree{Entries: []*gogithub.TreeEntry{
+		testEntry("a.go", "a2"), testEntry("b.go", "b1"),
+	}}
+
+	// release-7.9 applies cleanly, release-7.8 changed a.go
+	for branch, tree := range map[string]string{"release-7.8": "tree-conflict", "release-7.9": "tree-base"} {
+		fakes.addCommit(branch+"-head", tree)
+		fakes.git.Refs["heads/"+branch] = &gogithub.Reference{
+			Ref: gogithub.String("refs/heads/" + branch), Object: &gogithub.GitObject{SHA: gogithub.String(branch + "-head")},
+		}
+	}
+	fakes.git.Trees["tree-conflict"] = &gogithub.Tree{Entries: []*gogithub.TreeEntry{
+		testEntry("a.go", "a0"), testEntry("b.go", "b1"),
+	}}
+
+	pr := &PullRequest{
+		impl:           impl,
+		RepoOwner:      "mattermost",
+		RepoName:       "mattermost-server",
+		Number:         1,
+		MergeCommitSHA: "merge",
+	}
+	results, err := pr.CherryPickToBranches(
+		context.Background(), []string{"release-7.8", "release-7.9", "release-7.10"}, nil,
+	)
+	require.Nil(t, err)
+	require.Len(t, results, 3)
+	require.True(t, errors.Is(results["release-7.8"].Err, ErrCherryPickConflict))
+	require.True(t, errors.Is(results["release-7.10"].Err, ErrTargetBranchMissing))
+	require.Nil(t, results["release-7.9"].Err)
+	require.Equal(t, "cherry-pick-1-release-7.9", results["release-7.9"].Branch)
+	require.Equal(t, results["release-7.9"].SHA, fakes.git.Refs["heads/cherry-pick-1-release-7.9"].GetObject().GetSHA())
+
+	// The patch tree was searched only once
+	require.Equal(t, 1, fakes.repos.CommitCalls["pr-1"])
+}
+
+func TestCherryPickDeletedFork(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	gh := &defaultGithubImplementation{githubAPIUser: gau}
+
+	// GitHub returns a null head repository when the fork is gone
+	fakes.pulls.PullRequests[1] = &gogithub.PullRequest{
+		Number:         gogithub.Int(1),
+		State:          gogithub.String("closed"),
+		Merged:         gogithub.Bool(true),
+		MergeCommitSHA: gogithub.String("squashed"),
+		Head:           &gogithub.PullRequestBranch{Ref: gogithub.String("fix"), SHA: gogithub.String("pr-1")},
+		Base: &gogithub.PullRequestBranch{Repo: &gogithub.Repository{
+			Name: gogithub.String("mattermost-server"), Owner: &gogithub.User{Login: gogithub.String("mattermost")},
+		}},
+	}
+	fakes.pulls.Commits[1] = []*gogithub.RepositoryCommit{
+		fakes.addCommit("pr-1", "tree-1", "main-old"),
+		fakes.addCommit("pr-2", "tree-2", "pr-1"),
+	}
+	fakes.addCommit("main-old", "tree-base")
+	fakes.addCommit("squashed", "tree-merged", "main-old")
+	fakes.git.Trees["tree-base"] = &gogithub.Tree{Entries: []*gogithub.TreeEntry{testEntry("a.go", "a1")}}
+	fakes.git.Trees["tree-merged"] = &gogithub.Tree{Entries: []*gogithub.TreeEntry{testEntry("a.go", "a2")}}
+	fakes.addCommit("release-head", "tree-base")
+	fakes.git.Refs["heads/release-7.1"] = &gogithub.Reference{
+		Ref: gogithub.String("refs/heads/release-7.1"), Object: &gogithub.GitObject{SHA: gogithub.String("release-head")},
+	}
+
+	pr, err := gh.getPullRequestFromAPI(context.Background(), "mattermost", "mattermost-server", 1)
+	require.Nil(t, err)
+	require.Empty(t, pr.FullName)
+
+	// Only the base repository is needed, the fork is never looked up
+	branch, _, err := pr.CherryPick(context.Background(), "release-7.1")
+	require.Nil(t, err)
+	require.Equal(t, "cherry-pick-1-release-7.1", branch)
+	require.Equal(t, 1, fakes.repos.GetCalls)
+}
+
+func TestCherryPickDryRun(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	gau.options.DryRun = true
+	impl := &defaultPRImplementation{githubAPIUser: gau}
+
+	fakes.pulls.Commits[1] = []*gogithub.RepositoryCommit{fakes.addCommit("pr-1", "tree-1", "main-old")}
+	fakes.addCommit("main-old", "tree-base")
+	fakes.addCommit("squashed", "tree-merged", "main-old")
+	fakes.git.Trees["tree-base"] = &gogithub.Tree{Entries: []*gogithub.TreeEntry{testEntry("a.go", "a1")}}
+	fakes.git.Trees["tree-merged"] = &gogithub.Tree{Entries: []*gogithub.TreeEntry{testEntry("a.go", "a2")}}
+	fakes.addCommit("release-head", "tree-base")
+	fakes.git.Refs["heads/release-7.1"] = &gogithub.Reference{
+		Ref: gogithub.String("refs/heads/release-7.1"), Object: &gogithub.GitObject{SHA: gogithub.String("release-head")},
+	}
+
+	pr := &PullRequest{
+		impl:           impl,
+		RepoOwner:      "mattermost",
+		RepoName:       "mattermost-server",
+		Number:         1,
+		MergeCommitSHA: "squashed",
+		Labels:         []string{"CherryPick/Approved"},
+		labelsLoaded:   true,
+	}
+	ctx := context.Background()
+
+	// The cherry-pick is computed, even to a branch to be created
+	branch, sha, err := pr.CherryPickWithOptions(
+		ctx, "release-7.2", &CherryPickOptions{CreateIfMissing: true, BaseRef: "release-7.1"},
+	)
+	require.Nil(t, err)
+	require.Equal(t, "cherry-pick-1-release-7.2", branch)
+	require.Equal(t, dryRunSHA, sha)
+
+	backport, err := pr.OpenBackportPR(ctx, "release-7.2", branch, nil)
+	require.Nil(t, err)
+	require.Nil(t, backport)
+
+	id, err := pr.CommentOnPR(ctx, "Cherry-pick to release-7.2 succeeded")
+	require.Nil(t, err)
+	require.Zero(t, id)
+
+	require.Nil(t, pr.AddLabels(ctx, "CherryPick/Done"))
+	require.Equal(t, []string{"CherryPick/Approved", "CherryPick/Done"}, pr.Labels)
+
+	// But nothing was written
+	require.Len(t, fakes.git.Refs, 1)
+	require.Empty(t, fakes.git.CreatedTrees)
+	require.Empty(t, fakes.git.CreatedCommits)
+	require.Empty(t, fakes.pulls.Created)
+	require.Empty(t, fakes.issues.Comments)
+	require.Empty(t, fakes.issues.Labels)
+}
+
+func TestBuildCherryPickCommit(t *testing.T) {
+	date := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+	source := &Commit{
+		SHA:       "source",
+		Message:   "Fix the build\n\nSigned-off-by: Jane Doe <jane@example.com>\n",
+		Author:    &CommitAuthor{Name: "Jane Doe", Email: "jane@example.com", Date: date},
+		Committer: &CommitAuthor{Name: "GitHub", Email: "noreply@github.com", Date: date},
+	}
+	bot := &CommitAuthor{Name: "Mattermod", Email: "mattermod@example.com"}
+
+	// By default the commit is copied as is
+	commit := buildCherryPickCommit(source, "tree", "parent", &Options{})
+	require.Equal(t, source.Message, commit.GetMessage())
+	require.Equal(t, "GitHub", commit.GetCommitter().GetName())
+	require.Nil(t, commit.SigningKey)
+
+	// The author is always preserved, the committer is the bot
+	commit = buildCherryPickCommit(source, "tree", "parent", &Options{Committer: bot, SignOff: true})
+	require.Equal(t, "Jane Doe", commit.GetAuthor().GetName())
+	require.Equal(t, "jane@example.com", commit.GetAuthor().GetEmail())
+	require.Equal(t, date, commit.GetAuthor().GetDate())
+	require.Equal(t, "Mattermod", commit.GetCommitter().GetName())
+	require.Equal(t, "mattermod@example.com", commit.GetCommitter().GetEmail())
+	require.Equal(t,
+		"Fix the build\n\nSigned-off-by: Jane Doe <jane@example.com>\nSigned-off-by: Mattermod <mattermod@example.com>",
+		commit.GetMessage(),
+	)
+}
+
+func TestAppendSignOff(t *testing.T) {
+	bot := &CommitAuthor{Name: "Mattermod", Email: "mattermod@example.com"}
+	for _, tc := range []struct {
+		Message  string
+		Expected string
+	}{
+		{"Fix", "Fix\n\nSigned-off-by: Mattermod <mattermod@example.com>"},
+		{"Fix\n\nMore details\n", "Fix\n\nMore details\n\nSigned-off-by: Mattermod <mattermod@example.com>"},
+		{"Fix\n\nSigned-off-by: Mattermod <mattermod@example.com>", "Fix\n\nSigned-off-by: Mattermod <mattermod@example.com>"},
+	} {
+		require.Equal(t, tc.Expected, appendSignOff(tc.Message, bot))
+	}
+}
+
+func TestSquashCherryPickSteps(t *testing.T) {
+	pr := &PullRequest{Number: 42, Title: "Add the frobnicator"}
+	jane := &CommitAuthor{Name: "Jane Doe", Email: "jane@example.com"}
+	john := &CommitAuthor{Name: "John Doe", Email: "john@example.com"}
+	steps := []cherryPickStep{
+		{from: "main-old", source: &Commit{SHA: "rebased-1", TreeSHA: "tree-1", Message: "Add frobnicator API", Author: jane}},
+		{from: "rebased-1", source: &Commit{SHA: "rebased-2", TreeSHA: "tree-2", Message: "Fix tests", Author: john}},
+	}
+
+	squashed, err := squashCherryPickSteps(pr, steps)
+	require.Nil(t, err)
+	require.Len(t, squashed, 1)
+	require.Equal(t, "main-old", squashed[0].from)
+	require.Equal(t, "tree-2", squashed[0].source.TreeSHA)
+	require.Equal(t, jane, squashed[0].source.Author)
+	require.Equal(t,
+		"Add the frobnicator (#42)\n\n* Add frobnicator API\n* Fix tests\n\n"+
+			"Co-authored-by: Jane Doe <jane@example.com>\nCo-authored-by: John Doe <john@example.com>",
+		squashed[0].source.Message,
+	)
+
+	// Only a chain of commits can be squashed
+	steps[1].from = "main-old"
+	_, err = squashCherryPickSteps(pr, steps)
+	require.NotNil(t, err)
+}
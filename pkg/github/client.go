@@ -0,0 +1,214 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+)
+
+// Client groups the GitHub API services used by the package. The default
+// implementation wraps a go-github client, but each service can be replaced
+// to test the package without reaching the GitHub API. Fakes for all
+// services are available in the githubfakes package.
+type Client struct {
+	PullRequests  PullRequestsService
+	Repositories  RepositoriesService
+	Git           GitService
+	Issues        IssuesService
+	Checks        ChecksService
+	Teams         TeamsService
+	Organizations OrganizationsService
+	Search        SearchService
+	Actions       ActionsService
+	GraphQL       GraphQLService
+
+	rateMtx sync.RWMutex
+	rates   map[string]gogithub.Rate // Last rate limit reported by GitHub, by resource
+
+	loginMtx sync.Mutex
+	login    string // Login of the authenticated user or app, once resolved
+}
+
+// Resources with their own rate limit in the GitHub API
+const (
+	RateResourceCore    = "core"    // REST API calls
+	RateResourceGraphQL = "graphql" // GraphQL queries and mutations
+	RateResourceSearch  = "search"  // Search API queries
+)
+
+// RateLimit returns the number of REST API calls left and the time when
+// the limit resets, as reported in the last response from GitHub. Until a
+// response with rate limit data is received, remaining is -1.
+func (c *Client) RateLimit() (remaining int, reset time.Time) {
+	return c.RateLimitFor(RateResourceCore)
+}
+
+// RateLimitFor returns the quota left of a resource, see RateLimit
+func (c *Client) RateLimitFor(resource string) (remaining int, reset time.Time) {
+	c.rateMtx.RLock()
+	defer c.rateMtx.RUnlock()
+	rate, ok := c.rates[resource]
+	if !ok {
+		return -1, time.Time{}
+	}
+	return rate.Remaining, rate.Reset.Time
+}
+
+// recordRate stores the rate limit of a resource reported in an API
+// response. Rate limit errors carry the rate even when no response is
+// returned.
+func (c *Client) recordRate(resource string, resp *gogithub.Response, err error) {
+	rate := gogithub.Rate{}
+	rateLimitErr := &gogithub.RateLimitError{}
+	switch {
+	case resp != nil:
+		rate = resp.Rate
+	case errors.As(err, &rateLimitErr):
+		rate = rateLimitErr.Rate
+	}
+	if rate.Limit == 0 {
+		return
+	}
+	c.rateMtx.Lock()
+	defer c.rateMtx.Unlock()
+	if c.rates == nil {
+		c.rates = map[string]gogithub.Rate{}
+	}
+	c.rates[resource] = rate
+}
+
+// rateResource returns the rate limit resource used by an endpoint
+func rateResource(endpoint string) string {
+	if strings.HasPrefix(endpoint, "graphql.") {
+		return RateResourceGraphQL
+	}
+	if strings.HasPrefix(endpoint, "search.") {
+		return RateResourceSearch
+	}
+	return RateResourceCore
+}
+
+// PullRequestsService is the subset of the go-github pull requests API used by the package
+type PullRequestsService interface {
+	Get(ctx context.Context, owner, repo string, number int) (*gogithub.PullRequest, *gogithub.Response, error)
+	List(ctx context.Context, owner, repo string, opts *gogithub.PullRequestListOptions) ([]*gogithub.PullRequest, *gogithub.Response, error)
+	Create(ctx context.Context, owner, repo string, pull *gogithub.NewPullRequest) (*gogithub.PullRequest, *gogithub.Response, error)
+	Edit(ctx context.Context, owner, repo string, number int, pull *gogithub.PullRequest) (*gogithub.PullRequest, *gogithub.Response, error)
+	ListCommits(ctx context.Context, owner, repo string, number int, opts *gogithub.ListOptions) ([]*gogithub.RepositoryCommit, *gogithub.Response, error)
+	ListFiles(ctx context.Context, owner, repo string, number int, opts *gogithub.ListOptions) ([]*gogithub.CommitFile, *gogithub.Response, error)
+	Merge(ctx context.Context, owner, repo string, number int, commitMessage string, options *gogithub.PullRequestOptions) (*gogithub.PullRequestMergeResult, *gogithub.Response, error)
+	ListReviews(ctx context.Context, owner, repo string, number int, opts *gogithub.ListOptions) ([]*gogithub.PullRequestReview, *gogithub.Response, error)
+	CreateReview(ctx context.Context, owner, repo string, number int, review *gogithub.PullRequestReviewRequest) (*gogithub.PullRequestReview, *gogithub.Response, error)
+	ListComments(ctx context.Context, owner, repo string, number int, opts *gogithub.PullRequestListCommentsOptions) ([]*gogithub.PullRequestComment, *gogithub.Response, error)
+	CreateComment(ctx context.Context, owner, repo string, number int, comment *gogithub.PullRequestComment) (*gogithub.PullRequestComment, *gogithub.Response, error)
+	RequestReviewers(ctx context.Context, owner, repo string, number int, reviewers gogithub.ReviewersRequest) (*gogithub.PullRequest, *gogithub.Response, error)
+	GetRaw(ctx context.Context, owner, repo string, number int, opts gogithub.RawOptions) (string, *gogithub.Response, error)
+}
+
+// RepositoriesService is the subset of the go-github repositories API used by the package
+type RepositoriesService interface {
+	Get(ctx context.Context, owner, repo string) (*gogithub.Repository, *gogithub.Response, error)
+	GetCommit(ctx context.Context, owner, repo, sha string, opts *gogithub.ListOptions) (*gogithub.RepositoryCommit, *gogithub.Response, error)
+	ListCommits(ctx context.Context, owner, repo string, opts *gogithub.CommitsListOptions) ([]*gogithub.RepositoryCommit, *gogithub.Response, error)
+	GetCombinedStatus(ctx context.Context, owner, repo, ref string, opts *gogithub.ListOptions) (*gogithub.CombinedStatus, *gogithub.Response, error)
+	CreateStatus(ctx context.Context, owner, repo, ref string, status *gogithub.RepoStatus) (*gogithub.RepoStatus, *gogithub.Response, error)
+	CompareCommits(ctx context.Context, owner, repo string, base, head string, opts *gogithub.ListOptions) (*gogithub.CommitsComparison, *gogithub.Response, error)
+	CompareCommitsRaw(ctx context.Context, owner, repo, base, head string, opts gogithub.RawOptions) (string, *gogithub.Response, error)
+	GetCommitRaw(ctx context.Context, owner, repo, sha string, opts gogithub.RawOptions) (string, *gogithub.Response, error)
+	ListReleases(ctx context.Context, owner, repo string, opts *gogithub.ListOptions) ([]*gogithub.RepositoryRelease, *gogithub.Response, error)
+	CreateRelease(ctx context.Context, owner, repo string, release *gogithub.RepositoryRelease) (*gogithub.RepositoryRelease, *gogithub.Response, error)
+	GetContents(ctx context.Context, owner, repo, path string, opts *gogithub.RepositoryContentGetOptions) (*gogithub.RepositoryContent, []*gogithub.RepositoryContent, *gogithub.Response, error)
+	CreateFile(ctx context.Context, owner, repo, path string, opts *gogithub.RepositoryContentFileOptions) (*gogithub.RepositoryContentResponse, *gogithub.Response, error)
+	UpdateFile(ctx context.Context, owner, repo, path string, opts *gogithub.RepositoryContentFileOptions) (*gogithub.RepositoryContentResponse, *gogithub.Response, error)
+	UploadReleaseAsset(ctx context.Context, owner, repo string, id int64, opts *gogithub.UploadOptions, file *os.File) (*gogithub.ReleaseAsset, *gogithub.Response, error)
+	GetPermissionLevel(ctx context.Context, owner, repo, user string) (*gogithub.RepositoryPermissionLevel, *gogithub.Response, error)
+}
+
+// GitService is the subset of the go-github git data API used by the package
+type GitService interface {
+	GetRef(ctx context.Context, owner, repo, ref string) (*gogithub.Reference, *gogithub.Response, error)
+	CreateRef(ctx context.Context, owner, repo string, ref *gogithub.Reference) (*gogithub.Reference, *gogithub.Response, error)
+	UpdateRef(ctx context.Context, owner, repo string, ref *gogithub.Reference, force bool) (*gogithub.Reference, *gogithub.Response, error)
+	DeleteRef(ctx context.Context, owner, repo, ref string) (*gogithub.Response, error)
+	GetTree(ctx context.Context, owner, repo, sha string, recursive bool) (*gogithub.Tree, *gogithub.Response, error)
+	CreateTree(ctx context.Context, owner, repo, baseTree string, entries []*gogithub.TreeEntry) (*gogithub.Tree, *gogithub.Response, error)
+	CreateCommit(ctx context.Context, owner, repo string, commit *gogithub.Commit) (*gogithub.Commit, *gogithub.Response, error)
+	CreateTag(ctx context.Context, owner, repo string, tag *gogithub.Tag) (*gogithub.Tag, *gogithub.Response, error)
+	CreateBlob(ctx context.Context, owner, repo string, blob *gogithub.Blob) (*gogithub.Blob, *gogithub.Response, error)
+}
+
+// IssuesService is the subset of the go-github issues API used by the package
+type IssuesService interface {
+	Get(ctx context.Context, owner, repo string, number int) (*gogithub.Issue, *gogithub.Response, error)
+	Create(ctx context.Context, owner, repo string, issue *gogithub.IssueRequest) (*gogithub.Issue, *gogithub.Response, error)
+	Edit(ctx context.Context, owner, repo string, number int, issue *gogithub.IssueRequest) (*gogithub.Issue, *gogithub.Response, error)
+	AddAssignees(ctx context.Context, owner, repo string, number int, assignees []string) (*gogithub.Issue, *gogithub.Response, error)
+	RemoveAssignees(ctx context.Context, owner, repo string, number int, assignees []string) (*gogithub.Issue, *gogithub.Response, error)
+	ListComments(ctx context.Context, owner, repo string, number int, opts *gogithub.IssueListCommentsOptions) ([]*gogithub.IssueComment, *gogithub.Response, error)
+	CreateComment(ctx context.Context, owner, repo string, number int, comment *gogithub.IssueComment) (*gogithub.IssueComment, *gogithub.Response, error)
+	EditComment(ctx context.Context, owner, repo string, commentID int64, comment *gogithub.IssueComment) (*gogithub.IssueComment, *gogithub.Response, error)
+	ListLabelsByIssue(ctx context.Context, owner, repo string, number int, opts *gogithub.ListOptions) ([]*gogithub.Label, *gogithub.Response, error)
+	AddLabelsToIssue(ctx context.Context, owner, repo string, number int, labels []string) ([]*gogithub.Label, *gogithub.Response, error)
+	RemoveLabelForIssue(ctx context.Context, owner, repo string, number int, label string) (*gogithub.Response, error)
+	ListMilestones(ctx context.Context, owner, repo string, opts *gogithub.MilestoneListOptions) ([]*gogithub.Milestone, *gogithub.Response, error)
+	CreateMilestone(ctx context.Context, owner, repo string, milestone *gogithub.Milestone) (*gogithub.Milestone, *gogithub.Response, error)
+}
+
+// ChecksService is the subset of the go-github checks API used by the package
+type ChecksService interface {
+	ListCheckRunsForRef(ctx context.Context, owner, repo, ref string, opts *gogithub.ListCheckRunsOptions) (*gogithub.ListCheckRunsResults, *gogithub.Response, error)
+	CreateCheckRun(ctx context.Context, owner, repo string, opts gogithub.CreateCheckRunOptions) (*gogithub.CheckRun, *gogithub.Response, error)
+	UpdateCheckRun(ctx context.Context, owner, repo string, checkRunID int64, opts gogithub.UpdateCheckRunOptions) (*gogithub.CheckRun, *gogithub.Response, error)
+}
+
+// TeamsService is the subset of the go-github teams API used by the package
+type TeamsService interface {
+	GetTeamMembershipBySlug(ctx context.Context, org, slug, user string) (*gogithub.Membership, *gogithub.Response, error)
+	ListTeamMembersBySlug(ctx context.Context, org, slug string, opts *gogithub.TeamListTeamMembersOptions) ([]*gogithub.User, *gogithub.Response, error)
+}
+
+// OrganizationsService is the subset of the go-github organizations API used by the package
+type OrganizationsService interface {
+	IsMember(ctx context.Context, org, user string) (bool, *gogithub.Response, error)
+}
+
+// SearchService is the subset of the go-github search API used by the package
+type SearchService interface {
+	Issues(ctx context.Context, query string, opts *gogithub.SearchOptions) (*gogithub.IssuesSearchResult, *gogithub.Response, error)
+}
+
+// ActionsService is the subset of the go-github actions API used by the package
+type ActionsService interface {
+	CreateWorkflowDispatchEventByFileName(ctx context.Context, owner, repo, workflowFileName string, event gogithub.CreateWorkflowDispatchEventRequest) (*gogithub.Response, error)
+	ListWorkflowRunsByFileName(ctx context.Context, owner, repo, workflowFileName string, opts *gogithub.ListWorkflowRunsOptions) (*gogithub.WorkflowRuns, *gogithub.Response, error)
+	GetWorkflowRunByID(ctx context.Context, owner, repo string, runID int64) (*gogithub.WorkflowRun, *gogithub.Response, error)
+}
+
+// GraphQLService sends queries and mutations to the GitHub GraphQL API
+type GraphQLService interface {
+	Do(ctx context.Context, query string, variables map[string]interface{}, result interface{}) (*gogithub.Response, error)
+}
+
+// NewClient returns a Client backed by the services of a go-github client
+func NewClient(ghclient *gogithub.Client) *Client {
+	return &Client{
+		PullRequests:  ghclient.PullRequests,
+		Repositories:  ghclient.Repositories,
+		Git:           ghclient.Git,
+		Issues:        ghclient.Issues,
+		Checks:        ghclient.Checks,
+		Teams:         ghclient.Teams,
+		Organizations: ghclient.Organizations,
+		Search:        ghclient.Search,
+		Actions:       ghclient.Actions,
+		GraphQL:       &graphQLClient{client: ghclient},
+	}
+}
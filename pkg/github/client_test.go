@@ -0,0 +1,106 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/puerco/mattermod-refactor/pkg/github/githubfakes"
+)
+
+// Make sure the fakes can stand in for the API services
+var (
+	_ PullRequestsService  = &githubfakes.FakePullRequestsService{}
+	_ RepositoriesService  = &githubfakes.FakeRepositoriesService{}
+	_ GitService           = &githubfakes.FakeGitService{}
+	_ IssuesService        = &githubfakes.FakeIssuesService{}
+	_ ChecksService        = &githubfakes.FakeChecksService{}
+	_ TeamsService         = &githubfakes.FakeTeamsService{}
+	_ OrganizationsService = &githubfakes.FakeOrganizationsService{}
+	_ SearchService        = &githubfakes.FakeSearchService{}
+	_ ActionsService       = &githubfakes.FakeActionsService{}
+	_ GraphQLService       = &githubfakes.FakeGraphQLService{}
+)
+
+// fakeServices holds the fakes behind a test API user
+type fakeServices struct {
+	pulls   *githubfakes.FakePullRequestsService
+	repos   *githubfakes.FakeRepositoriesService
+	git     *githubfakes.FakeGitService
+	issues  *githubfakes.FakeIssuesService
+	checks  *githubfakes.FakeChecksService
+	teams   *githubfakes.FakeTeamsService
+	orgs    *githubfakes.FakeOrganizationsService
+	search  *githubfakes.FakeSearchService
+	actions *githubfakes.FakeActionsService
+	graphql *githubfakes.FakeGraphQLService
+}
+
+// newFakeAPIUser returns an API user backed by empty fakes. The
+// mattermost/mattermost-server repository is preloaded.
+func newFakeAPIUser() (githubAPIUser, *fakeServices) {
+	fakes := &fakeServices{
+		pulls: &githubfakes.FakePullRequestsService{
+			PullRequests: map[int]*gogithub.PullRequest{},
+			Commits:      map[int][]*gogithub.RepositoryCommit{},
+		},
+		repos: &githubfakes.FakeRepositoriesService{
+			Repositories: map[string]*gogithub.Repository{
+				"mattermost/mattermost-server": {
+					Name:  gogithub.String("mattermost-server"),
+					Owner: &gogithub.User{Login: gogithub.String("mattermost")},
+				},
+			},
+			Commits: map[string]*gogithub.RepositoryCommit{},
+		},
+		git: &githubfakes.FakeGitService{
+			Refs:  map[string]*gogithub.Reference{},
+			Trees: map[string]*gogithub.Tree{},
+		},
+		issues:  &githubfakes.FakeIssuesService{Login: "mattermod[bot]"},
+		checks:  &githubfakes.FakeChecksService{},
+		teams:   &githubfakes.FakeTeamsService{Members: map[string][]string{}},
+		orgs:    &githubfakes.FakeOrganizationsService{Members: map[string][]string{}},
+		search:  &githubfakes.FakeSearchService{Results: map[string][]*gogithub.Issue{}},
+		actions: &githubfakes.FakeActionsService{},
+		graphql: &githubfakes.FakeGraphQLService{},
+	}
+	// Tests get their own options so cached data is not shared among them
+	opts := defaultOptions
+	opts.RepositoryCache = nil
+	opts.MilestoneCache = nil
+	opts.MembershipCache = nil
+	opts.Login = "mattermod[bot]"
+	return githubAPIUser{
+		options: &opts,
+		client: &Client{
+			PullRequests:  fakes.pulls,
+			Repositories:  fakes.repos,
+			Git:           fakes.git,
+			Issues:        fakes.issues,
+			Checks:        fakes.checks,
+			Teams:         fakes.teams,
+			Organizations: fakes.orgs,
+			Search:        fakes.search,
+			Actions:       fakes.actions,
+			GraphQL:       fakes.graphql,
+		},
+	}, fakes
+}
+
+// addCommit preloads a commit in the fake repository
+func (fakes *fakeServices) addCommit(sha, tree string, parents ...string) *gogithub.RepositoryCommit {
+	commit := &gogithub.RepositoryCommit{
+		SHA: gogithub.String(sha),
+		Commit: &gogithub.Commit{
+			Message: gogithub.String("Commit " + sha),
+			Tree:    &gogithub.Tree{SHA: gogithub.String(tree)},
+		},
+		Parents: []*gogithub.Commit{},
+	}
+	for _, p := range parents {
+		commit.Parents = append(commit.Parents, &gogithub.Commit{SHA: gogithub.String(p)})
+	}
+	fakes.repos.Commits[sha] = commit
+	return commit
+}
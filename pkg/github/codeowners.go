@@ -0,0 +1,144 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// codeOwnersPaths are the locations GitHub reads CODEOWNERS
+// from, in the order it looks for them
+var codeOwnersPaths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// CodeOwners are the rules of a CODEOWNERS file
+type CodeOwners struct {
+	rules []*codeOwnersRule
+
+	// Errors describes the lines skipped because of invalid
+	// syntax. Like GitHub, they are ignored.
+	Errors []string
+}
+
+type codeOwnersRule struct {
+	pattern string
+	regex   *regexp.Regexp
+	owners  []string
+}
+
+// ParseCodeOwners reads the rules of a CODEOWNERS file
+func ParseCodeOwners(data []byte) *CodeOwners {
+	co := &CodeOwners{rules: []*codeOwnersRule{}, Errors: []string{}}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, " #"); i != -1 {
+			line = line[:i]
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		regex, err := codeOwnersRegex(fields[0])
+		if err != nil {
+			co.Errors = append(co.Errors, fmt.Sprintf("line %d: %v", n, err))
+			continue
+		}
+		co.rules = append(co.rules, &codeOwnersRule{pattern: fields[0], regex: regex, owners: fields[1:]})
+	}
+	return co
+}
+
+// Owners returns the owners of the path as written in the file, eg
+// @user, @org/team or an email. The last matching rule wins, so the
+// list is empty when it has no owners or no rule matches the path.
+func (co *CodeOwners) Owners(path string) []string {
+	path = strings.TrimPrefix(path, "/")
+	for i := len(co.rules) - 1; i >= 0; i-- {
+		if co.rules[i].regex.MatchString(path) {
+			return append([]string{}, co.rules[i].owners...)
+		}
+	}
+	return []string{}
+}
+
+// OwnersOf returns the sorted owners of any of the paths
+func (co *CodeOwners) OwnersOf(paths []string) []string {
+	owners := []string{}
+	for _, path := range paths {
+		owners = appendMissing(owners, co.Owners(path))
+	}
+	sort.Strings(owners)
+	return owners
+}
+
+// codeOwnersRegex translates a CODEOWNERS pattern, which follows most of
+// the gitignore rules, to a regular expression matching the paths it owns
+func codeOwnersRegex(pattern string) (*regexp.Regexp, error) {
+	if strings.HasPrefix(pattern, "!") || strings.ContainsAny(pattern, "[]\\") {
+		return nil, fmt.Errorf("unsupported pattern %q", pattern)
+	}
+
+	// Patterns with a slash other than a trailing one are relative
+	// to the root, others match at any depth
+	trimmed := strings.TrimSuffix(pattern, "/")
+	anchored := strings.Contains(trimmed, "/")
+	trimmed = strings.TrimPrefix(trimmed, "/")
+	if trimmed == "" {
+		return nil, fmt.Errorf("empty pattern %q", pattern)
+	}
+
+	var expr strings.Builder
+	if anchored {
+		expr.WriteString("^")
+	} else {
+		expr.WriteString("^(?:.*/)?")
+	}
+	segments := strings.Split(trimmed, "/")
+	for i, segment := range segments {
+		last := i == len(segments)-1
+		switch {
+		case segment == "**" && last:
+			expr.WriteString(".*")
+		case segment == "**":
+			expr.WriteString("(?:.*/)?")
+		default:
+			for _, r := range segment {
+				switch r {
+				case '*':
+					expr.WriteString("[^/]*")
+				case '?':
+					expr.WriteString("[^/]")
+				default:
+					expr.WriteString(regexp.QuoteMeta(string(r)))
+				}
+			}
+			if !last {
+				expr.WriteString("/")
+			}
+		}
+	}
+
+	// Directories own all the files in them. A wildcard in the last
+	// segment only matches the files at that level (docs/*).
+	lastSegment := segments[len(segments)-1]
+	if strings.HasSuffix(pattern, "/") || !strings.ContainsAny(lastSegment, "*?") {
+		expr.WriteString("(?:/.*)?")
+	}
+	expr.WriteString("$")
+	return regexp.Compile(expr.String())
+}
+
+// ResolvedOwners are the owners of a set of paths, with
+// the teams expanded to their members
+type ResolvedOwners struct {
+	Users   []string // Logins of the owners, including the members of the teams
+	Teams   []string // Owning teams as org/slug
+	Unowned []string // Paths without owners
+}
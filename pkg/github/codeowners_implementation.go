@@ -0,0 +1,110 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+)
+
+// getCodeOwners reads and parses the CODEOWNERS file at the ref
+func (di *defaultRepoImplementation) getCodeOwners(ctx context.Context, owner, repo, ref string) (*CodeOwners, error) {
+	for _, path := range codeOwnersPaths {
+		data, _, err := di.getFileContents(ctx, owner, repo, path, ref)
+		if err != nil {
+			if errors.Is(err, ErrFileNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		co := ParseCodeOwners(data)
+		for _, e := range co.Errors {
+			di.getLogger().Warnf("Skipping rule of %s in %s/%s: %s", path, owner, repo, e)
+		}
+		return co, nil
+	}
+	return nil, errors.Wrapf(ErrFileNotFound, "looking for CODEOWNERS in %s/%s", owner, repo)
+}
+
+// resolveCodeOwners finds the owners of the paths and expands the
+// owning teams. Owners identified by email cannot be resolved to a
+// login and are skipped.
+func (di *defaultRepoImplementation) resolveCodeOwners(
+	ctx context.Context, owner, repo, ref string, paths []string,
+) (*ResolvedOwners, error) {
+	co, err := di.getCodeOwners(ctx, owner, repo, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := &ResolvedOwners{Users: []string{}, Teams: []string{}, Unowned: []string{}}
+	for _, path := range paths {
+		owners := co.Owners(path)
+		if len(owners) == 0 {
+			resolved.Unowned = append(resolved.Unowned, path)
+			continue
+		}
+		for _, o := range owners {
+			switch {
+			case !strings.HasPrefix(o, "@"):
+				di.getLogger().Infof("Skipping code owner %s of %s, emails cannot be resolved", o, path)
+			case strings.Contains(o, "/"):
+				resolved.Teams = appendMissing(resolved.Teams, []string{strings.TrimPrefix(o, "@")})
+			default:
+				resolved.Users = appendMissing(resolved.Users, []string{strings.TrimPrefix(o, "@")})
+			}
+		}
+	}
+
+	for _, team := range resolved.Teams {
+		parts := strings.SplitN(team, "/", 2)
+		members, err := di.listTeamMembers(ctx, parts[0], parts[1])
+		if err != nil {
+			return nil, errors.Wrapf(err, "expanding code owners team %s", team)
+		}
+		resolved.Users = appendMissing(resolved.Users, members)
+	}
+	sort.Strings(resolved.Users)
+	sort.Strings(resolved.Teams)
+	return resolved, nil
+}
+
+// listTeamMembers returns the logins of all the members of a team. The
+// list is kept in the membership cache when there is one.
+func (gau *githubAPIUser) listTeamMembers(ctx context.Context, org, slug string) ([]string, error) {
+	cache := gau.getOptions().MembershipCache
+	if cache != nil {
+		if members, ok := cache.Members(org + "/" + slug); ok {
+			return members, nil
+		}
+	}
+
+	members := []string{}
+	opts := &gogithub.TeamListTeamMembersOptions{ListOptions: gogithub.ListOptions{PerPage: 100}}
+	for {
+		var users []*gogithub.User
+		var resp *gogithub.Response
+		err := gau.doWithRetry(ctx, "teams.ListTeamMembersBySlug", func() (_ *gogithub.Response, err error) {
+			users, resp, err = gau.GitHubClient().Teams.ListTeamMembersBySlug(ctx, org, slug, opts)
+			return resp, err
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "listing members of %s/%s", org, slug)
+		}
+		members = append(members, userLogins(users)...)
+
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	if cache != nil {
+		cache.SetMembers(org+"/"+slug, members)
+	}
+	return members, nil
+}
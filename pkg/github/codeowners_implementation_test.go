@@ -0,0 +1,41 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"testing"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveCodeOwners(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	repo := gau.NewRepository(&gogithub.Repository{
+		Name: gogithub.String("mattermost-server"), Owner: &gogithub.User{Login: gogithub.String("mattermost")},
+	})
+	ctx := context.Background()
+
+	_, err := repo.GetCodeOwners(ctx, "master")
+	require.True(t, errors.Is(err, ErrFileNotFound))
+
+	fakes.repos.Files = map[string]map[string][]byte{
+		"master": {"CODEOWNERS": []byte(testCodeOwners)},
+	}
+	fakes.teams.Members["mattermost/core-devs"] = []string{"jane", "octocat"}
+
+	resolved, err := repo.ResolveCodeOwners(ctx, "master", []string{
+		"README.md", "apps/main.go", "docs/index.md", "model/post.go",
+	})
+	require.Nil(t, err)
+	require.Equal(t, []string{"jane", "octocat"}, resolved.Users)
+	require.Equal(t, []string{"mattermost/core-devs"}, resolved.Teams)
+	require.Equal(t, []string{"model/post.go"}, resolved.Unowned)
+
+	// Teams that cannot be expanded are an error
+	_, err = repo.ResolveCodeOwners(ctx, "master", []string{"server/plugins/api.go"})
+	require.True(t, isNotFound(err))
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testCodeOwners = `# Default owners
+*                       @mattermost/core-devs
+*.js                    @js-owner # Frontend code
+/build/logs/            @doctocat
+docs/*                  docs@example.com
+apps/                   @octocat
+**/plugins              @mattermost/plugins
+/scripts/**             @ops
+/model/post.go
+!negated.go             @nobody
+`
+
+func TestCodeOwners(t *testing.T) {
+	co := ParseCodeOwners([]byte(testCodeOwners))
+	require.Len(t, co.Errors, 1)
+
+	for _, tc := range []struct {
+		path     string
+		expected []string
+	}{
+		{"README.md", []string{"@mattermost/core-devs"}},
+		{"webapp/index.js", []string{"@js-owner"}},
+		{"build/logs/out.txt", []string{"@doctocat"}},
+		{"src/build/logs/out.txt", []string{"@mattermost/core-devs"}},
+		{"docs/getting-started.md", []string{"docs@example.com"}},
+		{"docs/build-app/troubleshooting.md", []string{"@mattermost/core-devs"}},
+		{"apps/github/main.go", []string{"@octocat"}},
+		{"server/apps/main.go", []string{"@octocat"}},
+		{"server/plugins/api.go", []string{"@mattermost/plugins"}},
+		{"scripts/release/cut.sh", []string{"@ops"}},
+		{"/model/post.go", []string{}},
+		{"negated.go", []string{"@mattermost/core-devs"}},
+	} {
+		require.Equal(t, tc.expected, co.Owners(tc.path), tc.path)
+	}
+
+	require.Equal(t,
+		[]string{"@js-owner", "@mattermost/core-devs", "@octocat"},
+		co.OwnersOf([]string{"apps/a.go", "README.md", "app.js", "model/post.go"}),
+	)
+	require.Empty(t, ParseCodeOwners([]byte("/docs/ @docs")).Owners("README.md"))
+}
@@ -0,0 +1,164 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+)
+
+// commentMarkerTemplate is the HTML comment used to find our comments
+// again. GitHub does not render it.
+const commentMarkerTemplate = "<!-- mattermod:%s -->"
+
+// commentOnPR posts a new comment on the pull request
+func (impl *defaultPRImplementation) commentOnPR(ctx context.Context, pr *PullRequest, body string) (int64, error) {
+	if impl.getOptions().DryRun {
+		impl.log(pr).Infof("[dry-run] Would comment on PR #%d: %s", pr.Number, body)
+		return 0, nil
+	}
+
+	var comment *gogithub.IssueComment
+	err := impl.doWithRetry(ctx, "issues.CreateComment", func() (resp *gogithub.Response, err error) {
+		comment, resp, err = impl.GitHubClient().Issues.CreateComment(
+			ctx, pr.RepoOwner, pr.RepoName, pr.Number, &gogithub.IssueComment{Body: gogithub.String(body)},
+		)
+		return resp, err
+	})
+	if err != nil {
+		return 0, errors.Wrapf(err, "commenting on PR #%d", pr.Number)
+	}
+	impl.log(pr).Infof("Posted comment %d on PR #%d", comment.GetID(), pr.Number)
+	return comment.GetID(), nil
+}
+
+// updateOrCreateComment edits the first comment in the PR containing the
+// marker. If none is found, a new comment is created.
+func (impl *defaultPRImplementation) updateOrCreateComment(
+	ctx context.Context, pr *PullRequest, marker, body string,
+) (int64, error) {
+	tag := fmt.Sprintf(commentMarkerTemplate, marker)
+	body = tag + "\n" + body
+
+	commentID, err := impl.findComment(ctx, pr, tag)
+	if err != nil {
+		return 0, errors.Wrapf(err, "searching for comment %q in PR #%d", marker, pr.Number)
+	}
+	if commentID == 0 {
+		return impl.commentOnPR(ctx, pr, body)
+	}
+
+	if err := impl.updateComment(ctx, pr, commentID, body); err != nil {
+		return 0, err
+	}
+	return commentID, nil
+}
+
+// updateComment replaces the body of a comment in the pull request
+func (impl *defaultPRImplementation) updateComment(
+	ctx context.Context, pr *PullRequest, commentID int64, body string,
+) error {
+	if impl.getOptions().DryRun {
+		impl.log(pr).Infof("[dry-run] Would update comment %d on PR #%d: %s", commentID, pr.Number, body)
+		return nil
+	}
+
+	err := impl.doWithRetry(ctx, "issues.EditComment", func() (resp *gogithub.Response, err error) {
+		_, resp, err = impl.GitHubClient().Issues.EditComment(
+			ctx, pr.RepoOwner, pr.RepoName, commentID, &gogithub.IssueComment{Body: gogithub.String(body)},
+		)
+		return resp, err
+	})
+	if err != nil {
+		return errors.Wrapf(err, "updating comment %d on PR #%d", commentID, pr.Number)
+	}
+	impl.log(pr).Infof("Updated comment %d on PR #%d", commentID, pr.Number)
+	return nil
+}
+
+// findCommentByMarker returns the ID of the first comment
+// tagged with the marker or zero if there is none
+func (impl *defaultPRImplementation) findCommentByMarker(
+	ctx context.Context, pr *PullRequest, marker string,
+) (int64, error) {
+	return impl.findComment(ctx, pr, fmt.Sprintf(commentMarkerTemplate, marker))
+}
+
+// findComment returns the ID of the first comment in the pull request
+// containing tag or zero if there is none
+func (impl *defaultPRImplementation) findComment(ctx context.Context, pr *PullRequest, tag string) (int64, error) {
+	return impl.findIssueComment(ctx, pr.RepoOwner, pr.RepoName, pr.Number, tag)
+}
+
+// viewerQuery reads the login of the authenticated user or app
+const viewerQuery = `query { viewer { login } }`
+
+// authenticatedLogin returns the login the client is authenticated as.
+// It is read from the API once and kept in the client.
+func (gau *githubAPIUser) authenticatedLogin(ctx context.Context) (string, error) {
+	if login := gau.getOptions().Login; login != "" {
+		return login, nil
+	}
+	client := gau.GitHubClient()
+	client.loginMtx.Lock()
+	defer client.loginMtx.Unlock()
+	if client.login != "" {
+		return client.login, nil
+	}
+
+	result := struct {
+		Viewer struct {
+			Login string `json:"login"`
+		} `json:"viewer"`
+	}{}
+	err := gau.doWithRetry(ctx, "graphql.Do", func() (*gogithub.Response, error) {
+		return client.GraphQL.Do(ctx, viewerQuery, nil, &result)
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "querying the authenticated user")
+	}
+	if result.Viewer.Login == "" {
+		return "", errors.New("GitHub did not return the login of the authenticated user")
+	}
+	client.login = result.Viewer.Login
+	return client.login, nil
+}
+
+// findIssueComment returns the ID of the first comment in an issue or
+// pull request containing tag or zero if there is none. Only comments
+// written by the authenticated user or app are considered, anyone
+// could paste the tag in theirs.
+func (gau *githubAPIUser) findIssueComment(ctx context.Context, owner, repo string, number int, tag string) (int64, error) {
+	login, err := gau.authenticatedLogin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	opts := &gogithub.IssueListCommentsOptions{}
+	for {
+		var comments []*gogithub.IssueComment
+		var resp *gogithub.Response
+		err := gau.doWithRetry(ctx, "issues.ListComments", func() (_ *gogithub.Response, err error) {
+			comments, resp, err = gau.GitHubClient().Issues.ListComments(ctx, owner, repo, number, opts)
+			return resp, err
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		for _, comment := range comments {
+			if comment.GetUser().GetLogin() == login && strings.Contains(comment.GetBody(), tag) {
+				return comment.GetID(), nil
+			}
+		}
+
+		if resp == nil || resp.NextPage == 0 {
+			return 0, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateOrCreateComment(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	impl := &defaultPRImplementation{githubAPIUser: gau}
+	pr := &PullRequest{impl: impl, RepoOwner: "mattermost", RepoName: "mattermost-server", Number: 1}
+	ctx := context.Background()
+
+	// Comments from users are left alone
+	fakes.issues.Comments = map[int][]*gogithub.IssueComment{
+		1: {{ID: gogithub.Int64(10), Body: gogithub.String("/cherry-pick release-7.1")}},
+	}
+
+	// Plain comments are always created
+	id, err := pr.CommentOnPR(ctx, "Hello")
+	require.Nil(t, err)
+	require.Equal(t, int64(11), id)
+
+	// The first marked comment is created...
+	id, err = pr.UpdateOrCreateComment(ctx, "cherry-pick-release-7.1", "Cherry-pick to release-7.1 failed due to conflicts")
+	require.Nil(t, err)
+	require.Equal(t, int64(12), id)
+	require.Len(t, fakes.issues.Comments[1], 3)
+
+	// ... and updated afterwards
+	updated, err := pr.UpdateOrCreateComment(ctx, "cherry-pick-release-7.1", "Cherry-pick to release-7.1 succeeded")
+	require.Nil(t, err)
+	require.Equal(t, id, updated)
+	require.Len(t, fakes.issues.Comments[1], 3)
+	require.Equal(t, 1, fakes.issues.CommentEdits)
+	require.True(t, strings.HasSuffix(fakes.issues.Comments[1][2].GetBody(), "succeeded"))
+
+	// A different marker gets its own comment
+	other, err := pr.UpdateOrCreateComment(ctx, "cherry-pick-release-7.0", "Cherry-pick to release-7.0 succeeded")
+	require.Nil(t, err)
+	require.NotEqual(t, id, other)
+	require.Len(t, fakes.issues.Comments[1], 4)
+
+	// Marked comments can be found and edited directly
+	found, err := pr.FindCommentByMarker(ctx, "cherry-pick-release-7.1")
+	require.Nil(t, err)
+	require.Equal(t, id, found)
+	require.Nil(t, pr.UpdateComment(ctx, found, "Edited"))
+	require.Equal(t, "Edited", fakes.issues.Comments[1][2].GetBody())
+
+	found, err = pr.FindCommentByMarker(ctx, "cherry-pick-release-6.0")
+	require.Nil(t, err)
+	require.Zero(t, found)
+}
+
+func TestFindCommentAuthor(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	gau.options.Login = ""
+	impl := &defaultPRImplementation{githubAPIUser: gau}
+	pr := &PullRequest{impl: impl, RepoOwner: "mattermost", RepoName: "mattermost-server", Number: 1}
+	ctx := context.Background()
+	fakes.graphql.Stub = func(query string, variables map[string]interface{}) (string, error) {
+		return `{"viewer":{"login":"mattermod[bot]"}}`, nil
+	}
+
+	// Markers pasted by users do not make their comments ours
+	fakes.issues.Comments = map[int][]*gogithub.IssueComment{
+		1: {{
+			ID:   gogithub.Int64(10),
+			Body: gogithub.String("<!-- mattermod:backport -->\nNot from the bot"),
+			User: &gogithub.User{Login: gogithub.String("someone")},
+		}},
+	}
+	found, err := pr.FindCommentByMarker(ctx, "backport")
+	require.Nil(t, err)
+	require.Zero(t, found)
+
+	id, err := pr.UpdateOrCreateComment(ctx, "backport", "Backported")
+	require.Nil(t, err)
+	require.Equal(t, int64(11), id)
+	require.Zero(t, fakes.issues.CommentEdits)
+
+	found, err = pr.FindCommentByMarker(ctx, "backport")
+	require.Nil(t, err)
+	require.Equal(t, id, found)
+
+	// The login is only read once
+	require.Len(t, fakes.graphql.Calls, 1)
+
+	// Comments cannot be matched without knowing the login
+	gau, _ = newFakeAPIUser()
+	gau.options.Login = ""
+	pr.impl = &defaultPRImplementation{githubAPIUser: gau}
+	_, err = pr.FindCommentByMarker(ctx, "backport")
+	require.NotNil(t, err)
+}
@@ -3,6 +3,8 @@
 
 package github
 
+import "time"
+
 func NewCommit() *Commit {
 	return &Commit{
 		impl: defaultCommitImplementation{},
@@ -10,10 +12,69 @@ func NewCommit() *Commit {
 }
 
 type Commit struct {
-	impl    CommitImplementation
-	SHA     string    // SHA sum of the commit
-	Parents []*Commit // Parent commits
-	TreeSHA string    // SHA of the commmit's tree
+	impl      CommitImplementation
+	SHA       string        // SHA sum of the commit
+	Parents   []*Commit     // Parent commits
+	TreeSHA   string        // SHA of the commmit's tree
+	Message   string        // Full commit message
+	Author    *CommitAuthor // Author of the changes
+	Committer *CommitAuthor // Identity that recorded the commit
+
+	// Data only returned when reading the commit from the repository
+	Files        []*CommitFile       // Files changed by the commit
+	Stats        *CommitStats        // Lines changed by the commit
+	Verification *CommitVerification // Signature of the commit
+}
+
+// CommitStats counts the lines changed by a commit
+type CommitStats struct {
+	Additions int
+	Deletions int
+	Total     int
+}
+
+// CommitVerification is the result of GitHub checking the signature
+// of a commit. Reason explains why it is not verified, eg unsigned.
+type CommitVerification struct {
+	Verified  bool
+	Reason    string
+	Signature string
+}
+
+// CommitAuthor captures the identity and date recorded in a commit
+type CommitAuthor struct {
+	Name  string
+	Email string
+	Date  time.Time
+}
+
+// CommitFile is a file changed by a commit or pull request
+type CommitFile struct {
+	Filename         string // Path of the file
+	PreviousFilename string // Former path of the file if it was renamed
+	Status           string // added, removed, modified, renamed...
+	Additions        int    // Number of lines added
+	Deletions        int    // Number of lines removed
+	Patch            string // Diff hunks, empty for binary files or large diffs
+}
+
+// ParentSHAs returns the SHAs of the parents of the commit
+func (c *Commit) ParentSHAs() []string {
+	shas := []string{}
+	for _, parent := range c.Parents {
+		shas = append(shas, parent.SHA)
+	}
+	return shas
+}
+
+// HasParent returns true if sha is one of the parents of the commit
+func (c *Commit) HasParent(sha string) bool {
+	for _, parent := range c.Parents {
+		if parent.SHA == sha {
+			return true
+		}
+	}
+	return false
 }
 
 type CommitImplementation interface {
@@ -0,0 +1,131 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// shortSHALength is the length of the abbreviated SHAs in branch
+// names, titles and logs
+const shortSHALength = 7
+
+// shortSHA abbreviates a commit SHA
+func shortSHA(sha string) string {
+	if len(sha) > shortSHALength {
+		return sha[:shortSHALength]
+	}
+	return sha
+}
+
+// cherryPickCommits replays the commits on top of the target branch with
+// the same machinery used for pull requests and optionally opens a pull
+// request with the result
+func (di *defaultRepoImplementation) cherryPickCommits(
+	ctx context.Context, repo *Repository, targetBranch string, commits []*Commit, opts *CommitCherryPickOptions,
+) (result *CommitCherryPickResult, err error) {
+	start := time.Now()
+	defer func() { di.observeCherryPick(start, err) }()
+
+	if di.getOptions().SignOff && di.getOptions().Committer == nil {
+		return nil, errors.New("a committer identity is required to sign off commits")
+	}
+
+	log := di.getLogger().WithFields(map[string]interface{}{"repo": repo.Owner + "/" + repo.Name})
+	result = &CommitCherryPickResult{Commits: []string{}}
+	steps := []cherryPickStep{}
+	for _, commit := range commits {
+		step, err := commitCherryPickStep(commit, opts.Mainline)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, step)
+		result.Commits = append(result.Commits, commit.SHA)
+	}
+
+	source := "commit " + shortSHA(commits[0].SHA)
+	if len(commits) > 1 {
+		source = fmt.Sprintf("commits %s..%s", shortSHA(commits[0].SHA), shortSHA(commits[len(commits)-1].SHA))
+	}
+	targetRef, err := di.checkTargetBranch(ctx, log, source, repo, targetBranch, &opts.CherryPickOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	branch := opts.Branch
+	if branch == "" {
+		prefix := cherryPickBranchPrefix
+		if opts.BranchPrefix != "" {
+			prefix = opts.BranchPrefix
+		}
+		branch = fmt.Sprintf("%s-%s-%s", prefix, shortSHA(commits[len(commits)-1].SHA), targetBranch)
+	}
+	result.Branch, result.SHA, err = di.applyCherryPick(ctx, log, source, repo, steps, targetBranch, targetRef, branch)
+	if err != nil {
+		return nil, err
+	}
+	if !opts.PullRequest {
+		return result, nil
+	}
+
+	existing, err := di.findOpenPullRequest(ctx, repo.Owner, repo.Name, result.Branch, targetBranch)
+	if err != nil {
+		return result, errors.Wrap(err, "searching for existing cherry-pick pull request")
+	}
+	if existing != nil {
+		log.Infof("Cherry-pick of %s to %s already open as #%d", source, targetBranch, existing.Number)
+		result.PullRequest = existing
+		return result, nil
+	}
+	if di.getOptions().DryRun {
+		log.Infof("[dry-run] Would open cherry-pick PR of %s from %s to %s", source, result.Branch, targetBranch)
+		return result, nil
+	}
+	title, body := commitCherryPickPRText(targetBranch, commits)
+	result.PullRequest, err = di.createPullRequest(
+		ctx, repo.Owner, repo.Name, result.Branch, targetBranch, title, body, &NewPullRequestOptions{MaintainerCanModify: true},
+	)
+	if err != nil {
+		return result, errors.Wrapf(err, "opening pull request for the cherry-pick of %s", source)
+	}
+	log.Infof("Opened cherry-pick PR #%d of %s on %s", result.PullRequest.Number, source, targetBranch)
+	return result, nil
+}
+
+// commitCherryPickStep returns the step replaying a commit. Merge commits
+// are diffed against the mainline parent.
+func commitCherryPickStep(commit *Commit, mainline int) (cherryPickStep, error) {
+	switch {
+	case len(commit.Parents) == 0:
+		return cherryPickStep{}, errors.Errorf("commit %s has no parents", commit.SHA)
+	case len(commit.Parents) == 1:
+		return cherryPickStep{from: commit.Parents[0].SHA, source: commit}, nil
+	case mainline == 0:
+		return cherryPickStep{}, errors.Errorf("commit %s is a merge but no mainline parent was specified", commit.SHA)
+	case mainline > len(commit.Parents):
+		return cherryPickStep{}, errors.Errorf("commit %s does not have parent %d", commit.SHA, mainline)
+	}
+	return cherryPickStep{from: commit.Parents[mainline-1].SHA, source: commit}, nil
+}
+
+// commitCherryPickPRText returns the title and body of the pull
+// request opened for a cherry-pick of commits
+func commitCherryPickPRText(targetBranch string, commits []*Commit) (title, body string) {
+	lines := []string{}
+	for _, commit := range commits {
+		subject, _ := splitCommitMessage(commit.Message)
+		lines = append(lines, fmt.Sprintf("- %s %s", commit.SHA, subject))
+	}
+	body = "Automated cherry-pick of:\n\n" + strings.Join(lines, "\n")
+	if len(commits) == 1 {
+		subject, _ := splitCommitMessage(commits[0].Message)
+		return fmt.Sprintf("[%s] %s (%s)", targetBranch, subject, shortSHA(commits[0].SHA)), body
+	}
+	return fmt.Sprintf("[%s] Cherry-pick %d commits", targetBranch, len(commits)), body
+}
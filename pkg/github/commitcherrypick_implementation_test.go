@@ -0,0 +1,101 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"testing"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCherryPickCommits(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	repo := gau.NewRepository(&gogithub.Repository{
+		Name: gogithub.String("mattermost-server"), Owner: &gogithub.User{Login: gogithub.String("mattermost")},
+	})
+
+	// Two hotfixes on main, the second one touching a file that
+	// differs in the release branch
+	fakes.addCommit("main-old", "tree-base")
+	fakes.addCommit("fix-1", "tree-fix-1", "main-old")
+	fakes.addCommit("fix-2", "tree-fix-2", "fix-1")
+	fakes.git.Trees["tree-base"] = &gogithub.Tree{Entries: []*gogithub.TreeEntry{
+		testEntry("a.go", "a1"), testEntry("b.go", "b1"),
+	}}
+	fakes.git.Trees["tree-fix-1"] = &gogithub.Tree{Entries: []*gogithub.TreeEntry{
+		testEntry("a.go", "a2"), testEntry("b.go", "b1"),
+	}}
+	fakes.git.Trees["tree-fix-2"] = &gogithub.Tree{Entries: []*gogithub.TreeEntry{
+		testEntry("a.go", "a2"), testEntry("b.go", "b2"),
+	}}
+	fakes.addCommit("release-head", "tree-release")
+	fakes.git.Refs["heads/release-7.1"] = &gogithub.Reference{
+		Ref: gogithub.String("refs/heads/release-7.1"), Object: &gogithub.GitObject{SHA: gogithub.String("release-head")},
+	}
+	fakes.git.Trees["tree-release"] = &gogithub.Tree{
+		SHA: gogithub.String("tree-release"), Entries: []*gogithub.TreeEntry{testEntry("a.go", "a1"), testEntry("b.go", "b0")},
+	}
+
+	// A single commit, with a pull request
+	result, err := repo.CherryPickCommit(context.Background(), "release-7.1", "fix-1", &CommitCherryPickOptions{PullRequest: true})
+	require.Nil(t, err)
+	require.Equal(t, "cherry-pick-fix-1-release-7.1", result.Branch)
+	require.Equal(t, []string{"fix-1"}, result.Commits)
+	require.Len(t, fakes.git.CreatedCommits, 1)
+	require.Equal(t, result.SHA, fakes.git.Refs["heads/"+result.Branch].GetObject().GetSHA())
+	require.NotNil(t, result.PullRequest)
+	require.Len(t, fakes.pulls.Created, 1)
+	require.Equal(t, "[release-7.1] Commit fix-1 (fix-1)", fakes.pulls.Created[0].GetTitle())
+	require.Equal(t, "release-7.1", fakes.pulls.Created[0].GetBase())
+
+	// Conflicts are detected like for pull requests
+	_, err = repo.CherryPickRange(context.Background(), "release-7.1", "main-old", "fix-2", nil)
+	require.NotNil(t, err)
+	fakes.repos.CommitComparisons = map[string]*gogithub.CommitsComparison{
+		"main-old...fix-2": {
+			AheadBy: gogithub.Int(2),
+			Commits: []*gogithub.RepositoryCommit{fakes.repos.Commits["fix-1"], fakes.repos.Commits["fix-2"]},
+		},
+	}
+	_, err = repo.CherryPickRange(context.Background(), "release-7.1", "main-old", "fix-2", nil)
+	require.True(t, errors.Is(err, ErrCherryPickConflict))
+	require.Equal(t, []string{"b.go"}, ConflictPaths(err))
+
+	// A range applying cleanly
+	fakes.git.Trees["tree-release"].Entries[1] = testEntry("b.go", "b1")
+	result, err = repo.CherryPickRange(context.Background(), "release-7.1", "main-old", "fix-2", &CommitCherryPickOptions{
+		Branch: "hotfixes",
+	})
+	require.Nil(t, err)
+	require.Equal(t, "hotfixes", result.Branch)
+	require.Equal(t, []string{"fix-1", "fix-2"}, result.Commits)
+	require.Nil(t, result.PullRequest)
+	require.Equal(t, result.SHA, fakes.git.Refs["heads/hotfixes"].GetObject().GetSHA())
+	last := fakes.git.CreatedCommits[len(fakes.git.CreatedCommits)-1]
+	require.Equal(t, "Commit fix-2", last.GetMessage())
+
+	// Merge commits need a mainline parent
+	fakes.addCommit("merge", "tree-fix-2", "main-old", "fix-2")
+	_, err = repo.CherryPickCommit(context.Background(), "release-7.1", "merge", nil)
+	require.NotNil(t, err)
+	_, err = repo.CherryPickCommit(context.Background(), "release-7.1", "merge", &CommitCherryPickOptions{Mainline: 3})
+	require.NotNil(t, err)
+}
+
+func TestCommitCherryPickPRText(t *testing.T) {
+	commits := []*Commit{
+		{SHA: "1a2b3c4d5e6f", Message: "Fix the thing\n\nLong description"},
+		{SHA: "aabbccddeeff", Message: "Fix the other thing"},
+	}
+	title, body := commitCherryPickPRText("release-7.1", commits[:1])
+	require.Equal(t, "[release-7.1] Fix the thing (1a2b3c4)", title)
+	require.Equal(t, "Automated cherry-pick of:\n\n- 1a2b3c4d5e6f Fix the thing", body)
+
+	title, body = commitCherryPickPRText("release-7.1", commits)
+	require.Equal(t, "[release-7.1] Cherry-pick 2 commits", title)
+	require.Equal(t, "Automated cherry-pick of:\n\n- 1a2b3c4d5e6f Fix the thing\n- aabbccddeeff Fix the other thing", body)
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ConflictReport describes a cherry-pick that failed because of
+// conflicts and how to finish it by hand
+type ConflictReport struct {
+	Number   int             // Pull request cherry-picked
+	Target   string          // Branch the pull request was cherry-picked to
+	Branch   string          // Branch to record the manual cherry-pick in
+	Commits  []string        // Commits to cherry-pick, oldest first
+	Mainline int             // Parent merge commits are diffed against (git cherry-pick -m), zero otherwise
+	Files    []*ConflictFile // Conflicting files, sorted by path
+	Revert   bool            // The pull request was reverted in Target, not cherry-picked to it
+}
+
+// ConflictFile is a file whose changes could not be cherry-picked
+type ConflictFile struct {
+	Path  string
+	Patch string // Hunks of the pull request changing the file, empty if GitHub did not return them
+}
+
+// ConflictReport builds the report of a cherry-pick of the pull request
+// that failed with a *CherryPickConflictError. The manual cherry-pick is
+// recorded in branch.
+func (pr *PullRequest) ConflictReport(ctx context.Context, conflict error, branch string) (*ConflictReport, error) {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	conflictErr := &CherryPickConflictError{}
+	if !errors.As(conflict, &conflictErr) {
+		return nil, errors.Errorf("cherry-pick of PR #%d did not fail with conflicts: %v", pr.Number, conflict)
+	}
+	report := &ConflictReport{Number: pr.Number, Target: conflictErr.Branch, Branch: branch, Files: []*ConflictFile{}}
+
+	mode, err := pr.GetMergeMode(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting merge mode of PR #%d", pr.Number)
+	}
+	switch mode {
+	case MergeModeSquash:
+		report.Commits = []string{pr.MergeCommitSHA}
+	case MergeModeMerge:
+		report.Commits = []string{pr.MergeCommitSHA}
+		if report.Mainline, err = pr.PatchTreeID(ctx); err != nil {
+			return nil, errors.Wrap(err, "searching for parent patch tree")
+		}
+	case MergeModeQueue:
+		report.Commits, report.Mainline = []string{pr.MergeCommitSHA}, 1
+	case MergeModeRebase:
+		// The rebase commits are listed from the newest
+		shas, err := pr.GetRebaseCommits(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting rebase commits of PR #%d", pr.Number)
+		}
+		for i := len(shas) - 1; i >= 0; i-- {
+			report.Commits = append(report.Commits, shas[i])
+		}
+	default:
+		return nil, errors.Errorf("unable to report conflicts of PR #%d merged as %s", pr.Number, mode)
+	}
+
+	files, err := pr.GetChangedFiles(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading changes of conflicting files")
+	}
+	for _, path := range conflictErr.Paths {
+		file := &ConflictFile{Path: path}
+		for _, f := range files {
+			if f.Filename == path || f.PreviousFilename == path {
+				file.Patch = f.Patch
+				break
+			}
+		}
+		report.Files = append(report.Files, file)
+	}
+	return report, nil
+}
+
+// Commands returns the git commands to cherry-pick or revert the pull
+// request by hand, from a clone where origin is the repository
+func (r *ConflictReport) Commands() []string {
+	command, flags, commits := "git cherry-pick", " -x", r.Commits
+	if r.Revert {
+		// Commits are reverted from the newest
+		command, flags, commits = "git revert", " --no-edit", []string{}
+		for i := len(r.Commits) - 1; i >= 0; i-- {
+			commits = append(commits, r.Commits[i])
+		}
+	}
+	if r.Mainline > 0 {
+		flags += fmt.Sprintf(" -m %d", r.Mainline)
+	}
+	return []string{
+		fmt.Sprintf("git fetch origin %s %s", r.Target, strings.Join(r.Commits, " ")),
+		fmt.Sprintf("git checkout -b %s origin/%s", r.Branch, r.Target),
+		fmt.Sprintf("%s%s %s", command, flags, strings.Join(commits, " ")),
+		"# Fix the conflicts, git add the files, then",
+		command + " --continue",
+		fmt.Sprintf("git push origin %s", r.Branch),
+	}
+}
+
+// Markdown formats the report to be posted as a comment
+func (r *ConflictReport) Markdown() string {
+	var sb strings.Builder
+	action, manually := "cherry-pick to", "backport this PR manually"
+	if r.Revert {
+		action, manually = "revert in", "revert this PR manually"
+	}
+	sb.WriteString(fmt.Sprintf("Could not %s `%s`, these files conflict:\n\n", action, r.Target))
+	for _, f := range r.Files {
+		sb.WriteString(fmt.Sprintf("- `%s`\n", f.Path))
+	}
+	sb.WriteString(fmt.Sprintf("\nPlease %s:\n\n", manually))
+	sb.WriteString(r.commandsMarkdown())
+
+	changes := false
+	for _, f := range r.Files {
+		if f.Patch == "" {
+			continue
+		}
+		if !changes {
+			sb.WriteString("\n<details><summary>Changes of this PR to the conflicting files</summary>\n")
+			changes = true
+		}
+		sb.WriteString(fmt.Sprintf("\n`%s`\n\n```diff\n%s\n```\n", f.Path, strings.TrimRight(f.Patch, "\n")))
+	}
+	if changes {
+		sb.WriteString("\n</details>\n")
+	}
+	return sb.String()
+}
+
+// commandsMarkdown returns the commands in a code block
+func (r *ConflictReport) commandsMarkdown() string {
+	return "```sh\n" + strings.Join(r.Commands(), "\n") + "\n```\n"
+}
@@ -0,0 +1,58 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConflictReportCommands(t *testing.T) {
+	report := &ConflictReport{
+		Target: "release-7.1", Branch: "backport-1-release-7.1", Commits: []string{"aaa", "bbb"},
+	}
+	require.Equal(t, []string{
+		"git fetch origin release-7.1 aaa bbb",
+		"git checkout -b backport-1-release-7.1 origin/release-7.1",
+		"git cherry-pick -x aaa bbb",
+		"# Fix the conflicts, git add the files, then",
+		"git cherry-pick --continue",
+		"git push origin backport-1-release-7.1",
+	}, report.Commands())
+
+	// Merge commits are picked against their mainline
+	report.Commits, report.Mainline = []string{"merge"}, 2
+	require.Equal(t, "git cherry-pick -x -m 2 merge", report.Commands()[2])
+
+	// Reverts undo the commits from the newest
+	report.Commits, report.Mainline, report.Revert = []string{"aaa", "bbb"}, 0, true
+	require.Equal(t, "git revert --no-edit bbb aaa", report.Commands()[2])
+	require.Equal(t, "git revert --continue", report.Commands()[4])
+}
+
+func TestConflictReportMarkdown(t *testing.T) {
+	report := &ConflictReport{
+		Target: "release-7.1", Branch: "backport-1-release-7.1", Commits: []string{"aaa"},
+		Files: []*ConflictFile{{Path: "a.go", Patch: "@@ -1 +1 @@\n-a1\n+a2\n"}, {Path: "logo.png"}},
+	}
+	md := report.Markdown()
+	require.Contains(t, md, "Could not cherry-pick to `release-7.1`, these files conflict:\n\n- `a.go`\n- `logo.png`\n")
+	require.Contains(t, md, "```sh\ngit fetch origin release-7.1 aaa\n")
+	require.Contains(t, md, "`a.go`\n\n```diff\n@@ -1 +1 @@\n-a1\n+a2\n```\n")
+	require.NotContains(t, md, "`logo.png`\n\n```diff")
+
+	// Without hunks there is nothing to expand
+	report.Files = []*ConflictFile{{Path: "logo.png"}}
+	require.NotContains(t, report.Markdown(), "<details>")
+}
+
+func TestConflictReportNoConflict(t *testing.T) {
+	gau, _ := newFakeAPIUser()
+	pr := &PullRequest{impl: &defaultPRImplementation{githubAPIUser: gau}, Number: 1}
+	_, err := pr.ConflictReport(context.Background(), errors.New("network down"), "backport-1-release-7.1")
+	require.NotNil(t, err)
+}
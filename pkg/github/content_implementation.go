@@ -0,0 +1,88 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"bytes"
+	"context"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+)
+
+// getFileContents reads a file at the ref and returns
+// its contents and the SHA of its blob
+func (di *defaultRepoImplementation) getFileContents(
+	ctx context.Context, owner, repo, path, ref string,
+) ([]byte, string, error) {
+	var file *gogithub.RepositoryContent
+	err := di.doWithRetry(ctx, "repos.GetContents", func() (resp *gogithub.Response, err error) {
+		file, _, resp, err = di.GitHubClient().Repositories.GetContents(
+			ctx, owner, repo, path, &gogithub.RepositoryContentGetOptions{Ref: ref},
+		)
+		return resp, err
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return nil, "", errors.Wrapf(ErrFileNotFound, "reading %s at %q in %s/%s", path, ref, owner, repo)
+		}
+		return nil, "", errors.Wrapf(err, "reading %s at %q", path, ref)
+	}
+	if file == nil {
+		return nil, "", errors.Errorf("%s is a directory", path)
+	}
+
+	// Files over 1MB are returned without contents
+	content, err := file.GetContent()
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "decoding %s", path)
+	}
+	return []byte(content), file.GetSHA(), nil
+}
+
+// createOrUpdateFile commits the contents of a file to the branch
+// and returns the SHA of the commit, or an empty string if the file
+// already had the same contents
+func (di *defaultRepoImplementation) createOrUpdateFile(
+	ctx context.Context, owner, repo, path, branch string, content []byte, message string,
+) (string, error) {
+	// Updating a file needs the SHA of the blob it replaces
+	current, blobSHA, err := di.getFileContents(ctx, owner, repo, path, branch)
+	if err != nil && !errors.Is(err, ErrFileNotFound) {
+		return "", err
+	}
+	if err == nil && bytes.Equal(current, content) {
+		di.getLogger().Infof("%s is up to date in %s of %s/%s", path, branch, owner, repo)
+		return "", nil
+	}
+
+	if di.getOptions().DryRun {
+		di.getLogger().Infof("[dry-run] Would commit %s to %s of %s/%s", path, branch, owner, repo)
+		return "", nil
+	}
+
+	opts := &gogithub.RepositoryContentFileOptions{
+		Message: gogithub.String(message),
+		Content: content,
+		Branch:  gogithub.String(branch),
+	}
+	var result *gogithub.RepositoryContentResponse
+	if blobSHA == "" {
+		err = di.doWithRetry(ctx, "repos.CreateFile", func() (resp *gogithub.Response, err error) {
+			result, resp, err = di.GitHubClient().Repositories.CreateFile(ctx, owner, repo, path, opts)
+			return resp, err
+		})
+	} else {
+		opts.SHA = gogithub.String(blobSHA)
+		err = di.doWithRetry(ctx, "repos.UpdateFile", func() (resp *gogithub.Response, err error) {
+			result, resp, err = di.GitHubClient().Repositories.UpdateFile(ctx, owner, repo, path, opts)
+			return resp, err
+		})
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "committing %s to %s", path, branch)
+	}
+	di.getLogger().Infof("Committed %s to %s of %s/%s (%s)", path, branch, owner, repo, result.GetSHA())
+	return result.GetSHA(), nil
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"testing"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileContents(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	repo := gau.NewRepository(&gogithub.Repository{
+		Name: gogithub.String("mattermost-server"), Owner: &gogithub.User{Login: gogithub.String("mattermost")},
+	})
+	ctx := context.Background()
+
+	_, err := repo.GetFileContents(ctx, "CHANGELOG.md", "master")
+	require.True(t, errors.Is(err, ErrFileNotFound))
+
+	// The file is created, then updated
+	sha, err := repo.CreateOrUpdateFile(ctx, "CHANGELOG.md", "master", []byte("# v7.8.0\n"), "Add changelog")
+	require.Nil(t, err)
+	require.Equal(t, "file-commit-1", sha)
+	sha, err = repo.CreateOrUpdateFile(ctx, "CHANGELOG.md", "master", []byte("# v7.8.1\n"), "Update changelog")
+	require.Nil(t, err)
+	require.Equal(t, "file-commit-2", sha)
+	require.NotNil(t, fakes.repos.FileCommits[1].SHA)
+
+	content, err := repo.GetFileContents(ctx, "CHANGELOG.md", "master")
+	require.Nil(t, err)
+	require.Equal(t, "# v7.8.1\n", string(content))
+
+	// Nothing is committed if the contents did not change
+	sha, err = repo.CreateOrUpdateFile(ctx, "CHANGELOG.md", "master", []byte("# v7.8.1\n"), "Update changelog")
+	require.Nil(t, err)
+	require.Empty(t, sha)
+	require.Len(t, fakes.repos.FileCommits, 2)
+
+	_, err = repo.CreateOrUpdateFile(ctx, "CHANGELOG.md", "master", []byte("# v7.8.2\n"), "")
+	require.NotNil(t, err)
+
+	gau.options.DryRun = true
+	_, err = repo.CreateOrUpdateFile(ctx, "VERSION", "master", []byte("7.8.2"), "Bump version")
+	require.Nil(t, err)
+	require.Len(t, fakes.repos.FileCommits, 2)
+}
@@ -0,0 +1,173 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+)
+
+// Errors returned by the package. They are wrapped with context about the
+// failed operation, use errors.Is to check for them.
+var (
+	// ErrRepositoryNotFound is returned when GitHub reports that a
+	// repository does not exist or is not visible to the client
+	ErrRepositoryNotFound = errors.New("repository not found")
+
+	// ErrNoRepository is returned when a pull request does not have
+	// the data needed to identify its repository
+	ErrNoRepository = errors.New("pull request has no repository")
+
+	// ErrNotMerged is returned when an operation that needs the merge
+	// commit is called on a pull request that has not been merged
+	ErrNotMerged = errors.New("pull request is not merged")
+
+	// ErrNoMergeCommit is returned when a pull request has no merge commit
+	// SHA, eg when it is open or its merge has not been synced yet
+	ErrNoMergeCommit = errors.New("pull request has no merge commit")
+
+	// ErrStaleMergeCommit is returned when the merge commit reported for a
+	// pull request is not part of its base branch, eg a test merge commit
+	// that GitHub did not update. Fetching the pull request again fixes it.
+	ErrStaleMergeCommit = errors.New("merge commit is not in the base branch")
+
+	// ErrEmptyCommit is returned when the API returns no data for a commit
+	ErrEmptyCommit = errors.New("commit returned empty")
+
+	// ErrNoCommits is returned when a pull request has no commits
+	ErrNoCommits = errors.New("pull request commit list is empty")
+
+	// ErrPatchTreeNotFound is returned when none of the parents of a
+	// merge commit matches the tree of the pull request
+	ErrPatchTreeNotFound = errors.New("patch tree not found")
+
+	// ErrTargetBranchMissing is returned when the branch a pull
+	// request should be cherry-picked to does not exist
+	ErrTargetBranchMissing = errors.New("target branch does not exist")
+
+	// ErrAutoMergeDisabled is returned when auto-merge cannot be
+	// enabled because it is not allowed in the repository settings
+	ErrAutoMergeDisabled = errors.New("auto-merge is not allowed in the repository")
+
+	// ErrInvalidEvent is returned when a webhook event lacks
+	// the data needed to identify its pull request
+	ErrInvalidEvent = errors.New("invalid webhook event")
+
+	// ErrMilestoneNotFound is returned when a repository has
+	// no open milestone with the requested title
+	ErrMilestoneNotFound = errors.New("milestone not found")
+
+	// ErrNotMergeable is returned when GitHub refuses to merge a pull
+	// request, eg because of conflicts or failing required checks
+	ErrNotMergeable = errors.New("pull request is not mergeable")
+
+	// ErrHeadModified is returned when the head of a pull request
+	// changed since it was read and the merge was refused
+	ErrHeadModified = errors.New("pull request head was modified")
+
+	// ErrFileNotFound is returned when reading a file
+	// that does not exist in the repository at the ref
+	ErrFileNotFound = errors.New("file not found")
+
+	// ErrCherryPickConflict is returned when the changes of a pull request
+	// cannot be applied cleanly on top of the target branch
+	ErrCherryPickConflict = errors.New("cherry-pick conflict")
+)
+
+// CherryPickConflictError is returned when a commit cannot be applied on
+// the target branch because files it changes also diverged there. It
+// matches ErrCherryPickConflict with errors.Is.
+type CherryPickConflictError struct {
+	Commit string   // SHA of the commit that could not be applied
+	Branch string   // Branch the commit was applied to
+	Paths  []string // Conflicting paths, sorted
+}
+
+func (e *CherryPickConflictError) Error() string {
+	return fmt.Sprintf(
+		"%s: applying %s to %s, conflicting paths: %s",
+		ErrCherryPickConflict, e.Commit, e.Branch, strings.Join(e.Paths, ", "),
+	)
+}
+
+// Is makes the error match ErrCherryPickConflict
+func (e *CherryPickConflictError) Is(target error) bool {
+	return target == ErrCherryPickConflict
+}
+
+// ConflictPaths returns the conflicting paths carried by a cherry-pick
+// conflict error, or nil if err does not report a conflict
+func ConflictPaths(err error) []string {
+	conflictErr := &CherryPickConflictError{}
+	if errors.As(err, &conflictErr) {
+		return conflictErr.Paths
+	}
+	return nil
+}
+
+// EmptyCommitError is returned when the API returns no data for a commit.
+// It records the operation that read it so failures can be told apart,
+// and matches ErrEmptyCommit with errors.Is.
+type EmptyCommitError struct {
+	SHA string // Commit that was queried
+	Op  string // Operation reading the commit
+}
+
+func (e *EmptyCommitError) Error() string {
+	return fmt.Sprintf("%s: %s querying sha %s", ErrEmptyCommit, e.Op, e.SHA)
+}
+
+// Is makes the error match ErrEmptyCommit
+func (e *EmptyCommitError) Is(target error) bool {
+	return target == ErrEmptyCommit
+}
+
+// MergeError is returned when GitHub refuses to merge a pull request.
+// It carries the reason given by GitHub and matches ErrNotMergeable
+// (405 responses) or ErrHeadModified (409 responses) with errors.Is.
+type MergeError struct {
+	Number  int    // Pull request number
+	Status  int    // HTTP status of the response
+	Message string // Reason reported by GitHub
+}
+
+func (e *MergeError) Error() string {
+	if e.Status == http.StatusConflict {
+		return fmt.Sprintf("%s: merging PR #%d: %s", ErrHeadModified, e.Number, e.Message)
+	}
+	return fmt.Sprintf("%s: merging PR #%d: %s", ErrNotMergeable, e.Number, e.Message)
+}
+
+// Is makes the error match ErrNotMergeable or ErrHeadModified
+func (e *MergeError) Is(target error) bool {
+	if e.Status == http.StatusConflict {
+		return target == ErrHeadModified
+	}
+	return target == ErrNotMergeable
+}
+
+// isMissingRef returns true if err reports that a git reference does
+// not exist. GitHub answers 422 instead of 404 when deleting them.
+func isMissingRef(err error) bool {
+	if isNotFound(err) {
+		return true
+	}
+	responseErr := &gogithub.ErrorResponse{}
+	return errors.As(err, &responseErr) &&
+		responseErr.Response != nil &&
+		responseErr.Response.StatusCode == http.StatusUnprocessableEntity &&
+		responseErr.Message == "Reference does not exist"
+}
+
+// isNotFound returns true if err is a GitHub API 404 response
+func isNotFound(err error) bool {
+	responseErr := &gogithub.ErrorResponse{}
+	return errors.As(err, &responseErr) &&
+		responseErr.Response != nil &&
+		responseErr.Response.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// CachedResponse is a response stored to send conditional requests
+type CachedResponse struct {
+	ETag   string      // ETag sent by GitHub with the response
+	Header http.Header // Headers of the response
+	Body   []byte      // Body of the response
+}
+
+// ETagCache stores the responses used to send conditional requests. It
+// can be implemented to share them among processes, eg in Redis.
+// Implementations must be safe for concurrent use.
+type ETagCache interface {
+	Get(key string) (*CachedResponse, bool)
+	Set(key string, response *CachedResponse)
+}
+
+// memoryETagCache keeps the responses in memory
+type memoryETagCache struct {
+	mtx     sync.RWMutex
+	entries map[string]*CachedResponse
+}
+
+// NewMemoryETagCache returns an ETagCache that keeps the responses in memory
+func NewMemoryETagCache() ETagCache {
+	return &memoryETagCache{entries: map[string]*CachedResponse{}}
+}
+
+func (mc *memoryETagCache) Get(key string) (*CachedResponse, bool) {
+	mc.mtx.RLock()
+	defer mc.mtx.RUnlock()
+	response, ok := mc.entries[key]
+	return response, ok
+}
+
+func (mc *memoryETagCache) Set(key string, response *CachedResponse) {
+	mc.mtx.Lock()
+	defer mc.mtx.Unlock()
+	mc.entries[key] = response
+}
+
+// etagTransport makes GET requests conditional using the ETags of the
+// previous responses. GitHub answers with 304 Not Modified when the data
+// did not change, and those responses do not count against the rate
+// limit. The stored response is then returned to the caller as if the
+// API had sent it again.
+type etagTransport struct {
+	base  http.RoundTripper
+	cache ETagCache
+}
+
+func newETagTransport(base http.RoundTripper, cache ETagCache) *etagTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if cache == nil {
+		cache = NewMemoryETagCache()
+	}
+	return &etagTransport{base: base, cache: cache}
+}
+
+// etagKey returns the key of a request in the cache. The Accept header
+// is part of it because GitHub returns different media types for it.
+func etagKey(req *http.Request) string {
+	return req.URL.String() + " " + req.Header.Get("Accept")
+}
+
+func (et *etagTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return et.base.RoundTrip(req)
+	}
+
+	key := etagKey(req)
+	entry, cached := et.cache.Get(key)
+
+	if cached {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", entry.ETag)
+	}
+
+	resp, err := et.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if cached && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		return entry.response(req, resp), nil
+	}
+
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode != http.StatusOK || etag == "" {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	et.cache.Set(key, &CachedResponse{ETag: etag, Header: resp.Header.Clone(), Body: body})
+	return resp, nil
+}
+
+// response builds a 200 response from the stored entry. The rate limit
+// headers are taken from the 304 response so they remain accurate.
+func (entry *CachedResponse) response(req *http.Request, notModified *http.Response) *http.Response {
+	header := entry.Header.Clone()
+	for name, values := range notModified.Header {
+		if strings.HasPrefix(http.CanonicalHeaderKey(name), "X-Ratelimit-") {
+			header[name] = values
+		}
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         notModified.Proto,
+		ProtoMajor:    notModified.ProtoMajor,
+		ProtoMinor:    notModified.ProtoMinor,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(entry.Body)),
+		ContentLength: int64(len(entry.Body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConditionalRequests(t *testing.T) {
+	calls, notModified := 0, 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/repos/mattermost/mattermost-server", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "4000")
+		w.Header().Set("X-RateLimit-Reset", "1700000000")
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`{"name":"mattermost-server","owner":{"login":"mattermost"}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	require.Nil(t, err)
+
+	gh := NewWithOptions(&Options{EnterpriseURL: serverURL, ConditionalRequests: true})
+	impl := gh.NewRepository("mattermost", "mattermost-server").impl.(*defaultRepoImplementation)
+
+	// The second request is answered with a 304, callers get the stored data
+	for i := 0; i < 2; i++ {
+		ghrepo, resp, err := impl.GitHubClient().Repositories.Get(context.Background(), "mattermost", "mattermost-server")
+		require.Nil(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "mattermost-server", ghrepo.GetName())
+		require.Equal(t, 4000, resp.Rate.Remaining)
+	}
+	require.Equal(t, 2, calls)
+	require.Equal(t, 1, notModified)
+
+	// A cache shared by clients lets a new one reuse the stored responses
+	shared := &countingETagCache{ETagCache: NewMemoryETagCache()}
+	for i := 0; i < 2; i++ {
+		gh := NewWithOptions(&Options{EnterpriseURL: serverURL, ConditionalRequests: true, ETagCache: shared})
+		impl := gh.NewRepository("mattermost", "mattermost-server").impl.(*defaultRepoImplementation)
+		_, _, err := impl.GitHubClient().Repositories.Get(context.Background(), "mattermost", "mattermost-server")
+		require.Nil(t, err)
+	}
+	require.Equal(t, 4, calls)
+	require.Equal(t, 2, notModified)
+	require.Equal(t, 1, shared.sets)
+}
+
+// countingETagCache counts the responses stored in a cache
+type countingETagCache struct {
+	ETagCache
+	sets int
+}
+
+func (cc *countingETagCache) Set(key string, response *CachedResponse) {
+	cc.sets++
+	cc.ETagCache.Set(key, response)
+}
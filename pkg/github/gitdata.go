@@ -0,0 +1,23 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+// Modes of the entries of git trees
+const (
+	TreeModeFile       = "100644"
+	TreeModeExecutable = "100755"
+	TreeModeSymlink    = "120000"
+	TreeModeDirectory  = "040000"
+	TreeModeSubmodule  = "160000"
+)
+
+// TreeEntry is a file in a git tree. When creating trees, the file is
+// either the blob SHA or the Content of the file, or Delete to remove it.
+type TreeEntry struct {
+	Path    string
+	Mode    string // One of the TreeMode constants, defaults to TreeModeFile
+	SHA     string
+	Content string
+	Delete  bool
+}
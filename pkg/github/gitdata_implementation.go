@@ -0,0 +1,184 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"encoding/base64"
+	"time"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+)
+
+// getTree reads all the entries of a tree recursively
+func (gau *githubAPIUser) getTree(ctx context.Context, owner, repo, sha string) ([]*gogithub.TreeEntry, error) {
+	var tree *gogithub.Tree
+	err := gau.doWithRetry(ctx, "git.GetTree", func() (resp *gogithub.Response, err error) {
+		tree, resp, err = gau.GitHubClient().Git.GetTree(ctx, owner, repo, sha, true)
+		return resp, err
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "fetching tree %s", sha)
+	}
+	if tree.GetTruncated() {
+		return nil, errors.Errorf("tree %s is too large to be read from the API", sha)
+	}
+	return tree.Entries, nil
+}
+
+// createBlob stores the content in the repository and returns its SHA
+func (gau *githubAPIUser) createBlob(ctx context.Context, owner, repo string, content []byte) (string, error) {
+	if gau.getOptions().DryRun {
+		gau.getLogger().Infof("[dry-run] Would create a blob of %d bytes in %s/%s", len(content), owner, repo)
+		return dryRunSHA, nil
+	}
+
+	var blob *gogithub.Blob
+	err := gau.doWithRetry(ctx, "git.CreateBlob", func() (resp *gogithub.Response, err error) {
+		blob, resp, err = gau.GitHubClient().Git.CreateBlob(ctx, owner, repo, &gogithub.Blob{
+			Content:  gogithub.String(base64.StdEncoding.EncodeToString(content)),
+			Encoding: gogithub.String("base64"),
+		})
+		return resp, err
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "creating blob")
+	}
+	return blob.GetSHA(), nil
+}
+
+// createTree creates a tree from the entries on top of the base
+// tree and returns its SHA
+func (gau *githubAPIUser) createTree(
+	ctx context.Context, owner, repo, baseTree string, entries []*gogithub.TreeEntry,
+) (string, error) {
+	if gau.getOptions().DryRun {
+		gau.getLogger().Infof("[dry-run] Would create a tree changing %d paths in %s/%s", len(entries), owner, repo)
+		return dryRunSHA, nil
+	}
+
+	var tree *gogithub.Tree
+	err := gau.doWithRetry(ctx, "git.CreateTree", func() (resp *gogithub.Response, err error) {
+		tree, resp, err = gau.GitHubClient().Git.CreateTree(ctx, owner, repo, baseTree, entries)
+		return resp, err
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "creating tree")
+	}
+	return tree.GetSHA(), nil
+}
+
+// createCommit creates the commit and returns its SHA
+func (gau *githubAPIUser) createCommit(ctx context.Context, owner, repo string, commit *gogithub.Commit) (string, error) {
+	if gau.getOptions().DryRun {
+		gau.getLogger().Infof("[dry-run] Would create a commit of tree %s in %s/%s", commit.GetTree().GetSHA(), owner, repo)
+		return dryRunSHA, nil
+	}
+
+	var created *gogithub.Commit
+	err := gau.doWithRetry(ctx, "git.CreateCommit", func() (resp *gogithub.Response, err error) {
+		created, resp, err = gau.GitHubClient().Git.CreateCommit(ctx, owner, repo, commit)
+		return resp, err
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "creating commit of tree %s", commit.GetTree().GetSHA())
+	}
+	return created.GetSHA(), nil
+}
+
+// updateRef points an existing ref to the commit. Unless forced, the
+// commit must be a descendant of the one the ref points to.
+func (gau *githubAPIUser) updateRef(ctx context.Context, owner, repo, ref, sha string, force bool) error {
+	ref = qualifyRef(ref)
+	if gau.getOptions().DryRun {
+		gau.getLogger().Infof("[dry-run] Would point %s to %s in %s/%s", ref, sha, owner, repo)
+		return nil
+	}
+
+	err := gau.doWithRetry(ctx, "git.UpdateRef", func() (resp *gogithub.Response, err error) {
+		_, resp, err = gau.GitHubClient().Git.UpdateRef(ctx, owner, repo, &gogithub.Reference{
+			Ref:    gogithub.String("refs/" + ref),
+			Object: &gogithub.GitObject{SHA: gogithub.String(sha)},
+		}, force)
+		return resp, err
+	})
+	if err != nil {
+		return errors.Wrapf(err, "updating %s to %s", ref, sha)
+	}
+	gau.getLogger().Infof("Pointed %s to %s in %s/%s", ref, sha, owner, repo)
+	return nil
+}
+
+// commitTree creates a commit of the tree recorded with the identity
+// of the options and returns its SHA
+func (di *defaultRepoImplementation) commitTree(
+	ctx context.Context, owner, repo, message, treeSHA string, parents []string,
+) (string, error) {
+	return di.createCommit(ctx, owner, repo, newCommitObject(message, treeSHA, parents, di.getOptions()))
+}
+
+// newCommitObject returns the commit to create with the committer and
+// signing key of the options. The committer is also used as author.
+func newCommitObject(message, treeSHA string, parents []string, opts *Options) *gogithub.Commit {
+	commit := &gogithub.Commit{
+		Message:    gogithub.String(message),
+		Tree:       &gogithub.Tree{SHA: gogithub.String(treeSHA)},
+		Parents:    []*gogithub.Commit{},
+		SigningKey: opts.SigningKey,
+	}
+	for _, p := range parents {
+		commit.Parents = append(commit.Parents, &gogithub.Commit{SHA: gogithub.String(p)})
+	}
+	if opts.Committer != nil {
+		now := time.Now()
+		identity := &gogithub.CommitAuthor{
+			Name:  gogithub.String(opts.Committer.Name),
+			Email: gogithub.String(opts.Committer.Email),
+			Date:  &now,
+		}
+		commit.Author = identity
+		commit.Committer = identity
+	}
+	return commit
+}
+
+// treeEntries converts the entries to create a tree
+func treeEntries(entries []*TreeEntry) []*gogithub.TreeEntry {
+	result := make([]*gogithub.TreeEntry, 0, len(entries))
+	for _, e := range entries {
+		mode := e.Mode
+		if mode == "" {
+			mode = TreeModeFile
+		}
+		entry := &gogithub.TreeEntry{
+			Path: gogithub.String(e.Path),
+			Mode: gogithub.String(mode),
+			Type: gogithub.String(treeObjectType(mode)),
+		}
+		// Entries without SHA nor content remove the path
+		switch {
+		case e.Delete:
+		case e.SHA != "":
+			entry.SHA = gogithub.String(e.SHA)
+		default:
+			entry.Content = gogithub.String(e.Content)
+		}
+		result = append(result, entry)
+	}
+	return result
+}
+
+// treeObjectType returns the type of the git object
+// a tree entry with the mode points to
+func treeObjectType(mode string) string {
+	switch mode {
+	case TreeModeDirectory:
+		return "tree"
+	case TreeModeSubmodule:
+		return "commit"
+	default:
+		return "blob"
+	}
+}
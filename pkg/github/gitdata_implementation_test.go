@@ -0,0 +1,69 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"testing"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildCommit(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	gau.options.Committer = &CommitAuthor{Name: "Mattermod", Email: "mattermod@mattermost.com"}
+	repo := gau.NewRepository(&gogithub.Repository{
+		Name: gogithub.String("mattermost-server"), Owner: &gogithub.User{Login: gogithub.String("mattermost")},
+	})
+	ctx := context.Background()
+	fakes.git.Trees["tree-1"] = &gogithub.Tree{Entries: []*gogithub.TreeEntry{
+		testEntry("README.md", "readme"), testEntry("VERSION", "v1"), testEntry("old.go", "old"),
+	}}
+	fakes.git.Refs["heads/master"] = &gogithub.Reference{
+		Ref: gogithub.String("refs/heads/master"), Object: &gogithub.GitObject{SHA: gogithub.String("commit-1")},
+	}
+
+	blob, err := repo.CreateBlob(ctx, []byte("7.8.1\n"))
+	require.Nil(t, err)
+	require.Equal(t, []byte("7.8.1\n"), fakes.git.Blobs[blob])
+
+	_, err = repo.CreateTree(ctx, "tree-1", []*TreeEntry{{SHA: blob}})
+	require.NotNil(t, err)
+	tree, err := repo.CreateTree(ctx, "tree-1", []*TreeEntry{
+		{Path: "VERSION", SHA: blob},
+		{Path: "CHANGELOG.md", Content: "# v7.8.1\n"},
+		{Path: "old.go", Delete: true},
+	})
+	require.Nil(t, err)
+
+	entries, err := repo.GetTree(ctx, tree)
+	require.Nil(t, err)
+	paths := map[string]string{}
+	for _, e := range entries {
+		paths[e.Path] = e.SHA
+		require.Equal(t, TreeModeFile, e.Mode)
+	}
+	require.Len(t, paths, 3)
+	require.Equal(t, blob, paths["VERSION"])
+	require.NotEmpty(t, paths["CHANGELOG.md"])
+
+	sha, err := repo.CreateCommit(ctx, "Prepare v7.8.1", tree, []string{"commit-1"})
+	require.Nil(t, err)
+	created := fakes.git.CreatedCommits[0]
+	require.Equal(t, tree, created.GetTree().GetSHA())
+	require.Equal(t, "commit-1", created.Parents[0].GetSHA())
+	require.Equal(t, "Mattermod", created.GetAuthor().GetName())
+
+	require.Nil(t, repo.UpdateRef(ctx, "master", sha, false))
+	require.Equal(t, sha, fakes.git.Refs["heads/master"].GetObject().GetSHA())
+	require.NotNil(t, repo.UpdateRef(ctx, "refs/heads/missing", sha, false))
+
+	// Nothing is written in dry-run mode
+	gau.options.DryRun = true
+	sha, err = repo.CreateCommit(ctx, "Prepare v7.8.2", tree, []string{sha})
+	require.Nil(t, err)
+	require.Equal(t, dryRunSHA, sha)
+	require.Len(t, fakes.git.CreatedCommits, 1)
+}
@@ -6,10 +6,15 @@ package github
 import (
 	"context"
 	"net/http"
+	"net/url"
 	"os"
+	"strings"
+	"time"
 
 	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/openpgp"
 	"golang.org/x/oauth2"
 )
 
@@ -22,39 +27,118 @@ type GitHub struct {
 
 // New returns a new GitHub client
 func New() *GitHub {
-	return NewWithOptions(&defaultOptions)
+	return NewWithOptions(nil)
 }
 
+// NewWithOptions returns a GitHub client configured with opts. Nil
+// options take the package defaults. The options are copied, missing
+// retry and concurrency settings are filled in the copy.
 func NewWithOptions(opts *Options) *GitHub {
-	gh := &GitHub{
-		impl:    &defaultGithubImplementation{},
-		options: opts,
+	o := copyOptions(opts)
+	if o.Retry.MaxAttempts == 0 {
+		o.Retry = defaultOptions.Retry
 	}
-	return gh
+	if o.Concurrency == 0 {
+		o.Concurrency = defaultOptions.Concurrency
+	}
+	impl := &defaultGithubImplementation{githubAPIUser: githubAPIUser{options: &o}}
+	// The client is created now, the objects created later share it
+	impl.GitHubClient()
+	return &GitHub{impl: impl, options: &o}
 }
 
 type githubAPIUser struct {
-	client *gogithub.Client
+	client  *Client
+	options *Options
+}
+
+// getOptions returns the options set for the API user, falling
+// back to the package defaults when none were specified
+func (gau *githubAPIUser) getOptions() *Options {
+	if gau.options == nil {
+		return &defaultOptions
+	}
+	return gau.options
+}
+
+// getLogger returns the logger set in the options or the
+// standard logrus logger if there is none
+func (gau *githubAPIUser) getLogger() Logger {
+	if gau.getOptions().Logger != nil {
+		return gau.getOptions().Logger
+	}
+	return NewLogrusLogger(logrus.StandardLogger())
+}
+
+// operationContext bounds ctx with the operation timeout set in the
+// options. When the parent context has a sooner deadline, it is kept.
+func (gau *githubAPIUser) operationContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if timeout := gau.getOptions().OperationTimeout; timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
 }
 
-// getGoGitHubClient returns a go-github client. If the environment
-// contains a GitHub token, the client will use it for authentication
-func (gau *githubAPIUser) GitHubClient() *gogithub.Client {
+// GitHubClient returns the client used to talk to the GitHub API. Unless
+// one was set in the options, it is backed by a go-github client. If a
+// transport is set in the options, it takes care of authentication.
+// Otherwise, if the environment contains a GitHub token, it will be used.
+func (gau *githubAPIUser) GitHubClient() *Client {
+	if gau.client == nil && gau.getOptions().Client != nil {
+		gau.client = gau.getOptions().Client
+	}
 	if gau.client == nil {
 		httpClient := http.DefaultClient
 		tkn := os.Getenv(GITHUB_TOKEN)
-		if tkn == "" {
-			logrus.Warn("Note: GitHub client will not be authenticated")
+		if gau.getOptions().Transport != nil {
+			httpClient = &http.Client{Transport: gau.getOptions().Transport}
+		} else if tkn == "" {
+			gau.getLogger().Warnf("Note: GitHub client will not be authenticated")
 		} else {
 			httpClient = oauth2.NewClient(context.Background(), oauth2.StaticTokenSource(
 				&oauth2.Token{AccessToken: tkn},
 			))
 		}
-		gau.client = gogithub.NewClient(httpClient)
+		if gau.getOptions().ConditionalRequests {
+			httpClient = &http.Client{Transport: newETagTransport(httpClient.Transport, gau.getOptions().ETagCache)}
+		}
+		gau.client = NewClient(newGoGitHubClient(httpClient, gau.getOptions()))
 	}
 	return gau.client
 }
 
+// newGoGitHubClient returns a go-github client. When an enterprise URL is
+// set in the options, the client will talk to that GitHub Enterprise
+// Server instead of api.github.com
+func newGoGitHubClient(httpClient *http.Client, opts *Options) *gogithub.Client {
+	ghclient := gogithub.NewClient(httpClient)
+	if opts.EnterpriseURL == nil {
+		return ghclient
+	}
+
+	ghclient.BaseURL = enterpriseAPIURL(opts.EnterpriseURL, "/api/v3/")
+	if opts.EnterpriseUploadURL != nil {
+		ghclient.UploadURL = enterpriseAPIURL(opts.EnterpriseUploadURL, "/api/uploads/")
+	} else {
+		ghclient.UploadURL = enterpriseAPIURL(opts.EnterpriseURL, "/api/uploads/")
+	}
+	return ghclient
+}
+
+// enterpriseAPIURL returns a copy of the URL of a GitHub Enterprise Server
+// ending with the API prefix. Relative API paths are resolved against it
+// so the URL must end with a slash.
+func enterpriseAPIURL(serverURL *url.URL, prefix string) *url.URL {
+	apiURL := *serverURL
+	if !strings.HasSuffix(apiURL.Path, "/") {
+		apiURL.Path += "/"
+	}
+	if !strings.HasSuffix(apiURL.Path, prefix) {
+		apiURL.Path = strings.TrimSuffix(apiURL.Path, "/") + prefix
+	}
+	return &apiURL
+}
+
 func (gau *githubAPIUser) NewCommit(commit *gogithub.Commit) *Commit {
 	c := &Commit{
 		SHA:     commit.GetSHA(),
@@ -65,19 +149,89 @@ func (gau *githubAPIUser) NewCommit(commit *gogithub.Commit) *Commit {
 	for _, parent := range commit.Parents {
 		c.Parents = append(c.Parents, gau.NewCommit(parent))
 	}
+
+	c.Message = commit.GetMessage()
+	if commit.Author != nil {
+		c.Author = &CommitAuthor{
+			Name:  commit.GetAuthor().GetName(),
+			Email: commit.GetAuthor().GetEmail(),
+			Date:  commit.GetAuthor().GetDate(),
+		}
+	}
+	if commit.Committer != nil {
+		c.Committer = &CommitAuthor{
+			Name:  commit.GetCommitter().GetName(),
+			Email: commit.GetCommitter().GetEmail(),
+			Date:  commit.GetCommitter().GetDate(),
+		}
+	}
+	if commit.Verification != nil {
+		c.Verification = &CommitVerification{
+			Verified:  commit.GetVerification().GetVerified(),
+			Reason:    commit.GetVerification().GetReason(),
+			Signature: commit.GetVerification().GetSignature(),
+		}
+	}
 	return c
 }
 
+// NewRepositoryCommit builds a Commit from the data returned by the
+// repositories and pull requests APIs. These return the commit SHA and
+// its parents outside of the git commit object.
+func (gau *githubAPIUser) NewRepositoryCommit(repoCommit *gogithub.RepositoryCommit) *Commit {
+	c := gau.NewCommit(repoCommit.GetCommit())
+	c.SHA = repoCommit.GetSHA()
+	if len(repoCommit.Parents) > 0 {
+		c.Parents = []*Commit{}
+		for _, parent := range repoCommit.Parents {
+			c.Parents = append(c.Parents, gau.NewCommit(parent))
+		}
+	}
+	if repoCommit.Stats != nil {
+		c.Stats = &CommitStats{
+			Additions: repoCommit.GetStats().GetAdditions(),
+			Deletions: repoCommit.GetStats().GetDeletions(),
+			Total:     repoCommit.GetStats().GetTotal(),
+		}
+	}
+	if repoCommit.Files != nil {
+		c.Files = []*CommitFile{}
+		for _, f := range repoCommit.Files {
+			c.Files = append(c.Files, newCommitFile(f))
+		}
+	}
+	return c
+}
+
+func newCommitFile(f *gogithub.CommitFile) *CommitFile {
+	return &CommitFile{
+		Filename:         f.GetFilename(),
+		PreviousFilename: f.GetPreviousFilename(),
+		Status:           f.GetStatus(),
+		Additions:        f.GetAdditions(),
+		Deletions:        f.GetDeletions(),
+		Patch:            f.GetPatch(),
+	}
+}
+
 // NewPullRequest builds a PullRequest object from a gogithub PR object
 func (gau *githubAPIUser) NewPullRequest(ghpr *gogithub.PullRequest) *PullRequest {
+	labels := []string{}
+	for _, label := range ghpr.Labels {
+		labels = append(labels, label.GetName())
+	}
 	return &PullRequest{
-		impl:                &defaultPRImplementation{},
+		impl:                &defaultPRImplementation{githubAPIUser: *gau, logger: gau.getLogger()},
+		NodeID:              ghpr.GetNodeID(),
 		RepoOwner:           ghpr.GetBase().GetRepo().GetOwner().GetLogin(),
 		RepoName:            ghpr.GetBase().GetRepo().GetName(),
 		Number:              ghpr.GetNumber(),
+		Title:               ghpr.GetTitle(),
+		Body:                ghpr.GetBody(),
 		Username:            ghpr.GetUser().GetLogin(),
 		FullName:            ghpr.GetHead().GetRepo().GetFullName(),
 		Ref:                 ghpr.GetHead().GetRef(),
+		BaseRef:             ghpr.GetBase().GetRef(),
 		Sha:                 ghpr.GetHead().GetSHA(),
 		State:               ghpr.GetState(),
 		URL:                 ghpr.GetURL(),
@@ -87,27 +241,235 @@ func (gau *githubAPIUser) NewPullRequest(ghpr *gogithub.PullRequest) *PullReques
 		MaintainerCanModify: gogithub.Bool(ghpr.GetMaintainerCanModify()),
 		MilestoneNumber:     gogithub.Int64(int64(ghpr.GetMilestone().GetNumber())),
 		MilestoneTitle:      gogithub.String(ghpr.GetMilestone().GetTitle()),
+		Labels:              labels,
+		Assignees:           userLogins(ghpr.Assignees),
+		labelsLoaded:        true,
+		draft:               ghpr.GetDraft(),
+		mergedAt:            ghpr.GetMergedAt(),
+	}
+}
+
+// NewIssue builds an Issue object from a gogithub issue of owner/repo
+func (gau *githubAPIUser) NewIssue(owner, repo string, ghissue *gogithub.Issue) *Issue {
+	return &Issue{
+		impl:           &defaultIssueImplementation{githubAPIUser: *gau, logger: gau.getLogger()},
+		Owner:          owner,
+		Repo:           repo,
+		Number:         ghissue.GetNumber(),
+		Title:          ghissue.GetTitle(),
+		Body:           ghissue.GetBody(),
+		State:          ghissue.GetState(),
+		URL:            ghissue.GetHTMLURL(),
+		Labels:         labelNames(ghissue.Labels),
+		Assignees:      userLogins(ghissue.Assignees),
+		MilestoneTitle: ghissue.GetMilestone().GetTitle(),
 	}
 }
 
 func (gau *githubAPIUser) NewRepository(ghrepo *gogithub.Repository) *Repository {
 	return &Repository{
-		impl:  &defaultRepoImplementation{},
+		impl:  &defaultRepoImplementation{githubAPIUser: *gau},
 		Owner: ghrepo.GetOwner().GetLogin(),
 		Name:  ghrepo.GetName(),
 	}
 }
 
 type Options struct {
+	Retry       RetryOptions // Controls how failed API calls are retried
+	Concurrency int          // Maximum number of parallel API calls per operation
+	PageSize    int          // Commits requested per page when listing them, GitHub's default (30) when zero
+
+	// OperationTimeout limits the time each exported operation can take,
+	// so a hung connection cannot block callers forever. It applies on top
+	// of the deadline of the context passed in. Zero disables it.
+	OperationTimeout time.Duration
+
+	// Client replaces the services used to talk to the GitHub API
+	Client *Client
+
+	// Transport is used to send the requests to the GitHub API. When set,
+	// the GITHUB_TOKEN in the environment is ignored and the transport is
+	// responsible for authentication (see NewWithAppAuth and NewWithTokens).
+	Transport http.RoundTripper
+
+	// EnterpriseURL is the address of a GitHub Enterprise Server. When set,
+	// all API calls are sent to it. The /api/v3/ prefix is added if missing.
+	EnterpriseURL *url.URL
+
+	// EnterpriseUploadURL is the upload address of the GitHub Enterprise
+	// Server. If not set, it is derived from EnterpriseURL.
+	EnterpriseUploadURL *url.URL
+
+	// ConditionalRequests keeps the responses of the API in memory and
+	// sends their ETags in later requests for the same data. Unchanged
+	// data is then returned by GitHub as 304 Not Modified, which does not
+	// count against the rate limit.
+	ConditionalRequests bool
+
+	// ETagCache stores the responses used for conditional requests.
+	// When nil, they are kept in memory.
+	ETagCache ETagCache
+
+	// RepositoryCache keeps the repositories read from the API to avoid
+	// fetching them repeatedly. Set it to nil to disable caching.
+	RepositoryCache *RepositoryCache
+
+	// MilestoneCache keeps the open milestones of the repositories to
+	// avoid listing them for each pull request. Set it to nil to disable it.
+	MilestoneCache *MilestoneCache
+
+	// MembershipCache keeps the results of organization and team
+	// membership checks. Set it to nil to always ask the API.
+	MembershipCache *MembershipCache
+
+	// AccurateSingleCommitMode makes the merge mode detection tell apart
+	// squashed and rebased pull requests with only one commit. When false,
+	// these are always reported as squashed.
+	AccurateSingleCommitMode bool
+
+	// VerifyMergeCommit makes the merge mode detection check that the merge
+	// commit is part of the base branch before reporting a rebase. Stale
+	// merge commits return ErrStaleMergeCommit. It costs one API call.
+	VerifyMergeCommit bool
+
+	// RateLimitThreshold makes API calls wait for the rate limit to reset
+	// when fewer calls than the threshold are left. Zero disables it.
+	RateLimitThreshold int
+
+	// Login is the user or app the client is authenticated as, eg
+	// mattermod[bot]. Only its comments are found by their markers.
+	// When empty, it is read once from the API.
+	Login string
+
+	// DryRun makes the operations that write to GitHub log the changes
+	// they would make instead of calling the API. Reads are not affected.
+	DryRun bool
+
+	// Logger receives the log messages of the package. When nil, the
+	// standard logrus logger is used.
+	Logger Logger
+
+	// Metrics receives measurements of the API calls, merge modes and
+	// cherry-picks. When nil, they are discarded.
+	Metrics Metrics
+
+	// Committer is the identity recorded as committer of the commits
+	// created when cherry-picking. Their authors are always preserved.
+	// When nil, the committer of the original commit is kept.
+	Committer *CommitAuthor
+
+	// SignOff appends a Signed-off-by trailer with the Committer
+	// identity to the messages of cherry-picked commits
+	SignOff bool
+
+	// SigningKey is the GPG key used to sign the commits created
+	// when cherry-picking. Commits are not signed when nil.
+	SigningKey *openpgp.Entity
 }
 
-var defaultOptions = Options{}
+var defaultOptions = Options{
+	Retry:       defaultRetryOptions,
+	Concurrency: 4,
+}
+
+// copyOptions returns a copy of opts. Nil options return the defaults
+// with new caches, the cache keys do not tell apart GitHub servers or
+// credentials so clients must not share them.
+func copyOptions(opts *Options) Options {
+	if opts != nil {
+		return *opts
+	}
+	o := defaultOptions
+	o.RepositoryCache = NewRepositoryCache(5 * time.Minute)
+	o.MilestoneCache = NewMilestoneCache(5 * time.Minute)
+	o.MembershipCache = NewMembershipCache(10 * time.Minute)
+	return o
+}
 
 type githubImplementation interface {
+	apiUser() githubAPIUser
 	getPullRequestFromAPI(ctx context.Context, owner, repo string, number int) (*PullRequest, error)
+	loadPullRequestFull(ctx context.Context, owner, repo string, number int) (*PullRequest, error)
+	rateLimit(resource string) (remaining int, reset time.Time)
+	operationContext(ctx context.Context) (context.Context, context.CancelFunc)
+	isOrgMember(ctx context.Context, org, login string) (bool, error)
+	isActiveTeamMember(ctx context.Context, org, slug, login string) (bool, error)
+	listTeamMembers(ctx context.Context, org, slug string) ([]string, error)
+	searchIssues(ctx context.Context, query string, limit int) ([]*Issue, error)
+}
+
+// RateLimit returns the REST API calls left before hitting the GitHub
+// rate limit and the time when it resets. Remaining is -1 until known.
+func (gh *GitHub) RateLimit() (remaining int, reset time.Time) {
+	return gh.impl.rateLimit(RateResourceCore)
+}
+
+// RateLimitFor returns the calls left of a rate limited resource,
+// eg RateResourceGraphQL, and the time when its limit resets
+func (gh *GitHub) RateLimitFor(resource string) (remaining int, reset time.Time) {
+	return gh.impl.rateLimit(resource)
 }
 
 // GetPullRequest fetches a PR from github
 func (gh *GitHub) GetPullRequest(ctx context.Context, owner, repo string, number int) (*PullRequest, error) {
+	ctx, cancel := gh.impl.operationContext(ctx)
+	defer cancel()
+
 	return gh.impl.getPullRequestFromAPI(ctx, owner, repo, number)
 }
+
+// LoadPullRequestFull reads a pull request along with its commits, labels,
+// reviews and merge data in a single GraphQL query, instead of the several
+// REST calls needed otherwise. The data is preloaded so the merge mode and
+// labels of the PR are available without further API calls.
+func (gh *GitHub) LoadPullRequestFull(ctx context.Context, owner, repo string, number int) (*PullRequest, error) {
+	ctx, cancel := gh.impl.operationContext(ctx)
+	defer cancel()
+
+	return gh.impl.loadPullRequestFull(ctx, owner, repo, number)
+}
+
+// IsOrgMember checks if a user is a member of an organization. Results
+// are cached for the time set in the MembershipCache option.
+func (gh *GitHub) IsOrgMember(ctx context.Context, org, login string) (bool, error) {
+	ctx, cancel := gh.impl.operationContext(ctx)
+	defer cancel()
+
+	if org == "" || login == "" {
+		return false, errors.New("organization and user are required to check membership")
+	}
+	return gh.impl.isOrgMember(ctx, org, login)
+}
+
+// IsTeamMember checks if a user is an active member of a team,
+// identified by its slug in the organization
+func (gh *GitHub) IsTeamMember(ctx context.Context, org, team, login string) (bool, error) {
+	ctx, cancel := gh.impl.operationContext(ctx)
+	defer cancel()
+
+	if org == "" || team == "" || login == "" {
+		return false, errors.New("organization, team and user are required to check membership")
+	}
+	return gh.impl.isActiveTeamMember(ctx, org, team, login)
+}
+
+// ListTeamMembers returns the logins of the members of a team
+func (gh *GitHub) ListTeamMembers(ctx context.Context, org, team string) ([]string, error) {
+	ctx, cancel := gh.impl.operationContext(ctx)
+	defer cancel()
+
+	if org == "" || team == "" {
+		return nil, errors.New("organization and team are required to list members")
+	}
+	return gh.impl.listTeamMembers(ctx, org, team)
+}
+
+// NewRepository returns a repository object which will use the
+// options and the client of the GitHub object to talk to the API
+func (gh *GitHub) NewRepository(owner, name string) *Repository {
+	return &Repository{
+		Owner: owner,
+		Name:  name,
+		impl:  &defaultRepoImplementation{githubAPIUser: gh.impl.apiUser()},
+	}
+}
@@ -5,6 +5,7 @@ package github
 
 import (
 	"context"
+	"time"
 
 	gogithub "github.com/google/go-github/v39/github"
 	"github.com/pkg/errors"
@@ -12,34 +13,30 @@ import (
 
 type defaultGithubImplementation struct {
 	githubAPIUser
-	client *gogithub.Client
+}
+
+// apiUser returns a copy of the API user sharing its client, so the
+// objects created from it share the rate limits and the ETag cache
+func (di *defaultGithubImplementation) apiUser() githubAPIUser {
+	di.GitHubClient()
+	return di.githubAPIUser
 }
 
 func (di *defaultGithubImplementation) getPullRequestFromAPI(
 	ctx context.Context, owner, repo string, number int,
 ) (*PullRequest, error) {
-	ghpr, _, err := di.client.PullRequests.Get(ctx, owner, repo, number)
+	var ghpr *gogithub.PullRequest
+	err := di.doWithRetry(ctx, "pulls.Get", func() (resp *gogithub.Response, err error) {
+		ghpr, resp, err = di.GitHubClient().PullRequests.Get(ctx, owner, repo, number)
+		return resp, err
+	})
 	if err != nil {
 		return nil, errors.Wrap(err, "getting PR from GitHub API")
 	}
 
-	pr := &PullRequest{
-		RepoOwner:           ghpr.GetBase().GetRepo().GetOwner().GetLogin(),
-		RepoName:            ghpr.GetBase().GetRepo().GetName(),
-		Number:              ghpr.GetNumber(),
-		Username:            ghpr.GetUser().GetLogin(),
-		FullName:            ghpr.GetHead().GetRepo().GetFullName(),
-		Ref:                 ghpr.GetHead().GetRef(),
-		Sha:                 ghpr.GetHead().GetSHA(),
-		State:               ghpr.GetState(),
-		URL:                 ghpr.GetURL(),
-		CreatedAt:           ghpr.GetCreatedAt(),
-		Merged:              gogithub.Bool(ghpr.GetMerged()),
-		MergeCommitSHA:      ghpr.GetMergeCommitSHA(),
-		MaintainerCanModify: gogithub.Bool(ghpr.GetMaintainerCanModify()),
-		MilestoneNumber:     gogithub.Int64(int64(ghpr.GetMilestone().GetNumber())),
-		MilestoneTitle:      gogithub.String(ghpr.GetMilestone().GetTitle()),
-	}
+	return di.NewPullRequest(ghpr), nil
+}
 
-	return pr, nil
+func (di *defaultGithubImplementation) rateLimit(resource string) (remaining int, reset time.Time) {
+	return di.GitHubClient().RateLimitFor(resource)
 }
@@ -14,7 +14,7 @@ import (
 
 func getTestImplementation(t *testing.T) *defaultGithubImplementation {
 	return &defaultGithubImplementation{
-		client: gogithub.NewClient(http.DefaultClient),
+		githubAPIUser: githubAPIUser{client: NewClient(gogithub.NewClient(http.DefaultClient))},
 	}
 }
 
@@ -0,0 +1,131 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnterpriseAPIURL(t *testing.T) {
+	for _, tc := range []struct {
+		URL      string
+		Expected string
+	}{
+		{URL: "https://github.example.com", Expected: "https://github.example.com/api/v3/"},
+		{URL: "https://github.example.com/", Expected: "https://github.example.com/api/v3/"},
+		{URL: "https://github.example.com/api/v3", Expected: "https://github.example.com/api/v3/"},
+		{URL: "https://github.example.com/api/v3/", Expected: "https://github.example.com/api/v3/"},
+		{URL: "https://example.com/github", Expected: "https://example.com/github/api/v3/"},
+	} {
+		serverURL, err := url.Parse(tc.URL)
+		require.Nil(t, err)
+		require.Equal(t, tc.Expected, enterpriseAPIURL(serverURL, "/api/v3/").String())
+	}
+}
+
+func TestEnterpriseClient(t *testing.T) {
+	called := false
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/repos/mattermost/mattermost-server", func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.Write([]byte(`{"name":"mattermost-server","owner":{"login":"mattermost"}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	require.Nil(t, err)
+
+	// Relative API paths must resolve under the enterprise /api/v3 prefix
+	gh := NewWithOptions(&Options{EnterpriseURL: serverURL})
+	repo := gh.NewRepository("mattermost", "mattermost-server")
+	impl := repo.impl.(*defaultRepoImplementation)
+	ghrepo, _, err := impl.GitHubClient().Repositories.Get(context.Background(), "mattermost", "mattermost-server")
+	require.Nil(t, err)
+	require.True(t, called)
+	require.Equal(t, "mattermost-server", ghrepo.GetName())
+}
+
+func TestOperationTimeout(t *testing.T) {
+	// The server never answers until the client gives up
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/repos/mattermost/mattermost-server/commits/abc", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	require.Nil(t, err)
+
+	gh := NewWithOptions(&Options{EnterpriseURL: serverURL, OperationTimeout: 50 * time.Millisecond})
+	repo := gh.NewRepository("mattermost", "mattermost-server")
+	start := time.Now()
+	_, err = repo.GetCommit(context.Background(), "abc")
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+	require.Less(t, int64(time.Since(start)), int64(5*time.Second))
+
+	// The sooner deadline of the parent context is kept
+	gau := githubAPIUser{options: &Options{OperationTimeout: time.Hour}}
+	parent, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	parentDeadline, _ := parent.Deadline()
+	ctx, cancelOp := gau.operationContext(parent)
+	defer cancelOp()
+	deadline, ok := ctx.Deadline()
+	require.True(t, ok)
+	require.Equal(t, parentDeadline, deadline)
+
+	// And a sooner operation timeout replaces it
+	gau.options.OperationTimeout = time.Second
+	ctx, cancelOp = gau.operationContext(parent)
+	defer cancelOp()
+	deadline, _ = ctx.Deadline()
+	require.True(t, deadline.Before(parentDeadline))
+}
+
+func TestNewWithOptions(t *testing.T) {
+	// Nil options take the defaults
+	gh := NewWithOptions(nil)
+	require.Equal(t, defaultOptions.Retry, gh.options.Retry)
+	require.Equal(t, defaultOptions.Concurrency, gh.options.Concurrency)
+
+	// Missing settings are filled in a copy of the options
+	opts := &Options{DryRun: true}
+	gh = NewWithOptions(opts)
+	require.True(t, gh.options.DryRun)
+	require.Equal(t, defaultOptions.Retry, gh.options.Retry)
+	require.Zero(t, opts.Retry.MaxAttempts)
+	require.Zero(t, opts.Concurrency)
+
+	// The package defaults are not shared
+	gh = New()
+	gh.options.DryRun = true
+	require.False(t, defaultOptions.DryRun)
+
+	// Each client gets its own caches, nil ones stay disabled
+	other := New()
+	require.NotNil(t, gh.options.RepositoryCache)
+	require.NotSame(t, gh.options.RepositoryCache, other.options.RepositoryCache)
+	require.NotSame(t, gh.options.MilestoneCache, other.options.MilestoneCache)
+	require.NotSame(t, gh.options.MembershipCache, other.options.MembershipCache)
+	require.Nil(t, NewWithOptions(&Options{}).options.RepositoryCache)
+}
+
+func TestNewRepositorySharesClient(t *testing.T) {
+	gh := NewWithOptions(&Options{})
+	gau := gh.impl.apiUser()
+	client := gau.GitHubClient()
+	for _, name := range []string{"mattermost-server", "mattermost-webapp"} {
+		impl := gh.NewRepository("mattermost", name).impl.(*defaultRepoImplementation)
+		require.Same(t, client, impl.GitHubClient())
+	}
+}
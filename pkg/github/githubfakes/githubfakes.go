@@ -0,0 +1,1496 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+// Package githubfakes provides in-memory implementations of the GitHub API
+// services used by the github package. The fakes are preloaded with data
+// and record the calls that modify it, so code built on the github package
+// can be tested without reaching the GitHub API.
+package githubfakes
+
+import (
+	"context"
+	"crypto/sha1" // nolint: gosec
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	gogithub "github.com/google/go-github/v39/github"
+)
+
+// NotFound returns the error go-github produces when the API returns a 404
+func NotFound(format string, args ...interface{}) error {
+	return ErrorResponse(http.StatusNotFound, format, args...)
+}
+
+// ErrorResponse returns the error go-github produces when the
+// API answers with the status code and message
+func ErrorResponse(status int, format string, args ...interface{}) error {
+	return &gogithub.ErrorResponse{
+		Response: &http.Response{StatusCode: status},
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
+
+// response returns an empty go-github response with a 200 status
+func response() *gogithub.Response {
+	return &gogithub.Response{Response: &http.Response{StatusCode: http.StatusOK}}
+}
+
+// paginate returns the bounds of the page requested in opts from a list
+// of total items and a response with the page links set. A zero page
+// size returns the whole list in a single page.
+func paginate(total, perPage int, opts *gogithub.ListOptions) (start, end int, resp *gogithub.Response) {
+	resp = response()
+	if perPage == 0 {
+		return 0, total, resp
+	}
+	page := 1
+	if opts != nil && opts.Page > 0 {
+		page = opts.Page
+	}
+	start = (page - 1) * perPage
+	if start > total {
+		start = total
+	}
+	end = start + perPage
+	if end > total {
+		end = total
+	}
+	if lastPage := (total + perPage - 1) / perPage; page < lastPage {
+		resp.NextPage = page + 1
+		resp.LastPage = lastPage
+	}
+	return start, end, resp
+}
+
+// FakePullRequestsService serves pull requests and their commits
+type FakePullRequestsService struct {
+	mtx sync.Mutex
+
+	PullRequests   map[int]*gogithub.PullRequest                                  // Pull requests by number
+	Commits        map[int][]*gogithub.RepositoryCommit                           // Commits of each pull request
+	CommitsPages   []int                                                          // Pages requested from ListCommits, in order
+	CommitsPerPage int                                                            // Page size of ListCommits, the requested one when zero
+	Files          map[int][]*gogithub.CommitFile                                 // Files changed by each pull request
+	FilesPerPage   int                                                            // Page size of ListFiles, all files when zero
+	Reviews        map[int][]*gogithub.PullRequestReview                          // Reviews of each pull request
+	ReviewsPerPage int                                                            // Page size of ListReviews and ListComments, all when zero
+	ReviewComments map[int][]*gogithub.PullRequestComment                         // Inline review comments of each pull request
+	ReviewsCreated []*gogithub.PullRequestReviewRequest                           // Reviews submitted, in order
+	Requested      []gogithub.ReviewersRequest                                    // Review requests received, in order
+	Created        []*gogithub.NewPullRequest                                     // Pull requests created
+	Edits          int                                                            // Number of times a pull request was edited
+	Merges         []MergeCall                                                    // Merge requests received, in order
+	ListStub       func(*gogithub.PullRequestListOptions) []*gogithub.PullRequest // Result of List calls
+	Raw            map[int]map[gogithub.RawType]string                            // Diff and patch of each pull request
+
+	lastID int64
+}
+
+func (f *FakePullRequestsService) Get(
+	ctx context.Context, owner, repo string, number int,
+) (*gogithub.PullRequest, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	pr, ok := f.PullRequests[number]
+	if !ok {
+		return nil, nil, NotFound("pull request %s/%s#%d not found", owner, repo, number)
+	}
+	return pr, response(), nil
+}
+
+func (f *FakePullRequestsService) List(
+	ctx context.Context, owner, repo string, opts *gogithub.PullRequestListOptions,
+) ([]*gogithub.PullRequest, *gogithub.Response, error) {
+	if f.ListStub == nil {
+		return []*gogithub.PullRequest{}, response(), nil
+	}
+	return f.ListStub(opts), response(), nil
+}
+
+func (f *FakePullRequestsService) Create(
+	ctx context.Context, owner, repo string, pull *gogithub.NewPullRequest,
+) (*gogithub.PullRequest, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if f.PullRequests == nil {
+		f.PullRequests = map[int]*gogithub.PullRequest{}
+	}
+	f.Created = append(f.Created, pull)
+	number := 1
+	for n := range f.PullRequests {
+		if n >= number {
+			number = n + 1
+		}
+	}
+	pr := &gogithub.PullRequest{
+		Number: gogithub.Int(number),
+		Title:  pull.Title,
+		Body:   pull.Body,
+		State:  gogithub.String("open"),
+		Head:   &gogithub.PullRequestBranch{Ref: pull.Head},
+		Base: &gogithub.PullRequestBranch{
+			Ref: pull.Base,
+			Repo: &gogithub.Repository{
+				Name:  gogithub.String(repo),
+				Owner: &gogithub.User{Login: gogithub.String(owner)},
+			},
+		},
+	}
+	f.PullRequests[number] = pr
+	return pr, response(), nil
+}
+
+// Edit changes the state of a stored pull request
+func (f *FakePullRequestsService) Edit(
+	ctx context.Context, owner, repo string, number int, pull *gogithub.PullRequest,
+) (*gogithub.PullRequest, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	pr, ok := f.PullRequests[number]
+	if !ok {
+		return nil, nil, NotFound("pull request %s/%s#%d not found", owner, repo, number)
+	}
+	f.Edits++
+	if pull.State != nil {
+		pr.State = pull.State
+	}
+	return pr, response(), nil
+}
+
+// MergeCall is a request to merge a pull request
+type MergeCall struct {
+	Number        int
+	CommitMessage string
+	Options       *gogithub.PullRequestOptions
+}
+
+// Merge merges the pull request like GitHub does: pull requests marked as
+// not mergeable are refused with a 405 and a SHA that is not the head of
+// the pull request with a 409. The merge commit is named merge-<number>.
+func (f *FakePullRequestsService) Merge(
+	ctx context.Context, owner, repo string, number int, commitMessage string, options *gogithub.PullRequestOptions,
+) (*gogithub.PullRequestMergeResult, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	pr, ok := f.PullRequests[number]
+	if !ok {
+		return nil, nil, NotFound("pull request %s/%s#%d not found", owner, repo, number)
+	}
+	f.Merges = append(f.Merges, MergeCall{Number: number, CommitMessage: commitMessage, Options: options})
+	if pr.Mergeable != nil && !*pr.Mergeable {
+		return nil, nil, ErrorResponse(http.StatusMethodNotAllowed, "Pull Request is not mergeable")
+	}
+	if options != nil && options.SHA != "" && options.SHA != pr.GetHead().GetSHA() {
+		return nil, nil, ErrorResponse(http.StatusConflict, "Head branch was modified. Review and try the merge again.")
+	}
+	sha := fmt.Sprintf("merge-%d", number)
+	pr.Merged = gogithub.Bool(true)
+	pr.State = gogithub.String("closed")
+	pr.MergeCommitSHA = gogithub.String(sha)
+	return &gogithub.PullRequestMergeResult{
+		SHA:     gogithub.String(sha),
+		Merged:  gogithub.Bool(true),
+		Message: gogithub.String("Pull Request successfully merged"),
+	}, response(), nil
+}
+
+func (f *FakePullRequestsService) ListCommits(
+	ctx context.Context, owner, repo string, number int, opts *gogithub.ListOptions,
+) ([]*gogithub.RepositoryCommit, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	commits, ok := f.Commits[number]
+	if !ok {
+		return nil, nil, NotFound("pull request %s/%s#%d not found", owner, repo, number)
+	}
+	page := 1
+	if opts != nil && opts.Page > 0 {
+		page = opts.Page
+	}
+	f.CommitsPages = append(f.CommitsPages, page)
+	perPage := f.CommitsPerPage
+	if perPage == 0 && opts != nil {
+		perPage = opts.PerPage
+	}
+	start, end, resp := paginate(len(commits), perPage, opts)
+	return commits[start:end], resp, nil
+}
+
+// ListFiles returns the files of the pull request. When FilesPerPage
+// is set, they are paginated like the GitHub API does.
+func (f *FakePullRequestsService) ListFiles(
+	ctx context.Context, owner, repo string, number int, opts *gogithub.ListOptions,
+) ([]*gogithub.CommitFile, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	files, ok := f.Files[number]
+	if !ok {
+		return nil, nil, NotFound("pull request %s/%s#%d not found", owner, repo, number)
+	}
+	start, end, resp := paginate(len(files), f.FilesPerPage, opts)
+	return files[start:end], resp, nil
+}
+
+// GetRaw returns the diff or patch of the pull request stored in Raw
+func (f *FakePullRequestsService) GetRaw(
+	ctx context.Context, owner, repo string, number int, opts gogithub.RawOptions,
+) (string, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	raw, ok := f.Raw[number][opts.Type]
+	if !ok {
+		return "", nil, NotFound("pull request %s/%s#%d not found", owner, repo, number)
+	}
+	return raw, response(), nil
+}
+
+// ListReviews returns the reviews of the pull request. A pull request
+// without reviews returns an empty list.
+func (f *FakePullRequestsService) ListReviews(
+	ctx context.Context, owner, repo string, number int, opts *gogithub.ListOptions,
+) ([]*gogithub.PullRequestReview, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if _, ok := f.PullRequests[number]; !ok {
+		return nil, nil, NotFound("pull request %s/%s#%d not found", owner, repo, number)
+	}
+	reviews := f.Reviews[number]
+	start, end, resp := paginate(len(reviews), f.ReviewsPerPage, opts)
+	return reviews[start:end], resp, nil
+}
+
+// ListComments returns the inline review comments of the pull request
+func (f *FakePullRequestsService) ListComments(
+	ctx context.Context, owner, repo string, number int, opts *gogithub.PullRequestListCommentsOptions,
+) ([]*gogithub.PullRequestComment, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if _, ok := f.PullRequests[number]; !ok {
+		return nil, nil, NotFound("pull request %s/%s#%d not found", owner, repo, number)
+	}
+	listOpts := &gogithub.ListOptions{}
+	if opts != nil {
+		listOpts = &opts.ListOptions
+	}
+	comments := f.ReviewComments[number]
+	start, end, resp := paginate(len(comments), f.ReviewsPerPage, listOpts)
+	return comments[start:end], resp, nil
+}
+
+// CreateComment adds a single inline review comment to the pull request
+func (f *FakePullRequestsService) CreateComment(
+	ctx context.Context, owner, repo string, number int, comment *gogithub.PullRequestComment,
+) (*gogithub.PullRequestComment, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if _, ok := f.PullRequests[number]; !ok {
+		return nil, nil, NotFound("pull request %s/%s#%d not found", owner, repo, number)
+	}
+	created := *comment
+	created.ID = gogithub.Int64(f.nextID())
+	f.addReviewComment(number, &created)
+	return &created, response(), nil
+}
+
+// CreateReview submits a review. It is added to Reviews with the
+// state matching its event and its comments to ReviewComments.
+func (f *FakePullRequestsService) CreateReview(
+	ctx context.Context, owner, repo string, number int, review *gogithub.PullRequestReviewRequest,
+) (*gogithub.PullRequestReview, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if _, ok := f.PullRequests[number]; !ok {
+		return nil, nil, NotFound("pull request %s/%s#%d not found", owner, repo, number)
+	}
+	f.ReviewsCreated = append(f.ReviewsCreated, review)
+	states := map[string]string{
+		"APPROVE":         "APPROVED",
+		"REQUEST_CHANGES": "CHANGES_REQUESTED",
+		"COMMENT":         "COMMENTED",
+	}
+	created := &gogithub.PullRequestReview{
+		ID:       gogithub.Int64(f.nextID()),
+		Body:     review.Body,
+		CommitID: review.CommitID,
+		State:    gogithub.String(states[review.GetEvent()]),
+	}
+	if f.Reviews == nil {
+		f.Reviews = map[int][]*gogithub.PullRequestReview{}
+	}
+	f.Reviews[number] = append(f.Reviews[number], created)
+	for _, c := range review.Comments {
+		f.addReviewComment(number, &gogithub.PullRequestComment{
+			ID:                  gogithub.Int64(f.nextID()),
+			PullRequestReviewID: created.ID,
+			CommitID:            review.CommitID,
+			Path:                c.Path,
+			Body:                c.Body,
+			Line:                c.Line,
+			StartLine:           c.StartLine,
+			Side:                c.Side,
+			StartSide:           c.StartSide,
+		})
+	}
+	return created, response(), nil
+}
+
+// RequestReviewers records the review request
+func (f *FakePullRequestsService) RequestReviewers(
+	ctx context.Context, owner, repo string, number int, reviewers gogithub.ReviewersRequest,
+) (*gogithub.PullRequest, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	pr, ok := f.PullRequests[number]
+	if !ok {
+		return nil, nil, NotFound("pull request %s/%s#%d not found", owner, repo, number)
+	}
+	f.Requested = append(f.Requested, reviewers)
+	return pr, response(), nil
+}
+
+func (f *FakePullRequestsService) addReviewComment(number int, comment *gogithub.PullRequestComment) {
+	if f.ReviewComments == nil {
+		f.ReviewComments = map[int][]*gogithub.PullRequestComment{}
+	}
+	f.ReviewComments[number] = append(f.ReviewComments[number], comment)
+}
+
+// nextID returns an ID for a new review or comment
+func (f *FakePullRequestsService) nextID() int64 {
+	for _, comments := range f.ReviewComments {
+		for _, c := range comments {
+			if c.GetID() > f.lastID {
+				f.lastID = c.GetID()
+			}
+		}
+	}
+	for _, reviews := range f.Reviews {
+		for _, r := range reviews {
+			if r.GetID() > f.lastID {
+				f.lastID = r.GetID()
+			}
+		}
+	}
+	f.lastID++
+	return f.lastID
+}
+
+// FakeRepositoriesService serves repositories and commits
+type FakeRepositoriesService struct {
+	mtx sync.Mutex
+
+	Repositories      map[string]*gogithub.Repository          // Repositories by "owner/name"
+	Commits           map[string]*gogithub.RepositoryCommit    // Commits by SHA
+	Statuses          map[string]*gogithub.CombinedStatus      // Combined statuses by ref
+	Comparisons       map[string]string                        // Status of the comparisons by "base...head"
+	CommitComparisons map[string]*gogithub.CommitsComparison   // Full comparisons by "base...head", checked first
+	GetCalls          int                                      // Number of times Get was called
+	CommitCalls       map[string]int                           // Number of times each commit was fetched
+	History           map[string][]*gogithub.RepositoryCommit  // Commits touching each path, newest first
+	Releases          []*gogithub.RepositoryRelease            // Releases, newest first
+	Files             map[string]map[string][]byte             // Contents of the files by ref and path
+	FileCommits       []*gogithub.RepositoryContentFileOptions // Files committed, in order
+	Assets            map[int64]map[string][]byte              // Contents of the assets by release ID and name
+	Permissions       map[string]string                        // Role of the collaborators by login, eg maintain
+	RawCommits        map[string]map[gogithub.RawType]string   // Diff and patch of the commits by SHA
+	RawComparisons    map[string]map[gogithub.RawType]string   // Diff and patch of the comparisons by "base...head"
+}
+
+// permissionFlags are the flags GitHub sets on users for each role
+var permissionFlags = map[string][]string{
+	"admin":    {"admin", "maintain", "push", "triage", "pull"},
+	"maintain": {"maintain", "push", "triage", "pull"},
+	"write":    {"push", "triage", "pull"},
+	"triage":   {"triage", "pull"},
+	"read":     {"pull"},
+}
+
+// GetPermissionLevel returns the role of the user in Permissions. Like
+// GitHub, the permission field only has admin, write, read or none.
+func (f *FakeRepositoriesService) GetPermissionLevel(
+	ctx context.Context, owner, repo, user string,
+) (*gogithub.RepositoryPermissionLevel, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	role, ok := f.Permissions[user]
+	if !ok {
+		return nil, nil, NotFound("user %s not found", user)
+	}
+	permission := map[string]string{"maintain": "write", "triage": "read"}[role]
+	if permission == "" {
+		permission = role
+	}
+	flags := map[string]bool{}
+	for _, flag := range permissionFlags[role] {
+		flags[flag] = true
+	}
+	return &gogithub.RepositoryPermissionLevel{
+		Permission: gogithub.String(permission),
+		User:       &gogithub.User{Login: gogithub.String(user), Permissions: flags},
+	}, response(), nil
+}
+
+// GetContents returns a file from Files. Directories are not supported.
+func (f *FakeRepositoriesService) GetContents(
+	ctx context.Context, owner, repo, path string, opts *gogithub.RepositoryContentGetOptions,
+) (*gogithub.RepositoryContent, []*gogithub.RepositoryContent, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	ref := ""
+	if opts != nil {
+		ref = opts.Ref
+	}
+	content, ok := f.Files[ref][path]
+	if !ok {
+		return nil, nil, nil, NotFound("%s not found at %q", path, ref)
+	}
+	return &gogithub.RepositoryContent{
+		Type:     gogithub.String("file"),
+		Path:     gogithub.String(path),
+		SHA:      gogithub.String(blobSHA(content)),
+		Encoding: gogithub.String("base64"),
+		Content:  gogithub.String(base64.StdEncoding.EncodeToString(content)),
+	}, nil, response(), nil
+}
+
+// CreateFile commits a new file to Files. Like GitHub, creating
+// a file that exists returns a 422 error.
+func (f *FakeRepositoriesService) CreateFile(
+	ctx context.Context, owner, repo, path string, opts *gogithub.RepositoryContentFileOptions,
+) (*gogithub.RepositoryContentResponse, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if _, ok := f.Files[opts.GetBranch()][path]; ok {
+		return nil, nil, ErrorResponse(http.StatusUnprocessableEntity, "%s already exists", path)
+	}
+	return f.commitFile(path, opts), response(), nil
+}
+
+// UpdateFile commits a file to Files. Like GitHub, the SHA of the
+// options must be the blob of the file replaced or a 409 is returned.
+func (f *FakeRepositoriesService) UpdateFile(
+	ctx context.Context, owner, repo, path string, opts *gogithub.RepositoryContentFileOptions,
+) (*gogithub.RepositoryContentResponse, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	content, ok := f.Files[opts.GetBranch()][path]
+	if !ok || blobSHA(content) != opts.GetSHA() {
+		return nil, nil, ErrorResponse(http.StatusConflict, "%s does not match %s", path, opts.GetSHA())
+	}
+	return f.commitFile(path, opts), response(), nil
+}
+
+func (f *FakeRepositoriesService) commitFile(
+	path string, opts *gogithub.RepositoryContentFileOptions,
+) *gogithub.RepositoryContentResponse {
+	if f.Files == nil {
+		f.Files = map[string]map[string][]byte{}
+	}
+	if f.Files[opts.GetBranch()] == nil {
+		f.Files[opts.GetBranch()] = map[string][]byte{}
+	}
+	f.Files[opts.GetBranch()][path] = opts.Content
+	f.FileCommits = append(f.FileCommits, opts)
+	return &gogithub.RepositoryContentResponse{
+		Content: &gogithub.RepositoryContent{Path: gogithub.String(path), SHA: gogithub.String(blobSHA(opts.Content))},
+		Commit:  gogithub.Commit{SHA: gogithub.String(fmt.Sprintf("file-commit-%d", len(f.FileCommits)))},
+	}
+}
+
+// blobSHA returns the git blob SHA of the content
+func blobSHA(content []byte) string {
+	return fmt.Sprintf("%x", sha1.Sum(append([]byte(fmt.Sprintf("blob %d\x00", len(content))), content...)))
+}
+
+// ListReleases returns the releases of the repository
+func (f *FakeRepositoriesService) ListReleases(
+	ctx context.Context, owner, repo string, opts *gogithub.ListOptions,
+) ([]*gogithub.RepositoryRelease, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.Releases, response(), nil
+}
+
+// CreateRelease adds the release first in Releases. Like GitHub, a
+// second release of the same tag returns a 422 error.
+func (f *FakeRepositoriesService) CreateRelease(
+	ctx context.Context, owner, repo string, release *gogithub.RepositoryRelease,
+) (*gogithub.RepositoryRelease, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	for _, r := range f.Releases {
+		if r.GetTagName() == release.GetTagName() {
+			return nil, nil, ErrorResponse(http.StatusUnprocessableEntity, "release %s already exists", r.GetTagName())
+		}
+	}
+	created := *release
+	created.ID = gogithub.Int64(int64(len(f.Releases) + 1))
+	created.HTMLURL = gogithub.String(
+		fmt.Sprintf("https://github.com/%s/%s/releases/tag/%s", owner, repo, release.GetTagName()),
+	)
+	f.Releases = append([]*gogithub.RepositoryRelease{&created}, f.Releases...)
+	return &created, response(), nil
+}
+
+// UploadReleaseAsset stores the contents of the file in Assets
+func (f *FakeRepositoriesService) UploadReleaseAsset(
+	ctx context.Context, owner, repo string, id int64, opts *gogithub.UploadOptions, file *os.File,
+) (*gogithub.ReleaseAsset, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	if f.Assets == nil {
+		f.Assets = map[int64]map[string][]byte{}
+	}
+	if f.Assets[id] == nil {
+		f.Assets[id] = map[string][]byte{}
+	}
+	f.Assets[id][opts.Name] = data
+	return &gogithub.ReleaseAsset{
+		ID:   gogithub.Int64(int64(len(f.Assets[id]))),
+		Name: gogithub.String(opts.Name),
+		Size: gogithub.Int(len(data)),
+		BrowserDownloadURL: gogithub.String(
+			fmt.Sprintf("https://github.com/%s/%s/releases/download/%d/%s", owner, repo, id, opts.Name),
+		),
+	}, response(), nil
+}
+
+// ListCommits returns the commits in History touching the path of the
+// options, filtered by author and committer date. The branch is ignored,
+// all paths share a single history.
+func (f *FakeRepositoriesService) ListCommits(
+	ctx context.Context, owner, repo string, opts *gogithub.CommitsListOptions,
+) ([]*gogithub.RepositoryCommit, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if opts == nil {
+		opts = &gogithub.CommitsListOptions{}
+	}
+	commits := []*gogithub.RepositoryCommit{}
+	for _, c := range f.History[opts.Path] {
+		date := c.GetCommit().GetCommitter().GetDate()
+		switch {
+		case opts.Author != "" && opts.Author != c.GetAuthor().GetLogin() &&
+			opts.Author != c.GetCommit().GetAuthor().GetEmail():
+		case !opts.Since.IsZero() && date.Before(opts.Since):
+		case !opts.Until.IsZero() && date.After(opts.Until):
+		default:
+			commits = append(commits, c)
+		}
+	}
+	listOpts := &opts.ListOptions
+	start, end, resp := paginate(len(commits), listOpts.PerPage, listOpts)
+	return commits[start:end], resp, nil
+}
+
+func (f *FakeRepositoriesService) Get(
+	ctx context.Context, owner, repo string,
+) (*gogithub.Repository, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.GetCalls++
+	r, ok := f.Repositories[owner+"/"+repo]
+	if !ok {
+		return nil, nil, NotFound("repository %s/%s not found", owner, repo)
+	}
+	return r, response(), nil
+}
+
+func (f *FakeRepositoriesService) GetCommit(
+	ctx context.Context, owner, repo, sha string, opts *gogithub.ListOptions,
+) (*gogithub.RepositoryCommit, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if f.CommitCalls == nil {
+		f.CommitCalls = map[string]int{}
+	}
+	f.CommitCalls[sha]++
+	commit, ok := f.Commits[sha]
+	if !ok {
+		return nil, nil, NotFound("commit %s not found in %s/%s", sha, owner, repo)
+	}
+	return commit, response(), nil
+}
+
+// GetCombinedStatus returns the status stored for the ref. Like GitHub,
+// refs without statuses are reported as pending with no statuses.
+// CreateStatus adds a status to the combined status of the ref,
+// replacing the one with the same context, and updates its state
+func (f *FakeRepositoriesService) CreateStatus(
+	ctx context.Context, owner, repo, ref string, status *gogithub.RepoStatus,
+) (*gogithub.RepoStatus, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if f.Statuses == nil {
+		f.Statuses = map[string]*gogithub.CombinedStatus{}
+	}
+	combined, ok := f.Statuses[ref]
+	if !ok {
+		combined = &gogithub.CombinedStatus{SHA: gogithub.String(ref)}
+		f.Statuses[ref] = combined
+	}
+	statuses := []*gogithub.RepoStatus{}
+	for _, s := range combined.Statuses {
+		if s.GetContext() != status.GetContext() {
+			statuses = append(statuses, s)
+		}
+	}
+	combined.Statuses = append(statuses, status)
+	combined.TotalCount = gogithub.Int(len(combined.Statuses))
+	state := "success"
+	for _, s := range combined.Statuses {
+		switch s.GetState() {
+		case "failure", "error":
+			state = "failure"
+		case "pending":
+			if state == "success" {
+				state = "pending"
+			}
+		}
+	}
+	combined.State = gogithub.String(state)
+	return status, response(), nil
+}
+
+func (f *FakeRepositoriesService) GetCombinedStatus(
+	ctx context.Context, owner, repo, ref string, opts *gogithub.ListOptions,
+) (*gogithub.CombinedStatus, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	status, ok := f.Statuses[ref]
+	if !ok {
+		return &gogithub.CombinedStatus{
+			State:      gogithub.String("pending"),
+			SHA:        gogithub.String(ref),
+			TotalCount: gogithub.Int(0),
+		}, response(), nil
+	}
+	return status, response(), nil
+}
+
+// CompareCommits returns the status stored for the comparison. Unknown
+// comparisons return a 404, like GitHub does for missing commits.
+func (f *FakeRepositoriesService) CompareCommits(
+	ctx context.Context, owner, repo string, base, head string, opts *gogithub.ListOptions,
+) (*gogithub.CommitsComparison, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if comparison, ok := f.CommitComparisons[base+"..."+head]; ok {
+		return comparison, response(), nil
+	}
+	status, ok := f.Comparisons[base+"..."+head]
+	if !ok {
+		return nil, nil, NotFound("cannot compare %s with %s in %s/%s", base, head, owner, repo)
+	}
+	return &gogithub.CommitsComparison{Status: gogithub.String(status)}, response(), nil
+}
+
+// CompareCommitsRaw returns the diff or patch stored for the comparison
+func (f *FakeRepositoriesService) CompareCommitsRaw(
+	ctx context.Context, owner, repo, base, head string, opts gogithub.RawOptions,
+) (string, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	raw, ok := f.RawComparisons[base+"..."+head][opts.Type]
+	if !ok {
+		return "", nil, NotFound("cannot compare %s with %s in %s/%s", base, head, owner, repo)
+	}
+	return raw, response(), nil
+}
+
+// GetCommitRaw returns the diff or patch stored for the commit
+func (f *FakeRepositoriesService) GetCommitRaw(
+	ctx context.Context, owner, repo, sha string, opts gogithub.RawOptions,
+) (string, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	raw, ok := f.RawCommits[sha][opts.Type]
+	if !ok {
+		return "", nil, NotFound("commit %s not found in %s/%s", sha, owner, repo)
+	}
+	return raw, response(), nil
+}
+
+// FakeGitService serves git data: references, trees and commits
+type FakeGitService struct {
+	mtx sync.Mutex
+
+	Refs           map[string]*gogithub.Reference // References by name ("heads/main")
+	Trees          map[string]*gogithub.Tree      // Trees by SHA
+	CreatedTrees   []*gogithub.Tree               // Trees created, in order
+	CreatedCommits []*gogithub.Commit             // Commits created, in order
+	Tags           map[string]*gogithub.Tag       // Annotated tag objects by SHA
+	Blobs          map[string][]byte              // Contents of the blobs created, by SHA
+}
+
+// CreateTag stores an annotated tag object with the SHA "tag-<name>"
+func (f *FakeGitService) CreateTag(
+	ctx context.Context, owner, repo string, tag *gogithub.Tag,
+) (*gogithub.Tag, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if f.Tags == nil {
+		f.Tags = map[string]*gogithub.Tag{}
+	}
+	created := *tag
+	created.SHA = gogithub.String("tag-" + tag.GetTag())
+	f.Tags[created.GetSHA()] = &created
+	return &created, response(), nil
+}
+
+func (f *FakeGitService) GetRef(
+	ctx context.Context, owner, repo, ref string,
+) (*gogithub.Reference, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	r, ok := f.Refs[ref]
+	if !ok {
+		return nil, nil, NotFound("reference %s not found", ref)
+	}
+	return r, response(), nil
+}
+
+func (f *FakeGitService) CreateRef(
+	ctx context.Context, owner, repo string, ref *gogithub.Reference,
+) (*gogithub.Reference, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if f.Refs == nil {
+		f.Refs = map[string]*gogithub.Reference{}
+	}
+	name := strings.TrimPrefix(ref.GetRef(), "refs/")
+	if _, ok := f.Refs[name]; ok {
+		return nil, nil, &gogithub.ErrorResponse{
+			Response: &http.Response{StatusCode: http.StatusUnprocessableEntity},
+			Message:  "Reference already exists",
+		}
+	}
+	f.Refs[name] = ref
+	return ref, response(), nil
+}
+
+// DeleteRef removes a reference. Like GitHub, a missing
+// reference returns a 422 error.
+func (f *FakeGitService) DeleteRef(
+	ctx context.Context, owner, repo, ref string,
+) (*gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	name := strings.TrimPrefix(ref, "refs/")
+	if _, ok := f.Refs[name]; !ok {
+		return nil, &gogithub.ErrorResponse{
+			Response: &http.Response{StatusCode: http.StatusUnprocessableEntity},
+			Message:  "Reference does not exist",
+		}
+	}
+	delete(f.Refs, name)
+	return response(), nil
+}
+
+func (f *FakeGitService) UpdateRef(
+	ctx context.Context, owner, repo string, ref *gogithub.Reference, force bool,
+) (*gogithub.Reference, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	name := strings.TrimPrefix(ref.GetRef(), "refs/")
+	if _, ok := f.Refs[name]; !ok {
+		return nil, nil, NotFound("reference %s not found", name)
+	}
+	f.Refs[name] = ref
+	return ref, response(), nil
+}
+
+func (f *FakeGitService) GetTree(
+	ctx context.Context, owner, repo, sha string, recursive bool,
+) (*gogithub.Tree, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	tree, ok := f.Trees[sha]
+	if !ok {
+		return nil, nil, NotFound("tree %s not found", sha)
+	}
+	return tree, response(), nil
+}
+
+// CreateBlob stores the blob in Blobs by its git SHA
+func (f *FakeGitService) CreateBlob(
+	ctx context.Context, owner, repo string, blob *gogithub.Blob,
+) (*gogithub.Blob, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	content := []byte(blob.GetContent())
+	if blob.GetEncoding() == "base64" {
+		decoded, err := base64.StdEncoding.DecodeString(blob.GetContent())
+		if err != nil {
+			return nil, nil, ErrorResponse(http.StatusUnprocessableEntity, "invalid blob content: %v", err)
+		}
+		content = decoded
+	}
+	if f.Blobs == nil {
+		f.Blobs = map[string][]byte{}
+	}
+	sha := blobSHA(content)
+	f.Blobs[sha] = content
+	return &gogithub.Blob{SHA: gogithub.String(sha), Size: gogithub.Int(len(content))}, response(), nil
+}
+
+// CreateTree records the new tree. The tree is stored with all the
+// entries of the base tree modified by the new entries.
+func (f *FakeGitService) CreateTree(
+	ctx context.Context, owner, repo, baseTree string, entries []*gogithub.TreeEntry,
+) (*gogithub.Tree, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if f.Trees == nil {
+		f.Trees = map[string]*gogithub.Tree{}
+	}
+
+	files := map[string]*gogithub.TreeEntry{}
+	order := []string{}
+	if base, ok := f.Trees[baseTree]; ok {
+		for _, e := range base.Entries {
+			files[e.GetPath()] = e
+			order = append(order, e.GetPath())
+		}
+	}
+	for _, e := range entries {
+		if _, ok := files[e.GetPath()]; !ok {
+			order = append(order, e.GetPath())
+		}
+		// Inline contents are stored as blobs
+		if e.Content != nil {
+			e = &gogithub.TreeEntry{
+				Path: e.Path, Mode: e.Mode, Type: e.Type, SHA: gogithub.String(blobSHA([]byte(e.GetContent()))),
+			}
+		}
+		files[e.GetPath()] = e
+	}
+
+	tree := &gogithub.Tree{
+		SHA:     gogithub.String(fmt.Sprintf("created-tree-%d", len(f.CreatedTrees)+1)),
+		Entries: []*gogithub.TreeEntry{},
+	}
+	for _, path := range order {
+		if files[path].SHA == nil {
+			continue
+		}
+		tree.Entries = append(tree.Entries, files[path])
+	}
+	f.Trees[tree.GetSHA()] = tree
+	f.CreatedTrees = append(f.CreatedTrees, tree)
+	return tree, response(), nil
+}
+
+func (f *FakeGitService) CreateCommit(
+	ctx context.Context, owner, repo string, commit *gogithub.Commit,
+) (*gogithub.Commit, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	created := *commit
+	created.SHA = gogithub.String(fmt.Sprintf("created-commit-%d", len(f.CreatedCommits)+1))
+	f.CreatedCommits = append(f.CreatedCommits, &created)
+	return &created, response(), nil
+}
+
+// FakeIssuesService records the edits made to issues and pull
+// requests and keeps their comments and labels
+type FakeIssuesService struct {
+	mtx sync.Mutex
+
+	Edits           map[int][]*gogithub.IssueRequest // Edits by issue number
+	Comments        map[int][]*gogithub.IssueComment // Comments by issue number
+	CommentEdits    int                              // Number of times a comment was edited
+	Labels          map[int][]string                 // Labels by issue number
+	LabelCalls      int                              // Number of times labels were listed
+	Milestones      []*gogithub.Milestone            // Milestones of the repository
+	MilestoneCalls  int                              // Number of times milestones were listed
+	IssueMilestones map[int]*gogithub.Milestone      // Milestone of each issue
+	Issues          map[string]*gogithub.Issue       // Issues by "owner/repo#number", nil if not found. Others are synthesized.
+	Login           string                           // Author of the comments created
+	lastID          int64
+}
+
+// Get returns the issue with the milestone assigned to it
+func (f *FakeIssuesService) Get(
+	ctx context.Context, owner, repo string, number int,
+) (*gogithub.Issue, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if issue, ok := f.Issues[fmt.Sprintf("%s/%s#%d", owner, repo, number)]; ok {
+		if issue == nil {
+			return nil, nil, NotFound("issue %s/%s#%d not found", owner, repo, number)
+		}
+		return issue, response(), nil
+	}
+	return &gogithub.Issue{Number: gogithub.Int(number), Milestone: f.IssueMilestones[number]}, response(), nil
+}
+
+// Create stores a new issue in Issues with the next free number
+func (f *FakeIssuesService) Create(
+	ctx context.Context, owner, repo string, issue *gogithub.IssueRequest,
+) (*gogithub.Issue, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if f.Issues == nil {
+		f.Issues = map[string]*gogithub.Issue{}
+	}
+	number := 1
+	for key := range f.Issues {
+		n := 0
+		if _, err := fmt.Sscanf(key[strings.LastIndex(key, "#")+1:], "%d", &n); err == nil && n >= number {
+			number = n + 1
+		}
+	}
+	created := &gogithub.Issue{
+		Number: gogithub.Int(number),
+		Title:  issue.Title,
+		Body:   issue.Body,
+		State:  gogithub.String("open"),
+		Labels: []*gogithub.Label{},
+	}
+	if issue.Labels != nil {
+		for _, name := range *issue.Labels {
+			created.Labels = append(created.Labels, &gogithub.Label{Name: gogithub.String(name)})
+		}
+		if f.Labels == nil {
+			f.Labels = map[int][]string{}
+		}
+		f.Labels[number] = append([]string{}, *issue.Labels...)
+	}
+	if issue.Assignees != nil {
+		created.Assignees = users(*issue.Assignees)
+	}
+	f.Issues[fmt.Sprintf("%s/%s#%d", owner, repo, number)] = created
+	return created, response(), nil
+}
+
+// Edit records the change. Milestones set in it are assigned to the
+// issue, the title, body and state are changed in the issues in Issues.
+func (f *FakeIssuesService) Edit(
+	ctx context.Context, owner, repo string, number int, issue *gogithub.IssueRequest,
+) (*gogithub.Issue, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if f.Edits == nil {
+		f.Edits = map[int][]*gogithub.IssueRequest{}
+	}
+	f.Edits[number] = append(f.Edits[number], issue)
+	if stored := f.Issues[fmt.Sprintf("%s/%s#%d", owner, repo, number)]; stored != nil {
+		if issue.Title != nil {
+			stored.Title = issue.Title
+		}
+		if issue.Body != nil {
+			stored.Body = issue.Body
+		}
+		if issue.State != nil {
+			stored.State = issue.State
+		}
+	}
+	if issue.Milestone != nil {
+		if f.IssueMilestones == nil {
+			f.IssueMilestones = map[int]*gogithub.Milestone{}
+		}
+		for _, m := range f.Milestones {
+			if m.GetNumber() == *issue.Milestone {
+				f.IssueMilestones[number] = m
+			}
+		}
+	}
+	return &gogithub.Issue{Number: gogithub.Int(number), Milestone: f.IssueMilestones[number]}, response(), nil
+}
+
+// AddAssignees assigns users to an issue stored in Issues
+func (f *FakeIssuesService) AddAssignees(
+	ctx context.Context, owner, repo string, number int, assignees []string,
+) (*gogithub.Issue, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	issue := f.Issues[fmt.Sprintf("%s/%s#%d", owner, repo, number)]
+	if issue == nil {
+		return nil, nil, NotFound("issue %s/%s#%d not found", owner, repo, number)
+	}
+	for _, login := range assignees {
+		found := false
+		for _, u := range issue.Assignees {
+			found = found || u.GetLogin() == login
+		}
+		if !found {
+			issue.Assignees = append(issue.Assignees, users([]string{login})...)
+		}
+	}
+	return issue, response(), nil
+}
+
+// RemoveAssignees unassigns users from an issue stored in Issues
+func (f *FakeIssuesService) RemoveAssignees(
+	ctx context.Context, owner, repo string, number int, assignees []string,
+) (*gogithub.Issue, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	issue := f.Issues[fmt.Sprintf("%s/%s#%d", owner, repo, number)]
+	if issue == nil {
+		return nil, nil, NotFound("issue %s/%s#%d not found", owner, repo, number)
+	}
+	kept := []*gogithub.User{}
+	for _, u := range issue.Assignees {
+		removed := false
+		for _, login := range assignees {
+			removed = removed || u.GetLogin() == login
+		}
+		if !removed {
+			kept = append(kept, u)
+		}
+	}
+	issue.Assignees = kept
+	return issue, response(), nil
+}
+
+// users returns go-github users with the logins
+func users(logins []string) []*gogithub.User {
+	result := []*gogithub.User{}
+	for _, login := range logins {
+		result = append(result, &gogithub.User{Login: gogithub.String(login)})
+	}
+	return result
+}
+
+// ListMilestones returns the milestones in the state requested
+func (f *FakeIssuesService) ListMilestones(
+	ctx context.Context, owner, repo string, opts *gogithub.MilestoneListOptions,
+) ([]*gogithub.Milestone, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.MilestoneCalls++
+	milestones := []*gogithub.Milestone{}
+	for _, m := range f.Milestones {
+		if opts == nil || opts.State == "" || opts.State == "all" || opts.State == m.GetState() {
+			milestones = append(milestones, m)
+		}
+	}
+	return milestones, response(), nil
+}
+
+// CreateMilestone adds an open milestone with the next free number
+func (f *FakeIssuesService) CreateMilestone(
+	ctx context.Context, owner, repo string, milestone *gogithub.Milestone,
+) (*gogithub.Milestone, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	number := 1
+	for _, m := range f.Milestones {
+		if m.GetTitle() == milestone.GetTitle() {
+			return nil, nil, ErrorResponse(http.StatusUnprocessableEntity, "milestone %s already exists", m.GetTitle())
+		}
+		if m.GetNumber() >= number {
+			number = m.GetNumber() + 1
+		}
+	}
+	created := &gogithub.Milestone{
+		Number:      gogithub.Int(number),
+		Title:       milestone.Title,
+		Description: milestone.Description,
+		State:       gogithub.String("open"),
+	}
+	f.Milestones = append(f.Milestones, created)
+	return created, response(), nil
+}
+
+func (f *FakeIssuesService) ListComments(
+	ctx context.Context, owner, repo string, number int, opts *gogithub.IssueListCommentsOptions,
+) ([]*gogithub.IssueComment, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.Comments[number], response(), nil
+}
+
+// CreateComment stores a new comment with the next free ID
+func (f *FakeIssuesService) CreateComment(
+	ctx context.Context, owner, repo string, number int, comment *gogithub.IssueComment,
+) (*gogithub.IssueComment, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if f.Comments == nil {
+		f.Comments = map[int][]*gogithub.IssueComment{}
+	}
+	for _, comments := range f.Comments {
+		for _, c := range comments {
+			if c.GetID() > f.lastID {
+				f.lastID = c.GetID()
+			}
+		}
+	}
+	f.lastID++
+	created := &gogithub.IssueComment{ID: gogithub.Int64(f.lastID), Body: comment.Body}
+	if f.Login != "" {
+		created.User = &gogithub.User{Login: gogithub.String(f.Login)}
+	}
+	f.Comments[number] = append(f.Comments[number], created)
+	return created, response(), nil
+}
+
+func (f *FakeIssuesService) EditComment(
+	ctx context.Context, owner, repo string, commentID int64, comment *gogithub.IssueComment,
+) (*gogithub.IssueComment, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	for _, comments := range f.Comments {
+		for _, c := range comments {
+			if c.GetID() == commentID {
+				c.Body = comment.Body
+				f.CommentEdits++
+				return c, response(), nil
+			}
+		}
+	}
+	return nil, nil, NotFound("comment %d not found in %s/%s", commentID, owner, repo)
+}
+
+func (f *FakeIssuesService) ListLabelsByIssue(
+	ctx context.Context, owner, repo string, number int, opts *gogithub.ListOptions,
+) ([]*gogithub.Label, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.LabelCalls++
+	return f.labels(number), response(), nil
+}
+
+func (f *FakeIssuesService) AddLabelsToIssue(
+	ctx context.Context, owner, repo string, number int, labels []string,
+) ([]*gogithub.Label, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if f.Labels == nil {
+		f.Labels = map[int][]string{}
+	}
+	for _, label := range labels {
+		found := false
+		for _, l := range f.Labels[number] {
+			if l == label {
+				found = true
+				break
+			}
+		}
+		if !found {
+			f.Labels[number] = append(f.Labels[number], label)
+		}
+	}
+	return f.labels(number), response(), nil
+}
+
+func (f *FakeIssuesService) RemoveLabelForIssue(
+	ctx context.Context, owner, repo string, number int, label string,
+) (*gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	for i, l := range f.Labels[number] {
+		if l == label {
+			f.Labels[number] = append(f.Labels[number][:i], f.Labels[number][i+1:]...)
+			return response(), nil
+		}
+	}
+	return nil, NotFound("label %s not found in %s/%s#%d", label, owner, repo, number)
+}
+
+// labels returns the labels of an issue as go-github objects
+func (f *FakeIssuesService) labels(number int) []*gogithub.Label {
+	labels := []*gogithub.Label{}
+	for _, l := range f.Labels[number] {
+		labels = append(labels, &gogithub.Label{Name: gogithub.String(l)})
+	}
+	return labels
+}
+
+// FakeChecksService serves the check runs of commits
+type FakeChecksService struct {
+	mtx sync.Mutex
+
+	CheckRuns map[string][]*gogithub.CheckRun  // Check runs by ref
+	Updates   []gogithub.UpdateCheckRunOptions // Check run updates received, in order
+	lastID    int64
+}
+
+// CreateCheckRun adds a check run to the runs of its head SHA
+func (f *FakeChecksService) CreateCheckRun(
+	ctx context.Context, owner, repo string, opts gogithub.CreateCheckRunOptions,
+) (*gogithub.CheckRun, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if f.CheckRuns == nil {
+		f.CheckRuns = map[string][]*gogithub.CheckRun{}
+	}
+	for _, runs := range f.CheckRuns {
+		for _, r := range runs {
+			if r.GetID() > f.lastID {
+				f.lastID = r.GetID()
+			}
+		}
+	}
+	f.lastID++
+	status := opts.Status
+	if status == nil {
+		status = gogithub.String("queued")
+	}
+	run := &gogithub.CheckRun{
+		ID:          gogithub.Int64(f.lastID),
+		Name:        gogithub.String(opts.Name),
+		HeadSHA:     gogithub.String(opts.HeadSHA),
+		Status:      status,
+		Conclusion:  opts.Conclusion,
+		DetailsURL:  opts.DetailsURL,
+		CompletedAt: opts.CompletedAt,
+		Output:      opts.Output,
+	}
+	f.CheckRuns[opts.HeadSHA] = append(f.CheckRuns[opts.HeadSHA], run)
+	return run, response(), nil
+}
+
+// UpdateCheckRun changes a check run. Annotations are appended to the
+// ones in the run, the rest of the output is replaced.
+func (f *FakeChecksService) UpdateCheckRun(
+	ctx context.Context, owner, repo string, checkRunID int64, opts gogithub.UpdateCheckRunOptions,
+) (*gogithub.CheckRun, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.Updates = append(f.Updates, opts)
+	for _, runs := range f.CheckRuns {
+		for _, run := range runs {
+			if run.GetID() != checkRunID {
+				continue
+			}
+			if opts.Status != nil {
+				run.Status = opts.Status
+			}
+			if opts.Conclusion != nil {
+				run.Conclusion = opts.Conclusion
+				run.Status = gogithub.String("completed")
+			}
+			if opts.CompletedAt != nil {
+				run.CompletedAt = opts.CompletedAt
+			}
+			if opts.DetailsURL != nil {
+				run.DetailsURL = opts.DetailsURL
+			}
+			if opts.Output != nil {
+				output := *opts.Output
+				if run.Output != nil {
+					output.Annotations = append(run.Output.Annotations, opts.Output.Annotations...)
+				}
+				run.Output = &output
+			}
+			return run, response(), nil
+		}
+	}
+	return nil, nil, NotFound("check run %d not found in %s/%s", checkRunID, owner, repo)
+}
+
+func (f *FakeChecksService) ListCheckRunsForRef(
+	ctx context.Context, owner, repo, ref string, opts *gogithub.ListCheckRunsOptions,
+) (*gogithub.ListCheckRunsResults, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	runs := f.CheckRuns[ref]
+	return &gogithub.ListCheckRunsResults{
+		Total:     gogithub.Int(len(runs)),
+		CheckRuns: runs,
+	}, response(), nil
+}
+
+// FakeTeamsService serves the members of organization teams
+type FakeTeamsService struct {
+	mtx sync.Mutex
+
+	Members map[string][]string // Logins of the active members by "org/team-slug"
+	Calls   int                 // Requests received
+}
+
+func (f *FakeTeamsService) GetTeamMembershipBySlug(
+	ctx context.Context, org, slug, user string,
+) (*gogithub.Membership, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.Calls++
+	for _, member := range f.Members[org+"/"+slug] {
+		if strings.EqualFold(member, user) {
+			return &gogithub.Membership{
+				State: gogithub.String("active"),
+				Role:  gogithub.String("member"),
+			}, response(), nil
+		}
+	}
+	return nil, nil, NotFound("%s is not a member of %s/%s", user, org, slug)
+}
+
+// ListTeamMembersBySlug returns the members of the team. Like
+// GitHub, unknown teams return a 404 error.
+func (f *FakeTeamsService) ListTeamMembersBySlug(
+	ctx context.Context, org, slug string, opts *gogithub.TeamListTeamMembersOptions,
+) ([]*gogithub.User, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.Calls++
+	members, ok := f.Members[org+"/"+slug]
+	if !ok {
+		return nil, nil, NotFound("team %s/%s not found", org, slug)
+	}
+	users := []*gogithub.User{}
+	for _, login := range members {
+		users = append(users, &gogithub.User{Login: gogithub.String(login)})
+	}
+	return users, response(), nil
+}
+
+// FakeOrganizationsService serves the members of organizations
+type FakeOrganizationsService struct {
+	mtx sync.Mutex
+
+	Members map[string][]string // Logins of the members by organization
+	Calls   int                 // Requests received
+}
+
+func (f *FakeOrganizationsService) IsMember(
+	ctx context.Context, org, user string,
+) (bool, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.Calls++
+	for _, member := range f.Members[org] {
+		if strings.EqualFold(member, user) {
+			return true, response(), nil
+		}
+	}
+	return false, response(), nil
+}
+
+// FakeSearchService answers searches with preloaded results. Queries
+// are not parsed, the results are looked up by the full query string.
+type FakeSearchService struct {
+	mtx sync.Mutex
+
+	Results map[string][]*gogithub.Issue // Issues found by query
+	Queries []string                     // Queries received, one per page
+	PerPage int                          // Results per page, all in one page when zero
+}
+
+// Issues returns a page of the results of the query
+func (f *FakeSearchService) Issues(
+	ctx context.Context, query string, opts *gogithub.SearchOptions,
+) (*gogithub.IssuesSearchResult, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.Queries = append(f.Queries, query)
+	issues := f.Results[query]
+	var listOpts *gogithub.ListOptions
+	if opts != nil {
+		listOpts = &opts.ListOptions
+	}
+	start, end, resp := paginate(len(issues), f.PerPage, listOpts)
+	return &gogithub.IssuesSearchResult{
+		Total:  gogithub.Int(len(issues)),
+		Issues: issues[start:end],
+	}, resp, nil
+}
+
+// GraphQLCall is a request sent to the GraphQL API
+type GraphQLCall struct {
+	Query     string
+	Variables map[string]interface{}
+}
+
+// FakeGraphQLService records GraphQL requests. Responses are
+// produced by Stub as the JSON of the data field.
+type FakeGraphQLService struct {
+	mtx sync.Mutex
+
+	Calls []GraphQLCall                                                        // Requests received, in order
+	Stub  func(query string, variables map[string]interface{}) (string, error) // Result of the calls
+}
+
+func (f *FakeGraphQLService) Do(
+	ctx context.Context, query string, variables map[string]interface{}, result interface{},
+) (*gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.Calls = append(f.Calls, GraphQLCall{Query: query, Variables: variables})
+	if f.Stub == nil {
+		return response(), nil
+	}
+	data, err := f.Stub(query, variables)
+	if err != nil {
+		return response(), err
+	}
+	if result != nil && data != "" {
+		if err := json.Unmarshal([]byte(data), result); err != nil {
+			return response(), err
+		}
+	}
+	return response(), nil
+}
+
+// FakeActionsService records workflow dispatches and serves workflow runs
+type FakeActionsService struct {
+	mtx sync.Mutex
+
+	Runs       map[string][]*gogithub.WorkflowRun // Runs by workflow file name, newest first
+	Dispatches []DispatchCall                     // Workflow dispatches received, in order
+}
+
+// DispatchCall is a request to run a workflow
+type DispatchCall struct {
+	Workflow string
+	Ref      string
+	Inputs   map[string]interface{}
+}
+
+// CreateWorkflowDispatchEventByFileName records the dispatch
+func (f *FakeActionsService) CreateWorkflowDispatchEventByFileName(
+	ctx context.Context, owner, repo, workflowFileName string, event gogithub.CreateWorkflowDispatchEventRequest,
+) (*gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.Dispatches = append(f.Dispatches, DispatchCall{
+		Workflow: workflowFileName, Ref: event.Ref, Inputs: event.Inputs,
+	})
+	return response(), nil
+}
+
+// ListWorkflowRunsByFileName returns the runs of the workflow
+// on the branch of the options, or all of them if not set
+func (f *FakeActionsService) ListWorkflowRunsByFileName(
+	ctx context.Context, owner, repo, workflowFileName string, opts *gogithub.ListWorkflowRunsOptions,
+) (*gogithub.WorkflowRuns, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	runs := []*gogithub.WorkflowRun{}
+	for _, run := range f.Runs[workflowFileName] {
+		if opts == nil || opts.Branch == "" || opts.Branch == run.GetHeadBranch() {
+			runs = append(runs, run)
+		}
+	}
+	return &gogithub.WorkflowRuns{TotalCount: gogithub.Int(len(runs)), WorkflowRuns: runs}, response(), nil
+}
+
+// GetWorkflowRunByID returns the run with the ID from any workflow
+func (f *FakeActionsService) GetWorkflowRunByID(
+	ctx context.Context, owner, repo string, runID int64,
+) (*gogithub.WorkflowRun, *gogithub.Response, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	for _, runs := range f.Runs {
+		for _, run := range runs {
+			if run.GetID() == runID {
+				return run, response(), nil
+			}
+		}
+	}
+	return nil, nil, NotFound("workflow run %d not found", runID)
+}
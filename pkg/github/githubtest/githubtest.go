@@ -0,0 +1,207 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+// Package githubtest provides a GitHub backed by the in-memory fakes of
+// githubfakes. The objects it returns run their real logic (merge mode
+// detection, cherry-picks, backports...) against the data preloaded in
+// the fakes, so code using the github package can be tested offline.
+// The fakes record the changes made to them to verify the calls.
+package githubtest
+
+import (
+	"fmt"
+	"sort"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/puerco/mattermod-refactor/pkg/github"
+	"github.com/puerco/mattermod-refactor/pkg/github/githubfakes"
+)
+
+// Login is the author of the comments created in the fakes and the
+// user the GitHub objects are authenticated as, unless set in the options
+const Login = "mattermod[bot]"
+
+// FakeGitHub holds the fakes behind the GitHub objects it creates.
+// They can be preloaded and inspected directly or through the helpers.
+type FakeGitHub struct {
+	PullRequests  *githubfakes.FakePullRequestsService
+	Repositories  *githubfakes.FakeRepositoriesService
+	Git           *githubfakes.FakeGitService
+	Issues        *githubfakes.FakeIssuesService
+	Checks        *githubfakes.FakeChecksService
+	Teams         *githubfakes.FakeTeamsService
+	Organizations *githubfakes.FakeOrganizationsService
+	Search        *githubfakes.FakeSearchService
+	Actions       *githubfakes.FakeActionsService
+	GraphQL       *githubfakes.FakeGraphQLService
+}
+
+// New returns a FakeGitHub with empty fakes
+func New() *FakeGitHub {
+	return &FakeGitHub{
+		PullRequests: &githubfakes.FakePullRequestsService{
+			PullRequests: map[int]*gogithub.PullRequest{},
+			Commits:      map[int][]*gogithub.RepositoryCommit{},
+		},
+		Repositories: &githubfakes.FakeRepositoriesService{
+			Repositories: map[string]*gogithub.Repository{},
+			Commits:      map[string]*gogithub.RepositoryCommit{},
+		},
+		Git: &githubfakes.FakeGitService{
+			Refs:  map[string]*gogithub.Reference{},
+			Trees: map[string]*gogithub.Tree{},
+		},
+		Issues:        &githubfakes.FakeIssuesService{Login: Login},
+		Checks:        &githubfakes.FakeChecksService{},
+		Teams:         &githubfakes.FakeTeamsService{Members: map[string][]string{}},
+		Organizations: &githubfakes.FakeOrganizationsService{Members: map[string][]string{}},
+		Search:        &githubfakes.FakeSearchService{Results: map[string][]*gogithub.Issue{}},
+		Actions:       &githubfakes.FakeActionsService{},
+		GraphQL:       &githubfakes.FakeGraphQLService{},
+	}
+}
+
+// Client returns a client talking to the fakes
+func (f *FakeGitHub) Client() *github.Client {
+	return &github.Client{
+		PullRequests:  f.PullRequests,
+		Repositories:  f.Repositories,
+		Git:           f.Git,
+		Issues:        f.Issues,
+		Checks:        f.Checks,
+		Teams:         f.Teams,
+		Organizations: f.Organizations,
+		Search:        f.Search,
+		Actions:       f.Actions,
+		GraphQL:       f.GraphQL,
+	}
+}
+
+// GitHub returns a GitHub object talking to the fakes. The options are
+// copied and their client replaced. When nil, no caches are used so
+// changes made to the fakes are always seen.
+func (f *FakeGitHub) GitHub(opts *github.Options) *github.GitHub {
+	o := github.Options{}
+	if opts != nil {
+		o = *opts
+	}
+	o.Client = f.Client()
+	if o.Login == "" {
+		o.Login = Login
+	}
+	return github.NewWithOptions(&o)
+}
+
+// AddRepository preloads a repository
+func (f *FakeGitHub) AddRepository(owner, name string) *gogithub.Repository {
+	repo := &gogithub.Repository{
+		Name:     gogithub.String(name),
+		FullName: gogithub.String(owner + "/" + name),
+		Owner:    &gogithub.User{Login: gogithub.String(owner)},
+	}
+	f.Repositories.Repositories[owner+"/"+name] = repo
+	return repo
+}
+
+// AddCommit preloads a commit with its tree and parents
+func (f *FakeGitHub) AddCommit(sha, tree string, parents ...string) *gogithub.RepositoryCommit {
+	commit := &gogithub.RepositoryCommit{
+		SHA: gogithub.String(sha),
+		Commit: &gogithub.Commit{
+			SHA:     gogithub.String(sha),
+			Message: gogithub.String("Commit " + sha),
+			Tree:    &gogithub.Tree{SHA: gogithub.String(tree)},
+		},
+		Parents: []*gogithub.Commit{},
+	}
+	for _, p := range parents {
+		commit.Parents = append(commit.Parents, &gogithub.Commit{SHA: gogithub.String(p)})
+	}
+	f.Repositories.Commits[sha] = commit
+	return commit
+}
+
+// AddTree preloads a tree with files, from their paths to their blob SHAs
+func (f *FakeGitHub) AddTree(sha string, files map[string]string) *gogithub.Tree {
+	paths := []string{}
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	tree := &gogithub.Tree{SHA: gogithub.String(sha), Entries: []*gogithub.TreeEntry{}}
+	for _, path := range paths {
+		tree.Entries = append(tree.Entries, &gogithub.TreeEntry{
+			Path: gogithub.String(path),
+			Mode: gogithub.String("100644"),
+			Type: gogithub.String("blob"),
+			SHA:  gogithub.String(files[path]),
+		})
+	}
+	f.Git.Trees[sha] = tree
+	return tree
+}
+
+// AddBranch points a branch to a commit
+func (f *FakeGitHub) AddBranch(branch, sha string) {
+	f.Git.Refs["heads/"+branch] = &gogithub.Reference{
+		Ref:    gogithub.String("refs/heads/" + branch),
+		Object: &gogithub.GitObject{SHA: gogithub.String(sha)},
+	}
+}
+
+// AddPullRequest preloads a pull request of the repository made of
+// the commits, which must have been added first. If mergeSHA is not
+// empty the pull request is merged, otherwise it is open. The returned
+// object can be modified to set other fields, eg labels or base branch.
+func (f *FakeGitHub) AddPullRequest(
+	owner, repo string, number int, mergeSHA string, commits ...string,
+) *gogithub.PullRequest {
+	pr := &gogithub.PullRequest{
+		Number: gogithub.Int(number),
+		Title:  gogithub.String(fmt.Sprintf("Pull request #%d", number)),
+		State:  gogithub.String("open"),
+		Merged: gogithub.Bool(mergeSHA != ""),
+		Base: &gogithub.PullRequestBranch{
+			Ref: gogithub.String("master"),
+			Repo: &gogithub.Repository{
+				Name:  gogithub.String(repo),
+				Owner: &gogithub.User{Login: gogithub.String(owner)},
+			},
+		},
+	}
+	if mergeSHA != "" {
+		pr.State = gogithub.String("closed")
+		pr.MergeCommitSHA = gogithub.String(mergeSHA)
+	}
+	if len(commits) > 0 {
+		pr.Head = &gogithub.PullRequestBranch{SHA: gogithub.String(commits[len(commits)-1])}
+	}
+
+	f.PullRequests.Commits[number] = []*gogithub.RepositoryCommit{}
+	for _, sha := range commits {
+		f.PullRequests.Commits[number] = append(f.PullRequests.Commits[number], f.Repositories.Commits[sha])
+	}
+	f.PullRequests.PullRequests[number] = pr
+	return pr
+}
+
+// Comments returns the bodies of the comments posted on an issue or PR
+func (f *FakeGitHub) Comments(number int) []string {
+	bodies := []string{}
+	for _, c := range f.Issues.Comments[number] {
+		bodies = append(bodies, c.GetBody())
+	}
+	return bodies
+}
+
+// Labels returns the labels added to an issue or PR
+func (f *FakeGitHub) Labels(number int) []string {
+	return append([]string{}, f.Issues.Labels[number]...)
+}
+
+// Branch returns the commit a branch points to, or an
+// empty string if the branch does not exist
+func (f *FakeGitHub) Branch(branch string) string {
+	return f.Git.Refs["heads/"+branch].GetObject().GetSHA()
+}
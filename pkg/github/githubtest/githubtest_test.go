@@ -0,0 +1,61 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package githubtest_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/puerco/mattermod-refactor/pkg/github"
+	"github.com/puerco/mattermod-refactor/pkg/github/githubtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeGitHub(t *testing.T) {
+	ctx := context.Background()
+	fake := githubtest.New()
+	fake.AddRepository("mattermost", "mattermost-server")
+
+	// A PR with two commits squashed on master, to backport to release-7.1
+	fake.AddCommit("main", "main-tree")
+	fake.AddCommit("pr-1", "pr-tree-1", "main")
+	fake.AddCommit("pr-2", "pr-tree-2", "pr-1")
+	fake.AddCommit("squashed", "merged-tree", "main")
+	fake.AddCommit("release", "release-tree")
+	fake.AddTree("main-tree", map[string]string{"a.go": "a1", "b.go": "b1"})
+	fake.AddTree("merged-tree", map[string]string{"a.go": "a2", "b.go": "b1"})
+	fake.AddTree("release-tree", map[string]string{"a.go": "a1", "b.go": "b0"})
+	fake.AddBranch("release-7.1", "release")
+	fake.AddPullRequest("mattermost", "mattermost-server", 10, "squashed", "pr-1", "pr-2")
+
+	gh := fake.GitHub(nil)
+	pr, err := gh.GetPullRequest(ctx, "mattermost", "mattermost-server", 10)
+	require.Nil(t, err)
+	mode, err := pr.GetMergeMode(ctx)
+	require.Nil(t, err)
+	require.Equal(t, github.MergeModeSquash, mode)
+
+	// The cherry-pick runs against the fake trees
+	branch, sha, err := pr.CherryPick(ctx, "release-7.1")
+	require.Nil(t, err)
+	require.Equal(t, "cherry-pick-10-release-7.1", branch)
+	require.Equal(t, sha, fake.Branch(branch))
+	require.Len(t, fake.Git.CreatedCommits, 1)
+	require.Equal(t, "release", fake.Git.CreatedCommits[0].Parents[0].GetSHA())
+
+	// Changes are recorded in the fakes
+	_, err = pr.CommentOnPR(ctx, "Cherry-picked")
+	require.Nil(t, err)
+	require.Nil(t, pr.AddLabels(ctx, "CherryPick/Done"))
+	require.Equal(t, []string{"Cherry-picked"}, fake.Comments(10))
+	require.Equal(t, []string{"CherryPick/Done"}, fake.Labels(10))
+
+	// Conflicts are reported like with the real API
+	fake.AddTree("release-tree", map[string]string{"a.go": "a0", "b.go": "b0"})
+	fake.AddBranch("release-7.0", "release")
+	_, _, err = pr.CherryPick(ctx, "release-7.0")
+	require.True(t, errors.Is(err, github.ErrCherryPickConflict))
+	require.Equal(t, []string{"a.go"}, github.ConflictPaths(err))
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+)
+
+// graphQLPath is the GraphQL endpoint relative to the REST API base URL.
+// It resolves to /graphql on api.github.com and to /api/graphql on
+// GitHub Enterprise Server, where the REST API lives under /api/v3/.
+const graphQLPath = "../graphql"
+
+// GraphQLError is an error reported by the GitHub GraphQL API
+type GraphQLError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// GraphQLErrors holds the errors returned in a GraphQL response
+type GraphQLErrors []GraphQLError
+
+func (ge GraphQLErrors) Error() string {
+	messages := []string{}
+	for _, e := range ge {
+		messages = append(messages, e.Message)
+	}
+	return "graphql: " + strings.Join(messages, "; ")
+}
+
+// graphQLClient sends GraphQL requests through a go-github client
+// so they share its authentication, base URL and rate limit handling
+type graphQLClient struct {
+	client *gogithub.Client
+}
+
+// Do runs a query or mutation and decodes its data into result
+func (gc *graphQLClient) Do(
+	ctx context.Context, query string, variables map[string]interface{}, result interface{},
+) (*gogithub.Response, error) {
+	req, err := gc.client.NewRequest("POST", graphQLPath, map[string]interface{}{
+		"query":     query,
+		"variables": variables,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "building GraphQL request")
+	}
+
+	payload := struct {
+		Data   json.RawMessage `json:"data"`
+		Errors GraphQLErrors   `json:"errors"`
+	}{}
+	resp, err := gc.client.Do(ctx, req, &payload)
+	if err != nil {
+		return resp, err
+	}
+	if len(payload.Errors) > 0 {
+		return resp, payload.Errors
+	}
+	if result != nil && len(payload.Data) > 0 {
+		if err := json.Unmarshal(payload.Data, result); err != nil {
+			return resp, errors.Wrap(err, "decoding GraphQL response")
+		}
+	}
+	return resp, nil
+}
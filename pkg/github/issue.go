@@ -0,0 +1,245 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// Issue is a GitHub issue
+type Issue struct {
+	impl           IssueImplementation
+	Owner          string
+	Repo           string
+	Number         int
+	Title          string
+	Body           string
+	State          string // open or closed
+	URL            string
+	Labels         []string
+	Assignees      []string // Logins of the users assigned to the issue
+	MilestoneTitle string   // Empty if the issue has no milestone
+}
+
+type IssueImplementation interface {
+	log(issue *Issue) Logger
+	operationContext(ctx context.Context) (context.Context, context.CancelFunc)
+	update(ctx context.Context, issue *Issue, opts *IssueUpdate) error
+	addLabels(ctx context.Context, issue *Issue, labels []string) ([]string, error)
+	removeLabel(ctx context.Context, issue *Issue, label string) error
+	addAssignees(ctx context.Context, issue *Issue, logins []string) ([]string, error)
+	removeAssignees(ctx context.Context, issue *Issue, logins []string) ([]string, error)
+	comment(ctx context.Context, issue *Issue, body string) (int64, error)
+	updateOrCreateComment(ctx context.Context, issue *Issue, marker, body string) (int64, error)
+}
+
+// NewIssueOptions set the data of new issues besides their title and body
+type NewIssueOptions struct {
+	Labels    []string
+	Assignees []string
+}
+
+// IssueUpdate lists the fields of an issue to change, nil ones are kept
+type IssueUpdate struct {
+	Title *string
+	Body  *string
+	State *string // open or closed
+}
+
+// IsOpen returns true if the issue is open
+func (issue *Issue) IsOpen() bool {
+	return issue.State == "open"
+}
+
+// Update changes the title, body or state of the issue
+func (issue *Issue) Update(ctx context.Context, opts *IssueUpdate) error {
+	ctx, cancel := issue.impl.operationContext(ctx)
+	defer cancel()
+
+	if opts == nil || (opts.Title == nil && opts.Body == nil && opts.State == nil) {
+		return nil
+	}
+	if err := issue.impl.update(ctx, issue, opts); err != nil {
+		return errors.Wrapf(err, "updating issue #%d", issue.Number)
+	}
+	if opts.Title != nil {
+		issue.Title = *opts.Title
+	}
+	if opts.Body != nil {
+		issue.Body = *opts.Body
+	}
+	if opts.State != nil {
+		issue.State = *opts.State
+	}
+	return nil
+}
+
+// Close closes the issue. Closing an issue that is already closed does nothing.
+func (issue *Issue) Close(ctx context.Context) error {
+	if !issue.IsOpen() {
+		return nil
+	}
+	state := "closed"
+	return issue.Update(ctx, &IssueUpdate{State: &state})
+}
+
+// Reopen reopens the issue. Reopening an issue that is open does nothing.
+func (issue *Issue) Reopen(ctx context.Context) error {
+	if issue.IsOpen() {
+		return nil
+	}
+	state := "open"
+	return issue.Update(ctx, &IssueUpdate{State: &state})
+}
+
+// HasLabel returns true if the issue has the label
+func (issue *Issue) HasLabel(label string) bool {
+	for _, l := range issue.Labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+// AddLabels adds labels to the issue
+func (issue *Issue) AddLabels(ctx context.Context, labels ...string) error {
+	ctx, cancel := issue.impl.operationContext(ctx)
+	defer cancel()
+
+	if len(labels) == 0 {
+		return nil
+	}
+	current, err := issue.impl.addLabels(ctx, issue, labels)
+	if err != nil {
+		return errors.Wrapf(err, "adding labels to issue #%d", issue.Number)
+	}
+	issue.Labels = current
+	return nil
+}
+
+// RemoveLabel removes a label from the issue. Removing a
+// label the issue does not have is not an error.
+func (issue *Issue) RemoveLabel(ctx context.Context, label string) error {
+	ctx, cancel := issue.impl.operationContext(ctx)
+	defer cancel()
+
+	if err := issue.impl.removeLabel(ctx, issue, label); err != nil {
+		return errors.Wrapf(err, "removing label %s from issue #%d", label, issue.Number)
+	}
+	labels := []string{}
+	for _, l := range issue.Labels {
+		if l != label {
+			labels = append(labels, l)
+		}
+	}
+	issue.Labels = labels
+	return nil
+}
+
+// AddAssignees assigns users to the issue
+func (issue *Issue) AddAssignees(ctx context.Context, logins ...string) error {
+	ctx, cancel := issue.impl.operationContext(ctx)
+	defer cancel()
+
+	if len(logins) == 0 {
+		return nil
+	}
+	assignees, err := issue.impl.addAssignees(ctx, issue, logins)
+	if err != nil {
+		return errors.Wrapf(err, "assigning %v to issue #%d", logins, issue.Number)
+	}
+	issue.Assignees = assignees
+	return nil
+}
+
+// RemoveAssignees unassigns users from the issue
+func (issue *Issue) RemoveAssignees(ctx context.Context, logins ...string) error {
+	ctx, cancel := issue.impl.operationContext(ctx)
+	defer cancel()
+
+	if len(logins) == 0 {
+		return nil
+	}
+	assignees, err := issue.impl.removeAssignees(ctx, issue, logins)
+	if err != nil {
+		return errors.Wrapf(err, "unassigning %v from issue #%d", logins, issue.Number)
+	}
+	issue.Assignees = assignees
+	return nil
+}
+
+// Comment posts a comment on the issue and returns its
+// ID. In dry-run mode the returned ID is zero.
+func (issue *Issue) Comment(ctx context.Context, body string) (int64, error) {
+	ctx, cancel := issue.impl.operationContext(ctx)
+	defer cancel()
+
+	return issue.impl.comment(ctx, issue, body)
+}
+
+// UpdateOrCreateComment posts a comment tagged with a hidden marker, or
+// edits the comment with the same marker if there is one. Returns the
+// comment ID.
+func (issue *Issue) UpdateOrCreateComment(ctx context.Context, marker, body string) (int64, error) {
+	ctx, cancel := issue.impl.operationContext(ctx)
+	defer cancel()
+
+	if marker == "" {
+		return 0, errors.New("comment marker cannot be empty")
+	}
+	return issue.impl.updateOrCreateComment(ctx, issue, marker, body)
+}
+
+// IssueReference points to an issue, in the repository of
+// the pull request or another one
+type IssueReference struct {
+	Owner  string
+	Repo   string
+	Number int
+}
+
+// String returns the reference in the owner/repo#number form
+func (ref IssueReference) String() string {
+	return fmt.Sprintf("%s/%s#%d", ref.Owner, ref.Repo, ref.Number)
+}
+
+// closingReferenceRe matches the keywords GitHub uses to close issues
+// followed by #123, org/repo#123 or the URL of the issue
+var closingReferenceRe = regexp.MustCompile(
+	`(?i)\b(?:close[sd]?|fix(?:e[sd])?|resolve[sd]?):?\s+` +
+		`(?:https?://[^/\s]+/([\w.-]+)/([\w.-]+)/issues/|(?:([\w.-]+)/([\w.-]+))?#)(\d+)\b`,
+)
+
+// ParseIssueReferences returns the issues closed by a pull request body
+// or commit message, in order and without duplicates. References
+// without a repository point to owner/repo.
+func ParseIssueReferences(text, owner, repo string) []IssueReference {
+	refs := []IssueReference{}
+	seen := map[IssueReference]bool{}
+	for _, m := range closingReferenceRe.FindAllStringSubmatch(text, -1) {
+		ref := IssueReference{Owner: owner, Repo: repo}
+		switch {
+		case m[1] != "":
+			ref.Owner, ref.Repo = m[1], m[2]
+		case m[3] != "":
+			ref.Owner, ref.Repo = m[3], m[4]
+		}
+		number, err := strconv.Atoi(m[5])
+		if err != nil || number == 0 {
+			continue
+		}
+		ref.Number = number
+		if !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
@@ -0,0 +1,252 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+)
+
+// getLinkedIssues resolves the issues referenced with closing keywords
+// in the body and the commits of the pull request. References to pull
+// requests and to issues that do not exist are skipped.
+func (impl *defaultPRImplementation) getLinkedIssues(ctx context.Context, pr *PullRequest) ([]*Issue, error) {
+	commits, err := impl.getCommits(ctx, pr)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading commits")
+	}
+	text := pr.Body
+	for _, commit := range commits {
+		text += "\n" + commit.Message
+	}
+
+	issues := []*Issue{}
+	for _, ref := range ParseIssueReferences(text, pr.RepoOwner, pr.RepoName) {
+		var ghIssue *gogithub.Issue
+		err := impl.doWithRetry(ctx, "issues.Get", func() (resp *gogithub.Response, err error) {
+			ghIssue, resp, err = impl.GitHubClient().Issues.Get(ctx, ref.Owner, ref.Repo, ref.Number)
+			return resp, err
+		})
+		if isNotFound(err) {
+			impl.log(pr).Warnf("PR #%d references %s, which does not exist", pr.Number, ref)
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading issue %s", ref)
+		}
+		if ghIssue.IsPullRequest() {
+			continue
+		}
+		issues = append(issues, impl.NewIssue(ref.Owner, ref.Repo, ghIssue))
+	}
+	return issues, nil
+}
+
+type defaultIssueImplementation struct {
+	githubAPIUser
+	logger Logger
+}
+
+// log returns a log entry carrying the fields that identify the issue
+func (impl *defaultIssueImplementation) log(issue *Issue) Logger {
+	logger := impl.logger
+	if logger == nil {
+		logger = impl.getLogger()
+	}
+	return logger.WithFields(map[string]interface{}{
+		"repo":         issue.Owner + "/" + issue.Repo,
+		"issue_number": issue.Number,
+	})
+}
+
+// update edits the title, body or state of the issue
+func (impl *defaultIssueImplementation) update(ctx context.Context, issue *Issue, opts *IssueUpdate) error {
+	if impl.getOptions().DryRun {
+		impl.log(issue).Infof("[dry-run] Would update issue #%d", issue.Number)
+		return nil
+	}
+
+	err := impl.doWithRetry(ctx, "issues.Edit", func() (resp *gogithub.Response, err error) {
+		_, resp, err = impl.GitHubClient().Issues.Edit(ctx, issue.Owner, issue.Repo, issue.Number, &gogithub.IssueRequest{
+			Title: opts.Title,
+			Body:  opts.Body,
+			State: opts.State,
+		})
+		return resp, err
+	})
+	if err != nil {
+		return err
+	}
+	impl.log(issue).Infof("Updated issue #%d", issue.Number)
+	return nil
+}
+
+// addLabels adds the labels to the issue and returns
+// the label set after the change
+func (impl *defaultIssueImplementation) addLabels(ctx context.Context, issue *Issue, labels []string) ([]string, error) {
+	if impl.getOptions().DryRun {
+		impl.log(issue).Infof("[dry-run] Would add labels %v to issue #%d", labels, issue.Number)
+		return appendMissing(issue.Labels, labels), nil
+	}
+
+	var ghLabels []*gogithub.Label
+	err := impl.doWithRetry(ctx, "issues.AddLabelsToIssue", func() (resp *gogithub.Response, err error) {
+		ghLabels, resp, err = impl.GitHubClient().Issues.AddLabelsToIssue(ctx, issue.Owner, issue.Repo, issue.Number, labels)
+		return resp, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	impl.log(issue).Infof("Added labels %v to issue #%d", labels, issue.Number)
+	return labelNames(ghLabels), nil
+}
+
+// removeLabel removes a label from the issue, ignoring the 404
+// GitHub returns when the label is not set
+func (impl *defaultIssueImplementation) removeLabel(ctx context.Context, issue *Issue, label string) error {
+	if impl.getOptions().DryRun {
+		impl.log(issue).Infof("[dry-run] Would remove label %s from issue #%d", label, issue.Number)
+		return nil
+	}
+
+	err := impl.doWithRetry(ctx, "issues.RemoveLabelForIssue", func() (resp *gogithub.Response, err error) {
+		return impl.GitHubClient().Issues.RemoveLabelForIssue(ctx, issue.Owner, issue.Repo, issue.Number, label)
+	})
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+	impl.log(issue).Infof("Removed label %s from issue #%d", label, issue.Number)
+	return nil
+}
+
+// addAssignees assigns users to the issue and returns the assignees after the change
+func (impl *defaultIssueImplementation) addAssignees(ctx context.Context, issue *Issue, logins []string) ([]string, error) {
+	if impl.getOptions().DryRun {
+		impl.log(issue).Infof("[dry-run] Would assign %v to issue #%d", logins, issue.Number)
+		return appendMissing(issue.Assignees, logins), nil
+	}
+
+	var ghIssue *gogithub.Issue
+	err := impl.doWithRetry(ctx, "issues.AddAssignees", func() (resp *gogithub.Response, err error) {
+		ghIssue, resp, err = impl.GitHubClient().Issues.AddAssignees(ctx, issue.Owner, issue.Repo, issue.Number, logins)
+		return resp, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	impl.log(issue).Infof("Assigned %v to issue #%d", logins, issue.Number)
+	return userLogins(ghIssue.Assignees), nil
+}
+
+// removeAssignees unassigns users from the issue and returns the assignees after the change
+func (impl *defaultIssueImplementation) removeAssignees(ctx context.Context, issue *Issue, logins []string) ([]string, error) {
+	if impl.getOptions().DryRun {
+		impl.log(issue).Infof("[dry-run] Would unassign %v from issue #%d", logins, issue.Number)
+		assignees := []string{}
+		for _, a := range issue.Assignees {
+			if !containsString(logins, a) {
+				assignees = append(assignees, a)
+			}
+		}
+		return assignees, nil
+	}
+
+	var ghIssue *gogithub.Issue
+	err := impl.doWithRetry(ctx, "issues.RemoveAssignees", func() (resp *gogithub.Response, err error) {
+		ghIssue, resp, err = impl.GitHubClient().Issues.RemoveAssignees(ctx, issue.Owner, issue.Repo, issue.Number, logins)
+		return resp, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	impl.log(issue).Infof("Unassigned %v from issue #%d", logins, issue.Number)
+	return userLogins(ghIssue.Assignees), nil
+}
+
+// comment posts a new comment on the issue
+func (impl *defaultIssueImplementation) comment(ctx context.Context, issue *Issue, body string) (int64, error) {
+	if impl.getOptions().DryRun {
+		impl.log(issue).Infof("[dry-run] Would comment on issue #%d: %s", issue.Number, body)
+		return 0, nil
+	}
+
+	var comment *gogithub.IssueComment
+	err := impl.doWithRetry(ctx, "issues.CreateComment", func() (resp *gogithub.Response, err error) {
+		comment, resp, err = impl.GitHubClient().Issues.CreateComment(
+			ctx, issue.Owner, issue.Repo, issue.Number, &gogithub.IssueComment{Body: gogithub.String(body)},
+		)
+		return resp, err
+	})
+	if err != nil {
+		return 0, errors.Wrapf(err, "commenting on issue #%d", issue.Number)
+	}
+	impl.log(issue).Infof("Posted comment %d on issue #%d", comment.GetID(), issue.Number)
+	return comment.GetID(), nil
+}
+
+// updateOrCreateComment edits the first comment in the issue containing
+// the marker. If none is found, a new comment is created.
+func (impl *defaultIssueImplementation) updateOrCreateComment(
+	ctx context.Context, issue *Issue, marker, body string,
+) (int64, error) {
+	tag := fmt.Sprintf(commentMarkerTemplate, marker)
+	body = tag + "\n" + body
+
+	commentID, err := impl.findIssueComment(ctx, issue.Owner, issue.Repo, issue.Number, tag)
+	if err != nil {
+		return 0, errors.Wrapf(err, "searching for comment %q in issue #%d", marker, issue.Number)
+	}
+	if commentID == 0 {
+		return impl.comment(ctx, issue, body)
+	}
+
+	if impl.getOptions().DryRun {
+		impl.log(issue).Infof("[dry-run] Would update comment %d on issue #%d: %s", commentID, issue.Number, body)
+		return commentID, nil
+	}
+	err = impl.doWithRetry(ctx, "issues.EditComment", func() (resp *gogithub.Response, err error) {
+		_, resp, err = impl.GitHubClient().Issues.EditComment(
+			ctx, issue.Owner, issue.Repo, commentID, &gogithub.IssueComment{Body: gogithub.String(body)},
+		)
+		return resp, err
+	})
+	if err != nil {
+		return 0, errors.Wrapf(err, "updating comment %d on issue #%d", commentID, issue.Number)
+	}
+	impl.log(issue).Infof("Updated comment %d on issue #%d", commentID, issue.Number)
+	return commentID, nil
+}
+
+// userLogins returns the logins of a list of users
+func userLogins(users []*gogithub.User) []string {
+	logins := []string{}
+	for _, u := range users {
+		logins = append(logins, u.GetLogin())
+	}
+	return logins
+}
+
+// containsString returns true if s is in list
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// containsFold returns true if s is in list, ignoring case
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"testing"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetLinkedIssues(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	pr := &PullRequest{
+		impl:      &defaultPRImplementation{githubAPIUser: gau},
+		RepoOwner: "mattermost",
+		RepoName:  "mattermost-server",
+		Number:    1,
+		Body:      "Fixes #10\nCloses mattermost/focalboard#20\nFixes #404",
+	}
+	commit := fakes.addCommit("abc", "tree")
+	commit.Commit.Message = gogithub.String("Fix the login page\n\nResolves #30")
+	fakes.pulls.Commits[1] = []*gogithub.RepositoryCommit{commit}
+	fakes.issues.Issues = map[string]*gogithub.Issue{
+		"mattermost/mattermost-server#10": {
+			Number:    gogithub.Int(10),
+			Title:     gogithub.String("Login fails"),
+			State:     gogithub.String("open"),
+			Labels:    []*gogithub.Label{{Name: gogithub.String("Bug")}},
+			Milestone: &gogithub.Milestone{Title: gogithub.String("v7.1")},
+		},
+		"mattermost/focalboard#20":         {Number: gogithub.Int(20), State: gogithub.String("closed")},
+		"mattermost/mattermost-server#404": nil,
+		"mattermost/mattermost-server#30": {
+			Number:           gogithub.Int(30),
+			PullRequestLinks: &gogithub.PullRequestLinks{URL: gogithub.String("https://api.github.com/pulls/30")},
+		},
+	}
+
+	issues, err := pr.GetLinkedIssues(context.Background())
+	require.Nil(t, err)
+	for _, issue := range issues {
+		require.NotNil(t, issue.impl)
+		issue.impl = nil
+	}
+	require.Equal(t, []*Issue{
+		{
+			Owner: "mattermost", Repo: "mattermost-server", Number: 10, Title: "Login fails",
+			State: "open", Labels: []string{"Bug"}, Assignees: []string{}, MilestoneTitle: "v7.1",
+		},
+		{Owner: "mattermost", Repo: "focalboard", Number: 20, State: "closed", Labels: []string{}, Assignees: []string{}},
+	}, issues)
+}
+
+func TestIssueLifecycle(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	repo := gau.NewRepository(&gogithub.Repository{
+		Name: gogithub.String("mattermost-server"), Owner: &gogithub.User{Login: gogithub.String("mattermost")},
+	})
+	ctx := context.Background()
+
+	issue, err := repo.CreateIssue(ctx, "Flaky test", "TestLogin fails sometimes", &NewIssueOptions{
+		Labels: []string{"Bug"}, Assignees: []string{"alice"},
+	})
+	require.Nil(t, err)
+	require.Equal(t, 1, issue.Number)
+	require.True(t, issue.IsOpen())
+	require.True(t, issue.HasLabel("Bug"))
+	require.Equal(t, []string{"alice"}, issue.Assignees)
+
+	// Changes are stored in GitHub and in the object
+	title := "Flaky TestLogin"
+	require.Nil(t, issue.Update(ctx, &IssueUpdate{Title: &title}))
+	require.Nil(t, issue.AddLabels(ctx, "Triage", "Bug"))
+	require.Equal(t, []string{"Bug", "Triage"}, issue.Labels)
+	require.Nil(t, issue.RemoveLabel(ctx, "Bug"))
+	require.Equal(t, []string{"Triage"}, issue.Labels)
+	require.Nil(t, issue.AddAssignees(ctx, "bob"))
+	require.Nil(t, issue.RemoveAssignees(ctx, "alice"))
+	require.Equal(t, []string{"bob"}, issue.Assignees)
+	for i := 0; i < 2; i++ {
+		require.Nil(t, issue.Close(ctx))
+	}
+	require.Len(t, fakes.issues.Edits[1], 2)
+
+	stored, err := repo.GetIssue(ctx, 1)
+	require.Nil(t, err)
+	require.Equal(t, "Flaky TestLogin", stored.Title)
+	require.Equal(t, "closed", stored.State)
+	require.Equal(t, []string{"bob"}, stored.Assignees)
+	require.Nil(t, stored.Reopen(ctx))
+	require.True(t, stored.IsOpen())
+
+	// Marked comments are updated in place
+	id, err := issue.UpdateOrCreateComment(ctx, "triage", "Needs triage")
+	require.Nil(t, err)
+	updated, err := issue.UpdateOrCreateComment(ctx, "triage", "Triaged")
+	require.Nil(t, err)
+	require.Equal(t, id, updated)
+	other, err := issue.Comment(ctx, "Thanks!")
+	require.Nil(t, err)
+	require.NotEqual(t, id, other)
+	require.Len(t, fakes.issues.Comments[1], 2)
+
+	// Nothing is changed in dry-run mode
+	gau.options.DryRun = true
+	require.Nil(t, stored.AddAssignees(ctx, "carol"))
+	require.Equal(t, []string{"bob", "carol"}, stored.Assignees)
+	require.Nil(t, stored.Close(ctx))
+	require.Len(t, fakes.issues.Edits[1], 3)
+}
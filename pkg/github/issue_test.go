@@ -0,0 +1,32 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIssueReferences(t *testing.T) {
+	for _, tc := range []struct {
+		text     string
+		expected []IssueReference
+	}{
+		{"No references here, see #12", []IssueReference{}},
+		{"Fixes #123", []IssueReference{{"mattermost", "mattermost-server", 123}}},
+		{"closes: #1 and RESOLVED #2", []IssueReference{
+			{"mattermost", "mattermost-server", 1}, {"mattermost", "mattermost-server", 2},
+		}},
+		{"Closes mattermost/focalboard#456", []IssueReference{{"mattermost", "focalboard", 456}}},
+		{"fixed https://github.com/mattermost/mattermost-webapp/issues/7", []IssueReference{
+			{"mattermost", "mattermost-webapp", 7},
+		}},
+		{"Fix #5\nfix #5", []IssueReference{{"mattermost", "mattermost-server", 5}}},
+		{"This fixes 2 bugs, prefixes #3 and fixes mattermost/repo12", []IssueReference{}},
+	} {
+		require.Equal(t, tc.expected, ParseIssueReferences(tc.text, "mattermost", "mattermost-server"), tc.text)
+	}
+	require.Equal(t, "mattermost/focalboard#456", IssueReference{"mattermost", "focalboard", 456}.String())
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+)
+
+// getLabels reads the labels of the pull request from the API
+func (impl *defaultPRImplementation) getLabels(ctx context.Context, pr *PullRequest) ([]string, error) {
+	labels := []string{}
+	opts := &gogithub.ListOptions{}
+	for {
+		var ghLabels []*gogithub.Label
+		var resp *gogithub.Response
+		err := impl.doWithRetry(ctx, "issues.ListLabelsByIssue", func() (_ *gogithub.Response, err error) {
+			ghLabels, resp, err = impl.GitHubClient().Issues.ListLabelsByIssue(
+				ctx, pr.RepoOwner, pr.RepoName, pr.Number, opts,
+			)
+			return resp, err
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "listing labels of PR #%d", pr.Number)
+		}
+		labels = append(labels, labelNames(ghLabels)...)
+
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return labels, nil
+}
+
+// addLabels adds the labels to the pull request and returns
+// the label set after the change
+func (impl *defaultPRImplementation) addLabels(
+	ctx context.Context, pr *PullRequest, labels []string,
+) ([]string, error) {
+	if impl.getOptions().DryRun {
+		impl.log(pr).Infof("[dry-run] Would add labels %v to PR #%d", labels, pr.Number)
+		return appendMissing(pr.Labels, labels), nil
+	}
+
+	var ghLabels []*gogithub.Label
+	err := impl.doWithRetry(ctx, "issues.AddLabelsToIssue", func() (resp *gogithub.Response, err error) {
+		ghLabels, resp, err = impl.GitHubClient().Issues.AddLabelsToIssue(
+			ctx, pr.RepoOwner, pr.RepoName, pr.Number, labels,
+		)
+		return resp, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	impl.log(pr).Infof("Added labels %v to PR #%d", labels, pr.Number)
+	return labelNames(ghLabels), nil
+}
+
+// removeLabel removes a label from the pull request. GitHub returns
+// a 404 when the label is not set, we ignore it.
+func (impl *defaultPRImplementation) removeLabel(ctx context.Context, pr *PullRequest, label string) error {
+	if impl.getOptions().DryRun {
+		impl.log(pr).Infof("[dry-run] Would remove label %s from PR #%d", label, pr.Number)
+		return nil
+	}
+
+	err := impl.doWithRetry(ctx, "issues.RemoveLabelForIssue", func() (resp *gogithub.Response, err error) {
+		resp, err = impl.GitHubClient().Issues.RemoveLabelForIssue(
+			ctx, pr.RepoOwner, pr.RepoName, pr.Number, label,
+		)
+		return resp, err
+	})
+	if err != nil {
+		if isNotFound(err) {
+			impl.log(pr).Infof("PR #%d does not have label %s", pr.Number, label)
+			return nil
+		}
+		return err
+	}
+	impl.log(pr).Infof("Removed label %s from PR #%d", label, pr.Number)
+	return nil
+}
+
+// addedLabels returns the labels in current plus the ones
+// in labels that are not there yet
+func appendMissing(current, labels []string) []string {
+	result := append([]string{}, current...)
+	for _, label := range labels {
+		found := false
+		for _, l := range result {
+			if l == label {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, label)
+		}
+	}
+	return result
+}
+
+// labelNames returns the names of a list of labels
+func labelNames(ghLabels []*gogithub.Label) []string {
+	names := []string{}
+	for _, label := range ghLabels {
+		names = append(names, label.GetName())
+	}
+	return names
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPullRequestLabels(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	impl := &defaultPRImplementation{githubAPIUser: gau}
+	pr := &PullRequest{impl: impl, RepoOwner: "mattermost", RepoName: "mattermost-server", Number: 1}
+	ctx := context.Background()
+	fakes.issues.Labels = map[int][]string{1: {"CherryPick/Approved"}}
+
+	// Labels are read once and then served from the PR
+	has, err := pr.HasLabel(ctx, "CherryPick/Approved")
+	require.Nil(t, err)
+	require.True(t, has)
+	has, err = pr.HasLabel(ctx, "CherryPick/Done")
+	require.Nil(t, err)
+	require.False(t, has)
+	require.Equal(t, 1, fakes.issues.LabelCalls)
+
+	// Adding and removing keeps the PR in sync without reading again
+	require.Nil(t, pr.AddLabels(ctx, "CherryPick/Done"))
+	require.Nil(t, pr.RemoveLabel(ctx, "CherryPick/Approved"))
+	require.Equal(t, []string{"CherryPick/Done"}, fakes.issues.Labels[1])
+	require.Equal(t, []string{"CherryPick/Done"}, pr.Labels)
+	has, err = pr.HasLabel(ctx, "CherryPick/Done")
+	require.Nil(t, err)
+	require.True(t, has)
+	require.Equal(t, 1, fakes.issues.LabelCalls)
+
+	// Removing a label the PR does not have is not an error
+	require.Nil(t, pr.RemoveLabel(ctx, "CherryPick/Approved"))
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import "github.com/sirupsen/logrus"
+
+// Logger receives the log messages of the package. Messages about a
+// pull request carry its repository and number as fields. Loggers of
+// logrus can be used through NewLogrusLogger.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+
+	// WithFields returns a logger adding the fields to its messages
+	WithFields(fields map[string]interface{}) Logger
+}
+
+// logrusLogger adapts a logrus logger to the Logger interface
+type logrusLogger struct {
+	logger logrus.FieldLogger
+}
+
+// NewLogrusLogger returns a Logger writing to a logrus logger
+func NewLogrusLogger(logger logrus.FieldLogger) Logger {
+	return &logrusLogger{logger: logger}
+}
+
+func (ll *logrusLogger) Debugf(format string, args ...interface{}) {
+	ll.logger.Debugf(format, args...)
+}
+
+func (ll *logrusLogger) Infof(format string, args ...interface{}) {
+	ll.logger.Infof(format, args...)
+}
+
+func (ll *logrusLogger) Warnf(format string, args ...interface{}) {
+	ll.logger.Warnf(format, args...)
+}
+
+func (ll *logrusLogger) Errorf(format string, args ...interface{}) {
+	ll.logger.Errorf(format, args...)
+}
+
+func (ll *logrusLogger) WithFields(fields map[string]interface{}) Logger {
+	return &logrusLogger{logger: ll.logger.WithFields(fields)}
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+)
+
+// isOrgMember checks if a user is a member of an organization. The
+// result is kept in the membership cache when there is one.
+func (gau *githubAPIUser) isOrgMember(ctx context.Context, org, login string) (bool, error) {
+	cache := gau.getOptions().MembershipCache
+	if cache != nil {
+		if member, ok := cache.IsMember(org, login); ok {
+			return member, nil
+		}
+	}
+
+	var member bool
+	err := gau.doWithRetry(ctx, "organizations.IsMember", func() (resp *gogithub.Response, err error) {
+		member, resp, err = gau.GitHubClient().Organizations.IsMember(ctx, org, login)
+		return resp, err
+	})
+	if err != nil {
+		return false, errors.Wrapf(err, "checking if %s is a member of %s", login, org)
+	}
+	if cache != nil {
+		cache.SetMember(org, login, member)
+	}
+	return member, nil
+}
+
+// isActiveTeamMember checks if a user is an active member of a team.
+// Pending invitations do not count.
+func (gau *githubAPIUser) isActiveTeamMember(ctx context.Context, org, slug, login string) (bool, error) {
+	cache := gau.getOptions().MembershipCache
+	if cache != nil {
+		if member, ok := cache.IsMember(org+"/"+slug, login); ok {
+			return member, nil
+		}
+	}
+
+	var membership *gogithub.Membership
+	err := gau.doWithRetry(ctx, "teams.GetTeamMembershipBySlug", func() (resp *gogithub.Response, err error) {
+		membership, resp, err = gau.GitHubClient().Teams.GetTeamMembershipBySlug(ctx, org, slug, login)
+		return resp, err
+	})
+	if err != nil && !isNotFound(err) {
+		return false, errors.Wrapf(err, "reading membership of %s in %s/%s", login, org, slug)
+	}
+	member := err == nil && membership.GetState() == "active"
+	if cache != nil {
+		cache.SetMember(org+"/"+slug, login, member)
+	}
+	return member, nil
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMembership(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	gau.options.MembershipCache = NewMembershipCache(time.Hour)
+	fakes.orgs.Members["mattermost"] = []string{"alice", "bob"}
+	fakes.teams.Members["mattermost/core"] = []string{"alice"}
+	gh := &GitHub{impl: &defaultGithubImplementation{githubAPIUser: gau}, options: gau.options}
+	ctx := context.Background()
+
+	// Repeated checks are served from the cache
+	for i := 0; i < 3; i++ {
+		member, err := gh.IsOrgMember(ctx, "mattermost", "Alice")
+		require.Nil(t, err)
+		require.True(t, member)
+		member, err = gh.IsOrgMember(ctx, "mattermost", "mallory")
+		require.Nil(t, err)
+		require.False(t, member)
+	}
+	require.Equal(t, 2, fakes.orgs.Calls)
+
+	member, err := gh.IsTeamMember(ctx, "mattermost", "core", "alice")
+	require.Nil(t, err)
+	require.True(t, member)
+	member, err = gh.IsTeamMember(ctx, "mattermost", "core", "bob")
+	require.Nil(t, err)
+	require.False(t, member)
+	require.Equal(t, 2, fakes.teams.Calls)
+
+	// A cached member list answers membership checks too
+	members, err := gh.ListTeamMembers(ctx, "mattermost", "core")
+	require.Nil(t, err)
+	require.Equal(t, []string{"alice"}, members)
+	_, err = gh.ListTeamMembers(ctx, "mattermost", "core")
+	require.Nil(t, err)
+	gau.options.MembershipCache.Flush()
+	_, err = gh.ListTeamMembers(ctx, "mattermost", "core")
+	require.Nil(t, err)
+	member, err = gh.IsTeamMember(ctx, "mattermost", "core", "bob")
+	require.Nil(t, err)
+	require.False(t, member)
+	require.Equal(t, 4, fakes.teams.Calls)
+
+	// Unknown teams are an error when listing
+	_, err = gh.ListTeamMembers(ctx, "mattermost", "nobody")
+	require.NotNil(t, err)
+
+	// Without a cache, every check hits the API
+	gau.options.MembershipCache = nil
+	for i := 0; i < 2; i++ {
+		_, err = gh.IsOrgMember(ctx, "mattermost", "alice")
+		require.Nil(t, err)
+	}
+	require.Equal(t, 4, fakes.orgs.Calls)
+
+	_, err = gh.IsOrgMember(ctx, "", "alice")
+	require.NotNil(t, err)
+}
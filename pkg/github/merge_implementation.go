@@ -0,0 +1,63 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"net/http"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+)
+
+// mergeMethods maps the merge modes to the REST API merge methods
+var mergeMethods = map[MergeMode]string{
+	MergeModeMerge:  "merge",
+	MergeModeSquash: "squash",
+	MergeModeRebase: "rebase",
+}
+
+// merge merges the pull request and returns the SHA of the merge commit
+func (impl *defaultPRImplementation) merge(
+	ctx context.Context, pr *PullRequest, mode MergeMode, commitTitle, commitMessage string,
+) (string, error) {
+	method, ok := mergeMethods[mode]
+	if !ok {
+		return "", errors.Errorf("merge mode %s cannot be used to merge a pull request", mode)
+	}
+
+	if impl.getOptions().DryRun {
+		impl.log(pr).Infof("[dry-run] Would merge PR #%d (%s)", pr.Number, mode)
+		return "", nil
+	}
+
+	opts := &gogithub.PullRequestOptions{
+		CommitTitle: commitTitle,
+		SHA:         pr.Sha,
+		MergeMethod: method,
+	}
+	var result *gogithub.PullRequestMergeResult
+	err := impl.doWithRetry(ctx, "pulls.Merge", func() (resp *gogithub.Response, err error) {
+		result, resp, err = impl.GitHubClient().PullRequests.Merge(
+			ctx, pr.RepoOwner, pr.RepoName, pr.Number, commitMessage, opts,
+		)
+		return resp, err
+	})
+	if err != nil {
+		responseErr := &gogithub.ErrorResponse{}
+		if errors.As(err, &responseErr) && responseErr.Response != nil {
+			switch responseErr.Response.StatusCode {
+			case http.StatusMethodNotAllowed, http.StatusConflict:
+				return "", &MergeError{
+					Number:  pr.Number,
+					Status:  responseErr.Response.StatusCode,
+					Message: responseErr.Message,
+				}
+			}
+		}
+		return "", errors.Wrapf(err, "merging PR #%d", pr.Number)
+	}
+	impl.log(pr).Infof("Merged PR #%d (%s) as %s", pr.Number, mode, result.GetSHA())
+	return result.GetSHA(), nil
+}
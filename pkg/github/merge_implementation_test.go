@@ -0,0 +1,71 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"testing"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerge(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	newPR := func() *PullRequest {
+		return &PullRequest{
+			impl:      &defaultPRImplementation{githubAPIUser: gau},
+			RepoOwner: "mattermost",
+			RepoName:  "mattermost-server",
+			Number:    1,
+			Sha:       "head",
+			State:     "open",
+		}
+	}
+	fakes.pulls.PullRequests[1] = &gogithub.PullRequest{
+		Number:    gogithub.Int(1),
+		Head:      &gogithub.PullRequestBranch{SHA: gogithub.String("head")},
+		Mergeable: gogithub.Bool(false),
+	}
+
+	// Unsupported merge modes are refused before calling the API
+	pr := newPR()
+	_, err := pr.Merge(context.Background(), MergeModeQueue, "", "")
+	require.NotNil(t, err)
+	require.Empty(t, fakes.pulls.Merges)
+
+	// 405: not mergeable
+	_, err = pr.Merge(context.Background(), MergeModeSquash, "", "")
+	require.True(t, errors.Is(err, ErrNotMergeable))
+	mergeErr := &MergeError{}
+	require.True(t, errors.As(err, &mergeErr))
+	require.Equal(t, "Pull Request is not mergeable", mergeErr.Message)
+	require.False(t, pr.IsMerged())
+
+	// 409: the head moved since the PR was read
+	fakes.pulls.PullRequests[1].Mergeable = nil
+	pr.Sha = "old"
+	_, err = pr.Merge(context.Background(), MergeModeSquash, "", "")
+	require.True(t, errors.Is(err, ErrHeadModified))
+	require.False(t, errors.Is(err, ErrNotMergeable))
+
+	// Dry-run does not merge
+	pr = newPR()
+	gau.options.DryRun = true
+	sha, err := pr.Merge(context.Background(), MergeModeSquash, "", "")
+	require.Nil(t, err)
+	require.Empty(t, sha)
+	require.Len(t, fakes.pulls.Merges, 2)
+	gau.options.DryRun = false
+
+	sha, err = pr.Merge(context.Background(), MergeModeSquash, "Title (#1)", "Body")
+	require.Nil(t, err)
+	require.Equal(t, "merge-1", sha)
+	require.True(t, pr.IsMerged())
+	require.Equal(t, "merge-1", pr.MergeCommitSHA)
+	call := fakes.pulls.Merges[2]
+	require.Equal(t, "Body", call.CommitMessage)
+	require.Equal(t, &gogithub.PullRequestOptions{CommitTitle: "Title (#1)", SHA: "head", MergeMethod: "squash"}, call.Options)
+}
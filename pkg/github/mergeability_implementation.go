@@ -0,0 +1,75 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"time"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+)
+
+// waitForMergeability reads the pull request until GitHub finishes
+// computing whether it can be merged or the timeout expires. The polls
+// back off like retried API calls do.
+func (impl *defaultPRImplementation) waitForMergeability(
+	ctx context.Context, pr *PullRequest, timeout time.Duration,
+) (Mergeability, error) {
+	opts := impl.getOptions().Retry
+	backoff := opts.InitialBackoff
+	deadline := time.Now().Add(timeout)
+	for {
+		var ghpr *gogithub.PullRequest
+		err := impl.doWithRetry(ctx, "pulls.Get", func() (resp *gogithub.Response, err error) {
+			ghpr, resp, err = impl.GitHubClient().PullRequests.Get(ctx, pr.RepoOwner, pr.RepoName, pr.Number)
+			return resp, err
+		})
+		if err != nil {
+			return MergeabilityUnknown, errors.Wrapf(err, "reading PR #%d", pr.Number)
+		}
+		if mergeability, ok := mergeabilityFromAPI(ghpr); ok {
+			return mergeability, nil
+		}
+
+		wait := jitter(backoff, opts.Jitter)
+		if time.Now().Add(wait).After(deadline) {
+			impl.log(pr).Warnf("GitHub did not compute the mergeability of PR #%d in %s", pr.Number, timeout)
+			return MergeabilityUnknown, nil
+		}
+		impl.log(pr).Debugf("Mergeability of PR #%d not computed yet, checking again in %s", pr.Number, wait)
+		select {
+		case <-ctx.Done():
+			return MergeabilityUnknown, errors.Wrap(ctx.Err(), "waiting for mergeability")
+		case <-time.After(wait):
+		}
+
+		backoff *= 2
+		if backoff > opts.MaxBackoff {
+			backoff = opts.MaxBackoff
+		}
+	}
+}
+
+// mergeabilityFromAPI translates the mergeable state reported by the
+// API. It returns false while GitHub is still computing it.
+func mergeabilityFromAPI(ghpr *gogithub.PullRequest) (Mergeability, bool) {
+	if ghpr.Mergeable == nil {
+		return MergeabilityUnknown, false
+	}
+	switch ghpr.GetMergeableState() {
+	case "unknown", "":
+		return MergeabilityUnknown, false
+	case "dirty":
+		return MergeabilityDirty, true
+	case "blocked", "behind", "draft":
+		return MergeabilityBlocked, true
+	case "clean", "unstable", "has_hooks":
+		return MergeabilityClean, true
+	}
+	if ghpr.GetMergeable() {
+		return MergeabilityClean, true
+	}
+	return MergeabilityDirty, true
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaitForMergeability(t *testing.T) {
+	calls := 0
+	pending := 2
+	state := "dirty"
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/mattermost/mattermost-server/pulls/1", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls <= pending {
+			fmt.Fprint(w, `{"number": 1, "mergeable": null, "mergeable_state": "unknown"}`)
+			return
+		}
+		fmt.Fprintf(w, `{"number": 1, "mergeable": %t, "mergeable_state": %q}`, state != "dirty", state)
+	})
+	gau := newTestAPIUser(t, mux)
+	gau.options.Retry = RetryOptions{MaxAttempts: 1, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+	pr := &PullRequest{
+		impl:      &defaultPRImplementation{githubAPIUser: gau},
+		RepoOwner: "mattermost",
+		RepoName:  "mattermost-server",
+		Number:    1,
+	}
+
+	// GitHub is polled until the state is computed
+	mergeability, err := pr.WaitForMergeability(context.Background(), time.Minute)
+	require.Nil(t, err)
+	require.Equal(t, MergeabilityDirty, mergeability)
+	require.Equal(t, 3, calls)
+
+	for apiState, expected := range map[string]Mergeability{
+		"clean":    MergeabilityClean,
+		"unstable": MergeabilityClean,
+		"blocked":  MergeabilityBlocked,
+		"behind":   MergeabilityBlocked,
+	} {
+		calls, pending, state = 0, 0, apiState
+		mergeability, err := pr.WaitForMergeability(context.Background(), time.Minute)
+		require.Nil(t, err)
+		require.Equal(t, expected, mergeability, apiState)
+	}
+
+	// Unknown when it takes too long
+	calls, pending = 0, 100
+	mergeability, err = pr.WaitForMergeability(context.Background(), 0)
+	require.Nil(t, err)
+	require.Equal(t, MergeabilityUnknown, mergeability)
+	require.Equal(t, "unknown", mergeability.String())
+	require.Equal(t, 1, calls)
+}
+
+func TestMergeabilityFromAPI(t *testing.T) {
+	// Null mergeable means GitHub is still computing it
+	_, ok := mergeabilityFromAPI(&gogithub.PullRequest{MergeableState: gogithub.String("clean")})
+	require.False(t, ok)
+
+	// States not known to the package fall back to the mergeable flag
+	mergeability, ok := mergeabilityFromAPI(&gogithub.PullRequest{
+		Mergeable: gogithub.Bool(false), MergeableState: gogithub.String("new_state"),
+	})
+	require.True(t, ok)
+	require.Equal(t, MergeabilityDirty, mergeability)
+}
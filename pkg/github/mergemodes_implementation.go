@@ -0,0 +1,170 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+)
+
+// mergeModesBatchSize is the number of pull requests queried at once
+const mergeModesBatchSize = 50
+
+// mergeModesPRFields are the fields of each pull request needed to
+// classify its merge mode: the merge commit with its parents and the
+// last commit of the PR along with the total number of commits.
+const mergeModesPRFields = `number
+      merged
+      baseRefName
+      mergeCommit { oid tree { oid } parents(first: 10) { nodes { oid } } }
+      commits(last: 1) { totalCount nodes { commit { oid tree { oid } } } }`
+
+// gqlCommit is a commit as returned by the GraphQL queries. The
+// message and identities are only set when the query asks for them.
+type gqlCommit struct {
+	OID     string `json:"oid"`
+	Message string `json:"message"`
+	Tree    struct {
+		OID string `json:"oid"`
+	} `json:"tree"`
+	Parents struct {
+		Nodes []struct {
+			OID string `json:"oid"`
+		} `json:"nodes"`
+	} `json:"parents"`
+	Author    *gqlCommitAuthor `json:"author"`
+	Committer *gqlCommitAuthor `json:"committer"`
+}
+
+// gqlCommitAuthor is the identity recorded in a commit
+type gqlCommitAuthor struct {
+	Name  string    `json:"name"`
+	Email string    `json:"email"`
+	Date  time.Time `json:"date"`
+}
+
+// commit returns the commit data in a Commit
+func (gc *gqlCommit) commit() *Commit {
+	c := &Commit{SHA: gc.OID, TreeSHA: gc.Tree.OID, Message: gc.Message, Parents: []*Commit{}}
+	for _, parent := range gc.Parents.Nodes {
+		c.Parents = append(c.Parents, &Commit{SHA: parent.OID})
+	}
+	if gc.Author != nil {
+		c.Author = &CommitAuthor{Name: gc.Author.Name, Email: gc.Author.Email, Date: gc.Author.Date}
+	}
+	if gc.Committer != nil {
+		c.Committer = &CommitAuthor{Name: gc.Committer.Name, Email: gc.Committer.Email, Date: gc.Committer.Date}
+	}
+	return c
+}
+
+// gqlMergeModePR holds the data queried to classify a pull request
+type gqlMergeModePR struct {
+	Number      int        `json:"number"`
+	Merged      bool       `json:"merged"`
+	BaseRefName string     `json:"baseRefName"`
+	MergeCommit *gqlCommit `json:"mergeCommit"`
+	Commits     struct {
+		TotalCount int `json:"totalCount"`
+		Nodes      []struct {
+			Commit gqlCommit `json:"commit"`
+		} `json:"nodes"`
+	} `json:"commits"`
+}
+
+// mergeModesQuery builds a query reading the data of a
+// batch of pull requests, each one under its own alias
+func mergeModesQuery(numbers []int) string {
+	var sb strings.Builder
+	sb.WriteString("query($owner: String!, $name: String!) {\n  repository(owner: $owner, name: $name) {\n")
+	for _, number := range numbers {
+		fmt.Fprintf(&sb, "    pr%d: pullRequest(number: %d) {\n      %s\n    }\n", number, number, mergeModesPRFields)
+	}
+	sb.WriteString("  }\n}")
+	return sb.String()
+}
+
+// getMergeModes classifies the merge mode of the pull requests reading
+// their commits with GraphQL, in batches. The data is then passed to the
+// same logic used for single pull requests, no REST calls are made.
+// Pull requests not merged are reported as MergeModeUnknown.
+func (di *defaultRepoImplementation) getMergeModes(
+	ctx context.Context, owner, repo string, numbers []int,
+) (map[int]MergeMode, error) {
+	// Aliases must be unique in the query
+	seen := map[int]bool{}
+	unique := []int{}
+	for _, number := range numbers {
+		if !seen[number] {
+			seen[number] = true
+			unique = append(unique, number)
+		}
+	}
+	numbers = unique
+
+	modes := map[int]MergeMode{}
+	for start := 0; start < len(numbers); start += mergeModesBatchSize {
+		end := start + mergeModesBatchSize
+		if end > len(numbers) {
+			end = len(numbers)
+		}
+
+		result := struct {
+			Repository map[string]*gqlMergeModePR `json:"repository"`
+		}{}
+		err := di.doWithRetry(ctx, "graphql.Do", func() (*gogithub.Response, error) {
+			return di.GitHubClient().GraphQL.Do(ctx, mergeModesQuery(numbers[start:end]), map[string]interface{}{
+				"owner": owner,
+				"name":  repo,
+			}, &result)
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "querying commits of %d pull requests", end-start)
+		}
+
+		for _, data := range result.Repository {
+			if data == nil {
+				continue
+			}
+			mode, err := di.classifyMergeMode(ctx, owner, repo, data)
+			if err != nil {
+				return nil, errors.Wrapf(err, "classifying PR #%d", data.Number)
+			}
+			modes[data.Number] = mode
+		}
+	}
+	return modes, nil
+}
+
+// classifyMergeMode runs the merge mode detection on the data read
+// with GraphQL. The PR is preloaded with it so no API calls are needed.
+func (di *defaultRepoImplementation) classifyMergeMode(
+	ctx context.Context, owner, repo string, data *gqlMergeModePR,
+) (MergeMode, error) {
+	if !data.Merged || data.MergeCommit == nil || len(data.Commits.Nodes) == 0 {
+		return MergeModeUnknown, nil
+	}
+
+	impl := &defaultPRImplementation{githubAPIUser: di.githubAPIUser, logger: di.getLogger()}
+	merged := true
+	lastCommit := data.Commits.Nodes[len(data.Commits.Nodes)-1].Commit.commit()
+	pr := &PullRequest{
+		impl:           impl,
+		RepoOwner:      owner,
+		RepoName:       repo,
+		Number:         data.Number,
+		BaseRef:        data.BaseRefName,
+		Merged:         &merged,
+		MergeCommitSHA: data.MergeCommit.OID,
+		mergeCommit:    data.MergeCommit.commit(),
+		lastCommit:     lastCommit,
+		commitCount:    data.Commits.TotalCount,
+	}
+	return impl.getMergeMode(ctx, pr, lastCommit, data.Commits.TotalCount)
+}
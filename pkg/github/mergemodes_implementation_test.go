@@ -0,0 +1,88 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"testing"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/stretchr/testify/require"
+)
+
+// testGQLPR returns the GraphQL data of a merged pull request
+func testGQLPR(number int, mergeSHA, mergeTree string, parents []string, lastSHA, lastTree string, total int) map[string]interface{} {
+	parentNodes := []map[string]string{}
+	for _, p := range parents {
+		parentNodes = append(parentNodes, map[string]string{"oid": p})
+	}
+	return map[string]interface{}{
+		"number": number,
+		"merged": true,
+		"mergeCommit": map[string]interface{}{
+			"oid": mergeSHA, "tree": map[string]string{"oid": mergeTree},
+			"parents": map[string]interface{}{"nodes": parentNodes},
+		},
+		"commits": map[string]interface{}{
+			"totalCount": total,
+			"nodes": []map[string]interface{}{
+				{"commit": map[string]interface{}{"oid": lastSHA, "tree": map[string]string{"oid": lastTree}}},
+			},
+		},
+	}
+}
+
+func TestGetMergeModes(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	prs := map[int]map[string]interface{}{
+		1: testGQLPR(1, "merge", "tree-2", []string{"main", "pr-2"}, "pr-2", "tree-2", 2),
+		2: testGQLPR(2, "squashed", "tree-3", []string{"main"}, "pr-2", "tree-2", 2),
+		3: testGQLPR(3, "rebased", "tree-2", []string{"rebased-1"}, "pr-2", "tree-2", 2),
+		4: testGQLPR(4, "queued", "tree-3", []string{"main", "gh-readonly-queue"}, "pr-2", "tree-2", 2),
+		5: {"number": 5, "merged": false, "mergeCommit": nil},
+	}
+	aliases := regexp.MustCompile(`pr(\d+): pullRequest`)
+	fakes.graphql.Stub = func(query string, variables map[string]interface{}) (string, error) {
+		repo := map[string]interface{}{}
+		for _, match := range aliases.FindAllStringSubmatch(query, -1) {
+			number, _ := strconv.Atoi(match[1])
+			data, ok := prs[number]
+			if !ok {
+				data = testGQLPR(number, "squashed", "tree-3", []string{"main"}, "pr-2", "tree-2", 2)
+			}
+			repo["pr"+match[1]] = data
+		}
+		data, err := json.Marshal(map[string]interface{}{"repository": repo})
+		return string(data), err
+	}
+
+	repo := gau.NewRepository(&gogithub.Repository{
+		Name: gogithub.String("mattermost-server"), Owner: &gogithub.User{Login: gogithub.String("mattermost")},
+	})
+	modes, err := repo.GetMergeModes(context.Background(), []int{1, 2, 3, 4, 5, 1})
+	require.Nil(t, err)
+	require.Equal(t, map[int]MergeMode{
+		1: MergeModeMerge, 2: MergeModeSquash, 3: MergeModeRebase, 4: MergeModeQueue, 5: MergeModeUnknown,
+	}, modes)
+
+	// A single query was sent and no REST calls were made
+	require.Len(t, fakes.graphql.Calls, 1)
+	require.Equal(t, "mattermost", fakes.graphql.Calls[0].Variables["owner"])
+	require.Empty(t, fakes.repos.CommitCalls)
+	require.Empty(t, fakes.pulls.CommitsPages)
+
+	// Large lists are split in batches
+	numbers := []int{}
+	for i := 100; i < 100+mergeModesBatchSize+10; i++ {
+		numbers = append(numbers, i)
+	}
+	modes, err = repo.GetMergeModes(context.Background(), numbers)
+	require.Nil(t, err)
+	require.Len(t, modes, len(numbers))
+	require.Len(t, fakes.graphql.Calls, 3)
+	require.Equal(t, MergeModeSquash, modes[100])
+}
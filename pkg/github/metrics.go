@@ -0,0 +1,66 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Outcomes of a cherry-pick reported to IncCherryPick
+const (
+	CherryPickSuccess  = "success"  // The changes were recorded in the cherry-pick branch
+	CherryPickConflict = "conflict" // The changes did not apply on the target branch
+	CherryPickFailure  = "failure"  // The cherry-pick failed for any other reason
+)
+
+// Metrics receives measurements of the work done by the package. Set
+// an implementation in the options to export them, for example to
+// Prometheus counters and histograms. Implementations must be safe for
+// concurrent use.
+type Metrics interface {
+	// ObserveAPICall is called after each attempt to call the GitHub API.
+	// The endpoint is the service and method called, eg "pulls.Get".
+	ObserveAPICall(endpoint string, dur time.Duration, err error)
+
+	// IncMergeMode counts the merge modes detected in pull requests
+	IncMergeMode(mode MergeMode)
+
+	// IncCherryPick counts cherry-picks to a branch by their outcome
+	IncCherryPick(result string)
+
+	// ObserveCherryPickDuration records how long a cherry-pick to a branch took
+	ObserveCherryPickDuration(dur time.Duration)
+}
+
+// noopMetrics discards all measurements
+type noopMetrics struct{}
+
+func (noopMetrics) ObserveAPICall(string, time.Duration, error) {}
+func (noopMetrics) IncMergeMode(MergeMode)                      {}
+func (noopMetrics) IncCherryPick(string)                        {}
+func (noopMetrics) ObserveCherryPickDuration(time.Duration)     {}
+
+// getMetrics returns the metrics set in the options or
+// an implementation discarding them if there are none
+func (gau *githubAPIUser) getMetrics() Metrics {
+	if gau.getOptions().Metrics != nil {
+		return gau.getOptions().Metrics
+	}
+	return noopMetrics{}
+}
+
+// cherryPickOutcome returns the outcome reported to the metrics
+// for a cherry-pick that ended with err
+func cherryPickOutcome(err error) string {
+	switch {
+	case err == nil:
+		return CherryPickSuccess
+	case errors.Is(err, ErrCherryPickConflict):
+		return CherryPickConflict
+	default:
+		return CherryPickFailure
+	}
+}
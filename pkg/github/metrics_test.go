@@ -0,0 +1,106 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// promMetrics shows how the metrics map to Prometheus collectors. A real
+// adapter would hold these vectors from the client_golang library:
+//
+//	apiCalls    *prometheus.HistogramVec // labels: endpoint, error
+//	mergeModes  *prometheus.CounterVec   // labels: mode
+//	cherryPicks *prometheus.CounterVec   // labels: result
+//	pickTime    prometheus.Histogram
+//
+// and call WithLabelValues(...).Observe() or Inc() on them. Here the
+// samples are kept in maps keyed by the label values.
+type promMetrics struct {
+	mtx         sync.Mutex
+	apiCalls    map[string]int
+	mergeModes  map[string]int
+	cherryPicks map[string]int
+	pickTime    []time.Duration
+}
+
+func newPromMetrics() *promMetrics {
+	return &promMetrics{
+		apiCalls:    map[string]int{},
+		mergeModes:  map[string]int{},
+		cherryPicks: map[string]int{},
+	}
+}
+
+func (m *promMetrics) ObserveAPICall(endpoint string, dur time.Duration, err error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	label := "false"
+	if err != nil {
+		label = "true"
+	}
+	m.apiCalls[endpoint+","+label]++
+}
+
+func (m *promMetrics) IncMergeMode(mode MergeMode) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.mergeModes[mode.String()]++
+}
+
+func (m *promMetrics) IncCherryPick(result string) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.cherryPicks[result]++
+}
+
+func (m *promMetrics) ObserveCherryPickDuration(dur time.Duration) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.pickTime = append(m.pickTime, dur)
+}
+
+func TestMetrics(t *testing.T) {
+	metrics := newPromMetrics()
+	gau, fakes := newFakeAPIUser()
+	gau.options.Metrics = metrics
+	pr := &PullRequest{
+		impl:           &defaultPRImplementation{githubAPIUser: gau},
+		RepoOwner:      "mattermost",
+		RepoName:       "mattermost-server",
+		Number:         1,
+		MergeCommitSHA: "merge",
+	}
+	fakes.pulls.Commits[1] = []*gogithub.RepositoryCommit{
+		fakes.addCommit("pr-1", "tree-1"), fakes.addCommit("pr-2", "tree-2"),
+	}
+	fakes.addCommit("merge", "tree-2", "main", "pr-2")
+
+	mode, err := pr.GetMergeMode(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, MergeModeMerge, mode)
+	require.Equal(t, map[string]int{"merge": 1}, metrics.mergeModes)
+	require.Equal(t, 1, metrics.apiCalls["pulls.ListCommits,false"])
+	require.Equal(t, 1, metrics.apiCalls["repos.GetCommit,false"])
+
+	// The missing branch fails the cherry-pick
+	_, _, err = pr.CherryPick(context.Background(), "release-7.1")
+	require.NotNil(t, err)
+	require.Equal(t, map[string]int{CherryPickFailure: 1}, metrics.cherryPicks)
+	require.Len(t, metrics.pickTime, 1)
+	require.Equal(t, 1, metrics.apiCalls["git.GetRef,true"])
+}
+
+func TestCherryPickOutcome(t *testing.T) {
+	require.Equal(t, CherryPickSuccess, cherryPickOutcome(nil))
+	require.Equal(t, CherryPickConflict, cherryPickOutcome(errors.Wrap(ErrCherryPickConflict, "applying")))
+	require.Equal(t, CherryPickFailure, cherryPickOutcome(ErrTargetBranchMissing))
+}
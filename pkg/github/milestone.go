@@ -0,0 +1,68 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// Milestone is a milestone of a repository
+type Milestone struct {
+	Number      int
+	Title       string
+	State       string // open or closed
+	Description string
+}
+
+var (
+	releaseBranchRegex    = regexp.MustCompile(`^release-(\d+)\.(\d+)$`)
+	releaseMilestoneRegex = regexp.MustCompile(`^v?(\d+)\.(\d+)\.\d+$`)
+	milestoneVersionExpr  = `^v?%s\.%s\.(\d+)$`
+)
+
+// MilestoneBranchResolver returns the release branch fixes for the
+// milestone are backported to. The second value is false when the
+// milestone is not a fix version.
+type MilestoneBranchResolver func(milestone string) (string, bool)
+
+// ReleaseBranchForMilestone is the default MilestoneBranchResolver,
+// the reverse of MatchReleaseMilestone: v7.8.1 maps to release-7.8.
+func ReleaseBranchForMilestone(milestone string) (string, bool) {
+	m := releaseMilestoneRegex.FindStringSubmatch(milestone)
+	if m == nil {
+		return "", false
+	}
+	return fmt.Sprintf("release-%s.%s", m[1], m[2]), true
+}
+
+// MatchReleaseMilestone picks the milestone for pull requests targeting
+// a release branch: release-7.8 matches v7.8.0, or the lowest v7.8.x
+// when the earlier patch milestones are already closed. The second
+// value is false if the branch is not a release branch or no milestone
+// matches.
+func MatchReleaseMilestone(branch string, titles []string) (string, bool) {
+	m := releaseBranchRegex.FindStringSubmatch(branch)
+	if m == nil {
+		return "", false
+	}
+	versionRegex := regexp.MustCompile(fmt.Sprintf(milestoneVersionExpr, m[1], m[2]))
+
+	match, lowest := "", -1
+	for _, title := range titles {
+		v := versionRegex.FindStringSubmatch(title)
+		if v == nil {
+			continue
+		}
+		patch, err := strconv.Atoi(v[1])
+		if err != nil {
+			continue
+		}
+		if lowest == -1 || patch < lowest {
+			match, lowest = title, patch
+		}
+	}
+	return match, match != ""
+}
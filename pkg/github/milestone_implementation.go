@@ -0,0 +1,180 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+)
+
+// getMilestone reads the title of the milestone of the pull request
+func (impl *defaultPRImplementation) getMilestone(ctx context.Context, pr *PullRequest) (string, error) {
+	var issue *gogithub.Issue
+	err := impl.doWithRetry(ctx, "issues.Get", func() (resp *gogithub.Response, err error) {
+		issue, resp, err = impl.GitHubClient().Issues.Get(ctx, pr.RepoOwner, pr.RepoName, pr.Number)
+		return resp, err
+	})
+	if err != nil {
+		return "", err
+	}
+	return issue.GetMilestone().GetTitle(), nil
+}
+
+// setMilestone assigns the milestone with the title to the pull
+// request and returns its number
+func (impl *defaultPRImplementation) setMilestone(
+	ctx context.Context, pr *PullRequest, title string,
+) (int, error) {
+	milestones, err := impl.listMilestones(ctx, pr.RepoOwner, pr.RepoName)
+	if err != nil {
+		return 0, errors.Wrap(err, "listing milestones")
+	}
+	number, ok := milestones[title]
+	if !ok {
+		return 0, errors.Wrapf(ErrMilestoneNotFound, "looking for %q in %s/%s", title, pr.RepoOwner, pr.RepoName)
+	}
+
+	if impl.getOptions().DryRun {
+		impl.log(pr).Infof("[dry-run] Would set milestone %s on PR #%d", title, pr.Number)
+		return number, nil
+	}
+
+	err = impl.doWithRetry(ctx, "issues.Edit", func() (resp *gogithub.Response, err error) {
+		_, resp, err = impl.GitHubClient().Issues.Edit(
+			ctx, pr.RepoOwner, pr.RepoName, pr.Number, &gogithub.IssueRequest{Milestone: gogithub.Int(number)},
+		)
+		return resp, err
+	})
+	if err != nil {
+		return 0, err
+	}
+	impl.log(pr).Infof("Set milestone %s on PR #%d", title, pr.Number)
+	return number, nil
+}
+
+// listMilestones returns the numbers of the open milestones of the
+// repository by title. The list is kept in the milestone cache.
+func (impl *defaultPRImplementation) listMilestones(ctx context.Context, owner, repo string) (map[string]int, error) {
+	cache := impl.getOptions().MilestoneCache
+	if cache != nil {
+		if milestones, ok := cache.Get(owner, repo); ok {
+			return milestones, nil
+		}
+	}
+
+	ghMilestones, err := impl.fetchMilestones(ctx, owner, repo, "open")
+	if err != nil {
+		return nil, err
+	}
+	milestones := map[string]int{}
+	for _, m := range ghMilestones {
+		milestones[m.GetTitle()] = m.GetNumber()
+	}
+
+	if cache != nil {
+		cache.Set(owner, repo, milestones)
+	}
+	return milestones, nil
+}
+
+// fetchMilestones reads all the milestones of the repository in a state
+func (gau *githubAPIUser) fetchMilestones(
+	ctx context.Context, owner, repo, state string,
+) ([]*gogithub.Milestone, error) {
+	milestones := []*gogithub.Milestone{}
+	opts := &gogithub.MilestoneListOptions{State: state, ListOptions: gogithub.ListOptions{PerPage: 100}}
+	for {
+		var ghMilestones []*gogithub.Milestone
+		var resp *gogithub.Response
+		err := gau.doWithRetry(ctx, "issues.ListMilestones", func() (_ *gogithub.Response, err error) {
+			ghMilestones, resp, err = gau.GitHubClient().Issues.ListMilestones(ctx, owner, repo, opts)
+			return resp, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		milestones = append(milestones, ghMilestones...)
+
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return milestones, nil
+}
+
+// findReleaseMilestone returns the open milestone matching the
+// release branch targeted by the pull request
+func (impl *defaultPRImplementation) findReleaseMilestone(ctx context.Context, pr *PullRequest) (string, error) {
+	milestones, err := impl.listMilestones(ctx, pr.RepoOwner, pr.RepoName)
+	if err != nil {
+		return "", errors.Wrap(err, "listing milestones")
+	}
+	titles := make([]string, 0, len(milestones))
+	for title := range milestones {
+		titles = append(titles, title)
+	}
+	title, ok := MatchReleaseMilestone(pr.BaseRef, titles)
+	if !ok {
+		return "", errors.Wrapf(
+			ErrMilestoneNotFound, "looking for the milestone of branch %s in %s/%s", pr.BaseRef, pr.RepoOwner, pr.RepoName,
+		)
+	}
+	return title, nil
+}
+
+// listMilestones returns the milestones of the repository in a state
+func (di *defaultRepoImplementation) listMilestones(
+	ctx context.Context, owner, repo, state string,
+) ([]*Milestone, error) {
+	ghMilestones, err := di.fetchMilestones(ctx, owner, repo, state)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing milestones of %s/%s", owner, repo)
+	}
+	milestones := make([]*Milestone, 0, len(ghMilestones))
+	for _, m := range ghMilestones {
+		milestones = append(milestones, newMilestone(m))
+	}
+	return milestones, nil
+}
+
+// createMilestone opens a new milestone in the repository. The cached
+// milestones of the repository are dropped so the new one can be found.
+func (di *defaultRepoImplementation) createMilestone(
+	ctx context.Context, owner, repo, title, description string,
+) (*Milestone, error) {
+	if di.getOptions().DryRun {
+		di.getLogger().Infof("[dry-run] Would create milestone %s in %s/%s", title, owner, repo)
+		return &Milestone{Title: title, State: "open", Description: description}, nil
+	}
+
+	request := &gogithub.Milestone{Title: gogithub.String(title)}
+	if description != "" {
+		request.Description = gogithub.String(description)
+	}
+	var created *gogithub.Milestone
+	err := di.doWithRetry(ctx, "issues.CreateMilestone", func() (resp *gogithub.Response, err error) {
+		created, resp, err = di.GitHubClient().Issues.CreateMilestone(ctx, owner, repo, request)
+		return resp, err
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating milestone %s in %s/%s", title, owner, repo)
+	}
+	if cache := di.getOptions().MilestoneCache; cache != nil {
+		cache.Delete(owner, repo)
+	}
+	di.getLogger().Infof("Created milestone %s (#%d) in %s/%s", title, created.GetNumber(), owner, repo)
+	return newMilestone(created), nil
+}
+
+func newMilestone(m *gogithub.Milestone) *Milestone {
+	return &Milestone{
+		Number:      m.GetNumber(),
+		Title:       m.GetTitle(),
+		State:       m.GetState(),
+		Description: m.GetDescription(),
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func testMilestone(number int, title, state string) *gogithub.Milestone {
+	return &gogithub.Milestone{
+		Number: gogithub.Int(number), Title: gogithub.String(title), State: gogithub.String(state),
+	}
+}
+
+func TestSetMilestone(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	gau.options.MilestoneCache = NewMilestoneCache(time.Minute)
+	impl := &defaultPRImplementation{githubAPIUser: gau}
+	fakes.issues.Milestones = []*gogithub.Milestone{
+		testMilestone(1, "v7.0.0", "closed"),
+		testMilestone(2, "v7.1.0", "open"),
+		testMilestone(3, "v7.2.0", "open"),
+	}
+
+	// Milestones are listed once for all the PRs in the repository
+	for _, number := range []int{10, 11} {
+		pr := &PullRequest{impl: impl, RepoOwner: "mattermost", RepoName: "mattermost-server", Number: number}
+		require.Nil(t, pr.SetMilestone(context.Background(), "v7.1.0"))
+		require.Equal(t, int64(2), *pr.MilestoneNumber)
+
+		title, err := pr.GetMilestone(context.Background())
+		require.Nil(t, err)
+		require.Equal(t, "v7.1.0", title)
+		require.Equal(t, 2, *fakes.issues.Edits[number][0].Milestone)
+	}
+	require.Equal(t, 1, fakes.issues.MilestoneCalls)
+
+	// Closed and unknown milestones are not found
+	pr := &PullRequest{impl: impl, RepoOwner: "mattermost", RepoName: "mattermost-server", Number: 12}
+	for _, title := range []string{"v7.0.0", "v8.0.0"} {
+		err := pr.SetMilestone(context.Background(), title)
+		require.True(t, errors.Is(err, ErrMilestoneNotFound), title)
+	}
+	require.Nil(t, pr.MilestoneNumber)
+}
+
+func TestGetMilestone(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	pr := &PullRequest{
+		impl:      &defaultPRImplementation{githubAPIUser: gau},
+		RepoOwner: "mattermost",
+		RepoName:  "mattermost-server",
+		Number:    1,
+	}
+	fakes.issues.IssueMilestones = map[int]*gogithub.Milestone{1: testMilestone(3, "v7.2.0", "open")}
+
+	title, err := pr.GetMilestone(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, "v7.2.0", title)
+
+	// PRs read from the API carry their milestone
+	fromAPI := gau.NewPullRequest(&gogithub.PullRequest{Number: gogithub.Int(2)})
+	title, err = fromAPI.GetMilestone(context.Background())
+	require.Nil(t, err)
+	require.Empty(t, title)
+}
+
+func TestSetReleaseMilestone(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	gau.options.MilestoneCache = NewMilestoneCache(time.Minute)
+	impl := &defaultPRImplementation{githubAPIUser: gau}
+	fakes.issues.Milestones = []*gogithub.Milestone{
+		testMilestone(1, "v7.8.0", "closed"),
+		testMilestone(2, "v7.8.1", "open"),
+	}
+	pr := &PullRequest{
+		impl: impl, RepoOwner: "mattermost", RepoName: "mattermost-server", Number: 10, BaseRef: "release-7.8",
+	}
+
+	// Closed milestones are skipped
+	title, err := pr.SetReleaseMilestone(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, "v7.8.1", title)
+	require.Equal(t, int64(2), *pr.MilestoneNumber)
+
+	pr.BaseRef = "release-7.9"
+	_, err = pr.SetReleaseMilestone(context.Background())
+	require.True(t, errors.Is(err, ErrMilestoneNotFound))
+
+	// Creating the milestone drops the cached ones
+	repo := gau.NewRepository(&gogithub.Repository{
+		Name: gogithub.String("mattermost-server"), Owner: &gogithub.User{Login: gogithub.String("mattermost")},
+	})
+	_, err = repo.CreateMilestone(context.Background(), "", "")
+	require.NotNil(t, err)
+	milestone, err := repo.CreateMilestone(context.Background(), "v7.9.0", "Mattermost v7.9")
+	require.Nil(t, err)
+	require.Equal(t, 3, milestone.Number)
+	title, err = pr.SetReleaseMilestone(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, "v7.9.0", title)
+	require.Equal(t, 2, fakes.issues.MilestoneCalls)
+
+	milestones, err := repo.ListMilestones(context.Background(), "all")
+	require.Nil(t, err)
+	require.Len(t, milestones, 3)
+	require.Equal(t, &Milestone{Number: 1, Title: "v7.8.0", State: "closed"}, milestones[0])
+	milestones, err = repo.ListMilestones(context.Background(), "open")
+	require.Nil(t, err)
+	require.Len(t, milestones, 2)
+}
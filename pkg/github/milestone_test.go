@@ -0,0 +1,46 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchReleaseMilestone(t *testing.T) {
+	titles := []string{"v7.7.1", "v7.8.2", "v7.8.1", "7.9.0", "v7.10.0", "Backlog"}
+	for _, tc := range []struct {
+		branch   string
+		expected string
+	}{
+		{"release-7.8", "v7.8.1"},
+		{"release-7.9", "7.9.0"},
+		{"release-7.10", "v7.10.0"},
+		{"release-7.1", ""},
+		{"master", ""},
+		{"release-7.8-rc1", ""},
+	} {
+		title, ok := MatchReleaseMilestone(tc.branch, titles)
+		require.Equal(t, tc.expected, title, tc.branch)
+		require.Equal(t, tc.expected != "", ok, tc.branch)
+	}
+}
+
+func TestReleaseBranchForMilestone(t *testing.T) {
+	for _, tc := range []struct {
+		milestone string
+		expected  string
+	}{
+		{"v7.8.1", "release-7.8"},
+		{"7.9.0", "release-7.9"},
+		{"v7.10.0", "release-7.10"},
+		{"v7.8", ""},
+		{"Backlog", ""},
+	} {
+		branch, ok := ReleaseBranchForMilestone(tc.milestone)
+		require.Equal(t, tc.expected, branch, tc.milestone)
+		require.Equal(t, tc.expected != "", ok, tc.milestone)
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+)
+
+// PatchFormat is the format changes are exported in
+type PatchFormat string
+
+const (
+	// PatchFormatDiff is a single unified diff of all the changes
+	PatchFormatDiff PatchFormat = "diff"
+
+	// PatchFormatPatch is a series of patches, one per commit, as
+	// produced by git format-patch and applied with git am
+	PatchFormatPatch PatchFormat = "patch"
+)
+
+// rawOptions returns the go-github options to request the format
+func (f PatchFormat) rawOptions() (gogithub.RawOptions, error) {
+	switch f {
+	case PatchFormatDiff:
+		return gogithub.RawOptions{Type: gogithub.Diff}, nil
+	case PatchFormatPatch:
+		return gogithub.RawOptions{Type: gogithub.Patch}, nil
+	}
+	return gogithub.RawOptions{}, errors.Errorf("unknown patch format %q", f)
+}
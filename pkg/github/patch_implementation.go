@@ -0,0 +1,90 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"io"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+)
+
+// writePatch exports the changes of the pull request. Open PRs are
+// exported as GitHub renders them. Merged PRs are exported from the
+// commits that landed in the base branch, so the diff of merge commits
+// is taken against the parent that is not the patch tree.
+func (impl *defaultPRImplementation) writePatch(
+	ctx context.Context, pr *PullRequest, w io.Writer, format PatchFormat,
+) error {
+	opts, err := format.rawOptions()
+	if err != nil {
+		return err
+	}
+
+	var raw string
+	if !pr.IsMerged() {
+		err = impl.doWithRetry(ctx, "pulls.GetRaw", func() (resp *gogithub.Response, err error) {
+			raw, resp, err = impl.GitHubClient().PullRequests.GetRaw(ctx, pr.RepoOwner, pr.RepoName, pr.Number, opts)
+			return resp, err
+		})
+		if err != nil {
+			return errors.Wrapf(err, "fetching %s of PR #%d", format, pr.Number)
+		}
+	} else {
+		repo, err := pr.GetRepository(ctx)
+		if err != nil {
+			return errors.Wrap(err, "unable to export patch")
+		}
+		steps, err := impl.prepareCherryPick(ctx, pr, repo, &CherryPickOptions{})
+		if err != nil {
+			return err
+		}
+		base, head := steps[0].from, steps[len(steps)-1].source.SHA
+		raw, err = impl.compareRaw(ctx, pr.RepoOwner, pr.RepoName, base, head, opts)
+		if err != nil {
+			return errors.Wrapf(err, "exporting %s of PR #%d", format, pr.Number)
+		}
+	}
+
+	_, err = io.WriteString(w, raw)
+	return errors.Wrapf(err, "writing %s of PR #%d", format, pr.Number)
+}
+
+// writeCommitPatch exports the changes of a commit
+func (di *defaultRepoImplementation) writeCommitPatch(
+	ctx context.Context, owner, repo, sha string, w io.Writer, format PatchFormat,
+) error {
+	opts, err := format.rawOptions()
+	if err != nil {
+		return err
+	}
+
+	var raw string
+	err = di.doWithRetry(ctx, "repos.GetCommitRaw", func() (resp *gogithub.Response, err error) {
+		raw, resp, err = di.GitHubClient().Repositories.GetCommitRaw(ctx, owner, repo, sha, opts)
+		return resp, err
+	})
+	if err != nil {
+		return errors.Wrapf(err, "fetching %s of commit %s", format, sha)
+	}
+
+	_, err = io.WriteString(w, raw)
+	return errors.Wrapf(err, "writing %s of commit %s", format, sha)
+}
+
+// compareRaw returns the changes between two commits in the raw format.
+// Patches list the commits reachable from head and not from base.
+func (gau *githubAPIUser) compareRaw(
+	ctx context.Context, owner, repo, base, head string, opts gogithub.RawOptions,
+) (raw string, err error) {
+	err = gau.doWithRetry(ctx, "repos.CompareCommitsRaw", func() (resp *gogithub.Response, err error) {
+		raw, resp, err = gau.GitHubClient().Repositories.CompareCommitsRaw(ctx, owner, repo, base, head, opts)
+		return resp, err
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "comparing %s with %s", head, base)
+	}
+	return raw, nil
+}
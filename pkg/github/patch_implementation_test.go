@@ -0,0 +1,72 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWritePatch(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	impl := &defaultPRImplementation{githubAPIUser: gau}
+	fakes.pulls.Commits[1] = []*gogithub.RepositoryCommit{fakes.addCommit("pr-1", "tree-1", "main-old")}
+	fakes.pulls.PullRequests[1] = &gogithub.PullRequest{Number: gogithub.Int(1)}
+	fakes.pulls.Raw = map[int]map[gogithub.RawType]string{1: {
+		gogithub.Diff: "diff --git a/a.go b/a.go\n", gogithub.Patch: "From pr-1 Mon Sep 17 00:00:00 2001\n",
+	}}
+	fakes.addCommit("main-old", "tree-base")
+	fakes.addCommit("squashed", "tree-merged", "main-old")
+	fakes.repos.RawComparisons = map[string]map[gogithub.RawType]string{"main-old...squashed": {
+		gogithub.Diff: "diff --git a/a.go b/a.go\nsquashed\n", gogithub.Patch: "From squashed Mon Sep 17 00:00:00 2001\n",
+	}}
+
+	pr := &PullRequest{
+		impl:      impl,
+		RepoOwner: "mattermost",
+		RepoName:  "mattermost-server",
+		Number:    1,
+		Merged:    gogithub.Bool(false),
+	}
+
+	// Open PRs are exported as GitHub renders them
+	var buf bytes.Buffer
+	require.Nil(t, pr.WritePatch(context.Background(), &buf, PatchFormatDiff))
+	require.Equal(t, "diff --git a/a.go b/a.go\n", buf.String())
+	buf.Reset()
+	require.Nil(t, pr.WritePatch(context.Background(), &buf, PatchFormatPatch))
+	require.Equal(t, "From pr-1 Mon Sep 17 00:00:00 2001\n", buf.String())
+
+	// Merged PRs are exported from the commits in the base branch
+	pr.Merged, pr.MergeCommitSHA = gogithub.Bool(true), "squashed"
+	buf.Reset()
+	require.Nil(t, pr.WritePatch(context.Background(), &buf, PatchFormatDiff))
+	require.Equal(t, "diff --git a/a.go b/a.go\nsquashed\n", buf.String())
+	buf.Reset()
+	require.Nil(t, pr.WritePatch(context.Background(), &buf, PatchFormatPatch))
+	require.Equal(t, "From squashed Mon Sep 17 00:00:00 2001\n", buf.String())
+
+	require.NotNil(t, pr.WritePatch(context.Background(), &buf, PatchFormat("zip")))
+}
+
+func TestWriteCommitPatch(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	repo := gau.NewRepository(&gogithub.Repository{
+		Name: gogithub.String("mattermost-server"), Owner: &gogithub.User{Login: gogithub.String("mattermost")},
+	})
+	fakes.repos.RawCommits = map[string]map[gogithub.RawType]string{"fix-1": {
+		gogithub.Diff: "diff --git a/a.go b/a.go\n", gogithub.Patch: "From fix-1 Mon Sep 17 00:00:00 2001\n",
+	}}
+
+	var buf bytes.Buffer
+	require.Nil(t, repo.WriteCommitPatch(context.Background(), &buf, "fix-1", PatchFormatPatch))
+	require.Equal(t, "From fix-1 Mon Sep 17 00:00:00 2001\n", buf.String())
+	buf.Reset()
+	require.Nil(t, repo.WriteCommitPatch(context.Background(), &buf, "fix-1", PatchFormatDiff))
+	require.Equal(t, "diff --git a/a.go b/a.go\n", buf.String())
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+// PermissionLevel is the access a user has to a repository
+type PermissionLevel string
+
+// Permission levels, from the highest to the lowest
+const (
+	PermissionAdmin    PermissionLevel = "admin"
+	PermissionMaintain PermissionLevel = "maintain"
+	PermissionWrite    PermissionLevel = "write"
+	PermissionTriage   PermissionLevel = "triage"
+	PermissionRead     PermissionLevel = "read"
+	PermissionNone     PermissionLevel = "none"
+)
+
+// permissionRanks orders the permission levels
+var permissionRanks = map[PermissionLevel]int{
+	PermissionNone:     0,
+	PermissionRead:     1,
+	PermissionTriage:   2,
+	PermissionWrite:    3,
+	PermissionMaintain: 4,
+	PermissionAdmin:    5,
+}
+
+// AtLeast returns true if the level grants at least the access of
+// required, eg PermissionMaintain is at least PermissionWrite
+func (p PermissionLevel) AtLeast(required PermissionLevel) bool {
+	return permissionRanks[p] >= permissionRanks[required]
+}
+
+// permissionFromFlags returns the highest level set in the permission
+// flags GitHub includes with users, as used by the REST API
+func permissionFromFlags(flags map[string]bool) PermissionLevel {
+	for _, level := range []struct {
+		flag  string
+		level PermissionLevel
+	}{
+		{"admin", PermissionAdmin},
+		{"maintain", PermissionMaintain},
+		{"push", PermissionWrite},
+		{"triage", PermissionTriage},
+		{"pull", PermissionRead},
+	} {
+		if flags[level.flag] {
+			return level.level
+		}
+	}
+	return PermissionNone
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+)
+
+// getPermissionLevel reads the access of a user to the repository. The
+// permission field only tells admin, write and read apart, so the finer
+// maintain and triage levels are read from the flags of the user.
+func (di *defaultRepoImplementation) getPermissionLevel(
+	ctx context.Context, owner, repo, login string,
+) (PermissionLevel, error) {
+	var level *gogithub.RepositoryPermissionLevel
+	err := di.doWithRetry(ctx, "repositories.GetPermissionLevel", func() (resp *gogithub.Response, err error) {
+		level, resp, err = di.GitHubClient().Repositories.GetPermissionLevel(ctx, owner, repo, login)
+		return resp, err
+	})
+	if err != nil {
+		// Unknown users have no access
+		if isNotFound(err) {
+			return PermissionNone, nil
+		}
+		return "", errors.Wrapf(err, "reading permission of %s in %s/%s", login, owner, repo)
+	}
+
+	if flags := level.GetUser().Permissions; len(flags) > 0 {
+		return permissionFromFlags(flags), nil
+	}
+	switch p := PermissionLevel(level.GetPermission()); p {
+	case PermissionAdmin, PermissionWrite, PermissionRead:
+		return p, nil
+	default:
+		return PermissionNone, nil
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"testing"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPermissionLevel(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	fakes.repos.Permissions = map[string]string{
+		"alice": "admin", "bob": "maintain", "carol": "write", "dave": "triage", "erin": "read",
+	}
+	repo := gau.NewRepository(&gogithub.Repository{
+		Name: gogithub.String("mattermost-server"), Owner: &gogithub.User{Login: gogithub.String("mattermost")},
+	})
+	ctx := context.Background()
+
+	// Maintain and triage are told apart from write and read
+	for login, expected := range map[string]PermissionLevel{
+		"alice":   PermissionAdmin,
+		"bob":     PermissionMaintain,
+		"carol":   PermissionWrite,
+		"dave":    PermissionTriage,
+		"erin":    PermissionRead,
+		"mallory": PermissionNone,
+	} {
+		level, err := repo.GetPermissionLevel(ctx, login)
+		require.Nil(t, err)
+		require.Equal(t, expected, level, login)
+	}
+
+	_, err := repo.GetPermissionLevel(ctx, "")
+	require.NotNil(t, err)
+
+	require.True(t, PermissionMaintain.AtLeast(PermissionWrite))
+	require.True(t, PermissionWrite.AtLeast(PermissionWrite))
+	require.False(t, PermissionTriage.AtLeast(PermissionWrite))
+	require.False(t, PermissionNone.AtLeast(PermissionRead))
+}
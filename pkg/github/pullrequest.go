@@ -5,18 +5,71 @@ package github
 
 import (
 	"context"
+	"io"
+	"sort"
+	"sync"
 	"time"
 
+	gogithub "github.com/google/go-github/v39/github"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 )
 
+// MergeMode describes how a pull request was merged into its target branch.
+//
+// It replaces the former REBASE, MERGE and SQUASH string constants. Code that
+// compared the result of GetMergeMode against those strings should compare
+// against the MergeModeRebase, MergeModeMerge and MergeModeSquash values and
+// use String() where the textual form is needed.
+type MergeMode int
+
 const (
-	REBASE = "rebase"
-	MERGE  = "merge"
-	SQUASH = "squash"
+	MergeModeUnknown MergeMode = iota // Merge mode could not be determined
+	MergeModeMerge                    // PR was merged with a merge commit
+	MergeModeSquash                   // PR commits were squashed into one
+	MergeModeRebase                   // PR commits were rebased onto the branch
+	MergeModeQueue                    // PR was merged by a merge queue
 )
 
+// String returns the name of the merge mode
+func (mm MergeMode) String() string {
+	switch mm {
+	case MergeModeMerge:
+		return "merge"
+	case MergeModeSquash:
+		return "squash"
+	case MergeModeRebase:
+		return "rebase"
+	case MergeModeQueue:
+		return "queue"
+	default:
+		return "unknown"
+	}
+}
+
+// Mergeability tells if a pull request can be merged
+type Mergeability int
+
+const (
+	MergeabilityUnknown Mergeability = iota // GitHub did not compute it in time
+	MergeabilityClean                       // PR can be merged
+	MergeabilityDirty                       // PR has conflicts with its base branch
+	MergeabilityBlocked                     // PR is blocked by branch protections, eg missing reviews
+)
+
+// String returns the name of the mergeability
+func (m Mergeability) String() string {
+	switch m {
+	case MergeabilityClean:
+		return "clean"
+	case MergeabilityDirty:
+		return "dirty"
+	case MergeabilityBlocked:
+		return "blocked"
+	default:
+		return "unknown"
+	}
+}
+
 type PullRequest struct {
 	impl                PRImplementation
 	Merged              *bool
@@ -24,11 +77,15 @@ type PullRequest struct {
 	MilestoneNumber     *int64
 	MilestoneTitle      *string
 	CreatedAt           time.Time
+	NodeID              string
 	RepoOwner           string
 	RepoName            string
-	FullName            string
+	FullName            string // Head repository, empty if the fork was deleted
+	Title               string
+	Body                string
 	Username            string
 	Ref                 string
+	BaseRef             string // Branch the pull request targets
 	Sha                 string
 	State               string
 	BuildStatus         string
@@ -37,8 +94,81 @@ type PullRequest struct {
 	URL                 string
 	MergeCommitSHA      string `db:"-"`
 	Labels              []string
+	Assignees           []string  // Logins of the users assigned to the PR
+	Reviews             []*Review // Set by GetReviews and LoadPullRequestFull
 	Number              int
 	Repository          *Repository
+
+	// labelsLoaded is set when Labels holds the current labels of the PR
+	labelsLoaded bool
+
+	// reviewsLoaded is set when Reviews holds all the reviews of the PR
+	reviewsLoaded bool
+
+	// Data read from the API, kept to avoid fetching it again. It is
+	// guarded by commitsMtx.
+	commitsMtx  sync.Mutex
+	commits     []*Commit
+	lastCommit  *Commit
+	commitCount int
+	mergeCommit *Commit
+
+	// mergeMode is stored once computed, guarded by mergeModeMtx
+	mergeModeMtx      sync.Mutex
+	mergeMode         MergeMode
+	mergeModeComputed bool
+
+	draft    bool
+	mergedAt time.Time
+}
+
+// Review is a review submitted on a pull request
+type Review struct {
+	Author      string    // Login of the reviewer
+	State       string    // APPROVED, CHANGES_REQUESTED, COMMENTED, DISMISSED or PENDING
+	SubmittedAt time.Time // Zero for pending reviews
+	CommitID    string    // Head of the pull request when the review was submitted
+}
+
+// ReviewComment is an inline comment on the diff of a pull request
+type ReviewComment struct {
+	ID        int64
+	Path      string // File the comment is attached to
+	Line      int    // Line of the file, the last one of multi-line comments
+	StartLine int    // First line of multi-line comments, zero otherwise
+	Side      string // LEFT for removed lines, RIGHT (default) for the rest
+	Body      string
+	Author    string // Login of the user that posted it
+	CommitID  string // Commit the comment was made on
+}
+
+// Events submitting a pull request review
+const (
+	ReviewEventApprove        = "APPROVE"
+	ReviewEventRequestChanges = "REQUEST_CHANGES"
+	ReviewEventComment        = "COMMENT"
+)
+
+// IsMerged returns true if the pull request was merged
+func (pr *PullRequest) IsMerged() bool {
+	return pr.Merged != nil && *pr.Merged
+}
+
+// IsDraft returns true if the pull request is a draft
+func (pr *PullRequest) IsDraft() bool {
+	return pr.draft
+}
+
+// IsOpen returns true if the pull request is open. The raw
+// state reported by GitHub is available in the State field.
+func (pr *PullRequest) IsOpen() bool {
+	return pr.State == "open"
+}
+
+// MergedAt returns the time when the pull request was merged. It
+// is the zero time if the pull request has not been merged.
+func (pr *PullRequest) MergedAt() time.Time {
+	return pr.mergedAt
 }
 
 func NewPullRequest() *PullRequest {
@@ -48,55 +178,322 @@ func NewPullRequest() *PullRequest {
 }
 
 type PRImplementation interface {
-	loadRepository(context.Context, *PullRequest)
-	getMergeMode(ctx context.Context, pr *PullRequest, commits []*Commit) (mode string, err error)
+	log(pr *PullRequest) Logger
+	operationContext(ctx context.Context) (context.Context, context.CancelFunc)
+	loadRepository(context.Context, *PullRequest) error
+	getMergeMode(ctx context.Context, pr *PullRequest, lastCommit *Commit, total int) (mode MergeMode, err error)
+	getMergeModeWithMergeSHA(ctx context.Context, pr *PullRequest, mergeSHA string, lastCommit *Commit, total int) (mode MergeMode, err error)
 	getCommits(ctx context.Context, pr *PullRequest) ([]*Commit, error)
+	getLastCommit(ctx context.Context, pr *PullRequest) (commit *Commit, total int, err error)
+	getChangedFiles(ctx context.Context, pr *PullRequest) ([]*CommitFile, error)
 	findPatchTree(ctx context.Context, pr *PullRequest) (parentNr int, err error)
+	cherryPick(ctx context.Context, pr *PullRequest, targetBranch string, opts *CherryPickOptions) (branch, sha string, err error)
+	cherryPickToBranches(ctx context.Context, pr *PullRequest, targets []string, opts *CherryPickOptions) (map[string]CherryPickResult, error)
+	checkCherryPick(ctx context.Context, pr *PullRequest, targetBranch string, opts *CherryPickOptions) (*BackportCheck, error)
+	writePatch(ctx context.Context, pr *PullRequest, w io.Writer, format PatchFormat) error
+	openBackportPR(ctx context.Context, pr *PullRequest, targetBranch, cherryBranch string, opts *BackportPROptions) (*PullRequest, error)
+	revert(ctx context.Context, pr *PullRequest, branch string) (sha string, err error)
+	openRevertPR(ctx context.Context, pr *PullRequest, branch string) (*PullRequest, error)
+	commentOnPR(ctx context.Context, pr *PullRequest, body string) (int64, error)
+	updateOrCreateComment(ctx context.Context, pr *PullRequest, marker, body string) (int64, error)
+	updateComment(ctx context.Context, pr *PullRequest, commentID int64, body string) error
+	findCommentByMarker(ctx context.Context, pr *PullRequest, marker string) (int64, error)
+	getLinkedIssues(ctx context.Context, pr *PullRequest) ([]*Issue, error)
+	getLabels(ctx context.Context, pr *PullRequest) ([]string, error)
+	getReviews(ctx context.Context, pr *PullRequest) ([]*Review, error)
+	getReviewComments(ctx context.Context, pr *PullRequest) ([]*ReviewComment, error)
+	addReviewComment(ctx context.Context, pr *PullRequest, comment *ReviewComment) (int64, error)
+	submitReview(ctx context.Context, pr *PullRequest, event, body string, comments []*ReviewComment) (int64, error)
+	requestReviewers(ctx context.Context, pr *PullRequest, users, teams []string) error
+	addAssignees(ctx context.Context, pr *PullRequest, logins []string) ([]string, error)
+	suggestReviewers(ctx context.Context, pr *PullRequest) ([]string, error)
+	isTeamMember(ctx context.Context, pr *PullRequest, team, login string) (bool, error)
+	addLabels(ctx context.Context, pr *PullRequest, labels []string) ([]string, error)
+	removeLabel(ctx context.Context, pr *PullRequest, label string) error
+	getMilestone(ctx context.Context, pr *PullRequest) (string, error)
+	setMilestone(ctx context.Context, pr *PullRequest, title string) (int, error)
+	findReleaseMilestone(ctx context.Context, pr *PullRequest) (string, error)
+	getCombinedStatus(ctx context.Context, pr *PullRequest, sha string) (string, error)
+	getCheckRuns(ctx context.Context, pr *PullRequest, sha string) (*CheckRunsStatus, error)
+	getCIStatus(ctx context.Context, pr *PullRequest, sha string) (*CIStatus, error)
+	enableAutoMerge(ctx context.Context, pr *PullRequest, mode MergeMode) error
+	merge(ctx context.Context, pr *PullRequest, mode MergeMode, commitTitle, commitMessage string) (string, error)
+	waitForMergeability(ctx context.Context, pr *PullRequest, timeout time.Duration) (Mergeability, error)
+	setState(ctx context.Context, pr *PullRequest, state string) error
+	setDraft(ctx context.Context, pr *PullRequest, draft bool) error
+}
+
+// CherryPickOptions control how pull requests are cherry-picked
+type CherryPickOptions struct {
+	// CreateIfMissing creates the target branch from BaseRef when
+	// it does not exist instead of returning ErrTargetBranchMissing
+	CreateIfMissing bool
+
+	// BaseRef is the branch or ref used to create the missing target branch
+	BaseRef string
+
+	// BranchPrefix names the branch where the cherry-pick is recorded,
+	// followed by the PR number and the target branch. Defaults to
+	// cherry-pick, eg cherry-pick-1234-release-7.8.
+	BranchPrefix string
+
+	// Squash records the commits of rebased PRs as a single commit with
+	// the squash message of the PR, which keeps their Co-authored-by
+	// trailers. PRs merged in other modes are a single commit already.
+	Squash bool
+}
+
+// CherryPickResult is the outcome of cherry-picking a pull request to
+// one of the target branches
+type CherryPickResult struct {
+	Branch string // Branch where the cherry-pick was recorded
+	SHA    string // Head of the cherry-pick branch
+	Err    error  // Error if the cherry-pick to the target failed
+}
+
+// BackportPROptions control how backport pull requests are opened
+type BackportPROptions struct {
+	// TriggerLabel is the label that requested the backport. It is not
+	// copied to the new pull request.
+	TriggerLabel string
+
+	// SkipLabels, SkipMilestone and SkipAssignees stop the labels, the
+	// milestone and the assignees of the original pull request from
+	// being copied
+	SkipLabels    bool
+	SkipMilestone bool
+	SkipAssignees bool
+
+	// SkipReviewers stops the approvers of the original pull
+	// request from being asked to review the backport
+	SkipReviewers bool
 }
 
 // GetRepository returns the Repository object representing the
 // repo where the PR was filed
-func (pr *PullRequest) GetRepository(ctx context.Context) *Repository {
+func (pr *PullRequest) GetRepository(ctx context.Context) (*Repository, error) {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
 	if pr.Repository == nil {
-		pr.impl.loadRepository(ctx, pr)
+		if pr.RepoOwner == "" || pr.RepoName == "" {
+			return nil, errors.Wrapf(ErrNoRepository, "PR #%d", pr.Number)
+		}
+		if err := pr.impl.loadRepository(ctx, pr); err != nil {
+			return nil, errors.Wrapf(err, "loading repository of PR #%d", pr.Number)
+		}
+	}
+	return pr.Repository, nil
+}
+
+// GetMergeMode returns the way the pull request was merged. It is
+// computed once, later calls return the stored result.
+func (pr *PullRequest) GetMergeMode(ctx context.Context) (mode MergeMode, err error) {
+	return pr.ComputeMergeMode(ctx, false)
+}
+
+// ComputeMergeMode returns the way the pull request was merged. The result
+// of the first successful computation is stored in the pull request and
+// returned by later calls. When force is true, the merge mode is computed
+// again from fresh commit data. It is safe to call it concurrently, also
+// along the other methods reading the commits of the pull request.
+func (pr *PullRequest) ComputeMergeMode(ctx context.Context, force bool) (mode MergeMode, err error) {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	pr.mergeModeMtx.Lock()
+	defer pr.mergeModeMtx.Unlock()
+	if pr.mergeModeComputed && !force {
+		return pr.mergeMode, nil
 	}
-	return pr.Repository
+
+	if pr.MergeCommitSHA == "" {
+		return MergeModeUnknown, errors.Wrapf(ErrNoMergeCommit, "getting merge mode of PR #%d", pr.Number)
+	}
+
+	if force {
+		pr.commitsMtx.Lock()
+		pr.commits, pr.lastCommit, pr.commitCount, pr.mergeCommit = nil, nil, 0, nil
+		pr.commitsMtx.Unlock()
+	}
+
+	// Only the last commit and the size of the PR are needed
+	lastCommit, total, err := pr.GetLastCommit(ctx)
+	if err != nil {
+		return MergeModeUnknown, errors.Wrapf(err, "getting commits from pull request #%d", pr.Number)
+	}
+	mode, err = pr.impl.getMergeMode(ctx, pr, lastCommit, total)
+	if err != nil {
+		return MergeModeUnknown, err
+	}
+	pr.mergeMode, pr.mergeModeComputed = mode, true
+	return mode, nil
 }
 
-// GetMergeMode returns a string describing the way the pull request was merged
-func (pr *PullRequest) GetMergeMode(ctx context.Context) (mode string, err error) {
-	// Get the commits merged by the pull request
-	commits, err := pr.impl.getCommits(ctx, pr)
+// GetMergeModeWithMergeSHA returns the way the pull request was merged
+// taking mergeSHA as its merge commit. It is meant to reprocess pull
+// requests whose merge commit SHA is known to be wrong: the PR is not
+// modified and the result is not stored in it.
+func (pr *PullRequest) GetMergeModeWithMergeSHA(ctx context.Context, mergeSHA string) (MergeMode, error) {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	if mergeSHA == "" {
+		return MergeModeUnknown, errors.Wrapf(ErrNoMergeCommit, "getting merge mode of PR #%d", pr.Number)
+	}
+
+	lastCommit, total, err := pr.GetLastCommit(ctx)
 	if err != nil {
-		return "", errors.Wrapf(err, "getting commits from pull request #%d", pr.Number)
+		return MergeModeUnknown, errors.Wrapf(err, "getting commits from pull request #%d", pr.Number)
 	}
-	return pr.impl.getMergeMode(ctx, pr, commits)
+	return pr.impl.getMergeModeWithMergeSHA(ctx, pr, mergeSHA, lastCommit, total)
 }
 
 // GetCommits returns the list of commits the pull request merged
-// into its target branch
+// into its target branch. They are read from the API only once.
 func (pr *PullRequest) GetCommits(ctx context.Context) ([]*Commit, error) {
-	commits, err := pr.impl.getCommits(ctx, pr)
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	pr.commitsMtx.Lock()
+	defer pr.commitsMtx.Unlock()
+	if pr.commits == nil {
+		commits, err := pr.impl.getCommits(ctx, pr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading commits from PR #%d", pr.Number)
+		}
+		pr.commits = commits
+	}
+	return pr.commits, nil
+}
+
+// GetLastCommit returns the last commit of the pull request and the
+// number of commits in it. Unless the commits were already loaded, only
+// the last page of the list is read, so it is much cheaper than
+// GetCommits for pull requests with thousands of commits.
+func (pr *PullRequest) GetLastCommit(ctx context.Context) (commit *Commit, total int, err error) {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	pr.commitsMtx.Lock()
+	defer pr.commitsMtx.Unlock()
+	if pr.commits != nil {
+		if len(pr.commits) == 0 {
+			return nil, 0, errors.Wrapf(ErrNoCommits, "PR #%d", pr.Number)
+		}
+		return pr.commits[len(pr.commits)-1], len(pr.commits), nil
+	}
+	if pr.lastCommit == nil {
+		commit, total, err := pr.impl.getLastCommit(ctx, pr)
+		if err != nil {
+			return nil, 0, errors.Wrapf(err, "reading last commit from PR #%d", pr.Number)
+		}
+		pr.lastCommit, pr.commitCount = commit, total
+	}
+	return pr.lastCommit, pr.commitCount, nil
+}
+
+// GetChangedFiles returns the files modified by the pull request
+func (pr *PullRequest) GetChangedFiles(ctx context.Context) ([]*CommitFile, error) {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	files, err := pr.impl.getChangedFiles(ctx, pr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading changed files from PR #%d", pr.Number)
+	}
+	return files, nil
+}
+
+// GetFiles returns the files changed by the pull request with their
+// status and line counts, reading every page. See GetChangedFiles.
+func (pr *PullRequest) GetFiles(ctx context.Context) ([]*CommitFile, error) {
+	return pr.GetChangedFiles(ctx)
+}
+
+// GetMergeCommit returns the commit pointed to by MergeCommitSHA. It is
+// read from the API only once and shared by all the operations that
+// need it. Pull requests without a merge commit SHA return ErrNoMergeCommit.
+// Those known not to be merged return ErrNotMerged, as GitHub reports a
+// test merge commit for open pull requests.
+func (pr *PullRequest) GetMergeCommit(ctx context.Context) (*Commit, error) {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	if pr.MergeCommitSHA == "" {
+		return nil, errors.Wrapf(ErrNoMergeCommit, "PR #%d", pr.Number)
+	}
+	if pr.Merged != nil && !*pr.Merged {
+		return nil, errors.Wrapf(ErrNotMerged, "PR #%d", pr.Number)
+	}
+	pr.commitsMtx.Lock()
+	defer pr.commitsMtx.Unlock()
+	if pr.mergeCommit == nil {
+		repo, err := pr.GetRepository(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to get merge commit")
+		}
+		commit, err := repo.GetCommit(ctx, pr.MergeCommitSHA)
+		if err != nil {
+			return nil, errors.Wrapf(err, "querying GitHub for merge commit %s", pr.MergeCommitSHA)
+		}
+		if commit == nil {
+			return nil, &EmptyCommitError{SHA: pr.MergeCommitSHA, Op: "reading merge commit"}
+		}
+		pr.mergeCommit = commit
+	}
+	return pr.mergeCommit, nil
+}
+
+// BuildSquashMessage returns the title and body of a commit squashing the
+// pull request. The title is the PR title followed by its number and the
+// body lists the subjects of the PR commits, with Co-authored-by trailers
+// for their authors. Single commit PRs use the message of their commit.
+func (pr *PullRequest) BuildSquashMessage(ctx context.Context) (title, body string, err error) {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	commits, err := pr.GetCommits(ctx)
 	if err != nil {
-		return nil, errors.Wrapf(err, "reading commits from PR #%d", pr.Number)
+		return "", "", errors.Wrap(err, "building squash message")
+	}
+	if len(commits) == 0 {
+		return "", "", errors.Wrapf(ErrNoCommits, "building squash message for PR #%d", pr.Number)
 	}
-	return commits, nil
+	title, body = squashMessage(pr, commits)
+	return title, body, nil
+}
+
+// WritePatch writes the changes of the pull request to w as a unified
+// diff or a git format-patch series. Merged PRs are exported from the
+// commits that landed in the base branch: the merge commit is diffed
+// against its mainline parent, skipping the patch tree, and rebased
+// commits are exported in order.
+func (pr *PullRequest) WritePatch(ctx context.Context, w io.Writer, format PatchFormat) error {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	return pr.impl.writePatch(ctx, pr, w, format)
 }
 
 // GetRebaseCommits returns the sequence of commits created when the PR
 // was merged. It should only be used by rebased PRs.
 func (pr *PullRequest) GetRebaseCommits(ctx context.Context) (commitSHAs []string, err error) {
-	if pr.GetRepository(ctx) == nil {
-		return nil, errors.New("unable to get rebase commits, pr repository is nil")
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	repo, err := pr.GetRepository(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get rebase commits")
 	}
 
 	// First, the merge_commit_sha commit:
-	branchCommit, err := pr.GetRepository(ctx).GetCommit(ctx, pr.MergeCommitSHA)
+	branchCommit, err := pr.GetMergeCommit(ctx)
 	if err != nil {
 		return nil, errors.Wrap(err, "getting branch commit")
 	}
 
-	prCommits, err := pr.impl.getCommits(ctx, pr)
+	prCommits, err := pr.GetCommits(ctx)
 	if err != nil {
 		return nil, errors.Wrap(err, "getting commits from PR")
 	}
@@ -115,14 +512,14 @@ func (pr *PullRequest) GetRebaseCommits(ctx context.Context) (commitSHAs []strin
 			)
 		}
 
-		logrus.Infof("Match #%d PR:%s vs Branch:%s", i, prTreeSHA, branchTreeSha)
+		pr.impl.log(pr).Infof("Match #%d PR:%s vs Branch:%s", i, prTreeSHA, branchTreeSha)
 
 		// Append the commit sha to the list (note not to use the *tree hash* here)
 		commitSHAs = append(commitSHAs, branchCommit.SHA)
 
 		// While we traverse the PR commits linearly, we follow
 		// the git graph to get the neext commit int th branch
-		branchCommit, err = pr.Repository.GetCommit(ctx, branchCommit.Parents[0].SHA)
+		branchCommit, err = repo.GetCommit(ctx, branchCommit.Parents[0].SHA)
 		if err != nil {
 			return nil, errors.Wrapf(
 				err, "while fetching branch commit #%d - %s", i, branchCommit.Parents[0].SHA,
@@ -134,5 +531,689 @@ func (pr *PullRequest) GetRebaseCommits(ctx context.Context) (commitSHAs []strin
 
 // PatchTreeID return the parent ID of the pull request merge commit
 func (pr *PullRequest) PatchTreeID(ctx context.Context) (parentNr int, err error) {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
 	return pr.impl.findPatchTree(ctx, pr)
 }
+
+// CherryPick applies the changes merged by the pull request on top of
+// targetBranch using the GitHub API. The resulting commits are recorded
+// in a new branch, its name is returned along with the SHA of its head.
+// In dry-run mode nothing is written and the returned SHA is all zeros.
+func (pr *PullRequest) CherryPick(ctx context.Context, targetBranch string) (branch, sha string, err error) {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	return pr.CherryPickWithOptions(ctx, targetBranch, nil)
+}
+
+// CherryPickWithOptions works like CherryPick but the handling of
+// the target branch can be controlled with the options
+func (pr *PullRequest) CherryPickWithOptions(
+	ctx context.Context, targetBranch string, opts *CherryPickOptions,
+) (branch, sha string, err error) {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	if opts == nil {
+		opts = &CherryPickOptions{}
+	}
+	if opts.CreateIfMissing && opts.BaseRef == "" {
+		return "", "", errors.New("a base ref is required to create missing target branches")
+	}
+	return pr.impl.cherryPick(ctx, pr, targetBranch, opts)
+}
+
+// CherryPickToBranches cherry-picks the pull request to several target
+// branches. The result of each target is returned in a map keyed by branch.
+// Failing targets do not affect the rest. An error is returned only when
+// the cherry-pick cannot be attempted at all.
+func (pr *PullRequest) CherryPickToBranches(
+	ctx context.Context, targets []string, opts *CherryPickOptions,
+) (map[string]CherryPickResult, error) {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	if opts == nil {
+		opts = &CherryPickOptions{}
+	}
+	if opts.CreateIfMissing && opts.BaseRef == "" {
+		return nil, errors.New("a base ref is required to create missing target branches")
+	}
+	return pr.impl.cherryPickToBranches(ctx, pr, targets, opts)
+}
+
+// OpenBackportPR opens a pull request proposing the cherry-pick recorded in
+// cherryBranch to targetBranch. The milestone, labels and assignees of the
+// original pull request are carried over unless the options skip them. If
+// a pull request for the same branches is already open, it is returned
+// instead of creating a new one. In dry-run mode, a nil pull request is
+// returned when none exists.
+func (pr *PullRequest) OpenBackportPR(
+	ctx context.Context, targetBranch, cherryBranch string, opts *BackportPROptions,
+) (*PullRequest, error) {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	if opts == nil {
+		opts = &BackportPROptions{}
+	}
+	return pr.impl.openBackportPR(ctx, pr, targetBranch, cherryBranch, opts)
+}
+
+// ClosePR closes the pull request without merging it. Closing a pull
+// request that is already closed does nothing.
+func (pr *PullRequest) ClosePR(ctx context.Context) error {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	if pr.State == "closed" {
+		return nil
+	}
+	if err := pr.impl.setState(ctx, pr, "closed"); err != nil {
+		return errors.Wrapf(err, "closing PR #%d", pr.Number)
+	}
+	pr.State = "closed"
+	return nil
+}
+
+// ReopenPR reopens a closed pull request. Reopening a pull
+// request that is already open does nothing.
+func (pr *PullRequest) ReopenPR(ctx context.Context) error {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	if pr.IsOpen() {
+		return nil
+	}
+	if err := pr.impl.setState(ctx, pr, "open"); err != nil {
+		return errors.Wrapf(err, "reopening PR #%d", pr.Number)
+	}
+	pr.State = "open"
+	return nil
+}
+
+// Close closes the pull request without merging it, see ClosePR
+func (pr *PullRequest) Close(ctx context.Context) error {
+	return pr.ClosePR(ctx)
+}
+
+// Reopen reopens a closed pull request, see ReopenPR
+func (pr *PullRequest) Reopen(ctx context.Context) error {
+	return pr.ReopenPR(ctx)
+}
+
+// ConvertToDraft turns an open pull request into a draft. Converting
+// a pull request that is already a draft does nothing.
+func (pr *PullRequest) ConvertToDraft(ctx context.Context) error {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	if pr.draft {
+		return nil
+	}
+	if err := pr.impl.setDraft(ctx, pr, true); err != nil {
+		return errors.Wrapf(err, "converting PR #%d to draft", pr.Number)
+	}
+	pr.draft = true
+	return nil
+}
+
+// MarkReadyForReview takes a pull request out of draft. Marking a pull
+// request that is not a draft does nothing.
+func (pr *PullRequest) MarkReadyForReview(ctx context.Context) error {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	if !pr.draft {
+		return nil
+	}
+	if err := pr.impl.setDraft(ctx, pr, false); err != nil {
+		return errors.Wrapf(err, "marking PR #%d as ready for review", pr.Number)
+	}
+	pr.draft = false
+	return nil
+}
+
+// CommentOnPR posts a comment on the pull request and returns
+// its ID. In dry-run mode the returned ID is zero.
+func (pr *PullRequest) CommentOnPR(ctx context.Context, body string) (int64, error) {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	return pr.impl.commentOnPR(ctx, pr, body)
+}
+
+// UpdateOrCreateComment posts a comment tagged with a hidden marker. If a
+// comment with the same marker already exists, it is edited in place
+// instead of adding a new one to the thread. Returns the comment ID.
+func (pr *PullRequest) UpdateOrCreateComment(ctx context.Context, marker, body string) (int64, error) {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	if marker == "" {
+		return 0, errors.New("comment marker cannot be empty")
+	}
+	return pr.impl.updateOrCreateComment(ctx, pr, marker, body)
+}
+
+// UpdateComment replaces the body of a comment posted on the pull request
+func (pr *PullRequest) UpdateComment(ctx context.Context, commentID int64, body string) error {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	return pr.impl.updateComment(ctx, pr, commentID, body)
+}
+
+// FindCommentByMarker returns the ID of the comment posted with
+// UpdateOrCreateComment using the marker, or zero if there is none
+func (pr *PullRequest) FindCommentByMarker(ctx context.Context, marker string) (int64, error) {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	if marker == "" {
+		return 0, errors.New("comment marker cannot be empty")
+	}
+	id, err := pr.impl.findCommentByMarker(ctx, pr, marker)
+	if err != nil {
+		return 0, errors.Wrapf(err, "searching for comment %q in PR #%d", marker, pr.Number)
+	}
+	return id, nil
+}
+
+// GetLinkedIssues returns the issues the pull request closes, referenced
+// with keywords like "Fixes #123" in its body or its commit messages
+func (pr *PullRequest) GetLinkedIssues(ctx context.Context) ([]*Issue, error) {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	issues, err := pr.impl.getLinkedIssues(ctx, pr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading issues linked from PR #%d", pr.Number)
+	}
+	return issues, nil
+}
+
+// GetLabels returns the labels of the pull request. They are read from
+// the API only once, later calls return the labels stored in the PR.
+func (pr *PullRequest) GetLabels(ctx context.Context) ([]string, error) {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	if !pr.labelsLoaded {
+		labels, err := pr.impl.getLabels(ctx, pr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading labels of PR #%d", pr.Number)
+		}
+		pr.Labels = labels
+		pr.labelsLoaded = true
+	}
+	return pr.Labels, nil
+}
+
+// BackportTargets returns the branches the merged pull request has to be
+// backported to according to its labels. When config is nil, the labels
+// configured in the repository at the base branch are used.
+func (pr *PullRequest) BackportTargets(ctx context.Context, config *BackportLabels) ([]string, error) {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	if !pr.IsMerged() {
+		return nil, errors.Wrapf(ErrNotMerged, "reading backport targets of PR #%d", pr.Number)
+	}
+	if config == nil {
+		repo, err := pr.GetRepository(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading backport targets")
+		}
+		config, err = repo.GetBackportLabels(ctx, pr.BaseRef)
+		if err != nil {
+			return nil, err
+		}
+	}
+	labels, err := pr.GetLabels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return config.Targets(labels), nil
+}
+
+// BackportMilestoneTargets returns the branches the merged pull request
+// should be backported to according to the fix-version milestones of the
+// issues it closes in its repository. When resolve is nil, milestones
+// are mapped with ReleaseBranchForMilestone. The base branch of the PR
+// is not a target.
+func (pr *PullRequest) BackportMilestoneTargets(ctx context.Context, resolve MilestoneBranchResolver) ([]string, error) {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	if !pr.IsMerged() {
+		return nil, errors.Wrapf(ErrNotMerged, "reading backport targets of PR #%d", pr.Number)
+	}
+	if resolve == nil {
+		resolve = ReleaseBranchForMilestone
+	}
+	issues, err := pr.impl.getLinkedIssues(ctx, pr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading issues linked from PR #%d", pr.Number)
+	}
+
+	// Milestones of other repositories follow their own versions
+	targets := []string{}
+	seen := map[string]bool{pr.BaseRef: true}
+	for _, issue := range issues {
+		if issue.Owner != pr.RepoOwner || issue.Repo != pr.RepoName || issue.MilestoneTitle == "" {
+			continue
+		}
+		branch, ok := resolve(issue.MilestoneTitle)
+		if !ok || seen[branch] {
+			continue
+		}
+		seen[branch] = true
+		targets = append(targets, branch)
+	}
+	return targets, nil
+}
+
+// GetReviews returns the reviews submitted on the pull request. They are
+// read from the API only once, later calls return the stored reviews.
+func (pr *PullRequest) GetReviews(ctx context.Context) ([]*Review, error) {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	if !pr.reviewsLoaded {
+		reviews, err := pr.impl.getReviews(ctx, pr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading reviews of PR #%d", pr.Number)
+		}
+		pr.Reviews = reviews
+		pr.reviewsLoaded = true
+	}
+	return pr.Reviews, nil
+}
+
+// GetReviewComments returns the inline comments on the diff of the pull request
+func (pr *PullRequest) GetReviewComments(ctx context.Context) ([]*ReviewComment, error) {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	comments, err := pr.impl.getReviewComments(ctx, pr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading review comments of PR #%d", pr.Number)
+	}
+	return comments, nil
+}
+
+// AddReviewComment posts an inline comment on a line of the diff and
+// returns its ID. Comments without a commit are made on the head of the
+// pull request. In dry-run mode the returned ID is zero.
+func (pr *PullRequest) AddReviewComment(ctx context.Context, comment *ReviewComment) (int64, error) {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	if comment.Path == "" || comment.Line == 0 {
+		return 0, errors.New("review comments need a path and a line")
+	}
+	if comment.CommitID == "" {
+		sha, err := pr.reviewSHA(ctx)
+		if err != nil {
+			return 0, errors.Wrap(err, "adding review comment")
+		}
+		c := *comment
+		c.CommitID = sha
+		comment = &c
+	}
+	return pr.impl.addReviewComment(ctx, pr, comment)
+}
+
+// SubmitReview submits a review of the head of the pull request with
+// its inline comments, posted together, and returns its ID. The event is
+// one of ReviewEventApprove, ReviewEventRequestChanges or
+// ReviewEventComment. In dry-run mode the returned ID is zero.
+func (pr *PullRequest) SubmitReview(
+	ctx context.Context, event, body string, comments []*ReviewComment,
+) (int64, error) {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	switch event {
+	case ReviewEventApprove, ReviewEventRequestChanges, ReviewEventComment:
+	default:
+		return 0, errors.Errorf("invalid review event %q", event)
+	}
+	for _, c := range comments {
+		if c.Path == "" || c.Line == 0 {
+			return 0, errors.New("review comments need a path and a line")
+		}
+	}
+	id, err := pr.impl.submitReview(ctx, pr, event, body, comments)
+	if err != nil {
+		return 0, errors.Wrapf(err, "submitting review on PR #%d", pr.Number)
+	}
+	// The stored reviews no longer include all of them
+	pr.reviewsLoaded = false
+	return id, nil
+}
+
+// RequestReviewers asks users and teams, by their slug, to review the pull request
+func (pr *PullRequest) RequestReviewers(ctx context.Context, users, teams []string) error {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	if len(users) == 0 && len(teams) == 0 {
+		return nil
+	}
+	if err := pr.impl.requestReviewers(ctx, pr, users, teams); err != nil {
+		return errors.Wrapf(err, "requesting reviewers for PR #%d", pr.Number)
+	}
+	return nil
+}
+
+// SuggestReviewers proposes reviewers for the pull request, best first.
+// They are the authors of the recent commits in the base branch touching
+// the files it changes, excluding the author of the PR and bots.
+func (pr *PullRequest) SuggestReviewers(ctx context.Context) ([]string, error) {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	reviewers, err := pr.impl.suggestReviewers(ctx, pr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "suggesting reviewers for PR #%d", pr.Number)
+	}
+	return reviewers, nil
+}
+
+// reviewSHA returns the commit reviews are submitted on
+func (pr *PullRequest) reviewSHA(ctx context.Context) (string, error) {
+	if pr.Sha != "" {
+		return pr.Sha, nil
+	}
+	return pr.headSHA(ctx)
+}
+
+// IsApprovedBy returns true if at least n reviewers approved the pull
+// request and none of them requested changes. Only the latest review of
+// each reviewer counts and reviews submitted before the last push are
+// stale and ignored. Each of requiredTeams, as "org/slug" or a team slug
+// of the repository owner, must have at least one member among the
+// approvers.
+func (pr *PullRequest) IsApprovedBy(ctx context.Context, n int, requiredTeams []string) (bool, error) {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	reviews, err := pr.GetReviews(ctx)
+	if err != nil {
+		return false, err
+	}
+	approvers := []string{}
+	for _, review := range currentReviews(reviews, pr.Sha) {
+		switch review.State {
+		case ReviewStateChangesRequested:
+			return false, nil
+		case ReviewStateApproved:
+			approvers = append(approvers, review.Author)
+		}
+	}
+	if len(approvers) < n {
+		return false, nil
+	}
+
+	for _, team := range requiredTeams {
+		approved := false
+		for _, login := range approvers {
+			member, err := pr.impl.isTeamMember(ctx, pr, team, login)
+			if err != nil {
+				return false, errors.Wrapf(err, "checking if %s is a member of %s", login, team)
+			}
+			if member {
+				approved = true
+				break
+			}
+		}
+		if !approved {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// GetApprovers returns the logins of the reviewers whose latest review
+// approved the pull request, sorted. Unlike IsApprovedBy, approvals of
+// earlier pushes count.
+func (pr *PullRequest) GetApprovers(ctx context.Context) ([]string, error) {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	reviews, err := pr.GetReviews(ctx)
+	if err != nil {
+		return nil, err
+	}
+	approvers := []string{}
+	for login, review := range currentReviews(reviews, "") {
+		if review.State == ReviewStateApproved {
+			approvers = append(approvers, login)
+		}
+	}
+	sort.Strings(approvers)
+	return approvers, nil
+}
+
+// HasLabel returns true if the pull request has the label
+func (pr *PullRequest) HasLabel(ctx context.Context, label string) (bool, error) {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	labels, err := pr.GetLabels(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, l := range labels {
+		if l == label {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// AddLabels adds labels to the pull request
+func (pr *PullRequest) AddLabels(ctx context.Context, labels ...string) error {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	if len(labels) == 0 {
+		return nil
+	}
+	current, err := pr.impl.addLabels(ctx, pr, labels)
+	if err != nil {
+		return errors.Wrapf(err, "adding labels to PR #%d", pr.Number)
+	}
+	pr.Labels = current
+	pr.labelsLoaded = true
+	return nil
+}
+
+// AddAssignees assigns users to the pull request
+func (pr *PullRequest) AddAssignees(ctx context.Context, logins ...string) error {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	if len(logins) == 0 {
+		return nil
+	}
+	assignees, err := pr.impl.addAssignees(ctx, pr, logins)
+	if err != nil {
+		return errors.Wrapf(err, "assigning %v to PR #%d", logins, pr.Number)
+	}
+	pr.Assignees = assignees
+	return nil
+}
+
+// RemoveLabel removes a label from the pull request. Removing
+// a label the PR does not have is not an error.
+func (pr *PullRequest) RemoveLabel(ctx context.Context, label string) error {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	if err := pr.impl.removeLabel(ctx, pr, label); err != nil {
+		return errors.Wrapf(err, "removing label %s from PR #%d", label, pr.Number)
+	}
+	labels := []string{}
+	for _, l := range pr.Labels {
+		if l != label {
+			labels = append(labels, l)
+		}
+	}
+	pr.Labels = labels
+	return nil
+}
+
+// GetMilestone returns the title of the milestone of the pull request or
+// an empty string if it has none. Pull requests read from the API already
+// carry it, others read it once.
+func (pr *PullRequest) GetMilestone(ctx context.Context) (string, error) {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	if pr.MilestoneTitle == nil {
+		title, err := pr.impl.getMilestone(ctx, pr)
+		if err != nil {
+			return "", errors.Wrapf(err, "reading milestone of PR #%d", pr.Number)
+		}
+		pr.MilestoneTitle = &title
+	}
+	return *pr.MilestoneTitle, nil
+}
+
+// SetMilestone assigns the open milestone with the title to the pull
+// request. If the repository has no such milestone, ErrMilestoneNotFound
+// is returned.
+func (pr *PullRequest) SetMilestone(ctx context.Context, title string) error {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	number, err := pr.impl.setMilestone(ctx, pr, title)
+	if err != nil {
+		return errors.Wrapf(err, "setting milestone of PR #%d", pr.Number)
+	}
+	milestoneNumber := int64(number)
+	pr.MilestoneNumber = &milestoneNumber
+	pr.MilestoneTitle = &title
+	return nil
+}
+
+// SetReleaseMilestone assigns the open milestone of the release branch
+// targeted by the pull request (release-7.8 gets v7.8.0) and returns its
+// title. Pull requests not targeting a release branch, or targeting one
+// without an open milestone, get ErrMilestoneNotFound.
+func (pr *PullRequest) SetReleaseMilestone(ctx context.Context) (string, error) {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	title, err := pr.impl.findReleaseMilestone(ctx, pr)
+	if err != nil {
+		return "", errors.Wrapf(err, "finding release milestone of PR #%d", pr.Number)
+	}
+	if err := pr.SetMilestone(ctx, title); err != nil {
+		return "", err
+	}
+	return title, nil
+}
+
+// GetCombinedStatus returns the combined state of the commit statuses
+// reported on the head of the pull request: CIStatusSuccess,
+// CIStatusPending or CIStatusFailure. GitHub reports commits without any
+// status as pending, these return CIStatusNone instead so that a missing
+// CI can be told apart from one that has not finished.
+func (pr *PullRequest) GetCombinedStatus(ctx context.Context) (string, error) {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	sha, err := pr.headSHA(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "getting combined status")
+	}
+	return pr.impl.getCombinedStatus(ctx, pr, sha)
+}
+
+// GetCheckRuns aggregates the results of the check runs reported on the
+// head of the pull request. The state is computed like the combined
+// status and is CIStatusNone when the commit has no check runs.
+func (pr *PullRequest) GetCheckRuns(ctx context.Context) (*CheckRunsStatus, error) {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	sha, err := pr.headSHA(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting check runs")
+	}
+	return pr.impl.getCheckRuns(ctx, pr, sha)
+}
+
+// GetCIStatus merges the commit statuses and check runs of the head of
+// the pull request into one result per context. Any failure fails the
+// whole status, otherwise unfinished contexts leave it pending.
+func (pr *PullRequest) GetCIStatus(ctx context.Context) (*CIStatus, error) {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	sha, err := pr.headSHA(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting CI status")
+	}
+	return pr.impl.getCIStatus(ctx, pr, sha)
+}
+
+// headSHA returns the SHA of the last commit in the pull request
+func (pr *PullRequest) headSHA(ctx context.Context) (string, error) {
+	commit, _, err := pr.GetLastCommit(ctx)
+	if err != nil {
+		return "", err
+	}
+	return commit.SHA, nil
+}
+
+// EnableAutoMerge makes GitHub merge the pull request with the merge mode
+// once all its requirements are met. If auto-merge is not allowed in the
+// repository, ErrAutoMergeDisabled is returned.
+func (pr *PullRequest) EnableAutoMerge(ctx context.Context, mode MergeMode) error {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	return pr.impl.enableAutoMerge(ctx, pr, mode)
+}
+
+// WaitForMergeability returns whether the pull request can be merged.
+// GitHub computes it in the background after changes, so the PR is read
+// again until it is known. If it is not known after the timeout,
+// MergeabilityUnknown is returned.
+func (pr *PullRequest) WaitForMergeability(ctx context.Context, timeout time.Duration) (Mergeability, error) {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	return pr.impl.waitForMergeability(ctx, pr, timeout)
+}
+
+// Merge merges the pull request with the merge mode and returns the SHA
+// of the resulting commit. Empty title and message use the defaults of
+// GitHub. When the head SHA of the PR is known, the merge only happens
+// if it did not change. Refused merges return a *MergeError matching
+// ErrNotMergeable or ErrHeadModified.
+func (pr *PullRequest) Merge(ctx context.Context, mode MergeMode, commitTitle, commitMessage string) (string, error) {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	sha, err := pr.impl.merge(ctx, pr, mode, commitTitle, commitMessage)
+	if err != nil {
+		return "", err
+	}
+	if sha != "" {
+		pr.Merged = gogithub.Bool(true)
+		pr.State = "closed"
+		pr.MergeCommitSHA = sha
+		pr.commitsMtx.Lock()
+		pr.mergeCommit = nil
+		pr.commitsMtx.Unlock()
+	}
+	return sha, nil
+}
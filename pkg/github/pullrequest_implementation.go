@@ -5,25 +5,60 @@ package github
 
 import (
 	"context"
-	"fmt"
+	"sync"
 
 	gogithub "github.com/google/go-github/v39/github"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
 )
 
 type defaultPRImplementation struct {
 	githubAPIUser
+	logger Logger
 }
 
-// loadRepository  returns the repo where the PR lives
-func (impl *defaultPRImplementation) loadRepository(ctx context.Context, pr *PullRequest) {
-	ghRepo, _, err := impl.githubAPIUser.GitHubClient().Repositories.Get(ctx, pr.RepoOwner, pr.RepoName)
+// log returns a log entry carrying the fields that identify the pull request
+func (impl *defaultPRImplementation) log(pr *PullRequest) Logger {
+	logger := impl.logger
+	if logger == nil {
+		logger = impl.getLogger()
+	}
+	return logger.WithFields(map[string]interface{}{
+		"repo":      pr.RepoOwner + "/" + pr.RepoName,
+		"pr_number": pr.Number,
+	})
+}
+
+// loadRepository fetches the repo where the PR lives and stores it in
+// the pull request. A missing repository returns ErrRepositoryNotFound.
+//
+// This is always the base repository. The merge commit and its parents
+// live there, so PRs whose head fork was deleted can still be analyzed.
+func (impl *defaultPRImplementation) loadRepository(ctx context.Context, pr *PullRequest) error {
+	cache := impl.getOptions().RepositoryCache
+	if cache != nil {
+		if ghRepo, ok := cache.Get(pr.RepoOwner, pr.RepoName); ok {
+			pr.Repository = impl.githubAPIUser.NewRepository(ghRepo)
+			return nil
+		}
+	}
+
+	var ghRepo *gogithub.Repository
+	err := impl.doWithRetry(ctx, "repos.Get", func() (resp *gogithub.Response, err error) {
+		ghRepo, resp, err = impl.githubAPIUser.GitHubClient().Repositories.Get(ctx, pr.RepoOwner, pr.RepoName)
+		return resp, err
+	})
 	if err != nil {
-		logrus.Error(err)
-		return
+		if isNotFound(err) {
+			return errors.Wrapf(ErrRepositoryNotFound, "loading %s/%s", pr.RepoOwner, pr.RepoName)
+		}
+		return errors.Wrapf(err, "fetching repository %s/%s from github api", pr.RepoOwner, pr.RepoName)
+	}
+	if cache != nil {
+		cache.Set(pr.RepoOwner, pr.RepoName, ghRepo)
 	}
 	pr.Repository = impl.githubAPIUser.NewRepository(ghRepo)
+	return nil
 }
 
 // GetMergeMode implements an algo to try and determine how the PR was
@@ -31,36 +66,75 @@ func (impl *defaultPRImplementation) loadRepository(ctx context.Context, pr *Pul
 // which have been squashed or rebased, but for practical purposes this
 // edge case in non relevant.
 //
-// The PR commits must be fetched beforehand and passed to this function
-// to be able to mock it properly.
+// The last PR commit and the number of commits in the PR must be fetched
+// beforehand and passed to this function to be able to mock it properly.
 func (impl *defaultPRImplementation) getMergeMode(
-	ctx context.Context, pr *PullRequest, commits []*Commit,
-) (mode string, err error) {
+	ctx context.Context, pr *PullRequest, lastCommit *Commit, total int,
+) (mode MergeMode, err error) {
+	return impl.getMergeModeWithMergeSHA(ctx, pr, pr.MergeCommitSHA, lastCommit, total)
+}
 
-	if pr.GetRepository(ctx) == nil {
-		return "", errors.New("unable to get merge mode, pull request has no repo")
+// getMergeModeWithMergeSHA computes the merge mode of the pull request
+// taking mergeSHA as its merge commit instead of the one stored in the PR
+func (impl *defaultPRImplementation) getMergeModeWithMergeSHA(
+	ctx context.Context, pr *PullRequest, mergeSHA string, lastCommit *Commit, total int,
+) (mode MergeMode, err error) {
+	// Without a merge commit there is nothing to ask GitHub
+	if mergeSHA == "" {
+		return MergeModeUnknown, errors.Wrapf(ErrNoMergeCommit, "PR #%d", pr.Number)
 	}
+	// The trees and parents are compared against the last PR commit
+	if lastCommit == nil {
+		return MergeModeUnknown, errors.Wrapf(ErrNoCommits, "PR #%d", pr.Number)
+	}
+	defer func() {
+		if err == nil {
+			impl.getMetrics().IncMergeMode(mode)
+		}
+	}()
 
-	// Fetch the PR data from the github API
-	mergeCommit, err := pr.GetRepository(ctx).GetCommit(ctx, pr.MergeCommitSHA)
+	// Fetch the merge commit from the github API
+	mergeCommit, err := impl.getMergeCommit(ctx, pr, mergeSHA)
 	if err != nil {
-		return "", errors.Wrapf(err, "querying GitHub for merge commit %s", pr.MergeCommitSHA)
-	}
-	if mergeCommit == nil {
-		return "", errors.Errorf("commit returned empty when querying sha %s", pr.MergeCommitSHA)
+		return MergeModeUnknown, errors.Wrap(err, "unable to get merge mode")
 	}
 
 	// If the SHA commit has more than one parent, it is definitely a merge commit.
+	// A classic merge commit has the last PR commit as one of its parents. When
+	// it does not, the commit was created by a merge queue from its temporary
+	// branch and the PR commits are not part of the history.
 	if len(mergeCommit.Parents) > 1 {
-		logrus.Info(fmt.Sprintf("PR #%d merged via a merge commit", pr.Number))
-		return MERGE, nil
+		if !mergeCommit.HasParent(lastCommit.SHA) {
+			impl.log(pr).Infof("PR #%d merged via a merge queue", pr.Number)
+			return MergeModeQueue, nil
+		}
+		impl.log(pr).Infof("PR #%d merged via a merge commit", pr.Number)
+		return MergeModeMerge, nil
 	}
 
 	// A special case: if the PR only has one commit, we cannot tell if it was rebased or
-	// squashed. We return "squash" preemptibly to avoid recomputing trees unnecessarily.
-	if len(commits) == 1 {
-		logrus.Info(fmt.Sprintf("Considering PR #%d as squash as it only has one commit", pr.Number))
-		return SQUASH, nil
+	// squashed by comparing trees. By default we return "squash" preemptibly to avoid
+	// recomputing trees unnecessarily.
+	if total == 1 {
+		if !impl.getOptions().AccurateSingleCommitMode {
+			impl.log(pr).Infof("Considering PR #%d as squash as it only has one commit", pr.Number)
+			return MergeModeSquash, nil
+		}
+
+		// In accurate mode, a rebased commit keeps its SHA in the branch
+		// while a squashed commit only shares the tree with the PR commit
+		if mergeCommit.SHA == lastCommit.SHA {
+			if err := impl.verifyMergeCommit(ctx, pr, mergeCommit.SHA); err != nil {
+				return MergeModeUnknown, err
+			}
+			impl.log(pr).Infof("PR #%d was merged via rebase of its only commit", pr.Number)
+			return MergeModeRebase, nil
+		}
+		impl.log(pr).Infof(
+			"PR #%d was merged via squash (merge tree: %s - PR tree: %s)",
+			pr.Number, mergeCommit.TreeSHA, lastCommit.TreeSHA,
+		)
+		return MergeModeSquash, nil
 	}
 
 	// Now, to be able to determine if the PR was squashed, we have to compare the trees
@@ -78,41 +152,180 @@ func (impl *defaultPRImplementation) getMergeMode(
 
 	// Fetch trees from both the merge commit and the last commit in the PR
 	mergeTree := mergeCommit.TreeSHA
-	prTree := commits[len(commits)-1].TreeSHA
+	prTree := lastCommit.TreeSHA
 
-	logrus.Info(fmt.Sprintf("Merge tree: %s - PR tree: %s", mergeTree, prTree))
+	impl.log(pr).Infof("Merge tree: %s - PR tree: %s", mergeTree, prTree)
 
 	// Compare the tree shas...
 	if mergeTree == prTree {
-		// ... if they match the PR was rebased
-		logrus.Info(fmt.Sprintf("PR #%d was merged via rebase", pr.Number))
-		return REBASE, nil
+		// ... if they match the PR was rebased, unless the merge commit is stale
+		if err := impl.verifyMergeCommit(ctx, pr, mergeCommit.SHA); err != nil {
+			return MergeModeUnknown, err
+		}
+		impl.log(pr).Infof("PR #%d was merged via rebase", pr.Number)
+		return MergeModeRebase, nil
 	}
 
 	// Otherwise it was squashed
-	logrus.Info(fmt.Sprintf("PR #%d was merged via squash", pr.Number))
-	return SQUASH, nil
+	impl.log(pr).Infof("PR #%d was merged via squash", pr.Number)
+	return MergeModeSquash, nil
 }
 
-// getCommits returns the commits of the PR
-func (impl *defaultPRImplementation) getCommits(ctx context.Context, pr *PullRequest) ([]*Commit, error) {
-	// Fixme read response and add retries
-	commitList, _, err := impl.githubAPIUser.GitHubClient().PullRequests.ListCommits(
-		ctx, pr.RepoOwner, pr.RepoName, pr.Number, &gogithub.ListOptions{},
-	)
+// getMergeCommit returns the commit with the SHA. When it is the merge
+// commit of the PR, the copy stored in it is used. Other commits are read
+// from the API without storing them, so the PR is not modified.
+func (impl *defaultPRImplementation) getMergeCommit(ctx context.Context, pr *PullRequest, sha string) (*Commit, error) {
+	if sha == pr.MergeCommitSHA {
+		return pr.GetMergeCommit(ctx)
+	}
+	repo, err := pr.GetRepository(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get merge commit")
+	}
+	commit, err := repo.GetCommit(ctx, sha)
+	if err != nil {
+		return nil, errors.Wrapf(err, "querying GitHub for merge commit %s", sha)
+	}
+	if commit == nil {
+		return nil, &EmptyCommitError{SHA: sha, Op: "reading overridden merge commit"}
+	}
+	return commit, nil
+}
+
+// verifyMergeCommit checks that the merge commit is reachable from the
+// base branch of the PR when the option to verify it is set. Otherwise
+// it may be an old test merge commit and its tree cannot be trusted.
+func (impl *defaultPRImplementation) verifyMergeCommit(ctx context.Context, pr *PullRequest, sha string) error {
+	if !impl.getOptions().VerifyMergeCommit {
+		return nil
+	}
+	if pr.BaseRef == "" {
+		impl.log(pr).Warnf("Base branch of PR #%d is unknown, merge commit %s not verified", pr.Number, sha)
+		return nil
+	}
+
+	// The branch contains the commit if it is identical or ahead of it
+	var comparison *gogithub.CommitsComparison
+	err := impl.doWithRetry(ctx, "repos.CompareCommits", func() (resp *gogithub.Response, err error) {
+		comparison, resp, err = impl.GitHubClient().Repositories.CompareCommits(
+			ctx, pr.RepoOwner, pr.RepoName, sha, pr.BaseRef, &gogithub.ListOptions{PerPage: 1},
+		)
+		return resp, err
+	})
 	if err != nil {
-		return nil, errors.Wrapf(err, "querying GitHub for commits in PR %d", pr.Number)
+		if isNotFound(err) {
+			return errors.Wrapf(ErrStaleMergeCommit, "merge commit %s of PR #%d does not exist", sha, pr.Number)
+		}
+		return errors.Wrapf(err, "comparing merge commit %s with %s", sha, pr.BaseRef)
 	}
+	if status := comparison.GetStatus(); status != "identical" && status != "ahead" {
+		return errors.Wrapf(
+			ErrStaleMergeCommit, "merge commit %s of PR #%d is not in %s (%s)", sha, pr.Number, pr.BaseRef, status,
+		)
+	}
+	return nil
+}
 
+// getCommits returns the commits of the PR
+func (impl *defaultPRImplementation) getCommits(ctx context.Context, pr *PullRequest) ([]*Commit, error) {
 	list := []*Commit{}
-	for _, ghCommit := range commitList {
-		list = append(list, impl.githubAPIUser.NewCommit(ghCommit.Commit))
+	opts := &gogithub.ListOptions{PerPage: impl.getOptions().PageSize}
+	for {
+		var commitList []*gogithub.RepositoryCommit
+		var resp *gogithub.Response
+		err := impl.doWithRetry(ctx, "pulls.ListCommits", func() (_ *gogithub.Response, err error) {
+			commitList, resp, err = impl.githubAPIUser.GitHubClient().PullRequests.ListCommits(
+				ctx, pr.RepoOwner, pr.RepoName, pr.Number, opts,
+			)
+			return resp, err
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "querying GitHub for commits in PR %d", pr.Number)
+		}
+
+		for _, ghCommit := range commitList {
+			list = append(list, impl.githubAPIUser.NewRepositoryCommit(ghCommit))
+		}
+
+		// Keep reading until GitHub stops returning a next page
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
 	}
 
-	logrus.Info(fmt.Sprintf("Read %d commits from PR %d", len(commitList), pr.Number))
+	impl.log(pr).Infof("Read %d commits from PR %d", len(list), pr.Number)
 	return list, nil
 }
 
+// getChangedFiles lists the files modified by the PR
+func (impl *defaultPRImplementation) getChangedFiles(ctx context.Context, pr *PullRequest) ([]*CommitFile, error) {
+	files := []*CommitFile{}
+	opts := &gogithub.ListOptions{PerPage: 100}
+	for {
+		var ghFiles []*gogithub.CommitFile
+		var resp *gogithub.Response
+		err := impl.doWithRetry(ctx, "pulls.ListFiles", func() (_ *gogithub.Response, err error) {
+			ghFiles, resp, err = impl.GitHubClient().PullRequests.ListFiles(
+				ctx, pr.RepoOwner, pr.RepoName, pr.Number, opts,
+			)
+			return resp, err
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "querying GitHub for files in PR %d", pr.Number)
+		}
+
+		for _, f := range ghFiles {
+			files = append(files, newCommitFile(f))
+		}
+
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	impl.log(pr).Infof("Read %d changed files from PR %d", len(files), pr.Number)
+	return files, nil
+}
+
+// getLastCommit returns the last commit of the PR and the number of
+// commits in it. It reads the first page to find out how many pages
+// there are and then the last one, without loading the rest.
+func (impl *defaultPRImplementation) getLastCommit(
+	ctx context.Context, pr *PullRequest,
+) (commit *Commit, total int, err error) {
+	opts := &gogithub.ListOptions{PerPage: impl.getOptions().PageSize}
+	var commitList []*gogithub.RepositoryCommit
+	var resp *gogithub.Response
+	listPage := func() error {
+		return impl.doWithRetry(ctx, "pulls.ListCommits", func() (_ *gogithub.Response, err error) {
+			commitList, resp, err = impl.GitHubClient().PullRequests.ListCommits(
+				ctx, pr.RepoOwner, pr.RepoName, pr.Number, opts,
+			)
+			return resp, err
+		})
+	}
+
+	if err := listPage(); err != nil {
+		return nil, 0, errors.Wrapf(err, "querying GitHub for commits in PR %d", pr.Number)
+	}
+	total = len(commitList)
+	if resp != nil && resp.LastPage > 1 {
+		// All pages but the last are full, use the size of the first one
+		pageSize := len(commitList)
+		opts.Page = resp.LastPage
+		if err := listPage(); err != nil {
+			return nil, 0, errors.Wrapf(err, "querying GitHub for the last commits in PR %d", pr.Number)
+		}
+		total = (opts.Page-1)*pageSize + len(commitList)
+	}
+	if len(commitList) == 0 {
+		return nil, 0, errors.Wrapf(ErrNoCommits, "PR #%d", pr.Number)
+	}
+	return impl.NewRepositoryCommit(commitList[len(commitList)-1]), total, nil
+}
+
 // findPatchTree analyzes the parents of the PR's merge commit and
 // returns the parent ID whose tree should be used to generate diff for
 // the cherry pick.
@@ -122,13 +335,10 @@ func (impl *defaultPRImplementation) getCommits(ctx context.Context, pr *PullReq
 func (impl *defaultPRImplementation) findPatchTree(
 	ctx context.Context, pr *PullRequest,
 ) (parentNr int, err error) {
-	// Get the pull request commits
-	commits, err := pr.GetCommits(ctx)
+	// Only the last commit of the pull request is needed
+	lastCommit, _, err := pr.GetLastCommit(ctx)
 	if err != nil {
-		return 0, errors.Wrap(err, "getting pr commits")
-	}
-	if len(commits) == 0 {
-		return 0, errors.New("unable to find patch tree, commit list is empty")
+		return 0, errors.Wrap(err, "getting last pr commit")
 	}
 
 	// They way to find out which tree to use is to search the tree from
@@ -136,43 +346,85 @@ func (impl *defaultPRImplementation) findPatchTree(
 	// the tree in the PR parent
 
 	// Get the commit information
-	repoCommit, _, err := impl.GitHubClient().Repositories.GetCommit(
-		ctx, pr.RepoOwner, pr.RepoName, pr.MergeCommitSHA, &gogithub.ListOptions{},
-	)
+	mergeCommit, err := pr.GetMergeCommit(ctx)
 	if err != nil {
-		return 0, errors.Wrapf(err, "querying GitHub for merge commit %s", pr.MergeCommitSHA)
-	}
-	if repoCommit == nil {
-		return 0, errors.Errorf("commit returned empty when querying sha %s", pr.MergeCommitSHA)
+		return 0, errors.Wrap(err, "unable to find patch tree")
 	}
 
-	mergeCommit := impl.githubAPIUser.NewCommit(repoCommit.Commit)
-
 	// First, get the tree hash from the last commit in the PR
-	prSHA := commits[len(commits)-1].TreeSHA
+	prSHA := lastCommit.TreeSHA
+
+	// Now, fetch the parents concurrently and see which one matches the
+	// tree hash extracted from the commit. As soon as a match is found and
+	// all parents before it were checked, the rest of the requests are
+	// canceled. This keeps the result deterministic: if more than one
+	// parent matches, the lowest index wins.
+	searchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mtx sync.Mutex
+	checked := make([]bool, len(mergeCommit.Parents))
+	matched := make([]bool, len(mergeCommit.Parents))
+	winner := -1
 
-	// Now, cycle the parents, fetch their commits and see which one matches
-	// the tree hash extracted from the commit
+	concurrency := impl.getOptions().Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	g, gctx := errgroup.WithContext(searchCtx)
+	g.SetLimit(concurrency)
 	for pn, parent := range mergeCommit.Parents {
-		parentCommit, _, err := impl.GitHubClient().Repositories.GetCommit(
-			ctx, pr.RepoOwner, pr.RepoName, parent.SHA, &gogithub.ListOptions{})
-		if err != nil {
-			return 0, errors.Wrapf(err, "querying GitHub for parent commit %s", parent.SHA)
-		}
-		if parentCommit == nil {
-			return 0, errors.Errorf("commit returned empty when querying sha %s", parent.SHA)
-		}
+		pn, parent := pn, parent
+		g.Go(func() error {
+			var parentCommit *gogithub.RepositoryCommit
+			err := impl.doWithRetry(gctx, "repos.GetCommit", func() (resp *gogithub.Response, err error) {
+				parentCommit, resp, err = impl.GitHubClient().Repositories.GetCommit(
+					gctx, pr.RepoOwner, pr.RepoName, parent.SHA, &gogithub.ListOptions{})
+				return resp, err
+			})
+			if err != nil {
+				return errors.Wrapf(err, "querying GitHub for parent commit %s", parent.SHA)
+			}
+			if parentCommit == nil {
+				return &EmptyCommitError{SHA: parent.SHA, Op: "reading merge commit parent"}
+			}
 
-		parentTreeSHA := parentCommit.Commit.GetTree().GetSHA()
-		logrus.Info(fmt.Sprintf("PR: %s - Parent: %s", prSHA, parentTreeSHA))
-		if parentTreeSHA == prSHA {
-			logrus.Info(fmt.Sprintf("Cherry pick to be performed diffing the parent #%d tree ", pn))
-			return pn, nil
-		}
+			// Read the tree like the rest of the commits, from the wrapped type
+			parentTreeSHA := impl.NewRepositoryCommit(parentCommit).TreeSHA
+			impl.log(pr).Infof("PR: %s - Parent: %s", prSHA, parentTreeSHA)
+
+			mtx.Lock()
+			defer mtx.Unlock()
+			checked[pn] = true
+			matched[pn] = parentTreeSHA == prSHA
+			for i := range checked {
+				if !checked[i] {
+					break
+				}
+				if matched[i] {
+					winner = i
+					cancel()
+					break
+				}
+			}
+			return nil
+		})
+	}
+
+	err = g.Wait()
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	if winner >= 0 {
+		impl.log(pr).Infof("Cherry pick to be performed diffing the parent #%d tree ", winner)
+		return winner, nil
+	}
+	if err != nil {
+		return 0, err
 	}
 
 	// If not found, we return an error to make sure we don't use 0
-	return 0, errors.Errorf(
-		"unable to find patch tree of merge commit among %d parents", len(mergeCommit.Parents),
+	return 0, errors.Wrapf(
+		ErrPatchTreeNotFound, "searching merge commit %s among %d parents", pr.MergeCommitSHA, len(mergeCommit.Parents),
 	)
 }
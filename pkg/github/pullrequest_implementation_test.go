@@ -0,0 +1,653 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestAPIUser returns a githubAPIUser whose client talks to a local
+// test server driven by mux instead of the real GitHub API
+func newTestAPIUser(t testing.TB, mux *http.ServeMux) githubAPIUser {
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client := gogithub.NewClient(nil)
+	baseURL, err := url.Parse(server.URL + "/")
+	require.Nil(t, err)
+	client.BaseURL = baseURL
+	opts := defaultOptions
+	opts.RepositoryCache = nil
+	return githubAPIUser{client: NewClient(client), options: &opts}
+}
+
+func TestGetCommitsPagination(t *testing.T) {
+	const totalPages = 3
+	pagesRead := 0
+	perPage := ""
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/mattermost/mattermost-server/pulls/18746/commits", func(w http.ResponseWriter, r *http.Request) {
+		page := 1
+		if p := r.URL.Query().Get("page"); p != "" {
+			page, _ = strconv.Atoi(p)
+		}
+		pagesRead++
+		perPage = r.URL.Query().Get("per_page")
+		if page < totalPages {
+			w.Header().Set("Link", fmt.Sprintf(
+				`<http://%s%s?page=%d>; rel="next"`, r.Host, r.URL.Path, page+1,
+			))
+		}
+		fmt.Fprintf(w,
+			`[{"sha":"commit-%d-a","commit":{"tree":{"sha":"tree-%d-a"}}},{"sha":"commit-%d-b","commit":{"tree":{"sha":"tree-%d-b"}}}]`,
+			page, page, page, page,
+		)
+	})
+
+	impl := &defaultPRImplementation{githubAPIUser: newTestAPIUser(t, mux)}
+	pr := &PullRequest{
+		RepoOwner: "mattermost",
+		RepoName:  "mattermost-server",
+		Number:    18746,
+	}
+
+	commits, err := impl.getCommits(context.Background(), pr)
+	require.Nil(t, err)
+	require.Equal(t, totalPages, pagesRead)
+	require.Len(t, commits, totalPages*2)
+	require.Equal(t, "tree-1-a", commits[0].TreeSHA)
+	require.Equal(t, "tree-3-b", commits[len(commits)-1].TreeSHA)
+	require.Empty(t, perPage)
+
+	// The page size can be set in the options
+	impl.options.PageSize = 2
+	pagesRead = 0
+	commits, err = impl.getCommits(context.Background(), pr)
+	require.Nil(t, err)
+	require.Equal(t, totalPages, pagesRead)
+	require.Len(t, commits, totalPages*2)
+	require.Equal(t, "2", perPage)
+}
+
+func TestLoadRepository(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/mattermost/mattermost-server", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"name":"mattermost-server","owner":{"login":"mattermost"}}`))
+	})
+	mux.HandleFunc("/repos/mattermost/deleted-repo", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	impl := &defaultPRImplementation{githubAPIUser: newTestAPIUser(t, mux)}
+
+	// A repository that exists gets loaded into the PR
+	pr := &PullRequest{RepoOwner: "mattermost", RepoName: "mattermost-server", Number: 1}
+	require.Nil(t, impl.loadRepository(context.Background(), pr))
+	require.NotNil(t, pr.Repository)
+	require.Equal(t, "mattermost", pr.Repository.Owner)
+	require.Equal(t, "mattermost-server", pr.Repository.Name)
+
+	// A 404 must be distinguishable from other errors
+	pr = &PullRequest{RepoOwner: "mattermost", RepoName: "deleted-repo", Number: 1}
+	err := impl.loadRepository(context.Background(), pr)
+	require.NotNil(t, err)
+	require.True(t, errors.Is(err, ErrRepositoryNotFound))
+	require.Nil(t, pr.Repository)
+
+	// A PR without owner or name cannot look up its repository
+	pr = &PullRequest{impl: impl, Number: 1}
+	_, err = pr.GetRepository(context.Background())
+	require.True(t, errors.Is(err, ErrNoRepository))
+}
+
+// serveCommit registers in mux a commit returned by the repository API
+func serveCommit(mux *http.ServeMux, sha, tree string, parents ...string) {
+	parentList := []string{}
+	for _, p := range parents {
+		parentList = append(parentList, fmt.Sprintf(`{"sha":%q}`, p))
+	}
+	mux.HandleFunc("/repos/mattermost/mattermost-server/commits/"+sha, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"sha":%q,"commit":{"tree":{"sha":%q}},"parents":[%s]}`, sha, tree, strings.Join(parentList, ","))
+	})
+}
+
+// newMergeCommitMux returns a mux serving a PR whose last commit has the
+// tree "pr-tree" and was merged by a commit with the specified parent trees
+func newMergeCommitMux(parentTrees []string, delay time.Duration) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/mattermost/mattermost-server", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"name":"mattermost-server","owner":{"login":"mattermost"}}`))
+	})
+	mux.HandleFunc("/repos/mattermost/mattermost-server/pulls/1/commits", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"sha":"pr-commit","commit":{"tree":{"sha":"pr-tree"}}}]`))
+	})
+	parents := []string{}
+	for i, tree := range parentTrees {
+		sha := fmt.Sprintf("parent-%d", i)
+		parents = append(parents, sha)
+		parentTree := tree
+		mux.HandleFunc("/repos/mattermost/mattermost-server/commits/"+sha, func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(delay)
+			fmt.Fprintf(w, `{"sha":%q,"commit":{"tree":{"sha":%q}}}`, sha, parentTree)
+		})
+	}
+	serveCommit(mux, "merge-commit", "merge-tree", parents...)
+	return mux
+}
+
+func TestFindPatchTree(t *testing.T) {
+	for _, tc := range []struct {
+		ParentTrees []string
+		Expected    int
+		ShouldErr   bool
+	}{
+		{ParentTrees: []string{"branch-tree", "pr-tree"}, Expected: 1},
+		{ParentTrees: []string{"pr-tree", "branch-tree"}, Expected: 0},
+		{ParentTrees: []string{"branch-tree", "other-tree", "pr-tree", "another-tree"}, Expected: 2}, // Octopus merge
+		{ParentTrees: []string{"branch-tree", "pr-tree", "pr-tree"}, Expected: 1},                    // Lowest index wins
+		{ParentTrees: []string{"branch-tree", "other-tree"}, ShouldErr: true},
+	} {
+		impl := &defaultPRImplementation{
+			githubAPIUser: newTestAPIUser(t, newMergeCommitMux(tc.ParentTrees, 0)),
+		}
+		pr := &PullRequest{
+			impl:           impl,
+			RepoOwner:      "mattermost",
+			RepoName:       "mattermost-server",
+			Number:         1,
+			MergeCommitSHA: "merge-commit",
+		}
+		parent, err := impl.findPatchTree(context.Background(), pr)
+		if tc.ShouldErr {
+			require.NotNil(t, err)
+			require.True(t, errors.Is(err, ErrPatchTreeNotFound))
+			continue
+		}
+		require.Nil(t, err)
+		require.Equal(t, tc.Expected, parent)
+	}
+}
+
+func TestFindPatchTreeEmptyParent(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	impl := &defaultPRImplementation{githubAPIUser: gau}
+	pr := &PullRequest{
+		impl:           impl,
+		RepoOwner:      "mattermost",
+		RepoName:       "mattermost-server",
+		Number:         1,
+		MergeCommitSHA: "merge",
+	}
+	fakes.pulls.Commits[1] = []*gogithub.RepositoryCommit{fakes.addCommit("pr-1", "pr-tree")}
+	fakes.addCommit("merge", "merge-tree", "main", "pr-1")
+	fakes.repos.Commits["main"] = nil
+
+	// The error tells which commit came back empty and what was reading it
+	_, err := impl.findPatchTree(context.Background(), pr)
+	require.True(t, errors.Is(err, ErrEmptyCommit))
+	emptyErr := &EmptyCommitError{}
+	require.True(t, errors.As(err, &emptyErr))
+	require.Equal(t, "main", emptyErr.SHA)
+	require.Equal(t, "reading merge commit parent", emptyErr.Op)
+}
+
+func TestCommitTreeAccessors(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	impl := &defaultPRImplementation{githubAPIUser: gau}
+
+	// The wrapped commit exposes the same data as the raw one
+	raw := fakes.addCommit("merge", "merge-tree", "main", "pr-2")
+	commit := impl.NewRepositoryCommit(raw)
+	require.Equal(t, raw.GetCommit().GetTree().GetSHA(), commit.TreeSHA)
+	require.Equal(t, []string{"main", "pr-2"}, commit.ParentSHAs())
+	require.True(t, commit.HasParent("pr-2"))
+	require.False(t, commit.HasParent("merge"))
+	require.Empty(t, (&Commit{}).ParentSHAs())
+
+	// findPatchTree and getMergeMode agree on the tree of the PR head
+	fakes.pulls.Commits[1] = []*gogithub.RepositoryCommit{
+		fakes.addCommit("pr-1", "tree-1", "main"), fakes.addCommit("pr-2", "tree-2", "pr-1"),
+	}
+	fakes.addCommit("main", "main-tree")
+	pr := &PullRequest{
+		impl:           impl,
+		RepoOwner:      "mattermost",
+		RepoName:       "mattermost-server",
+		Number:         1,
+		MergeCommitSHA: "merge",
+	}
+	parent, err := impl.findPatchTree(context.Background(), pr)
+	require.Nil(t, err)
+	require.Equal(t, 1, parent)
+	mode, err := pr.GetMergeModeWithMergeSHA(context.Background(), commit.ParentSHAs()[parent])
+	require.Nil(t, err)
+	require.Equal(t, MergeModeRebase, mode)
+}
+
+func BenchmarkFindPatchTree(b *testing.B) {
+	impl := &defaultPRImplementation{
+		githubAPIUser: newTestAPIUser(b, newMergeCommitMux(
+			[]string{"branch-tree", "other-tree", "another-tree", "pr-tree"}, 10*time.Millisecond,
+		)),
+	}
+	pr := &PullRequest{
+		impl:           impl,
+		RepoOwner:      "mattermost",
+		RepoName:       "mattermost-server",
+		Number:         1,
+		MergeCommitSHA: "merge-commit",
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := impl.findPatchTree(context.Background(), pr); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestGetMergeMode(t *testing.T) {
+	for _, tc := range []struct {
+		Name      string
+		Accurate  bool
+		MergeTree string
+		MergeSHA  string
+		Parents   []string
+		PRCommits []*Commit
+		Expected  MergeMode
+	}{
+		{
+			Name: "merge commit", MergeSHA: "merge", MergeTree: "tree-2", Parents: []string{"branch", "pr-2"},
+			PRCommits: []*Commit{{SHA: "pr-1", TreeSHA: "tree-1"}, {SHA: "pr-2", TreeSHA: "tree-2"}},
+			Expected:  MergeModeMerge,
+		},
+		{
+			// The queue merges its temporary branch, the PR head is not a parent
+			Name: "merge queue", MergeSHA: "queue-merge", MergeTree: "tree-3", Parents: []string{"branch", "gh-readonly-queue-1"},
+			PRCommits: []*Commit{{SHA: "pr-1", TreeSHA: "tree-1"}, {SHA: "pr-2", TreeSHA: "tree-2"}},
+			Expected:  MergeModeQueue,
+		},
+		{
+			Name: "rebase", MergeSHA: "rebased-2", MergeTree: "tree-2", Parents: []string{"rebased-1"},
+			PRCommits: []*Commit{{SHA: "pr-1", TreeSHA: "tree-1"}, {SHA: "pr-2", TreeSHA: "tree-2"}},
+			Expected:  MergeModeRebase,
+		},
+		{
+			Name: "squash", MergeSHA: "squashed", MergeTree: "tree-3", Parents: []string{"branch"},
+			PRCommits: []*Commit{{SHA: "pr-1", TreeSHA: "tree-1"}, {SHA: "pr-2", TreeSHA: "tree-2"}},
+			Expected:  MergeModeSquash,
+		},
+		{
+			Name: "single commit, fast mode", MergeSHA: "pr-1", MergeTree: "tree-1", Parents: []string{"branch"},
+			PRCommits: []*Commit{{SHA: "pr-1", TreeSHA: "tree-1"}},
+			Expected:  MergeModeSquash,
+		},
+		{
+			Name: "single commit rebased, accurate mode", Accurate: true, MergeSHA: "pr-1", MergeTree: "tree-1",
+			Parents: []string{"branch"}, PRCommits: []*Commit{{SHA: "pr-1", TreeSHA: "tree-1"}},
+			Expected: MergeModeRebase,
+		},
+		{
+			Name: "single commit squashed, accurate mode", Accurate: true, MergeSHA: "squashed", MergeTree: "tree-1",
+			Parents: []string{"branch"}, PRCommits: []*Commit{{SHA: "pr-1", TreeSHA: "tree-1"}},
+			Expected: MergeModeSquash,
+		},
+	} {
+		gau, fakes := newFakeAPIUser()
+		fakes.addCommit(tc.MergeSHA, tc.MergeTree, tc.Parents...)
+		gau.options = &Options{AccurateSingleCommitMode: tc.Accurate}
+		impl := &defaultPRImplementation{githubAPIUser: gau}
+		pr := &PullRequest{
+			impl:           impl,
+			RepoOwner:      "mattermost",
+			RepoName:       "mattermost-server",
+			Number:         1,
+			MergeCommitSHA: tc.MergeSHA,
+		}
+		mode, err := impl.getMergeMode(
+			context.Background(), pr, tc.PRCommits[len(tc.PRCommits)-1], len(tc.PRCommits),
+		)
+		require.Nil(t, err, tc.Name)
+		require.Equal(t, tc.Expected, mode, tc.Name)
+	}
+}
+
+func TestPRLogger(t *testing.T) {
+	logger, hook := logtest.NewNullLogger()
+	gau, _ := newFakeAPIUser()
+	gau.options.Logger = NewLogrusLogger(logger)
+	pr := gau.NewPullRequest(&gogithub.PullRequest{
+		Number: gogithub.Int(1),
+		Base: &gogithub.PullRequestBranch{Repo: &gogithub.Repository{
+			Name: gogithub.String("mattermost-server"), Owner: &gogithub.User{Login: gogithub.String("mattermost")},
+		}},
+	})
+
+	// Log entries identify the pull request
+	pr.impl.log(pr).Infof("test")
+	require.Len(t, hook.Entries, 1)
+	require.Equal(t, "mattermost/mattermost-server", hook.LastEntry().Data["repo"])
+	require.Equal(t, 1, hook.LastEntry().Data["pr_number"])
+
+	// Any logger can be plugged in
+	recorder := &recordingLogger{}
+	gau.options.Logger = recorder
+	pr = gau.NewPullRequest(&gogithub.PullRequest{Number: gogithub.Int(2)})
+	pr.impl.log(pr).Warnf("careful with #%d", 2)
+	require.Equal(t, []string{"careful with #2"}, recorder.messages)
+	require.Equal(t, 2, recorder.fields["pr_number"])
+}
+
+// recordingLogger keeps the messages logged and the last fields set
+type recordingLogger struct {
+	messages []string
+	fields   map[string]interface{}
+}
+
+func (rl *recordingLogger) log(format string, args ...interface{}) {
+	rl.messages = append(rl.messages, fmt.Sprintf(format, args...))
+}
+
+func (rl *recordingLogger) Debugf(format string, args ...interface{}) { rl.log(format, args...) }
+func (rl *recordingLogger) Infof(format string, args ...interface{})  { rl.log(format, args...) }
+func (rl *recordingLogger) Warnf(format string, args ...interface{})  { rl.log(format, args...) }
+func (rl *recordingLogger) Errorf(format string, args ...interface{}) { rl.log(format, args...) }
+
+func (rl *recordingLogger) WithFields(fields map[string]interface{}) Logger {
+	rl.fields = fields
+	return rl
+}
+
+func TestMergeCommitFetchedOnce(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	impl := &defaultPRImplementation{githubAPIUser: gau}
+	fakes.pulls.Commits[1] = []*gogithub.RepositoryCommit{
+		fakes.addCommit("pr-1", "tree-1", "main-old"),
+		fakes.addCommit("pr-2", "tree-2", "pr-1"),
+	}
+	fakes.addCommit("main", "tree-main")
+	fakes.addCommit("pr-merge", "tree-merged", "main", "pr-2")
+	pr := &PullRequest{
+		impl:           impl,
+		RepoOwner:      "mattermost",
+		RepoName:       "mattermost-server",
+		Number:         1,
+		MergeCommitSHA: "pr-merge",
+	}
+
+	mode, err := pr.GetMergeMode(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, MergeModeMerge, mode)
+	parent, err := pr.PatchTreeID(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, 1, parent)
+
+	// The merge commit is shared by both operations
+	require.Equal(t, 1, fakes.repos.CommitCalls["pr-merge"])
+}
+
+func TestPullRequestState(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	mergedAt := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+	merged := gau.NewPullRequest(&gogithub.PullRequest{
+		Number: gogithub.Int(1), State: gogithub.String("closed"), Merged: gogithub.Bool(true),
+		MergedAt: &mergedAt, MergeCommitSHA: gogithub.String("merge"),
+	})
+	require.True(t, merged.IsMerged())
+	require.False(t, merged.IsOpen())
+	require.False(t, merged.IsDraft())
+	require.Equal(t, mergedAt, merged.MergedAt())
+
+	// Open PRs have a merge_commit_sha pointing to a test merge
+	open := gau.NewPullRequest(&gogithub.PullRequest{
+		Number: gogithub.Int(2), State: gogithub.String("open"), Draft: gogithub.Bool(true),
+		MergeCommitSHA: gogithub.String("test-merge"),
+		Base: &gogithub.PullRequestBranch{Repo: &gogithub.Repository{
+			Name: gogithub.String("mattermost-server"), Owner: &gogithub.User{Login: gogithub.String("mattermost")},
+		}},
+	})
+	require.False(t, open.IsMerged())
+	require.True(t, open.IsOpen())
+	require.True(t, open.IsDraft())
+	require.True(t, open.MergedAt().IsZero())
+
+	fakes.pulls.Commits[2] = []*gogithub.RepositoryCommit{fakes.addCommit("pr-1", "tree-1")}
+	_, err := open.GetMergeMode(context.Background())
+	require.True(t, errors.Is(err, ErrNotMerged))
+	require.Zero(t, fakes.repos.CommitCalls["test-merge"])
+}
+
+func TestGetChangedFiles(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	pr := &PullRequest{
+		impl:      &defaultPRImplementation{githubAPIUser: gau},
+		RepoOwner: "mattermost",
+		RepoName:  "mattermost-server",
+		Number:    1,
+	}
+	fakes.pulls.FilesPerPage = 2
+	fakes.pulls.Files = map[int][]*gogithub.CommitFile{1: {
+		{Filename: gogithub.String("app/app.go"), Status: gogithub.String("modified"), Additions: gogithub.Int(3), Deletions: gogithub.Int(1)},
+		{Filename: gogithub.String("app/new.go"), Status: gogithub.String("added"), Additions: gogithub.Int(10)},
+		{Filename: gogithub.String("img/logo.png"), Status: gogithub.String("removed")},
+		{Filename: gogithub.String("api/v5.go"), PreviousFilename: gogithub.String("api/v4.go"), Status: gogithub.String("renamed")},
+	}}
+
+	files, err := pr.GetChangedFiles(context.Background())
+	require.Nil(t, err)
+	require.Len(t, files, 4)
+	require.Equal(t, &CommitFile{Filename: "app/app.go", Status: "modified", Additions: 3, Deletions: 1}, files[0])
+	require.Equal(t, "img/logo.png", files[2].Filename)
+	require.Equal(t, "api/v4.go", files[3].PreviousFilename)
+
+	// GetFiles returns the same list
+	all, err := pr.GetFiles(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, files, all)
+
+	// Unknown PRs return the API error
+	pr.Number = 2
+	_, err = pr.GetChangedFiles(context.Background())
+	require.NotNil(t, err)
+	_, err = pr.GetFiles(context.Background())
+	require.NotNil(t, err)
+}
+
+func TestGetLastCommit(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	gau.options = &Options{PageSize: 3}
+	pr := &PullRequest{
+		impl:      &defaultPRImplementation{githubAPIUser: gau},
+		RepoOwner: "mattermost",
+		RepoName:  "mattermost-server",
+		Number:    1,
+	}
+	commits := []*gogithub.RepositoryCommit{}
+	for i := 1; i <= 7; i++ {
+		commits = append(commits, fakes.addCommit(fmt.Sprintf("pr-%d", i), fmt.Sprintf("tree-%d", i)))
+	}
+	fakes.pulls.Commits[1] = commits
+
+	// Only the first and last pages are read, once
+	for i := 0; i < 2; i++ {
+		commit, total, err := pr.GetLastCommit(context.Background())
+		require.Nil(t, err)
+		require.Equal(t, "pr-7", commit.SHA)
+		require.Equal(t, "tree-7", commit.TreeSHA)
+		require.Equal(t, 7, total)
+	}
+	require.Equal(t, []int{1, 3}, fakes.pulls.CommitsPages)
+
+	// PRs without commits return ErrNoCommits
+	fakes.pulls.Commits[2] = []*gogithub.RepositoryCommit{}
+	empty := &PullRequest{impl: pr.impl, RepoOwner: "mattermost", RepoName: "mattermost-server", Number: 2}
+	_, _, err := empty.GetLastCommit(context.Background())
+	require.True(t, errors.Is(err, ErrNoCommits))
+}
+
+func TestGetMergeModeNoMergeCommit(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	impl := &defaultPRImplementation{githubAPIUser: gau}
+	pr := &PullRequest{impl: impl, RepoOwner: "mattermost", RepoName: "mattermost-server", Number: 1}
+	fakes.pulls.Commits[1] = []*gogithub.RepositoryCommit{fakes.addCommit("pr-1", "tree-1")}
+
+	// No API calls are made for a PR without merge commit
+	_, err := pr.GetMergeMode(context.Background())
+	require.True(t, errors.Is(err, ErrNoMergeCommit))
+	_, err = impl.getMergeMode(context.Background(), pr, &Commit{SHA: "pr-1"}, 1)
+	require.True(t, errors.Is(err, ErrNoMergeCommit))
+
+	// Nor for a merged PR without commits
+	pr.MergeCommitSHA = "merge"
+	_, err = impl.getMergeMode(context.Background(), pr, nil, 0)
+	require.True(t, errors.Is(err, ErrNoCommits))
+	pr.MergeCommitSHA = ""
+	_, err = pr.GetMergeCommit(context.Background())
+	require.True(t, errors.Is(err, ErrNoMergeCommit))
+	require.Empty(t, fakes.pulls.CommitsPages)
+	require.Empty(t, fakes.repos.CommitCalls)
+}
+
+func TestMergeModeCached(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	pr := &PullRequest{
+		impl:           &defaultPRImplementation{githubAPIUser: gau},
+		RepoOwner:      "mattermost",
+		RepoName:       "mattermost-server",
+		Number:         1,
+		MergeCommitSHA: "merge",
+	}
+	fakes.pulls.Commits[1] = []*gogithub.RepositoryCommit{
+		fakes.addCommit("pr-1", "tree-1"), fakes.addCommit("pr-2", "tree-2"),
+	}
+	fakes.addCommit("merge", "tree-2", "main", "pr-2")
+
+	// Concurrent and repeated calls compute the merge mode once
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mode, err := pr.GetMergeMode(context.Background())
+			require.Nil(t, err)
+			require.Equal(t, MergeModeMerge, mode)
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, 1, fakes.repos.CommitCalls["merge"])
+	require.Len(t, fakes.pulls.CommitsPages, 1)
+
+	// Forcing it reads the data again
+	mode, err := pr.ComputeMergeMode(context.Background(), true)
+	require.Nil(t, err)
+	require.Equal(t, MergeModeMerge, mode)
+	require.Equal(t, 2, fakes.repos.CommitCalls["merge"])
+	require.Len(t, fakes.pulls.CommitsPages, 2)
+
+	// Forced computations can run along the readers of the commits
+	for i := 0; i < 5; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			_, err := pr.ComputeMergeMode(context.Background(), true)
+			require.Nil(t, err)
+		}()
+		go func() {
+			defer wg.Done()
+			commits, err := pr.GetCommits(context.Background())
+			require.Nil(t, err)
+			require.Len(t, commits, 2)
+		}()
+		go func() {
+			defer wg.Done()
+			commit, err := pr.GetMergeCommit(context.Background())
+			require.Nil(t, err)
+			require.Equal(t, "merge", commit.SHA)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestGetMergeModeVerifyMergeCommit(t *testing.T) {
+	for _, tc := range []struct {
+		Name       string
+		Verify     bool
+		Comparison string
+		Stale      bool
+	}{
+		{Name: "verification disabled", Comparison: "diverged"},
+		{Name: "merge commit in branch", Verify: true, Comparison: "ahead"},
+		{Name: "merge commit is the branch head", Verify: true, Comparison: "identical"},
+		{Name: "merge commit not in branch", Verify: true, Comparison: "diverged", Stale: true},
+		{Name: "merge commit missing", Verify: true, Stale: true},
+	} {
+		gau, fakes := newFakeAPIUser()
+		gau.options.VerifyMergeCommit = tc.Verify
+		fakes.addCommit("rebased-2", "tree-2", "rebased-1")
+		if tc.Comparison != "" {
+			fakes.repos.Comparisons = map[string]string{"rebased-2...master": tc.Comparison}
+		}
+		impl := &defaultPRImplementation{githubAPIUser: gau}
+		pr := &PullRequest{
+			impl:           impl,
+			RepoOwner:      "mattermost",
+			RepoName:       "mattermost-server",
+			Number:         1,
+			BaseRef:        "master",
+			MergeCommitSHA: "rebased-2",
+		}
+		mode, err := impl.getMergeMode(context.Background(), pr, &Commit{SHA: "pr-2", TreeSHA: "tree-2"}, 2)
+		if tc.Stale {
+			require.True(t, errors.Is(err, ErrStaleMergeCommit), tc.Name)
+			require.Equal(t, MergeModeUnknown, mode, tc.Name)
+			continue
+		}
+		require.Nil(t, err, tc.Name)
+		require.Equal(t, MergeModeRebase, mode, tc.Name)
+	}
+}
+
+func TestGetMergeModeWithMergeSHA(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	pr := &PullRequest{
+		impl:           &defaultPRImplementation{githubAPIUser: gau},
+		RepoOwner:      "mattermost",
+		RepoName:       "mattermost-server",
+		Number:         1,
+		MergeCommitSHA: "test-merge",
+	}
+	fakes.pulls.Commits[1] = []*gogithub.RepositoryCommit{
+		fakes.addCommit("pr-1", "tree-1"), fakes.addCommit("pr-2", "tree-2"),
+	}
+	fakes.addCommit("test-merge", "tree-test", "main", "pr-2")
+	fakes.addCommit("squashed", "tree-3", "main")
+
+	// The stored SHA points to a merge commit, the real one was a squash
+	mode, err := pr.GetMergeModeWithMergeSHA(context.Background(), "squashed")
+	require.Nil(t, err)
+	require.Equal(t, MergeModeSquash, mode)
+
+	// The PR is left untouched
+	require.Equal(t, "test-merge", pr.MergeCommitSHA)
+	require.Nil(t, pr.mergeCommit)
+	mode, err = pr.GetMergeMode(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, MergeModeMerge, mode)
+
+	_, err = pr.GetMergeModeWithMergeSHA(context.Background(), "")
+	require.True(t, errors.Is(err, ErrNoMergeCommit))
+}
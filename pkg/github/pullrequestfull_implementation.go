@@ -0,0 +1,215 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+)
+
+// fullPRCommits is the number of commits read with the pull request. When
+// it has more, only the last one is kept and the rest are read on demand.
+const fullPRCommits = 100
+
+// fullPRQuery reads all the data of a pull request used by the package
+const fullPRQuery = `query($owner: String!, $name: String!, $number: Int!) {
+  repository(owner: $owner, name: $name) {
+    pullRequest(number: $number) {
+      id number title body url state isDraft merged mergedAt createdAt
+      maintainerCanModify baseRefName headRefName headRefOid
+      author { login }
+      headRepository { nameWithOwner }
+      milestone { number title }
+      labels(first: 100) { nodes { name } }
+      reviews(first: 100) { nodes { author { login } state submittedAt commit { oid } } }
+      mergeCommit { oid tree { oid } parents(first: 10) { nodes { oid } } }
+      allCommits: commits(first: 100) {
+        totalCount
+        nodes {
+          commit {
+            oid message tree { oid } parents(first: 10) { nodes { oid } }
+            author { name email date } committer { name email date }
+          }
+        }
+      }
+      lastCommit: commits(last: 1) {
+        nodes {
+          commit {
+            oid message tree { oid } parents(first: 10) { nodes { oid } }
+            author { name email date } committer { name email date }
+          }
+        }
+      }
+    }
+  }
+}`
+
+// gqlLogin is an actor as returned by the GraphQL queries
+type gqlLogin struct {
+	Login string `json:"login"`
+}
+
+// gqlCommitList is a page of the commits of a pull request
+type gqlCommitList struct {
+	TotalCount int `json:"totalCount"`
+	Nodes      []struct {
+		Commit gqlCommit `json:"commit"`
+	} `json:"nodes"`
+}
+
+// gqlFullPR holds the data read by fullPRQuery
+type gqlFullPR struct {
+	ID                  string     `json:"id"`
+	Number              int        `json:"number"`
+	Title               string     `json:"title"`
+	Body                string     `json:"body"`
+	URL                 string     `json:"url"`
+	State               string     `json:"state"`
+	IsDraft             bool       `json:"isDraft"`
+	Merged              bool       `json:"merged"`
+	MergedAt            *time.Time `json:"mergedAt"`
+	CreatedAt           time.Time  `json:"createdAt"`
+	MaintainerCanModify bool       `json:"maintainerCanModify"`
+	BaseRefName         string     `json:"baseRefName"`
+	HeadRefName         string     `json:"headRefName"`
+	HeadRefOID          string     `json:"headRefOid"`
+	Author              *gqlLogin  `json:"author"`
+	HeadRepository      *struct {
+		NameWithOwner string `json:"nameWithOwner"`
+	} `json:"headRepository"`
+	Milestone *struct {
+		Number int64  `json:"number"`
+		Title  string `json:"title"`
+	} `json:"milestone"`
+	Labels struct {
+		Nodes []struct {
+			Name string `json:"name"`
+		} `json:"nodes"`
+	} `json:"labels"`
+	Reviews struct {
+		Nodes []struct {
+			Author      *gqlLogin  `json:"author"`
+			State       string     `json:"state"`
+			SubmittedAt *time.Time `json:"submittedAt"`
+			Commit      *struct {
+				OID string `json:"oid"`
+			} `json:"commit"`
+		} `json:"nodes"`
+	} `json:"reviews"`
+	MergeCommit *gqlCommit    `json:"mergeCommit"`
+	AllCommits  gqlCommitList `json:"allCommits"`
+	LastCommit  gqlCommitList `json:"lastCommit"`
+}
+
+// loadPullRequestFull reads a pull request and its related data with
+// a single GraphQL query and returns it with the data preloaded
+func (di *defaultGithubImplementation) loadPullRequestFull(
+	ctx context.Context, owner, repo string, number int,
+) (*PullRequest, error) {
+	result := struct {
+		Repository struct {
+			PullRequest *gqlFullPR `json:"pullRequest"`
+		} `json:"repository"`
+	}{}
+	err := di.doWithRetry(ctx, "graphql.Do", func() (*gogithub.Response, error) {
+		return di.GitHubClient().GraphQL.Do(ctx, fullPRQuery, map[string]interface{}{
+			"owner":  owner,
+			"name":   repo,
+			"number": number,
+		}, &result)
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "querying PR #%d from GitHub", number)
+	}
+	if result.Repository.PullRequest == nil {
+		return nil, errors.Errorf("PR #%d not found in %s/%s", number, owner, repo)
+	}
+	return di.newPullRequestFromGraphQL(owner, repo, result.Repository.PullRequest), nil
+}
+
+// newPullRequestFromGraphQL builds a PullRequest from the data
+// read by fullPRQuery, mirroring NewPullRequest
+func (di *defaultGithubImplementation) newPullRequestFromGraphQL(owner, repo string, data *gqlFullPR) *PullRequest {
+	// GraphQL reports merged PRs with their own state, REST as closed
+	state := "closed"
+	if data.State == "OPEN" {
+		state = "open"
+	}
+	pr := &PullRequest{
+		impl:                &defaultPRImplementation{githubAPIUser: di.githubAPIUser, logger: di.getLogger()},
+		NodeID:              data.ID,
+		RepoOwner:           owner,
+		RepoName:            repo,
+		Number:              data.Number,
+		Title:               data.Title,
+		Body:                data.Body,
+		Ref:                 data.HeadRefName,
+		BaseRef:             data.BaseRefName,
+		Sha:                 data.HeadRefOID,
+		State:               state,
+		URL:                 data.URL,
+		CreatedAt:           data.CreatedAt,
+		Merged:              gogithub.Bool(data.Merged),
+		MaintainerCanModify: gogithub.Bool(data.MaintainerCanModify),
+		MilestoneNumber:     gogithub.Int64(0),
+		MilestoneTitle:      gogithub.String(""),
+		Labels:              []string{},
+		labelsLoaded:        true,
+		Reviews:             []*Review{},
+		reviewsLoaded:       true,
+		draft:               data.IsDraft,
+	}
+	if data.Author != nil {
+		pr.Username = data.Author.Login
+	}
+	if data.HeadRepository != nil {
+		pr.FullName = data.HeadRepository.NameWithOwner
+	}
+	if data.Milestone != nil {
+		pr.MilestoneNumber = gogithub.Int64(data.Milestone.Number)
+		pr.MilestoneTitle = gogithub.String(data.Milestone.Title)
+	}
+	if data.MergedAt != nil {
+		pr.mergedAt = *data.MergedAt
+	}
+	for _, label := range data.Labels.Nodes {
+		pr.Labels = append(pr.Labels, label.Name)
+	}
+	for _, node := range data.Reviews.Nodes {
+		review := &Review{State: strings.ToUpper(node.State)}
+		if node.Author != nil {
+			review.Author = node.Author.Login
+		}
+		if node.SubmittedAt != nil {
+			review.SubmittedAt = *node.SubmittedAt
+		}
+		if node.Commit != nil {
+			review.CommitID = node.Commit.OID
+		}
+		pr.Reviews = append(pr.Reviews, review)
+	}
+
+	// Only merged PRs have a merge commit worth keeping
+	if data.Merged && data.MergeCommit != nil {
+		pr.MergeCommitSHA = data.MergeCommit.OID
+		pr.mergeCommit = data.MergeCommit.commit()
+	}
+
+	// Keep the full list when it was read entirely, the last commit always
+	if data.AllCommits.TotalCount <= fullPRCommits {
+		pr.commits = []*Commit{}
+		for _, node := range data.AllCommits.Nodes {
+			pr.commits = append(pr.commits, node.Commit.commit())
+		}
+	}
+	if len(data.LastCommit.Nodes) > 0 {
+		pr.lastCommit = data.LastCommit.Nodes[len(data.LastCommit.Nodes)-1].Commit.commit()
+		pr.commitCount = data.AllCommits.TotalCount
+	}
+	return pr
+}
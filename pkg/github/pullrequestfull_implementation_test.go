@@ -0,0 +1,79 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testFullPR = `{"repository": {"pullRequest": {
+  "id": "PR_kwDOA", "number": 42, "title": "Fix the thing", "body": "Details",
+  "url": "https://github.com/mattermost/mattermost-server/pull/42",
+  "state": "MERGED", "isDraft": false, "merged": true,
+  "mergedAt": "2021-06-01T10:00:00Z", "createdAt": "2021-05-30T10:00:00Z",
+  "maintainerCanModify": true, "baseRefName": "master", "headRefName": "fix-thing", "headRefOid": "pr-2",
+  "author": {"login": "jdoe"},
+  "headRepository": {"nameWithOwner": "jdoe/mattermost-server"},
+  "milestone": {"number": 7, "title": "v7.1"},
+  "labels": {"nodes": [{"name": "Bug"}, {"name": "CherryPick/Approved"}]},
+  "reviews": {"nodes": [{"author": {"login": "reviewer"}, "state": "APPROVED", "submittedAt": "2021-05-31T10:00:00Z"}]},
+  "mergeCommit": {"oid": "squashed", "tree": {"oid": "tree-merged"}, "parents": {"nodes": [{"oid": "main"}]}},
+  "allCommits": {"totalCount": 2, "nodes": [
+    {"commit": {"oid": "pr-1", "message": "First", "tree": {"oid": "tree-1"}, "parents": {"nodes": [{"oid": "main"}]},
+                "author": {"name": "Jane Doe", "email": "jane@example.com", "date": "2021-05-30T10:00:00Z"}}},
+    {"commit": {"oid": "pr-2", "message": "Second", "tree": {"oid": "tree-2"}, "parents": {"nodes": [{"oid": "pr-1"}]}}}
+  ]},
+  "lastCommit": {"nodes": [
+    {"commit": {"oid": "pr-2", "message": "Second", "tree": {"oid": "tree-2"}, "parents": {"nodes": [{"oid": "pr-1"}]}}}
+  ]}
+}}}`
+
+func TestLoadPullRequestFull(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	fakes.graphql.Stub = func(query string, variables map[string]interface{}) (string, error) {
+		return testFullPR, nil
+	}
+	impl := &defaultGithubImplementation{githubAPIUser: gau}
+
+	pr, err := impl.loadPullRequestFull(context.Background(), "mattermost", "mattermost-server", 42)
+	require.Nil(t, err)
+	require.Len(t, fakes.graphql.Calls, 1)
+	require.Equal(t, 42, fakes.graphql.Calls[0].Variables["number"])
+
+	require.Equal(t, 42, pr.Number)
+	require.Equal(t, "jdoe", pr.Username)
+	require.Equal(t, "closed", pr.State)
+	require.True(t, pr.IsMerged())
+	require.Equal(t, "squashed", pr.MergeCommitSHA)
+	require.Equal(t, "master", pr.BaseRef)
+	require.Equal(t, "v7.1", *pr.MilestoneTitle)
+	require.Len(t, pr.Reviews, 1)
+	require.Equal(t, "reviewer", pr.Reviews[0].Author)
+	require.Equal(t, "APPROVED", pr.Reviews[0].State)
+
+	// Everything needed afterwards is preloaded, no more calls are made
+	labels, err := pr.GetLabels(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, []string{"Bug", "CherryPick/Approved"}, labels)
+	commits, err := pr.GetCommits(context.Background())
+	require.Nil(t, err)
+	require.Len(t, commits, 2)
+	require.Equal(t, "Jane Doe", commits[0].Author.Name)
+	mode, err := pr.GetMergeMode(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, MergeModeSquash, mode)
+	require.Empty(t, fakes.repos.CommitCalls)
+	require.Empty(t, fakes.pulls.CommitsPages)
+	require.Zero(t, fakes.issues.LabelCalls)
+
+	// Missing pull requests are reported
+	fakes.graphql.Stub = func(query string, variables map[string]interface{}) (string, error) {
+		return `{"repository": {"pullRequest": null}}`, nil
+	}
+	_, err = impl.loadPullRequestFull(context.Background(), "mattermost", "mattermost-server", 43)
+	require.NotNil(t, err)
+}
@@ -0,0 +1,36 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import "time"
+
+// Release is a GitHub release of a repository
+type Release struct {
+	ID              int64
+	TagName         string
+	TargetCommitish string
+	Name            string
+	Body            string
+	Draft           bool
+	Prerelease      bool
+	URL             string
+	CreatedAt       time.Time
+}
+
+// NewReleaseOptions control how releases are created
+type NewReleaseOptions struct {
+	// Target is the branch or commit the tag is created from
+	// when it does not exist yet. Defaults to the default branch.
+	Target     string
+	Draft      bool
+	Prerelease bool
+}
+
+// ReleaseAsset is a file uploaded to a release
+type ReleaseAsset struct {
+	ID          int64
+	Name        string
+	Size        int
+	DownloadURL string
+}
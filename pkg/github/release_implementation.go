@@ -0,0 +1,168 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+)
+
+// listReleases reads all the releases of the repository, newest first
+func (di *defaultRepoImplementation) listReleases(ctx context.Context, owner, repo string) ([]*Release, error) {
+	releases := []*Release{}
+	opts := &gogithub.ListOptions{PerPage: 100}
+	for {
+		var ghReleases []*gogithub.RepositoryRelease
+		var resp *gogithub.Response
+		err := di.doWithRetry(ctx, "repos.ListReleases", func() (_ *gogithub.Response, err error) {
+			ghReleases, resp, err = di.GitHubClient().Repositories.ListReleases(ctx, owner, repo, opts)
+			return resp, err
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "listing releases of %s/%s", owner, repo)
+		}
+		for _, r := range ghReleases {
+			releases = append(releases, newRelease(r))
+		}
+
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return releases, nil
+}
+
+// createRelease publishes a release of the tag
+func (di *defaultRepoImplementation) createRelease(
+	ctx context.Context, owner, repo, tag, name, body string, opts *NewReleaseOptions,
+) (*Release, error) {
+	if di.getOptions().DryRun {
+		di.getLogger().Infof("[dry-run] Would create release %s in %s/%s", tag, owner, repo)
+		return &Release{
+			TagName: tag, TargetCommitish: opts.Target, Name: name, Body: body,
+			Draft: opts.Draft, Prerelease: opts.Prerelease,
+		}, nil
+	}
+
+	request := &gogithub.RepositoryRelease{
+		TagName:    gogithub.String(tag),
+		Name:       gogithub.String(name),
+		Body:       gogithub.String(body),
+		Draft:      gogithub.Bool(opts.Draft),
+		Prerelease: gogithub.Bool(opts.Prerelease),
+	}
+	if opts.Target != "" {
+		request.TargetCommitish = gogithub.String(opts.Target)
+	}
+
+	var created *gogithub.RepositoryRelease
+	err := di.doWithRetry(ctx, "repos.CreateRelease", func() (resp *gogithub.Response, err error) {
+		created, resp, err = di.GitHubClient().Repositories.CreateRelease(ctx, owner, repo, request)
+		return resp, err
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating release %s in %s/%s", tag, owner, repo)
+	}
+	di.getLogger().Infof("Created release %s (%d) in %s/%s", tag, created.GetID(), owner, repo)
+	return newRelease(created), nil
+}
+
+// createTag tags the commit. Tags with a message are created as
+// annotated tags, otherwise a lightweight tag is created.
+func (di *defaultRepoImplementation) createTag(ctx context.Context, owner, repo, tag, sha, message string) error {
+	if di.getOptions().DryRun {
+		di.getLogger().Infof("[dry-run] Would tag %s as %s in %s/%s", sha, tag, owner, repo)
+		return nil
+	}
+
+	// Annotated tags are a tag object the reference points to
+	target := sha
+	if message != "" {
+		var tagObject *gogithub.Tag
+		err := di.doWithRetry(ctx, "git.CreateTag", func() (resp *gogithub.Response, err error) {
+			tagObject, resp, err = di.GitHubClient().Git.CreateTag(ctx, owner, repo, &gogithub.Tag{
+				Tag:     gogithub.String(tag),
+				Message: gogithub.String(message),
+				Object:  &gogithub.GitObject{Type: gogithub.String("commit"), SHA: gogithub.String(sha)},
+			})
+			return resp, err
+		})
+		if err != nil {
+			return errors.Wrapf(err, "creating tag object %s", tag)
+		}
+		target = tagObject.GetSHA()
+	}
+
+	err := di.doWithRetry(ctx, "git.CreateRef", func() (resp *gogithub.Response, err error) {
+		_, resp, err = di.GitHubClient().Git.CreateRef(ctx, owner, repo, &gogithub.Reference{
+			Ref:    gogithub.String("refs/tags/" + tag),
+			Object: &gogithub.GitObject{SHA: gogithub.String(target)},
+		})
+		return resp, err
+	})
+	if err != nil {
+		return errors.Wrapf(err, "creating tag %s on %s", tag, sha)
+	}
+	di.getLogger().Infof("Tagged %s as %s in %s/%s", sha, tag, owner, repo)
+	return nil
+}
+
+// uploadReleaseAsset uploads the file to the release, named after it
+func (di *defaultRepoImplementation) uploadReleaseAsset(
+	ctx context.Context, owner, repo string, releaseID int64, path string,
+) (*ReleaseAsset, error) {
+	name := filepath.Base(path)
+	if di.getOptions().DryRun {
+		di.getLogger().Infof("[dry-run] Would upload %s to release %d of %s/%s", name, releaseID, owner, repo)
+		return &ReleaseAsset{Name: name}, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening release asset %s", path)
+	}
+	defer file.Close()
+
+	var asset *gogithub.ReleaseAsset
+	err = di.doWithRetry(ctx, "repos.UploadReleaseAsset", func() (resp *gogithub.Response, err error) {
+		// Retries have to send the file from the beginning
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return nil, errors.Wrap(err, "rewinding release asset")
+		}
+		asset, resp, err = di.GitHubClient().Repositories.UploadReleaseAsset(
+			ctx, owner, repo, releaseID, &gogithub.UploadOptions{Name: name}, file,
+		)
+		return resp, err
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "uploading %s to release %d", name, releaseID)
+	}
+	di.getLogger().Infof("Uploaded %s to release %d of %s/%s", name, releaseID, owner, repo)
+	return &ReleaseAsset{
+		ID:          asset.GetID(),
+		Name:        asset.GetName(),
+		Size:        asset.GetSize(),
+		DownloadURL: asset.GetBrowserDownloadURL(),
+	}, nil
+}
+
+func newRelease(r *gogithub.RepositoryRelease) *Release {
+	return &Release{
+		ID:              r.GetID(),
+		TagName:         r.GetTagName(),
+		TargetCommitish: r.GetTargetCommitish(),
+		Name:            r.GetName(),
+		Body:            r.GetBody(),
+		Draft:           r.GetDraft(),
+		Prerelease:      r.GetPrerelease(),
+		URL:             r.GetHTMLURL(),
+		CreatedAt:       r.GetCreatedAt().Time,
+	}
+}
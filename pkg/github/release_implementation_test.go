@@ -0,0 +1,66 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReleases(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	repo := gau.NewRepository(&gogithub.Repository{
+		Name: gogithub.String("mattermost-server"), Owner: &gogithub.User{Login: gogithub.String("mattermost")},
+	})
+	ctx := context.Background()
+
+	// Lightweight tags point to the commit
+	require.Nil(t, repo.CreateTag(ctx, "v7.8.0", "sha-1", ""))
+	require.Equal(t, "sha-1", fakes.git.Refs["tags/v7.8.0"].GetObject().GetSHA())
+	require.Empty(t, fakes.git.Tags)
+
+	// Annotated tags point to the tag object
+	require.Nil(t, repo.CreateTag(ctx, "v7.8.1", "sha-2", "Mattermost v7.8.1"))
+	require.Equal(t, "tag-v7.8.1", fakes.git.Refs["tags/v7.8.1"].GetObject().GetSHA())
+	require.Equal(t, "sha-2", fakes.git.Tags["tag-v7.8.1"].GetObject().GetSHA())
+	require.NotNil(t, repo.CreateTag(ctx, "v7.8.1", "sha-2", ""))
+
+	_, err := repo.CreateRelease(ctx, "", "", "", nil)
+	require.NotNil(t, err)
+	release, err := repo.CreateRelease(ctx, "v7.8.1", "v7.8.1", "Bug fixes", &NewReleaseOptions{Prerelease: true})
+	require.Nil(t, err)
+	require.Equal(t, int64(1), release.ID)
+	require.True(t, release.Prerelease)
+	_, err = repo.CreateRelease(ctx, "v7.8.1", "v7.8.1", "Bug fixes", nil)
+	require.NotNil(t, err)
+
+	releases, err := repo.ListReleases(ctx)
+	require.Nil(t, err)
+	require.Len(t, releases, 1)
+	require.Equal(t, "v7.8.1", releases[0].TagName)
+
+	path := filepath.Join(t.TempDir(), "mattermost-7.8.1-linux-amd64.tar.gz")
+	require.Nil(t, os.WriteFile(path, []byte("release"), 0o644))
+	asset, err := repo.UploadReleaseAsset(ctx, release.ID, path)
+	require.Nil(t, err)
+	require.Equal(t, "mattermost-7.8.1-linux-amd64.tar.gz", asset.Name)
+	require.Equal(t, 7, asset.Size)
+	require.Equal(t, []byte("release"), fakes.repos.Assets[1]["mattermost-7.8.1-linux-amd64.tar.gz"])
+
+	_, err = repo.UploadReleaseAsset(ctx, release.ID, filepath.Join(t.TempDir(), "missing"))
+	require.NotNil(t, err)
+
+	// Nothing is changed in dry-run mode
+	gau.options.DryRun = true
+	require.Nil(t, repo.CreateTag(ctx, "v7.8.2", "sha-3", ""))
+	_, err = repo.CreateRelease(ctx, "v7.8.2", "v7.8.2", "", nil)
+	require.Nil(t, err)
+	require.NotContains(t, fakes.git.Refs, "tags/v7.8.2")
+	require.Len(t, fakes.repos.Releases, 1)
+}
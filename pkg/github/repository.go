@@ -3,7 +3,14 @@
 
 package github
 
-import "context"
+import (
+	"context"
+	"io"
+	"time"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+)
 
 type Repository struct {
 	impl                       repositoryImplementation
@@ -33,16 +40,166 @@ type repositoryImplementation interface {
 	createPullRequest(
 		ctx context.Context, owner, repo, head, base, title, body string, opts *NewPullRequestOptions,
 	) (*PullRequest, error)
+	createIssue(ctx context.Context, owner, repo, title, body string, opts *NewIssueOptions) (*Issue, error)
+	getIssue(ctx context.Context, owner, repo string, number int) (*Issue, error)
+	createCheckRun(ctx context.Context, owner, repo, sha string, run *CheckRun) (int64, error)
+	updateCheckRun(ctx context.Context, owner, repo string, id int64, run *CheckRun) error
+	setCommitStatus(ctx context.Context, owner, repo, sha string, status *CommitStatus) error
+	listMilestones(ctx context.Context, owner, repo, state string) ([]*Milestone, error)
+	createMilestone(ctx context.Context, owner, repo, title, description string) (*Milestone, error)
+	listReleases(ctx context.Context, owner, repo string) ([]*Release, error)
+	createRelease(ctx context.Context, owner, repo, tag, name, body string, opts *NewReleaseOptions) (*Release, error)
+	createTag(ctx context.Context, owner, repo, tag, sha, message string) error
+	uploadReleaseAsset(ctx context.Context, owner, repo string, releaseID int64, path string) (*ReleaseAsset, error)
+	dispatchWorkflow(ctx context.Context, owner, repo, workflow, ref string, inputs map[string]string) error
+	listWorkflowRuns(ctx context.Context, owner, repo, workflow, branch string) ([]*WorkflowRun, error)
+	getWorkflowRun(ctx context.Context, owner, repo string, id int64) (*WorkflowRun, error)
+	getFileContents(ctx context.Context, owner, repo, path, ref string) ([]byte, string, error)
+	createOrUpdateFile(ctx context.Context, owner, repo, path, branch string, content []byte, message string) (string, error)
+	getTree(ctx context.Context, owner, repo, sha string) ([]*gogithub.TreeEntry, error)
+	createBlob(ctx context.Context, owner, repo string, content []byte) (string, error)
+	createTree(ctx context.Context, owner, repo, baseTree string, entries []*gogithub.TreeEntry) (string, error)
+	commitTree(ctx context.Context, owner, repo, message, treeSHA string, parents []string) (string, error)
+	updateRef(ctx context.Context, owner, repo, ref, sha string, force bool) error
+	getCodeOwners(ctx context.Context, owner, repo, ref string) (*CodeOwners, error)
+	resolveCodeOwners(ctx context.Context, owner, repo, ref string, paths []string) (*ResolvedOwners, error)
+	getPermissionLevel(ctx context.Context, owner, repo, login string) (PermissionLevel, error)
+	branchExists(ctx context.Context, owner, repo, branch string) (bool, error)
+	createBranch(ctx context.Context, owner, repo, branch, baseRef string) error
+	deleteBranch(ctx context.Context, owner, repo, branch string) error
+	listCommits(ctx context.Context, owner, repo string, opts *ListCommitsOptions) ([]*Commit, error)
+	compareBranches(ctx context.Context, owner, repo, base, head string) (*Comparison, error)
+	getMergeModes(ctx context.Context, owner, repo string, numbers []int) (map[int]MergeMode, error)
+	writeCommitPatch(ctx context.Context, owner, repo, sha string, w io.Writer, format PatchFormat) error
+	cherryPickCommits(
+		ctx context.Context, repo *Repository, targetBranch string, commits []*Commit, opts *CommitCherryPickOptions,
+	) (*CommitCherryPickResult, error)
+	operationContext(ctx context.Context) (context.Context, context.CancelFunc)
 }
 
 type NewPullRequestOptions struct {
 	MaintainerCanModify bool
 }
 
+// ListCommitsOptions filter the commits listed from a repository
+type ListCommitsOptions struct {
+	Ref    string    // Branch, tag or SHA to list the history of, the default branch if empty
+	Path   string    // Only list commits changing the file or directory
+	Author string    // Only list commits by the GitHub login or email
+	Since  time.Time // Only list commits after the date, if set
+	Until  time.Time // Only list commits before the date, if set
+	Limit  int       // Maximum number of commits returned, all of them if zero
+}
+
+// Comparison is the result of comparing two branches or commits
+type Comparison struct {
+	Status   string    // identical, ahead, behind or diverged
+	AheadBy  int       // Number of commits in head missing from base
+	BehindBy int       // Number of commits in base missing from head
+	Commits  []*Commit // Commits in head missing from base, oldest first
+}
+
+// WriteCommitPatch writes the changes of a commit to w as a unified
+// diff or as a patch to be applied with git am
+func (repo *Repository) WriteCommitPatch(ctx context.Context, w io.Writer, sha string, format PatchFormat) error {
+	ctx, cancel := repo.impl.operationContext(ctx)
+	defer cancel()
+
+	return repo.impl.writeCommitPatch(ctx, repo.Owner, repo.Name, sha, w, format)
+}
+
+// CommitCherryPickOptions configure the cherry-pick of commits that did
+// not go through a pull request
+type CommitCherryPickOptions struct {
+	CherryPickOptions
+
+	// Branch records the cherry-pick. Defaults to the branch prefix
+	// followed by the short SHA of the last commit and the target
+	// branch, eg cherry-pick-1a2b3c4-release-7.8.
+	Branch string
+
+	// Mainline is the parent merge commits are diffed against, starting
+	// at 1 like git cherry-pick -m. Merge commits fail without it.
+	Mainline int
+
+	// PullRequest opens a pull request from the branch to the target
+	PullRequest bool
+}
+
+// CommitCherryPickResult is the outcome of cherry-picking commits
+type CommitCherryPickResult struct {
+	Branch      string       // Branch where the cherry-pick was recorded
+	SHA         string       // Head of the branch
+	Commits     []string     // Commits cherry-picked, oldest first
+	PullRequest *PullRequest // Pull request opened, nil if not requested or in dry-run mode
+}
+
+// CherryPickCommit cherry-picks a commit to the target branch
+func (repo *Repository) CherryPickCommit(
+	ctx context.Context, targetBranch, sha string, opts *CommitCherryPickOptions,
+) (*CommitCherryPickResult, error) {
+	return repo.CherryPickCommits(ctx, targetBranch, []string{sha}, opts)
+}
+
+// CherryPickCommits cherry-picks the commits, in order, to the target
+// branch. Conflicts return a *CherryPickConflictError.
+func (repo *Repository) CherryPickCommits(
+	ctx context.Context, targetBranch string, shas []string, opts *CommitCherryPickOptions,
+) (*CommitCherryPickResult, error) {
+	ctx, cancel := repo.impl.operationContext(ctx)
+	defer cancel()
+
+	if len(shas) == 0 {
+		return nil, errors.New("no commits to cherry-pick")
+	}
+	commits := []*Commit{}
+	for _, sha := range shas {
+		commit, err := repo.impl.getCommit(ctx, repo.Owner, repo.Name, sha)
+		if err != nil {
+			return nil, errors.Wrapf(err, "fetching commit %s", sha)
+		}
+		commits = append(commits, commit)
+	}
+	if opts == nil {
+		opts = &CommitCherryPickOptions{}
+	}
+	return repo.impl.cherryPickCommits(ctx, repo, targetBranch, commits, opts)
+}
+
+// CherryPickRange cherry-picks to the target branch the commits reachable
+// from head but not from base, oldest first, like git cherry-pick base..head
+func (repo *Repository) CherryPickRange(
+	ctx context.Context, targetBranch, base, head string, opts *CommitCherryPickOptions,
+) (*CommitCherryPickResult, error) {
+	ctx, cancel := repo.impl.operationContext(ctx)
+	defer cancel()
+
+	comparison, err := repo.impl.compareBranches(ctx, repo.Owner, repo.Name, base, head)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing commits in %s..%s", base, head)
+	}
+	if len(comparison.Commits) == 0 {
+		return nil, errors.Errorf("no commits to cherry-pick in %s..%s", base, head)
+	}
+	// The compare API lists up to 250 commits
+	if len(comparison.Commits) < comparison.AheadBy {
+		return nil, errors.Errorf(
+			"%s..%s has %d commits, only %d can be cherry-picked at once", base, head, comparison.AheadBy, len(comparison.Commits),
+		)
+	}
+	if opts == nil {
+		opts = &CommitCherryPickOptions{}
+	}
+	return repo.impl.cherryPickCommits(ctx, repo, targetBranch, comparison.Commits, opts)
+}
+
 // CreatePullRequest creates a new pull request in the repository
 func (repo *Repository) CreatePullRequest(
 	ctx context.Context, head, base, title, body string, opts *NewPullRequestOptions,
 ) (*PullRequest, error) {
+	ctx, cancel := repo.impl.operationContext(ctx)
+	defer cancel()
+
 	// Call the create new PR function
 	return repo.impl.createPullRequest(
 		ctx, repo.Owner, repo.Name, head, base, title, body, opts,
@@ -52,9 +209,371 @@ func (repo *Repository) CreatePullRequest(
 
 // GetCommit fteches from the repository the commit at sha
 func (repo *Repository) GetCommit(ctx context.Context, sha string) (c *Commit, err error) {
+	ctx, cancel := repo.impl.operationContext(ctx)
+	defer cancel()
+
 	return repo.impl.getCommit(ctx, repo.Owner, repo.Name, sha)
 }
 
+// ListCommits returns the commits of the repository matching the
+// options, newest first
+func (repo *Repository) ListCommits(ctx context.Context, opts *ListCommitsOptions) ([]*Commit, error) {
+	ctx, cancel := repo.impl.operationContext(ctx)
+	defer cancel()
+
+	if opts == nil {
+		opts = &ListCommitsOptions{}
+	}
+	return repo.impl.listCommits(ctx, repo.Owner, repo.Name, opts)
+}
+
+// GetPermissionLevel returns the access a user has to the repository:
+// admin, maintain, write, triage, read or none
+func (repo *Repository) GetPermissionLevel(ctx context.Context, login string) (PermissionLevel, error) {
+	ctx, cancel := repo.impl.operationContext(ctx)
+	defer cancel()
+
+	if login == "" {
+		return "", errors.New("user is required to read its permission level")
+	}
+	return repo.impl.getPermissionLevel(ctx, repo.Owner, repo.Name, login)
+}
+
 func (repo *Repository) GetPullRequest(ctx context.Context, number int) (pr *PullRequest, err error) {
+	ctx, cancel := repo.impl.operationContext(ctx)
+	defer cancel()
+
 	return repo.impl.getPullRequest(ctx, repo.Owner, repo.Name, number)
 }
+
+// CreateIssue opens a new issue in the repository
+func (repo *Repository) CreateIssue(ctx context.Context, title, body string, opts *NewIssueOptions) (*Issue, error) {
+	ctx, cancel := repo.impl.operationContext(ctx)
+	defer cancel()
+
+	if opts == nil {
+		opts = &NewIssueOptions{}
+	}
+	return repo.impl.createIssue(ctx, repo.Owner, repo.Name, title, body, opts)
+}
+
+// GetIssue reads an issue of the repository
+func (repo *Repository) GetIssue(ctx context.Context, number int) (*Issue, error) {
+	ctx, cancel := repo.impl.operationContext(ctx)
+	defer cancel()
+
+	return repo.impl.getIssue(ctx, repo.Owner, repo.Name, number)
+}
+
+// ListMilestones returns the milestones of the repository in the
+// state, one of open, closed or all
+func (repo *Repository) ListMilestones(ctx context.Context, state string) ([]*Milestone, error) {
+	ctx, cancel := repo.impl.operationContext(ctx)
+	defer cancel()
+
+	return repo.impl.listMilestones(ctx, repo.Owner, repo.Name, state)
+}
+
+// CreateMilestone opens a new milestone in the repository
+func (repo *Repository) CreateMilestone(ctx context.Context, title, description string) (*Milestone, error) {
+	ctx, cancel := repo.impl.operationContext(ctx)
+	defer cancel()
+
+	if title == "" {
+		return nil, errors.New("milestones need a title")
+	}
+	return repo.impl.createMilestone(ctx, repo.Owner, repo.Name, title, description)
+}
+
+// GetFileContents reads a file of the repository at the ref, a branch,
+// tag or commit. An empty ref reads the default branch. If the file does
+// not exist, ErrFileNotFound is returned.
+func (repo *Repository) GetFileContents(ctx context.Context, path, ref string) ([]byte, error) {
+	ctx, cancel := repo.impl.operationContext(ctx)
+	defer cancel()
+
+	content, _, err := repo.impl.getFileContents(ctx, repo.Owner, repo.Name, path, ref)
+	return content, err
+}
+
+// CreateOrUpdateFile commits the content to the file in the branch and
+// returns the SHA of the new commit. If the file already has the content,
+// nothing is committed and the SHA is empty.
+func (repo *Repository) CreateOrUpdateFile(
+	ctx context.Context, path, branch string, content []byte, message string,
+) (string, error) {
+	ctx, cancel := repo.impl.operationContext(ctx)
+	defer cancel()
+
+	if path == "" || branch == "" || message == "" {
+		return "", errors.New("committing a file needs its path, a branch and a commit message")
+	}
+	return repo.impl.createOrUpdateFile(ctx, repo.Owner, repo.Name, path, branch, content, message)
+}
+
+// GetCodeOwners reads the CODEOWNERS file of the repository at the ref
+// from any of the locations GitHub supports. If the repository has none,
+// ErrFileNotFound is returned.
+func (repo *Repository) GetCodeOwners(ctx context.Context, ref string) (*CodeOwners, error) {
+	ctx, cancel := repo.impl.operationContext(ctx)
+	defer cancel()
+
+	return repo.impl.getCodeOwners(ctx, repo.Owner, repo.Name, ref)
+}
+
+// GetBackportLabels reads the configuration of the backport labels from
+// BackportLabelsPath at the ref. Repositories without it get the defaults.
+func (repo *Repository) GetBackportLabels(ctx context.Context, ref string) (*BackportLabels, error) {
+	ctx, cancel := repo.impl.operationContext(ctx)
+	defer cancel()
+
+	data, err := repo.GetFileContents(ctx, BackportLabelsPath, ref)
+	if errors.Is(err, ErrFileNotFound) {
+		return DefaultBackportLabels(), nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "reading backport labels configuration")
+	}
+	return ParseBackportLabels(data)
+}
+
+// ResolveCodeOwners returns the owners of the paths according to the
+// CODEOWNERS file at the ref. The members of the owning teams are
+// included in the users.
+func (repo *Repository) ResolveCodeOwners(ctx context.Context, ref string, paths []string) (*ResolvedOwners, error) {
+	ctx, cancel := repo.impl.operationContext(ctx)
+	defer cancel()
+
+	return repo.impl.resolveCodeOwners(ctx, repo.Owner, repo.Name, ref, paths)
+}
+
+// BranchExists returns true if the branch exists in the repository
+func (repo *Repository) BranchExists(ctx context.Context, branch string) (bool, error) {
+	ctx, cancel := repo.impl.operationContext(ctx)
+	defer cancel()
+
+	return repo.impl.branchExists(ctx, repo.Owner, repo.Name, branch)
+}
+
+// GetMergeModes returns how each of the pull requests was merged. It reads
+// the commits of many pull requests in a few GraphQL queries, which is much
+// faster and cheaper than calling GetMergeMode on each of them. Pull
+// requests that are not merged are reported as MergeModeUnknown.
+func (repo *Repository) GetMergeModes(ctx context.Context, numbers []int) (map[int]MergeMode, error) {
+	ctx, cancel := repo.impl.operationContext(ctx)
+	defer cancel()
+
+	return repo.impl.getMergeModes(ctx, repo.Owner, repo.Name, numbers)
+}
+
+// DeleteBranch removes a branch from the repository. Deleting
+// a branch that does not exist is not an error.
+func (repo *Repository) DeleteBranch(ctx context.Context, branch string) error {
+	ctx, cancel := repo.impl.operationContext(ctx)
+	defer cancel()
+
+	return repo.impl.deleteBranch(ctx, repo.Owner, repo.Name, branch)
+}
+
+// CreateBranch creates a new branch pointing to the same commit as baseRef.
+// The base can be a branch name, a fully qualified ref (refs/tags/v1.0.0)
+// or the SHA of a commit.
+func (repo *Repository) CreateBranch(ctx context.Context, branch, baseRef string) error {
+	ctx, cancel := repo.impl.operationContext(ctx)
+	defer cancel()
+
+	return repo.impl.createBranch(ctx, repo.Owner, repo.Name, branch, baseRef)
+}
+
+// CompareBranches compares the head branch or commit with the base and
+// returns how far apart they are and the commits only head has
+func (repo *Repository) CompareBranches(ctx context.Context, base, head string) (*Comparison, error) {
+	ctx, cancel := repo.impl.operationContext(ctx)
+	defer cancel()
+
+	return repo.impl.compareBranches(ctx, repo.Owner, repo.Name, base, head)
+}
+
+// CreateCheckRun reports a check run on the commit and returns its ID
+// to update it later. Any number of annotations can be set, they are
+// sent in as many requests as GitHub needs. In dry-run mode the ID is zero.
+func (repo *Repository) CreateCheckRun(ctx context.Context, sha string, run *CheckRun) (int64, error) {
+	ctx, cancel := repo.impl.operationContext(ctx)
+	defer cancel()
+
+	if run.Name == "" {
+		return 0, errors.New("check runs need a name")
+	}
+	return repo.impl.createCheckRun(ctx, repo.Owner, repo.Name, sha, run)
+}
+
+// UpdateCheckRun changes a check run, eg to complete it once it finishes.
+// Annotations are added to the ones already in the run.
+func (repo *Repository) UpdateCheckRun(ctx context.Context, id int64, run *CheckRun) error {
+	ctx, cancel := repo.impl.operationContext(ctx)
+	defer cancel()
+
+	if run.Name == "" {
+		return errors.New("check runs need a name")
+	}
+	return repo.impl.updateCheckRun(ctx, repo.Owner, repo.Name, id, run)
+}
+
+// SetCommitStatus reports a status on the commit. Setting it again with
+// the same context replaces the previous one.
+func (repo *Repository) SetCommitStatus(ctx context.Context, sha string, status *CommitStatus) error {
+	ctx, cancel := repo.impl.operationContext(ctx)
+	defer cancel()
+
+	if status.Context == "" {
+		return errors.New("commit statuses need a context")
+	}
+	return repo.impl.setCommitStatus(ctx, repo.Owner, repo.Name, sha, status)
+}
+
+// ListReleases returns the releases of the repository, newest first
+func (repo *Repository) ListReleases(ctx context.Context) ([]*Release, error) {
+	ctx, cancel := repo.impl.operationContext(ctx)
+	defer cancel()
+
+	return repo.impl.listReleases(ctx, repo.Owner, repo.Name)
+}
+
+// CreateRelease publishes a release of the tag. If the tag does not
+// exist, GitHub creates it from the target of the options.
+func (repo *Repository) CreateRelease(
+	ctx context.Context, tag, name, body string, opts *NewReleaseOptions,
+) (*Release, error) {
+	ctx, cancel := repo.impl.operationContext(ctx)
+	defer cancel()
+
+	if tag == "" {
+		return nil, errors.New("releases need a tag")
+	}
+	if opts == nil {
+		opts = &NewReleaseOptions{}
+	}
+	return repo.impl.createRelease(ctx, repo.Owner, repo.Name, tag, name, body, opts)
+}
+
+// CreateTag tags the commit at sha. When a message is set the tag is
+// annotated, otherwise a lightweight tag is created.
+func (repo *Repository) CreateTag(ctx context.Context, tag, sha, message string) error {
+	ctx, cancel := repo.impl.operationContext(ctx)
+	defer cancel()
+
+	if tag == "" || sha == "" {
+		return errors.New("tags need a name and a commit")
+	}
+	return repo.impl.createTag(ctx, repo.Owner, repo.Name, tag, sha, message)
+}
+
+// UploadReleaseAsset uploads the file at path to the release. The
+// asset is named after the file.
+func (repo *Repository) UploadReleaseAsset(ctx context.Context, releaseID int64, path string) (*ReleaseAsset, error) {
+	ctx, cancel := repo.impl.operationContext(ctx)
+	defer cancel()
+
+	return repo.impl.uploadReleaseAsset(ctx, repo.Owner, repo.Name, releaseID, path)
+}
+
+// DispatchWorkflow runs a GitHub Actions workflow on the ref by triggering
+// its workflow_dispatch event. The workflow is the name of its file, eg
+// e2e-tests.yml. GitHub does not return the run, use ListWorkflowRuns to
+// find it.
+func (repo *Repository) DispatchWorkflow(ctx context.Context, workflow, ref string, inputs map[string]string) error {
+	ctx, cancel := repo.impl.operationContext(ctx)
+	defer cancel()
+
+	if workflow == "" || ref == "" {
+		return errors.New("dispatching a workflow needs its file name and a ref")
+	}
+	if len(inputs) > maxWorkflowInputs {
+		return errors.Errorf("workflows accept up to %d inputs, got %d", maxWorkflowInputs, len(inputs))
+	}
+	return repo.impl.dispatchWorkflow(ctx, repo.Owner, repo.Name, workflow, ref, inputs)
+}
+
+// ListWorkflowRuns returns the latest 100 runs of the workflow on the
+// branch, newest first. When the branch is empty, runs on all branches
+// are returned.
+func (repo *Repository) ListWorkflowRuns(ctx context.Context, workflow, branch string) ([]*WorkflowRun, error) {
+	ctx, cancel := repo.impl.operationContext(ctx)
+	defer cancel()
+
+	return repo.impl.listWorkflowRuns(ctx, repo.Owner, repo.Name, workflow, branch)
+}
+
+// GetWorkflowRun reads a workflow run, eg to check if it finished
+func (repo *Repository) GetWorkflowRun(ctx context.Context, id int64) (*WorkflowRun, error) {
+	ctx, cancel := repo.impl.operationContext(ctx)
+	defer cancel()
+
+	return repo.impl.getWorkflowRun(ctx, repo.Owner, repo.Name, id)
+}
+
+// GetTree returns all the files of a tree, including those in subtrees
+func (repo *Repository) GetTree(ctx context.Context, sha string) ([]*TreeEntry, error) {
+	ctx, cancel := repo.impl.operationContext(ctx)
+	defer cancel()
+
+	entries, err := repo.impl.getTree(ctx, repo.Owner, repo.Name, sha)
+	if err != nil {
+		return nil, err
+	}
+	files := []*TreeEntry{}
+	for _, e := range entries {
+		if e.GetType() == "tree" {
+			continue
+		}
+		files = append(files, &TreeEntry{Path: e.GetPath(), Mode: e.GetMode(), SHA: e.GetSHA()})
+	}
+	return files, nil
+}
+
+// CreateBlob stores the content in the repository and returns its SHA
+// to use it in trees
+func (repo *Repository) CreateBlob(ctx context.Context, content []byte) (string, error) {
+	ctx, cancel := repo.impl.operationContext(ctx)
+	defer cancel()
+
+	return repo.impl.createBlob(ctx, repo.Owner, repo.Name, content)
+}
+
+// CreateTree creates a tree with the entries changed on top of the base
+// tree and returns its SHA. Without a base tree, only the entries are
+// in the new tree.
+func (repo *Repository) CreateTree(ctx context.Context, baseTree string, entries []*TreeEntry) (string, error) {
+	ctx, cancel := repo.impl.operationContext(ctx)
+	defer cancel()
+
+	for _, e := range entries {
+		if e.Path == "" {
+			return "", errors.New("tree entries need a path")
+		}
+	}
+	return repo.impl.createTree(ctx, repo.Owner, repo.Name, baseTree, treeEntries(entries))
+}
+
+// CreateCommit creates a commit of the tree and returns its SHA. The
+// commit is recorded and signed with the committer and signing key of
+// the options. No branch is moved, use UpdateRef to do it.
+func (repo *Repository) CreateCommit(ctx context.Context, message, treeSHA string, parents []string) (string, error) {
+	ctx, cancel := repo.impl.operationContext(ctx)
+	defer cancel()
+
+	if message == "" || treeSHA == "" {
+		return "", errors.New("commits need a message and a tree")
+	}
+	return repo.impl.commitTree(ctx, repo.Owner, repo.Name, message, treeSHA, parents)
+}
+
+// UpdateRef points an existing ref to a commit. The ref can be a branch
+// name or a fully qualified ref. Unless forced, the commit must be a
+// descendant of the current one.
+func (repo *Repository) UpdateRef(ctx context.Context, ref, sha string, force bool) error {
+	ctx, cancel := repo.impl.operationContext(ctx)
+	defer cancel()
+
+	return repo.impl.updateRef(ctx, repo.Owner, repo.Name, ref, sha, force)
+}
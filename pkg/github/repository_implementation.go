@@ -5,6 +5,8 @@ package github
 
 import (
 	"context"
+	"regexp"
+	"strings"
 
 	gogithub "github.com/google/go-github/v39/github"
 	"github.com/pkg/errors"
@@ -15,15 +17,39 @@ type defaultRepoImplementation struct {
 }
 
 func (di *defaultRepoImplementation) getCommit(ctx context.Context, owner, repo, sha string) (*Commit, error) {
-	repoCommit, _, err := di.githubAPIUser.GitHubClient().Repositories.GetCommit(ctx, owner, repo, sha, &gogithub.ListOptions{})
-	if err != nil {
-		return nil, errors.Wrap(err, "fetching commit from github API")
+	var repoCommit *gogithub.RepositoryCommit
+	opts := &gogithub.ListOptions{}
+	for {
+		var page *gogithub.RepositoryCommit
+		var resp *gogithub.Response
+		err := di.doWithRetry(ctx, "repos.GetCommit", func() (_ *gogithub.Response, err error) {
+			page, resp, err = di.githubAPIUser.GitHubClient().Repositories.GetCommit(ctx, owner, repo, sha, opts)
+			return resp, err
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "fetching commit from github API")
+		}
+
+		// Commits changing many files return them in pages
+		if repoCommit == nil {
+			repoCommit = page
+		} else {
+			repoCommit.Files = append(repoCommit.Files, page.Files...)
+		}
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
 	}
-	return di.githubAPIUser.NewCommit(repoCommit.Commit), nil
+	return di.githubAPIUser.NewRepositoryCommit(repoCommit), nil
 }
 
 func (di *defaultRepoImplementation) getPullRequest(ctx context.Context, owner, repo string, number int) (pr *PullRequest, err error) {
-	ghPr, _, err := di.githubAPIUser.GitHubClient().PullRequests.Get(ctx, owner, repo, number)
+	var ghPr *gogithub.PullRequest
+	err = di.doWithRetry(ctx, "pulls.Get", func() (resp *gogithub.Response, err error) {
+		ghPr, resp, err = di.githubAPIUser.GitHubClient().PullRequests.Get(ctx, owner, repo, number)
+		return resp, err
+	})
 	if err != nil {
 		return nil, errors.Wrapf(err, "fetching PR #%d from github api", number)
 	}
@@ -42,10 +68,200 @@ func (di *defaultRepoImplementation) createPullRequest(
 		Title:               &title,
 		MaintainerCanModify: &opts.MaintainerCanModify,
 	}
-	pullrequest, _, err := di.githubAPIUser.GitHubClient().PullRequests.Create(ctx, owner, repo, newPullRequest)
+	var pullrequest *gogithub.PullRequest
+	err := di.doWithRetry(ctx, "pulls.Create", func() (resp *gogithub.Response, err error) {
+		pullrequest, resp, err = di.githubAPIUser.GitHubClient().PullRequests.Create(ctx, owner, repo, newPullRequest)
+		return resp, err
+	})
 	if err != nil {
 		return nil, errors.Wrap(err, "creating pull request")
 	}
 
 	return di.githubAPIUser.NewPullRequest(pullrequest), nil
 }
+
+func (di *defaultRepoImplementation) createIssue(
+	ctx context.Context, owner, repo, title, body string, opts *NewIssueOptions,
+) (*Issue, error) {
+	request := &gogithub.IssueRequest{
+		Title: &title,
+		Body:  &body,
+	}
+	if len(opts.Labels) > 0 {
+		request.Labels = &opts.Labels
+	}
+	if len(opts.Assignees) > 0 {
+		request.Assignees = &opts.Assignees
+	}
+	var issue *gogithub.Issue
+	err := di.doWithRetry(ctx, "issues.Create", func() (resp *gogithub.Response, err error) {
+		issue, resp, err = di.githubAPIUser.GitHubClient().Issues.Create(ctx, owner, repo, request)
+		return resp, err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "creating issue")
+	}
+	return di.githubAPIUser.NewIssue(owner, repo, issue), nil
+}
+
+func (di *defaultRepoImplementation) getIssue(ctx context.Context, owner, repo string, number int) (*Issue, error) {
+	var issue *gogithub.Issue
+	err := di.doWithRetry(ctx, "issues.Get", func() (resp *gogithub.Response, err error) {
+		issue, resp, err = di.githubAPIUser.GitHubClient().Issues.Get(ctx, owner, repo, number)
+		return resp, err
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "fetching issue #%d from github api", number)
+	}
+	return di.githubAPIUser.NewIssue(owner, repo, issue), nil
+}
+
+func (di *defaultRepoImplementation) branchExists(ctx context.Context, owner, repo, branch string) (bool, error) {
+	err := di.doWithRetry(ctx, "git.GetRef", func() (resp *gogithub.Response, err error) {
+		_, resp, err = di.githubAPIUser.GitHubClient().Git.GetRef(ctx, owner, repo, "heads/"+branch)
+		return resp, err
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "reading branch %s", branch)
+	}
+	return true, nil
+}
+
+func (di *defaultRepoImplementation) createBranch(ctx context.Context, owner, repo, branch, baseRef string) error {
+	// Commits are used as is, refs are resolved to their commit
+	sha := baseRef
+	if !shaRegex.MatchString(baseRef) {
+		baseRef = qualifyRef(baseRef)
+		var base *gogithub.Reference
+		err := di.doWithRetry(ctx, "git.GetRef", func() (resp *gogithub.Response, err error) {
+			base, resp, err = di.githubAPIUser.GitHubClient().Git.GetRef(ctx, owner, repo, baseRef)
+			return resp, err
+		})
+		if err != nil {
+			return errors.Wrapf(err, "reading base ref %s", baseRef)
+		}
+		sha = base.GetObject().GetSHA()
+	}
+
+	err := di.doWithRetry(ctx, "git.CreateRef", func() (resp *gogithub.Response, err error) {
+		_, resp, err = di.githubAPIUser.GitHubClient().Git.CreateRef(ctx, owner, repo, &gogithub.Reference{
+			Ref:    gogithub.String("refs/heads/" + branch),
+			Object: &gogithub.GitObject{SHA: gogithub.String(sha)},
+		})
+		return resp, err
+	})
+	if err != nil {
+		return errors.Wrapf(err, "creating branch %s from %s", branch, baseRef)
+	}
+	return nil
+}
+
+func (di *defaultRepoImplementation) deleteBranch(ctx context.Context, owner, repo, branch string) error {
+	if di.getOptions().DryRun {
+		di.getLogger().Infof("[dry-run] Would delete branch %s from %s/%s", branch, owner, repo)
+		return nil
+	}
+	err := di.doWithRetry(ctx, "git.DeleteRef", func() (resp *gogithub.Response, err error) {
+		return di.githubAPIUser.GitHubClient().Git.DeleteRef(ctx, owner, repo, "heads/"+branch)
+	})
+	if err != nil {
+		if isMissingRef(err) {
+			di.getLogger().Infof("Branch %s was already deleted from %s/%s", branch, owner, repo)
+			return nil
+		}
+		return errors.Wrapf(err, "deleting branch %s", branch)
+	}
+	di.getLogger().Infof("Deleted branch %s from %s/%s", branch, owner, repo)
+	return nil
+}
+
+// listCommits reads the commits matching the options page by page
+// until all of them, or the limit, are read
+func (di *defaultRepoImplementation) listCommits(
+	ctx context.Context, owner, repo string, opts *ListCommitsOptions,
+) ([]*Commit, error) {
+	listOpts := &gogithub.CommitsListOptions{
+		SHA:         opts.Ref,
+		Path:        opts.Path,
+		Author:      opts.Author,
+		Since:       opts.Since,
+		Until:       opts.Until,
+		ListOptions: gogithub.ListOptions{PerPage: 100},
+	}
+	if opts.Limit > 0 && opts.Limit < listOpts.PerPage {
+		listOpts.PerPage = opts.Limit
+	}
+
+	commits := []*Commit{}
+	for {
+		var page []*gogithub.RepositoryCommit
+		var resp *gogithub.Response
+		err := di.doWithRetry(ctx, "repos.ListCommits", func() (_ *gogithub.Response, err error) {
+			page, resp, err = di.GitHubClient().Repositories.ListCommits(ctx, owner, repo, listOpts)
+			return resp, err
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "listing commits of %s/%s", owner, repo)
+		}
+		for _, c := range page {
+			commits = append(commits, di.NewRepositoryCommit(c))
+			if opts.Limit > 0 && len(commits) == opts.Limit {
+				return commits, nil
+			}
+		}
+
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		listOpts.Page = resp.NextPage
+	}
+	return commits, nil
+}
+
+// compareBranches compares head with base, reading all
+// the commits head has that base does not
+func (di *defaultRepoImplementation) compareBranches(
+	ctx context.Context, owner, repo, base, head string,
+) (*Comparison, error) {
+	result := &Comparison{Commits: []*Commit{}}
+	opts := &gogithub.ListOptions{PerPage: 100}
+	for {
+		var comparison *gogithub.CommitsComparison
+		var resp *gogithub.Response
+		err := di.doWithRetry(ctx, "repos.CompareCommits", func() (_ *gogithub.Response, err error) {
+			comparison, resp, err = di.GitHubClient().Repositories.CompareCommits(ctx, owner, repo, base, head, opts)
+			return resp, err
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "comparing %s with %s", head, base)
+		}
+		result.Status = comparison.GetStatus()
+		result.AheadBy = comparison.GetAheadBy()
+		result.BehindBy = comparison.GetBehindBy()
+		for _, c := range comparison.Commits {
+			result.Commits = append(result.Commits, di.NewRepositoryCommit(c))
+		}
+
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return result, nil
+}
+
+// shaRegex matches full commit SHAs
+var shaRegex = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// qualifyRef returns a ref as expected by the git data API, without
+// the refs/ prefix. Plain names are considered branches.
+func qualifyRef(ref string) string {
+	ref = strings.TrimPrefix(ref, "refs/")
+	if !strings.Contains(ref, "/") {
+		ref = "heads/" + ref
+	}
+	return ref
+}
@@ -0,0 +1,149 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateBranch(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	repo := gau.NewRepository(&gogithub.Repository{
+		Name: gogithub.String("mattermost-server"), Owner: &gogithub.User{Login: gogithub.String("mattermost")},
+	})
+	fakes.git.Refs["heads/master"] = &gogithub.Reference{
+		Ref: gogithub.String("refs/heads/master"), Object: &gogithub.GitObject{SHA: gogithub.String("master-sha")},
+	}
+	sha := strings.Repeat("a", 40)
+
+	for branch, base := range map[string]string{"from-branch": "master", "from-sha": sha} {
+		require.Nil(t, repo.CreateBranch(context.Background(), branch, base))
+		exists, err := repo.BranchExists(context.Background(), branch)
+		require.Nil(t, err)
+		require.True(t, exists)
+	}
+	require.Equal(t, "master-sha", fakes.git.Refs["heads/from-branch"].GetObject().GetSHA())
+	require.Equal(t, sha, fakes.git.Refs["heads/from-sha"].GetObject().GetSHA())
+
+	require.NotNil(t, repo.CreateBranch(context.Background(), "from-missing", "release-7.1"))
+	require.NotNil(t, repo.CreateBranch(context.Background(), "from-sha", "master"))
+}
+
+func TestCompareBranches(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	repo := gau.NewRepository(&gogithub.Repository{
+		Name: gogithub.String("mattermost-server"), Owner: &gogithub.User{Login: gogithub.String("mattermost")},
+	})
+	fakes.repos.CommitComparisons = map[string]*gogithub.CommitsComparison{
+		"master...cherry-pick-1": {
+			Status:   gogithub.String("diverged"),
+			AheadBy:  gogithub.Int(2),
+			BehindBy: gogithub.Int(5),
+			Commits: []*gogithub.RepositoryCommit{
+				fakes.addCommit("pick-1", "tree-1"), fakes.addCommit("pick-2", "tree-2", "pick-1"),
+			},
+		},
+	}
+
+	comparison, err := repo.CompareBranches(context.Background(), "master", "cherry-pick-1")
+	require.Nil(t, err)
+	require.Equal(t, "diverged", comparison.Status)
+	require.Equal(t, 2, comparison.AheadBy)
+	require.Equal(t, 5, comparison.BehindBy)
+	require.Len(t, comparison.Commits, 2)
+	require.Equal(t, []string{"pick-1"}, comparison.Commits[1].ParentSHAs())
+
+	_, err = repo.CompareBranches(context.Background(), "master", "missing")
+	require.True(t, isNotFound(err))
+}
+
+func TestGetCommitDetails(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/mattermost/mattermost-server/commits/abc", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			fmt.Fprint(w, `{"sha":"abc","files":[{"filename":"model/post.go","status":"modified","additions":1}]}`)
+			return
+		}
+		w.Header().Set("Link", fmt.Sprintf(`<http://%s%s?page=2>; rel="next"`, r.Host, r.URL.Path))
+		fmt.Fprint(w, `{
+			"sha": "abc",
+			"commit": {
+				"message": "Fix the post list",
+				"tree": {"sha": "tree-abc"},
+				"author": {"name": "Jane", "email": "jane@example.com", "date": "2021-09-01T10:00:00Z"},
+				"verification": {"verified": false, "reason": "unsigned"}
+			},
+			"stats": {"additions": 11, "deletions": 2, "total": 13},
+			"files": [{"filename": "api/post.go", "previous_filename": "api/posts.go", "status": "renamed", "additions": 10, "deletions": 2}]
+		}`)
+	})
+	gau := newTestAPIUser(t, mux)
+	repo := gau.NewRepository(&gogithub.Repository{
+		Name: gogithub.String("mattermost-server"), Owner: &gogithub.User{Login: gogithub.String("mattermost")},
+	})
+
+	commit, err := repo.GetCommit(context.Background(), "abc")
+	require.Nil(t, err)
+	require.Equal(t, "tree-abc", commit.TreeSHA)
+	require.Equal(t, "jane@example.com", commit.Author.Email)
+	require.Equal(t, 2021, commit.Author.Date.Year())
+	require.Equal(t, &CommitStats{Additions: 11, Deletions: 2, Total: 13}, commit.Stats)
+	require.Equal(t, &CommitVerification{Reason: "unsigned"}, commit.Verification)
+	require.Len(t, commit.Files, 2)
+	require.Equal(t, "api/posts.go", commit.Files[0].PreviousFilename)
+	require.Equal(t, "model/post.go", commit.Files[1].Filename)
+}
+
+func TestListCommits(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	repo := gau.NewRepository(&gogithub.Repository{
+		Name: gogithub.String("mattermost-server"), Owner: &gogithub.User{Login: gogithub.String("mattermost")},
+	})
+	history := []*gogithub.RepositoryCommit{}
+	for i, login := range []string{"jane", "john", "jane", "jane", "john"} {
+		c := fakes.addCommit(fmt.Sprintf("commit-%d", 5-i), "tree")
+		date := time.Date(2021, 9, 5-i, 0, 0, 0, 0, time.UTC)
+		c.Author = &gogithub.User{Login: gogithub.String(login)}
+		c.Commit.Author = &gogithub.CommitAuthor{Email: gogithub.String(login + "@example.com"), Date: &date}
+		c.Commit.Committer = &gogithub.CommitAuthor{Date: &date}
+		history = append(history, c)
+	}
+	fakes.repos.History = map[string][]*gogithub.RepositoryCommit{"": history, "model/post.go": history[1:2]}
+
+	shas := func(commits []*Commit) []string {
+		result := []string{}
+		for _, c := range commits {
+			result = append(result, c.SHA)
+		}
+		return result
+	}
+	for _, tc := range []struct {
+		opts     *ListCommitsOptions
+		expected []string
+	}{
+		{nil, []string{"commit-5", "commit-4", "commit-3", "commit-2", "commit-1"}},
+		{&ListCommitsOptions{Author: "jane"}, []string{"commit-5", "commit-3", "commit-2"}},
+		{&ListCommitsOptions{Author: "john@example.com", Limit: 1}, []string{"commit-4"}},
+		{&ListCommitsOptions{Path: "model/post.go"}, []string{"commit-4"}},
+		{
+			&ListCommitsOptions{
+				Since: time.Date(2021, 9, 2, 0, 0, 0, 0, time.UTC), Until: time.Date(2021, 9, 4, 0, 0, 0, 0, time.UTC),
+			},
+			[]string{"commit-4", "commit-3", "commit-2"},
+		},
+		{&ListCommitsOptions{Limit: 3}, []string{"commit-5", "commit-4", "commit-3"}},
+	} {
+		commits, err := repo.ListCommits(context.Background(), tc.opts)
+		require.Nil(t, err)
+		require.Equal(t, tc.expected, shas(commits))
+	}
+}
@@ -0,0 +1,243 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+)
+
+// RetryOptions controls how calls to the GitHub API are retried
+// when they fail with a transient error
+type RetryOptions struct {
+	MaxAttempts    int           // Maximum number of times a call will be tried
+	InitialBackoff time.Duration // Time to wait after the first failure
+	MaxBackoff     time.Duration // Upper bound for the exponential backoff
+
+	// Jitter is the fraction of the backoff that is randomized, so
+	// concurrent calls failing together do not retry in lockstep. Waits
+	// requested by GitHub are not altered. Zero disables it.
+	Jitter float64
+
+	// MaxWait bounds the total time a call waits between its attempts,
+	// including the waits requested by GitHub. Once it would be exceeded,
+	// the last error is returned. Zero means no limit.
+	MaxWait time.Duration
+}
+
+var defaultRetryOptions = RetryOptions{
+	MaxAttempts:    5,
+	InitialBackoff: time.Second,
+	MaxBackoff:     time.Minute,
+	Jitter:         0.2,
+	MaxWait:        15 * time.Minute,
+}
+
+// doWithRetry runs fn until it succeeds, returns an error that is not
+// worth retrying or the maximum number of attempts is reached. Waits
+// between attempts honor the Retry-After and X-RateLimit-Reset headers
+// sent by GitHub, otherwise they grow exponentially. Calls give up when
+// the total wait would exceed the maximum set in the options.
+//
+// Calls creating objects (see isCreateCall) are only retried when
+// rejected by a rate limit: after a server error or a dropped connection
+// the object may exist already, and trying again would duplicate it.
+//
+// fn returns the response of the API call, the rate limit reported in it
+// is recorded in the client. If the remaining calls drop below the
+// threshold set in the options, calls wait until the limit resets. Each
+// attempt is reported to the metrics under the endpoint name.
+func (gau *githubAPIUser) doWithRetry(
+	ctx context.Context, endpoint string, fn func() (*gogithub.Response, error),
+) (err error) {
+	opts := gau.getOptions().Retry
+	backoff := opts.InitialBackoff
+	resource := rateResource(endpoint)
+	waited := time.Duration(0)
+	for attempt := 1; ; attempt++ {
+		if err := gau.waitForRateLimit(ctx, resource); err != nil {
+			return err
+		}
+
+		var resp *gogithub.Response
+		start := time.Now()
+		resp, err = fn()
+		gau.getMetrics().ObserveAPICall(endpoint, time.Since(start), err)
+		gau.GitHubClient().recordRate(resource, resp, err)
+		if err == nil {
+			return nil
+		}
+
+		wait, retryable := retryWait(err, jitter(backoff, opts.Jitter))
+		if retryable && isCreateCall(endpoint) && !rejectedByRateLimit(err) {
+			return err
+		}
+		if !retryable || attempt >= opts.MaxAttempts {
+			return err
+		}
+		if opts.MaxWait > 0 && waited+wait > opts.MaxWait {
+			gau.getLogger().Warnf(
+				"Not retrying GitHub API call, waiting %s would exceed the limit of %s: %v", wait, opts.MaxWait, err,
+			)
+			return err
+		}
+		waited += wait
+
+		gau.getLogger().Warnf(
+			"GitHub API call failed (attempt %d/%d), retrying in %s: %v",
+			attempt, opts.MaxAttempts, wait, err,
+		)
+
+		select {
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), "waiting to retry GitHub API call")
+		case <-time.After(wait):
+		}
+
+		backoff *= 2
+		if backoff > opts.MaxBackoff {
+			backoff = opts.MaxBackoff
+		}
+	}
+}
+
+// waitForRateLimit blocks until the rate limit of the resource resets when
+// the remaining API calls are below the threshold set in the options
+func (gau *githubAPIUser) waitForRateLimit(ctx context.Context, resource string) error {
+	threshold := gau.getOptions().RateLimitThreshold
+	// The search quota is only a few calls per minute, searches
+	// always wait once it is used up instead of failing
+	if resource == RateResourceSearch {
+		threshold = 1
+	}
+	if threshold <= 0 {
+		return nil
+	}
+	remaining, reset := gau.GitHubClient().RateLimitFor(resource)
+	if remaining < 0 || remaining >= threshold {
+		return nil
+	}
+	wait := time.Until(reset)
+	if wait <= 0 {
+		return nil
+	}
+
+	gau.getLogger().Warnf(
+		"Only %d GitHub %s API calls left, waiting %s for the rate limit to reset", remaining, resource, wait,
+	)
+	select {
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "waiting for GitHub rate limit to reset")
+	case <-time.After(wait):
+	}
+	return nil
+}
+
+// retryWait checks an error returned by go-github and returns if the
+// call can be retried and how long to wait before trying again
+func retryWait(err error, backoff time.Duration) (wait time.Duration, retryable bool) {
+	// Primary rate limit exhausted: wait until the limit resets
+	rateLimitErr := &gogithub.RateLimitError{}
+	if errors.As(err, &rateLimitErr) {
+		if until := time.Until(rateLimitErr.Rate.Reset.Time); until > 0 {
+			return until, true
+		}
+		return backoff, true
+	}
+
+	// Secondary (abuse) rate limit: GitHub tells us how long to wait
+	abuseErr := &gogithub.AbuseRateLimitError{}
+	if errors.As(err, &abuseErr) {
+		if abuseErr.RetryAfter != nil {
+			return *abuseErr.RetryAfter, true
+		}
+		return backoff, true
+	}
+
+	responseErr := &gogithub.ErrorResponse{}
+	if errors.As(err, &responseErr) && responseErr.Response != nil {
+		// go-github does not recognize the current secondary rate limit
+		// responses, they arrive as plain 403 or 429 errors
+		if !isSecondaryRateLimit(responseErr) && responseErr.Response.StatusCode < http.StatusInternalServerError {
+			return 0, false
+		}
+
+		// Server side errors are considered transient
+		if wait, ok := retryAfter(responseErr.Response); ok {
+			return wait, true
+		}
+		return backoff, true
+	}
+
+	// Connection problems are transient too, unless the call was canceled
+	netErr := net.Error(nil)
+	if errors.As(err, &netErr) && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+		return backoff, true
+	}
+
+	return 0, false
+}
+
+// isCreateCall returns true if the endpoint creates a new object each
+// time it is called, eg issues.CreateComment or pulls.Create
+func isCreateCall(endpoint string) bool {
+	method := endpoint[strings.LastIndex(endpoint, ".")+1:]
+	return strings.HasPrefix(method, "Create") || strings.HasPrefix(method, "Upload")
+}
+
+// rejectedByRateLimit returns true if the call was rejected by a primary or
+// secondary rate limit. GitHub checks them before doing any work.
+func rejectedByRateLimit(err error) bool {
+	rateLimitErr := &gogithub.RateLimitError{}
+	abuseErr := &gogithub.AbuseRateLimitError{}
+	if errors.As(err, &rateLimitErr) || errors.As(err, &abuseErr) {
+		return true
+	}
+	responseErr := &gogithub.ErrorResponse{}
+	return errors.As(err, &responseErr) && responseErr.Response != nil && isSecondaryRateLimit(responseErr)
+}
+
+// isSecondaryRateLimit returns true if the error response is GitHub
+// throttling the client with a secondary rate limit
+func isSecondaryRateLimit(responseErr *gogithub.ErrorResponse) bool {
+	switch responseErr.Response.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusForbidden:
+		return responseErr.Response.Header.Get("Retry-After") != "" ||
+			strings.Contains(responseErr.DocumentationURL, "secondary-rate-limits") ||
+			strings.Contains(strings.ToLower(responseErr.Message), "secondary rate limit")
+	}
+	return false
+}
+
+// retryAfter returns the wait requested in the Retry-After header
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// jitter returns the backoff changed by a random amount of
+// up to the fraction passed, in either direction
+func jitter(backoff time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 || backoff <= 0 {
+		return backoff
+	}
+	if fraction > 1 {
+		fraction = 1
+	}
+	delta := float64(backoff) * fraction * (2*rand.Float64() - 1) // nolint: gosec
+	return backoff + time.Duration(delta)
+}
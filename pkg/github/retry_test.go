@@ -0,0 +1,244 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoWithRetry(t *testing.T) {
+	for _, tc := range []struct {
+		Status        int
+		ExpectedCalls int
+		ShouldErr     bool
+	}{
+		{Status: http.StatusBadGateway, ExpectedCalls: 3, ShouldErr: false}, // Transient, succeeds on third try
+		{Status: http.StatusNotFound, ExpectedCalls: 1, ShouldErr: true},    // Not retryable
+		{Status: http.StatusUnprocessableEntity, ExpectedCalls: 1, ShouldErr: true},
+		{Status: http.StatusInternalServerError, ExpectedCalls: 3, ShouldErr: false},
+	} {
+		calls := 0
+		mux := http.NewServeMux()
+		mux.HandleFunc("/repos/mattermost/mattermost-server", func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if calls < 3 {
+				w.WriteHeader(tc.Status)
+				return
+			}
+			w.Write([]byte(`{"name":"mattermost-server","owner":{"login":"mattermost"}}`))
+		})
+
+		gau := newTestAPIUser(t, mux)
+		gau.options = &Options{
+			Retry: RetryOptions{MaxAttempts: 5, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond},
+		}
+
+		err := gau.doWithRetry(context.Background(), "repos.Get", func() (*gogithub.Response, error) {
+			_, resp, err := gau.GitHubClient().Repositories.Get(context.Background(), "mattermost", "mattermost-server")
+			return resp, err
+		})
+		if tc.ShouldErr {
+			require.NotNil(t, err)
+		} else {
+			require.Nil(t, err)
+		}
+		require.Equal(t, tc.ExpectedCalls, calls)
+	}
+}
+
+func TestDoWithRetryCreateCalls(t *testing.T) {
+	for _, tc := range []struct {
+		Status        int
+		ExpectedCalls int
+	}{
+		{Status: http.StatusBadGateway, ExpectedCalls: 1},      // The comment may have been created
+		{Status: http.StatusTooManyRequests, ExpectedCalls: 3}, // Rejected before creating anything
+	} {
+		calls := 0
+		mux := http.NewServeMux()
+		mux.HandleFunc("/repos/mattermost/mattermost-server/issues/1/comments", func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if calls < 3 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(tc.Status)
+				return
+			}
+			w.Write([]byte(`{"id":1}`))
+		})
+
+		gau := newTestAPIUser(t, mux)
+		gau.options = &Options{
+			Retry: RetryOptions{MaxAttempts: 5, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond},
+		}
+		err := gau.doWithRetry(context.Background(), "issues.CreateComment", func() (*gogithub.Response, error) {
+			_, resp, err := gau.GitHubClient().Issues.CreateComment(
+				context.Background(), "mattermost", "mattermost-server", 1, &gogithub.IssueComment{Body: gogithub.String("Hi")},
+			)
+			return resp, err
+		})
+		require.Equal(t, tc.ExpectedCalls, calls, tc.Status)
+		require.Equal(t, tc.ExpectedCalls == 1, err != nil, tc.Status)
+	}
+}
+
+func TestSecondaryRateLimit(t *testing.T) {
+	calls := 0
+	retryAfter := "0"
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/mattermost/mattermost-server", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", retryAfter)
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message":"You have exceeded a secondary rate limit",` +
+				`"documentation_url":"https://docs.github.com/rest/overview/resources-in-the-rest-api#secondary-rate-limits"}`))
+			return
+		}
+		w.Write([]byte(`{"name":"mattermost-server","owner":{"login":"mattermost"}}`))
+	})
+	gau := newTestAPIUser(t, mux)
+	gau.options.Retry.MaxWait = time.Minute
+	getRepo := func() (*gogithub.Response, error) {
+		_, resp, err := gau.GitHubClient().Repositories.Get(context.Background(), "mattermost", "mattermost-server")
+		return resp, err
+	}
+
+	// The call is retried after the time requested by GitHub
+	require.Nil(t, gau.doWithRetry(context.Background(), "repos.Get", getRepo))
+	require.Equal(t, 2, calls)
+
+	// Unless it is longer than the maximum wait
+	calls, retryAfter = 0, "3600"
+	err := gau.doWithRetry(context.Background(), "repos.Get", getRepo)
+	require.NotNil(t, err)
+	require.Equal(t, 1, calls)
+
+	// Other forbidden errors are not retried
+	responseErr := &gogithub.ErrorResponse{
+		Response: &http.Response{StatusCode: http.StatusForbidden, Header: http.Header{}},
+		Message:  "Resource not accessible by integration",
+	}
+	_, retryable := retryWait(responseErr, time.Second)
+	require.False(t, retryable)
+}
+
+func TestRetryWaitNetworkErrors(t *testing.T) {
+	// Failed connections are retried
+	_, err := http.Get("http://127.0.0.1:1")
+	require.NotNil(t, err)
+	wait, retryable := retryWait(err, time.Second)
+	require.True(t, retryable)
+	require.Equal(t, time.Second, wait)
+
+	// Canceled calls are not
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://127.0.0.1:1", nil)
+	require.Nil(t, err)
+	_, err = http.DefaultClient.Do(req)
+	require.NotNil(t, err)
+	_, retryable = retryWait(err, time.Second)
+	require.False(t, retryable)
+}
+
+func TestJitter(t *testing.T) {
+	// Disabled jitter keeps the backoff as is
+	require.Equal(t, time.Second, jitter(time.Second, 0))
+
+	// Otherwise the wait stays within the fraction around the backoff
+	seen := map[time.Duration]bool{}
+	for i := 0; i < 100; i++ {
+		wait := jitter(time.Second, 0.2)
+		require.GreaterOrEqual(t, int64(wait), int64(800*time.Millisecond))
+		require.LessOrEqual(t, int64(wait), int64(1200*time.Millisecond))
+		seen[wait] = true
+	}
+	require.Greater(t, len(seen), 1)
+}
+
+func TestRateLimit(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Truncate(time.Second)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/mattermost/mattermost-server", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "4321")
+		w.Header().Set("X-RateLimit-Reset", fmt.Sprintf("%d", reset.Unix()))
+		w.Write([]byte(`{"name":"mattermost-server","owner":{"login":"mattermost"}}`))
+	})
+	gau := newTestAPIUser(t, mux)
+
+	// Unknown until the first response
+	remaining, _ := gau.GitHubClient().RateLimit()
+	require.Equal(t, -1, remaining)
+
+	require.Nil(t, gau.doWithRetry(context.Background(), "repos.Get", func() (*gogithub.Response, error) {
+		_, resp, err := gau.GitHubClient().Repositories.Get(context.Background(), "mattermost", "mattermost-server")
+		return resp, err
+	}))
+	remaining, resetTime := gau.GitHubClient().RateLimit()
+	require.Equal(t, 4321, remaining)
+	require.True(t, reset.Equal(resetTime))
+
+	// GraphQL has its own quota, which does not replace the REST one
+	remaining, _ = gau.GitHubClient().RateLimitFor(RateResourceGraphQL)
+	require.Equal(t, -1, remaining)
+	require.Nil(t, gau.doWithRetry(context.Background(), "graphql.Do", func() (*gogithub.Response, error) {
+		return &gogithub.Response{Rate: gogithub.Rate{Limit: 5000, Remaining: 12}}, nil
+	}))
+	remaining, _ = gau.GitHubClient().RateLimitFor(RateResourceGraphQL)
+	require.Equal(t, 12, remaining)
+	remaining, _ = gau.GitHubClient().RateLimit()
+	require.Equal(t, 4321, remaining)
+}
+
+func TestWaitForRateLimit(t *testing.T) {
+	gau, _ := newFakeAPIUser()
+	gau.options.RateLimitThreshold = 100
+	reset := time.Now().Add(50 * time.Millisecond)
+	gau.GitHubClient().recordRate(RateResourceCore, &gogithub.Response{Rate: gogithub.Rate{
+		Limit: 5000, Remaining: 10, Reset: gogithub.Timestamp{Time: reset},
+	}}, nil)
+
+	// Calls wait for the reset when the budget is low
+	require.Nil(t, gau.waitForRateLimit(context.Background(), RateResourceCore))
+	require.False(t, time.Now().Before(reset))
+
+	// Canceling the context stops the wait
+	gau.GitHubClient().recordRate(RateResourceCore, &gogithub.Response{Rate: gogithub.Rate{
+		Limit: 5000, Remaining: 10, Reset: gogithub.Timestamp{Time: time.Now().Add(time.Hour)},
+	}}, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.NotNil(t, gau.waitForRateLimit(ctx, RateResourceCore))
+}
+
+func TestWaitForSearchRateLimit(t *testing.T) {
+	gau, _ := newFakeAPIUser()
+	gau.options.RateLimitThreshold = 100
+
+	// Searches with calls left do not wait, even below the threshold
+	gau.GitHubClient().recordRate(RateResourceSearch, &gogithub.Response{Rate: gogithub.Rate{
+		Limit: 30, Remaining: 5, Reset: gogithub.Timestamp{Time: time.Now().Add(time.Hour)},
+	}}, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.Nil(t, gau.waitForRateLimit(ctx, RateResourceSearch))
+
+	// Once the quota is used up they wait, even with waiting disabled
+	gau.options.RateLimitThreshold = 0
+	reset := time.Now().Add(50 * time.Millisecond)
+	gau.GitHubClient().recordRate(RateResourceSearch, &gogithub.Response{Rate: gogithub.Rate{
+		Limit: 30, Remaining: 0, Reset: gogithub.Timestamp{Time: reset},
+	}}, nil)
+	require.Nil(t, gau.waitForRateLimit(context.Background(), RateResourceSearch))
+	require.False(t, time.Now().Before(reset))
+	require.Equal(t, RateResourceSearch, rateResource("search.Issues"))
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// revertBranchPrefix starts the name of the branches where reverts are
+// recorded, eg revert-1234-master
+const revertBranchPrefix = "revert"
+
+// revertCommentMarker tags the comment reporting the revert of a pull request
+const revertCommentMarker = "revert"
+
+// RevertOptions control Revert
+type RevertOptions struct {
+	// BranchPrefix names the branch where the revert is recorded,
+	// followed by the PR number and its base branch. Defaults to revert.
+	BranchPrefix string
+
+	SkipPullRequest bool // Do not open the revert PR
+	SkipComment     bool // Do not report the result on the reverted PR
+}
+
+// RevertResult is the outcome of reverting a pull request
+type RevertResult struct {
+	Branch      string          // Branch where the revert was recorded
+	SHA         string          // Revert commit
+	PullRequest *PullRequest    // Revert PR, nil if it was not opened
+	Conflict    *ConflictReport // How to revert by hand when the revert conflicted
+	CommentID   int64           // ID of the comment posted on the reverted PR
+}
+
+// Revert undoes the changes of the merged pull request in its base
+// branch. The changes are reverted in a single commit recorded in a new
+// branch and a pull request referencing the original one is opened. The
+// commits are chosen like for cherry-picks, merge commits are reverted
+// against the parent that is not the patch tree. Conflicts return a
+// *CherryPickConflictError and are reported on the pull request.
+func (pr *PullRequest) Revert(ctx context.Context, opts *RevertOptions) (*RevertResult, error) {
+	ctx, cancel := pr.impl.operationContext(ctx)
+	defer cancel()
+
+	if !pr.IsMerged() {
+		return nil, errors.Wrapf(ErrNotMerged, "reverting PR #%d", pr.Number)
+	}
+	if opts == nil {
+		opts = &RevertOptions{}
+	}
+	prefix := revertBranchPrefix
+	if opts.BranchPrefix != "" {
+		prefix = opts.BranchPrefix
+	}
+	result := &RevertResult{Branch: fmt.Sprintf("%s-%d-%s", prefix, pr.Number, pr.BaseRef)}
+
+	var err error
+	result.SHA, err = pr.impl.revert(ctx, pr, result.Branch)
+	if err != nil {
+		if len(ConflictPaths(err)) == 0 {
+			return result, err
+		}
+		var rerr error
+		if result.Conflict, rerr = pr.ConflictReport(ctx, err, result.Branch); rerr != nil {
+			pr.impl.log(pr).Warnf("Could not build the conflict report: %v", rerr)
+		} else {
+			result.Conflict.Revert = true
+		}
+		if !opts.SkipComment {
+			result.CommentID, rerr = pr.UpdateOrCreateComment(ctx, revertCommentMarker, revertFailureComment(pr, err, result.Conflict))
+			if rerr != nil {
+				pr.impl.log(pr).Warnf("Could not report the revert conflicts: %v", rerr)
+			}
+		}
+		return result, err
+	}
+
+	if !opts.SkipPullRequest {
+		result.PullRequest, err = pr.impl.openRevertPR(ctx, pr, result.Branch)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	if !opts.SkipComment {
+		result.CommentID, err = pr.UpdateOrCreateComment(ctx, revertCommentMarker, revertSuccessComment(result))
+		if err != nil {
+			return result, errors.Wrapf(err, "reporting revert of PR #%d", pr.Number)
+		}
+	}
+	return result, nil
+}
+
+// revertSuccessComment is the comment reporting a revert
+func revertSuccessComment(result *RevertResult) string {
+	if result.PullRequest != nil {
+		return fmt.Sprintf("Reverted in #%d.", result.PullRequest.Number)
+	}
+	return fmt.Sprintf("Reverted in branch `%s`.", result.Branch)
+}
+
+// revertFailureComment is the comment reporting a revert that conflicted
+func revertFailureComment(pr *PullRequest, err error, report *ConflictReport) string {
+	if report != nil {
+		return report.Markdown()
+	}
+	return fmt.Sprintf("Could not revert in `%s`: %s", pr.BaseRef, failureReason(err))
+}
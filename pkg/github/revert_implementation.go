@@ -0,0 +1,97 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"fmt"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+)
+
+const (
+	revertTitleTemplate = "Revert \"%s\" (#%d)"
+	revertBodyTemplate  = "Reverts #%d"
+)
+
+// revert records in branch a commit undoing the changes of the pull
+// request on top of its base branch. The commits of the PR are found like
+// for cherry-picks and reverted at once, replaying the diff from the last
+// one back to the parent of the first.
+func (impl *defaultPRImplementation) revert(ctx context.Context, pr *PullRequest, branch string) (string, error) {
+	repo, err := pr.GetRepository(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to revert")
+	}
+
+	steps, err := impl.prepareCherryPick(ctx, pr, repo, &CherryPickOptions{})
+	if err != nil {
+		return "", err
+	}
+	title := fmt.Sprintf(revertTitleTemplate, pr.Title, pr.Number)
+	message := fmt.Sprintf("%s\n\nThis reverts commit %s.", title, steps[0].source.SHA)
+	if len(steps) > 1 {
+		message = fmt.Sprintf(
+			"%s\n\nThis reverts commits %s..%s.", title, steps[0].from, steps[len(steps)-1].source.SHA,
+		)
+		// Checks the rebased commits form a chain
+		if steps, err = squashCherryPickSteps(pr, steps); err != nil {
+			return "", errors.Wrap(err, "unable to revert")
+		}
+	}
+	base, err := repo.GetCommit(ctx, steps[0].from)
+	if err != nil {
+		return "", errors.Wrapf(err, "fetching commit %s", steps[0].from)
+	}
+
+	// The revert goes from the last commit back to the tree before the
+	// first one. No author is set, GitHub uses the authenticated user.
+	head := steps[0].source
+	revertStep := cherryPickStep{from: head.SHA, source: &Commit{SHA: head.SHA, TreeSHA: base.TreeSHA, Message: message}}
+
+	_, sha, err := impl.applyCherryPick(
+		ctx, impl.log(pr), fmt.Sprintf("revert of PR #%d", pr.Number), repo,
+		[]cherryPickStep{revertStep}, pr.BaseRef, "heads/"+pr.BaseRef, branch,
+	)
+	return sha, err
+}
+
+// openRevertPR opens the pull request merging the revert branch
+// into the base branch of the reverted pull request
+func (impl *defaultPRImplementation) openRevertPR(ctx context.Context, pr *PullRequest, branch string) (*PullRequest, error) {
+	existing, err := impl.findOpenPullRequest(ctx, pr.RepoOwner, pr.RepoName, branch, pr.BaseRef)
+	if err != nil {
+		return nil, errors.Wrap(err, "searching for existing revert pull request")
+	}
+	if existing != nil {
+		impl.log(pr).Infof("Revert of PR #%d already open as #%d", pr.Number, existing.Number)
+		return existing, nil
+	}
+
+	if impl.getOptions().DryRun {
+		impl.log(pr).Infof("[dry-run] Would open revert PR of #%d from %s to %s", pr.Number, branch, pr.BaseRef)
+		return nil, nil
+	}
+
+	var ghpr *gogithub.PullRequest
+	err = impl.doWithRetry(ctx, "pulls.Create", func() (resp *gogithub.Response, err error) {
+		ghpr, resp, err = impl.GitHubClient().PullRequests.Create(
+			ctx, pr.RepoOwner, pr.RepoName, &gogithub.NewPullRequest{
+				Title:               gogithub.String(fmt.Sprintf(revertTitleTemplate, pr.Title, pr.Number)),
+				Body:                gogithub.String(fmt.Sprintf(revertBodyTemplate, pr.Number)),
+				Head:                gogithub.String(branch),
+				Base:                gogithub.String(pr.BaseRef),
+				MaintainerCanModify: gogithub.Bool(true),
+			},
+		)
+		return resp, err
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating revert pull request for #%d", pr.Number)
+	}
+	revert := impl.NewPullRequest(ghpr)
+	impl.log(pr).Infof("Opened revert PR #%d for #%d on %s", revert.Number, pr.Number, pr.BaseRef)
+	return revert, nil
+}
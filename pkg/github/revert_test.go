@@ -0,0 +1,81 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"testing"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevert(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	impl := &defaultPRImplementation{githubAPIUser: gau}
+
+	// A squashed PR changing a.go, followed by another change in master
+	fakes.pulls.Commits[1] = []*gogithub.RepositoryCommit{fakes.addCommit("pr-1", "tree-1", "main-old")}
+	fakes.pulls.PullRequests[1] = &gogithub.PullRequest{Number: gogithub.Int(1)}
+	fakes.pulls.Files = map[int][]*gogithub.CommitFile{1: {{Filename: gogithub.String("a.go")}}}
+	fakes.addCommit("main-old", "tree-base")
+	fakes.addCommit("squashed", "tree-merged", "main-old")
+	fakes.git.Trees["tree-base"] = &gogithub.Tree{Entries: []*gogithub.TreeEntry{testEntry("a.go", "a1")}}
+	fakes.git.Trees["tree-merged"] = &gogithub.Tree{Entries: []*gogithub.TreeEntry{testEntry("a.go", "a2")}}
+	fakes.addCommit("master-head", "tree-master", "squashed")
+	fakes.git.Refs["heads/master"] = &gogithub.Reference{
+		Ref: gogithub.String("refs/heads/master"), Object: &gogithub.GitObject{SHA: gogithub.String("master-head")},
+	}
+	fakes.git.Trees["tree-master"] = &gogithub.Tree{
+		SHA: gogithub.String("tree-master"), Entries: []*gogithub.TreeEntry{testEntry("a.go", "a2"), testEntry("b.go", "b1")},
+	}
+
+	pr := &PullRequest{
+		impl:           impl,
+		RepoOwner:      "mattermost",
+		RepoName:       "mattermost-server",
+		Number:         1,
+		Title:          "Fix the thing",
+		BaseRef:        "master",
+		Merged:         gogithub.Bool(false),
+		MergeCommitSHA: "squashed",
+	}
+	_, err := pr.Revert(context.Background(), nil)
+	require.True(t, errors.Is(err, ErrNotMerged))
+
+	// The changes are undone on top of the base branch
+	pr.Merged = gogithub.Bool(true)
+	result, err := pr.Revert(context.Background(), nil)
+	require.Nil(t, err)
+	require.Equal(t, "revert-1-master", result.Branch)
+	require.Len(t, fakes.git.CreatedCommits, 1)
+	require.Equal(t, "Revert \"Fix the thing\" (#1)\n\nThis reverts commit squashed.", fakes.git.CreatedCommits[0].GetMessage())
+	require.Equal(t, "master-head", fakes.git.CreatedCommits[0].Parents[0].GetSHA())
+	require.Len(t, fakes.git.CreatedTrees, 1)
+	require.Equal(t, []*gogithub.TreeEntry{
+		testEntry("a.go", "a1"), testEntry("b.go", "b1"),
+	}, fakes.git.CreatedTrees[0].Entries)
+	require.Equal(t, result.SHA, fakes.git.Refs["heads/revert-1-master"].GetObject().GetSHA())
+
+	// The revert PR references the original one
+	require.NotNil(t, result.PullRequest)
+	require.Len(t, fakes.pulls.Created, 1)
+	require.Equal(t, "Revert \"Fix the thing\" (#1)", fakes.pulls.Created[0].GetTitle())
+	require.Equal(t, "Reverts #1", fakes.pulls.Created[0].GetBody())
+	require.Equal(t, "master", fakes.pulls.Created[0].GetBase())
+	require.Len(t, fakes.issues.Comments[1], 1)
+	require.Contains(t, fakes.issues.Comments[1][0].GetBody(), "Reverted in #2.")
+
+	// Conflicts are reported with the commands to revert by hand
+	fakes.git.Trees["tree-master"].Entries[0] = testEntry("a.go", "a3")
+	result, err = pr.Revert(context.Background(), &RevertOptions{SkipPullRequest: true})
+	require.True(t, errors.Is(err, ErrCherryPickConflict))
+	require.NotNil(t, result.Conflict)
+	require.True(t, result.Conflict.Revert)
+	require.Len(t, fakes.issues.Comments[1], 1)
+	body := fakes.issues.Comments[1][0].GetBody()
+	require.Contains(t, body, "Could not revert in `master`, these files conflict:\n\n- `a.go`")
+	require.Contains(t, body, "git revert --no-edit squashed")
+}
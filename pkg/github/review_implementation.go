@@ -0,0 +1,222 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"strings"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+)
+
+// States of a pull request review
+const (
+	ReviewStateApproved         = "APPROVED"
+	ReviewStateChangesRequested = "CHANGES_REQUESTED"
+	ReviewStateCommented        = "COMMENTED"
+	ReviewStateDismissed        = "DISMISSED"
+	ReviewStatePending          = "PENDING"
+)
+
+// getReviews reads the reviews of the pull request from the API
+func (impl *defaultPRImplementation) getReviews(ctx context.Context, pr *PullRequest) ([]*Review, error) {
+	reviews := []*Review{}
+	opts := &gogithub.ListOptions{PerPage: impl.getOptions().PageSize}
+	for {
+		var ghReviews []*gogithub.PullRequestReview
+		var resp *gogithub.Response
+		err := impl.doWithRetry(ctx, "pulls.ListReviews", func() (_ *gogithub.Response, err error) {
+			ghReviews, resp, err = impl.GitHubClient().PullRequests.ListReviews(
+				ctx, pr.RepoOwner, pr.RepoName, pr.Number, opts,
+			)
+			return resp, err
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "listing reviews of PR #%d", pr.Number)
+		}
+		for _, r := range ghReviews {
+			reviews = append(reviews, &Review{
+				Author:      r.GetUser().GetLogin(),
+				State:       strings.ToUpper(r.GetState()),
+				SubmittedAt: r.GetSubmittedAt(),
+				CommitID:    r.GetCommitID(),
+			})
+		}
+
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return reviews, nil
+}
+
+// getReviewComments reads the inline review comments of the pull request
+func (impl *defaultPRImplementation) getReviewComments(ctx context.Context, pr *PullRequest) ([]*ReviewComment, error) {
+	comments := []*ReviewComment{}
+	opts := &gogithub.PullRequestListCommentsOptions{
+		ListOptions: gogithub.ListOptions{PerPage: impl.getOptions().PageSize},
+	}
+	for {
+		var ghComments []*gogithub.PullRequestComment
+		var resp *gogithub.Response
+		err := impl.doWithRetry(ctx, "pulls.ListComments", func() (_ *gogithub.Response, err error) {
+			ghComments, resp, err = impl.GitHubClient().PullRequests.ListComments(
+				ctx, pr.RepoOwner, pr.RepoName, pr.Number, opts,
+			)
+			return resp, err
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "listing review comments of PR #%d", pr.Number)
+		}
+		for _, c := range ghComments {
+			comments = append(comments, &ReviewComment{
+				ID:        c.GetID(),
+				Path:      c.GetPath(),
+				Line:      c.GetLine(),
+				StartLine: c.GetStartLine(),
+				Side:      c.GetSide(),
+				Body:      c.GetBody(),
+				Author:    c.GetUser().GetLogin(),
+				CommitID:  c.GetCommitID(),
+			})
+		}
+
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return comments, nil
+}
+
+// addReviewComment posts a single inline comment on the pull request
+func (impl *defaultPRImplementation) addReviewComment(
+	ctx context.Context, pr *PullRequest, comment *ReviewComment,
+) (int64, error) {
+	if impl.getOptions().DryRun {
+		impl.log(pr).Infof(
+			"[dry-run] Would comment on %s:%d of PR #%d: %s", comment.Path, comment.Line, pr.Number, comment.Body,
+		)
+		return 0, nil
+	}
+
+	ghComment := &gogithub.PullRequestComment{
+		CommitID: gogithub.String(comment.CommitID),
+		Path:     gogithub.String(comment.Path),
+		Body:     gogithub.String(comment.Body),
+		Line:     gogithub.Int(comment.Line),
+	}
+	if comment.StartLine != 0 {
+		ghComment.StartLine = gogithub.Int(comment.StartLine)
+	}
+	if comment.Side != "" {
+		ghComment.Side = gogithub.String(comment.Side)
+		if comment.StartLine != 0 {
+			ghComment.StartSide = gogithub.String(comment.Side)
+		}
+	}
+	var created *gogithub.PullRequestComment
+	err := impl.doWithRetry(ctx, "pulls.CreateComment", func() (resp *gogithub.Response, err error) {
+		created, resp, err = impl.GitHubClient().PullRequests.CreateComment(
+			ctx, pr.RepoOwner, pr.RepoName, pr.Number, ghComment,
+		)
+		return resp, err
+	})
+	if err != nil {
+		return 0, errors.Wrapf(err, "commenting on %s:%d of PR #%d", comment.Path, comment.Line, pr.Number)
+	}
+	impl.log(pr).Infof("Posted review comment %d on PR #%d", created.GetID(), pr.Number)
+	return created.GetID(), nil
+}
+
+// submitReview submits a review with its inline comments
+func (impl *defaultPRImplementation) submitReview(
+	ctx context.Context, pr *PullRequest, event, body string, comments []*ReviewComment,
+) (int64, error) {
+	if impl.getOptions().DryRun {
+		impl.log(pr).Infof(
+			"[dry-run] Would submit a review (%s) with %d comments on PR #%d", event, len(comments), pr.Number,
+		)
+		return 0, nil
+	}
+
+	sha, err := pr.reviewSHA(ctx)
+	if err != nil {
+		return 0, err
+	}
+	review := &gogithub.PullRequestReviewRequest{
+		CommitID: gogithub.String(sha),
+		Event:    gogithub.String(event),
+		Comments: []*gogithub.DraftReviewComment{},
+	}
+	if body != "" {
+		review.Body = gogithub.String(body)
+	}
+	for _, c := range comments {
+		draft := &gogithub.DraftReviewComment{
+			Path: gogithub.String(c.Path),
+			Body: gogithub.String(c.Body),
+			Line: gogithub.Int(c.Line),
+		}
+		if c.StartLine != 0 {
+			draft.StartLine = gogithub.Int(c.StartLine)
+		}
+		if c.Side != "" {
+			draft.Side = gogithub.String(c.Side)
+			if c.StartLine != 0 {
+				draft.StartSide = gogithub.String(c.Side)
+			}
+		}
+		review.Comments = append(review.Comments, draft)
+	}
+
+	var created *gogithub.PullRequestReview
+	err = impl.doWithRetry(ctx, "pulls.CreateReview", func() (resp *gogithub.Response, err error) {
+		created, resp, err = impl.GitHubClient().PullRequests.CreateReview(
+			ctx, pr.RepoOwner, pr.RepoName, pr.Number, review,
+		)
+		return resp, err
+	})
+	if err != nil {
+		return 0, err
+	}
+	impl.log(pr).Infof("Submitted review %d (%s) on PR #%d", created.GetID(), event, pr.Number)
+	return created.GetID(), nil
+}
+
+// isTeamMember checks if a user is an active member of a team. Teams
+// are named "org/slug", a bare slug is looked up in the repository owner.
+func (impl *defaultPRImplementation) isTeamMember(
+	ctx context.Context, pr *PullRequest, team, login string,
+) (bool, error) {
+	org, slug := pr.RepoOwner, team
+	if i := strings.Index(team, "/"); i >= 0 {
+		org, slug = team[:i], team[i+1:]
+	}
+	return impl.isActiveTeamMember(ctx, org, slug, login)
+}
+
+// currentReviews returns the review that sets the current state of each
+// reviewer: the latest one that is not a comment or pending. Reviews
+// of a commit other than headSHA were submitted before the last push
+// and are dropped.
+func currentReviews(reviews []*Review, headSHA string) map[string]*Review {
+	current := map[string]*Review{}
+	for _, review := range reviews {
+		switch review.State {
+		case ReviewStateCommented, ReviewStatePending:
+			continue
+		}
+		if headSHA != "" && review.CommitID != "" && review.CommitID != headSHA {
+			continue
+		}
+		if prev, ok := current[review.Author]; ok && prev.SubmittedAt.After(review.SubmittedAt) {
+			continue
+		}
+		current[review.Author] = review
+	}
+	return current
+}
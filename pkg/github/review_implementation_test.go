@@ -0,0 +1,193 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetReviews(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	pr := &PullRequest{
+		impl:      &defaultPRImplementation{githubAPIUser: gau},
+		RepoOwner: "mattermost",
+		RepoName:  "mattermost-server",
+		Number:    1,
+		Sha:       "head",
+	}
+	submitted := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	fakes.pulls.PullRequests[1] = &gogithub.PullRequest{Number: gogithub.Int(1)}
+	fakes.pulls.ReviewsPerPage = 1
+	fakes.pulls.Reviews = map[int][]*gogithub.PullRequestReview{1: {
+		{User: &gogithub.User{Login: gogithub.String("alice")}, State: gogithub.String("APPROVED"), CommitID: gogithub.String("head"), SubmittedAt: &submitted},
+		{User: &gogithub.User{Login: gogithub.String("bob")}, State: gogithub.String("commented"), CommitID: gogithub.String("head")},
+	}}
+
+	reviews, err := pr.GetReviews(context.Background())
+	require.Nil(t, err)
+	require.Len(t, reviews, 2)
+	require.Equal(t, &Review{Author: "alice", State: ReviewStateApproved, SubmittedAt: submitted, CommitID: "head"}, reviews[0])
+	require.Equal(t, ReviewStateCommented, reviews[1].State)
+
+	// Reviews are read only once
+	fakes.pulls.Reviews[1] = nil
+	reviews, err = pr.GetReviews(context.Background())
+	require.Nil(t, err)
+	require.Len(t, reviews, 2)
+}
+
+func TestIsApprovedBy(t *testing.T) {
+	at := func(h int) time.Time { return time.Date(2021, 6, 1, h, 0, 0, 0, time.UTC) }
+	for _, tc := range []struct {
+		name     string
+		reviews  []*Review
+		n        int
+		teams    []string
+		approved bool
+	}{
+		{"no reviews", nil, 1, nil, false},
+		{"no threshold", nil, 0, nil, true},
+		{
+			"enough approvals",
+			[]*Review{
+				{Author: "alice", State: ReviewStateApproved, CommitID: "head", SubmittedAt: at(1)},
+				{Author: "bob", State: ReviewStateApproved, CommitID: "head", SubmittedAt: at(2)},
+			},
+			2, nil, true,
+		},
+		{
+			"reviewer counted once",
+			[]*Review{
+				{Author: "alice", State: ReviewStateApproved, CommitID: "head", SubmittedAt: at(1)},
+				{Author: "alice", State: ReviewStateApproved, CommitID: "head", SubmittedAt: at(2)},
+			},
+			2, nil, false,
+		},
+		{
+			"stale approval",
+			[]*Review{
+				{Author: "alice", State: ReviewStateApproved, CommitID: "old", SubmittedAt: at(1)},
+				{Author: "bob", State: ReviewStateApproved, CommitID: "head", SubmittedAt: at(2)},
+			},
+			2, nil, false,
+		},
+		{
+			"changes requested",
+			[]*Review{
+				{Author: "alice", State: ReviewStateApproved, CommitID: "head", SubmittedAt: at(1)},
+				{Author: "bob", State: ReviewStateChangesRequested, CommitID: "head", SubmittedAt: at(2)},
+			},
+			1, nil, false,
+		},
+		{
+			"changes requested then approved",
+			[]*Review{
+				{Author: "alice", State: ReviewStateChangesRequested, CommitID: "head", SubmittedAt: at(1)},
+				{Author: "alice", State: ReviewStateCommented, CommitID: "head", SubmittedAt: at(2)},
+				{Author: "alice", State: ReviewStateApproved, CommitID: "head", SubmittedAt: at(3)},
+			},
+			1, nil, true,
+		},
+		{
+			"approval dismissed",
+			[]*Review{
+				{Author: "alice", State: ReviewStateApproved, CommitID: "head", SubmittedAt: at(1)},
+				{Author: "alice", State: ReviewStateDismissed, CommitID: "head", SubmittedAt: at(2)},
+			},
+			1, nil, false,
+		},
+		{
+			"required team approved",
+			[]*Review{
+				{Author: "alice", State: ReviewStateApproved, CommitID: "head", SubmittedAt: at(1)},
+				{Author: "carol", State: ReviewStateApproved, CommitID: "head", SubmittedAt: at(2)},
+			},
+			1, []string{"core", "mattermost/qa"}, true,
+		},
+		{
+			"required team missing",
+			[]*Review{
+				{Author: "alice", State: ReviewStateApproved, CommitID: "head", SubmittedAt: at(1)},
+			},
+			1, []string{"qa"}, false,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			gau, fakes := newFakeAPIUser()
+			fakes.teams.Members["mattermost/core"] = []string{"alice"}
+			fakes.teams.Members["mattermost/qa"] = []string{"carol"}
+			pr := &PullRequest{
+				impl:          &defaultPRImplementation{githubAPIUser: gau},
+				RepoOwner:     "mattermost",
+				RepoName:      "mattermost-server",
+				Number:        1,
+				Sha:           "head",
+				Reviews:       tc.reviews,
+				reviewsLoaded: true,
+			}
+			approved, err := pr.IsApprovedBy(context.Background(), tc.n, tc.teams)
+			require.Nil(t, err)
+			require.Equal(t, tc.approved, approved)
+		})
+	}
+}
+
+func TestReviewComments(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	pr := &PullRequest{
+		impl:      &defaultPRImplementation{githubAPIUser: gau},
+		RepoOwner: "mattermost",
+		RepoName:  "mattermost-server",
+		Number:    1,
+		Sha:       "head",
+	}
+	fakes.pulls.PullRequests[1] = &gogithub.PullRequest{Number: gogithub.Int(1)}
+	ctx := context.Background()
+
+	// Comments need a position in the diff
+	_, err := pr.AddReviewComment(ctx, &ReviewComment{Path: "app/app.go", Body: "Missing line"})
+	require.NotNil(t, err)
+
+	id, err := pr.AddReviewComment(ctx, &ReviewComment{Path: "app/app.go", Line: 10, Body: "Unused variable"})
+	require.Nil(t, err)
+	require.NotZero(t, id)
+
+	// Reviews post all their comments at once
+	_, err = pr.SubmitReview(ctx, "LGTM", "", nil)
+	require.NotNil(t, err)
+	reviewID, err := pr.SubmitReview(ctx, ReviewEventRequestChanges, "Lint errors", []*ReviewComment{
+		{Path: "app/app.go", Line: 12, Body: "Line too long"},
+		{Path: "api/v4.go", StartLine: 3, Line: 5, Side: "LEFT", Body: "Removed docs"},
+	})
+	require.Nil(t, err)
+	require.NotZero(t, reviewID)
+	require.Len(t, fakes.pulls.ReviewsCreated, 1)
+	require.Equal(t, "head", fakes.pulls.ReviewsCreated[0].GetCommitID())
+	require.Equal(t, "LEFT", fakes.pulls.ReviewsCreated[0].Comments[1].GetStartSide())
+
+	comments, err := pr.GetReviewComments(ctx)
+	require.Nil(t, err)
+	require.Len(t, comments, 3)
+	require.Equal(t, &ReviewComment{ID: id, Path: "app/app.go", Line: 10, Body: "Unused variable", CommitID: "head"}, comments[0])
+	require.Equal(t, 3, comments[2].StartLine)
+	require.Equal(t, "LEFT", comments[2].Side)
+
+	// The submitted review is seen by GetReviews
+	reviews, err := pr.GetReviews(ctx)
+	require.Nil(t, err)
+	require.Len(t, reviews, 1)
+	require.Equal(t, ReviewStateChangesRequested, reviews[0].State)
+
+	// Nothing is posted in dry-run mode
+	gau.options.DryRun = true
+	id, err = pr.SubmitReview(ctx, ReviewEventComment, "", []*ReviewComment{{Path: "app/app.go", Line: 1}})
+	require.Nil(t, err)
+	require.Zero(t, id)
+	require.Len(t, fakes.pulls.ReviewsCreated, 1)
+}
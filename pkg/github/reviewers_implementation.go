@@ -0,0 +1,107 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+)
+
+const (
+	// suggestReviewerFiles is the number of changed files, the largest
+	// changes first, whose history is read to suggest reviewers
+	suggestReviewerFiles = 20
+
+	// suggestReviewerCommits is the number of recent commits
+	// read from the history of each file
+	suggestReviewerCommits = 10
+)
+
+// requestReviewers asks users and teams to review the pull request
+func (impl *defaultPRImplementation) requestReviewers(
+	ctx context.Context, pr *PullRequest, users, teams []string,
+) error {
+	if impl.getOptions().DryRun {
+		impl.log(pr).Infof("[dry-run] Would request reviews from %v and teams %v on PR #%d", users, teams, pr.Number)
+		return nil
+	}
+
+	err := impl.doWithRetry(ctx, "pulls.RequestReviewers", func() (resp *gogithub.Response, err error) {
+		_, resp, err = impl.GitHubClient().PullRequests.RequestReviewers(
+			ctx, pr.RepoOwner, pr.RepoName, pr.Number,
+			gogithub.ReviewersRequest{Reviewers: users, TeamReviewers: teams},
+		)
+		return resp, err
+	})
+	if err != nil {
+		return err
+	}
+	impl.log(pr).Infof("Requested reviews from %v and teams %v on PR #%d", users, teams, pr.Number)
+	return nil
+}
+
+// suggestReviewers ranks the authors of the recent commits in the base
+// branch touching the files changed by the pull request
+func (impl *defaultPRImplementation) suggestReviewers(ctx context.Context, pr *PullRequest) ([]string, error) {
+	files, err := impl.getChangedFiles(ctx, pr)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading changed files")
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].Additions+files[i].Deletions > files[j].Additions+files[j].Deletions
+	})
+	if len(files) > suggestReviewerFiles {
+		files = files[:suggestReviewerFiles]
+	}
+
+	scores := map[string]int{}
+	for _, file := range files {
+		// New files have no history, renamed ones have it in the old path
+		path := file.Filename
+		switch file.Status {
+		case "added":
+			continue
+		case "renamed":
+			path = file.PreviousFilename
+		}
+
+		var commits []*gogithub.RepositoryCommit
+		err := impl.doWithRetry(ctx, "repos.ListCommits", func() (resp *gogithub.Response, err error) {
+			commits, resp, err = impl.GitHubClient().Repositories.ListCommits(
+				ctx, pr.RepoOwner, pr.RepoName, &gogithub.CommitsListOptions{
+					SHA:         pr.BaseRef,
+					Path:        path,
+					ListOptions: gogithub.ListOptions{PerPage: suggestReviewerCommits},
+				},
+			)
+			return resp, err
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "listing commits touching %s", path)
+		}
+		for _, commit := range commits {
+			login := commit.GetAuthor().GetLogin()
+			if login == "" || strings.EqualFold(login, pr.Username) || strings.HasSuffix(login, "[bot]") {
+				continue
+			}
+			scores[login]++
+		}
+	}
+
+	reviewers := []string{}
+	for login := range scores {
+		reviewers = append(reviewers, login)
+	}
+	sort.Slice(reviewers, func(i, j int) bool {
+		if scores[reviewers[i]] != scores[reviewers[j]] {
+			return scores[reviewers[i]] > scores[reviewers[j]]
+		}
+		return reviewers[i] < reviewers[j]
+	})
+	return reviewers, nil
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"testing"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestReviewers(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	fakes.pulls.PullRequests[1] = &gogithub.PullRequest{Number: gogithub.Int(1)}
+	pr := &PullRequest{
+		impl:      &defaultPRImplementation{githubAPIUser: gau},
+		RepoOwner: "mattermost",
+		RepoName:  "mattermost-server",
+		Number:    1,
+	}
+
+	require.Nil(t, pr.RequestReviewers(context.Background(), nil, nil))
+	require.Empty(t, fakes.pulls.Requested)
+
+	require.Nil(t, pr.RequestReviewers(context.Background(), []string{"alice"}, []string{"core"}))
+	require.Equal(t, []gogithub.ReviewersRequest{
+		{Reviewers: []string{"alice"}, TeamReviewers: []string{"core"}},
+	}, fakes.pulls.Requested)
+}
+
+func TestSuggestReviewers(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	pr := &PullRequest{
+		impl:      &defaultPRImplementation{githubAPIUser: gau},
+		RepoOwner: "mattermost",
+		RepoName:  "mattermost-server",
+		BaseRef:   "master",
+		Username:  "author",
+		Number:    1,
+	}
+	fakes.pulls.Files = map[int][]*gogithub.CommitFile{1: {
+		{Filename: gogithub.String("app/app.go"), Status: gogithub.String("modified"), Additions: gogithub.Int(3)},
+		{Filename: gogithub.String("app/new.go"), Status: gogithub.String("added"), Additions: gogithub.Int(10)},
+		{Filename: gogithub.String("api/v5.go"), PreviousFilename: gogithub.String("api/v4.go"), Status: gogithub.String("renamed")},
+	}}
+	by := func(logins ...string) []*gogithub.RepositoryCommit {
+		commits := []*gogithub.RepositoryCommit{}
+		for _, login := range logins {
+			commits = append(commits, &gogithub.RepositoryCommit{Author: &gogithub.User{Login: gogithub.String(login)}})
+		}
+		return commits
+	}
+	fakes.repos.History = map[string][]*gogithub.RepositoryCommit{
+		"app/app.go": by("bob", "alice", "author", "dependabot[bot]", "bob"),
+		"app/new.go": by("mallory", "mallory", "mallory"),
+		"api/v4.go":  by("alice", "carol", ""),
+	}
+
+	reviewers, err := pr.SuggestReviewers(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, []string{"alice", "bob", "carol"}, reviewers)
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// searchTimeFormat is the format of the dates in search qualifiers
+const searchTimeFormat = "2006-01-02T15:04:05Z"
+
+// Search starts queries to the GitHub search API
+type Search struct {
+	impl githubImplementation
+}
+
+// SearchQuery is an issue or pull request search built from qualifiers,
+// eg gh.Search().PullRequests().Label("needs-backport").State("merged")
+type SearchQuery struct {
+	impl       githubImplementation
+	qualifiers []string
+	limit      int
+}
+
+// Search returns a builder of queries to the GitHub search API
+func (gh *GitHub) Search() *Search {
+	return &Search{impl: gh.impl}
+}
+
+// PullRequests starts a query that only finds pull requests
+func (s *Search) PullRequests() *SearchQuery {
+	return &SearchQuery{impl: s.impl, qualifiers: []string{"is:pr"}}
+}
+
+// Issues starts a query that only finds issues
+func (s *Search) Issues() *SearchQuery {
+	return &SearchQuery{impl: s.impl, qualifiers: []string{"is:issue"}}
+}
+
+// add appends a qualifier to the query, quoting values with spaces
+func (q *SearchQuery) add(qualifier, value string) *SearchQuery {
+	if strings.ContainsAny(value, " \t\"") {
+		value = fmt.Sprintf("%q", value)
+	}
+	q.qualifiers = append(q.qualifiers, qualifier+":"+value)
+	return q
+}
+
+// Repo limits the search to a repository
+func (q *SearchQuery) Repo(owner, name string) *SearchQuery {
+	return q.add("repo", owner+"/"+name)
+}
+
+// Org limits the search to the repositories of an organization
+func (q *SearchQuery) Org(org string) *SearchQuery {
+	return q.add("org", org)
+}
+
+// Label only finds items with the label. It can be used several
+// times to find items with all the labels.
+func (q *SearchQuery) Label(label string) *SearchQuery {
+	return q.add("label", label)
+}
+
+// WithoutLabel only finds items missing the label
+func (q *SearchQuery) WithoutLabel(label string) *SearchQuery {
+	return q.add("-label", label)
+}
+
+// State filters the items by state: open or closed, and for pull
+// requests also merged, unmerged or draft
+func (q *SearchQuery) State(state string) *SearchQuery {
+	switch state {
+	case "open", "closed":
+		return q.add("state", state)
+	default:
+		return q.add("is", state)
+	}
+}
+
+// Author only finds items opened by the user
+func (q *SearchQuery) Author(login string) *SearchQuery {
+	return q.add("author", login)
+}
+
+// Base only finds pull requests targeting the branch
+func (q *SearchQuery) Base(branch string) *SearchQuery {
+	return q.add("base", branch)
+}
+
+// UpdatedSince only finds items updated at or after t
+func (q *SearchQuery) UpdatedSince(t time.Time) *SearchQuery {
+	return q.add("updated", ">="+t.UTC().Format(searchTimeFormat))
+}
+
+// CreatedSince only finds items created at or after t
+func (q *SearchQuery) CreatedSince(t time.Time) *SearchQuery {
+	return q.add("created", ">="+t.UTC().Format(searchTimeFormat))
+}
+
+// Text adds free text to look for in the title, body and comments
+func (q *SearchQuery) Text(text string) *SearchQuery {
+	q.qualifiers = append(q.qualifiers, text)
+	return q
+}
+
+// Limit sets the maximum number of results returned. Without it, all
+// results are returned, which GitHub caps at 1000 per query.
+func (q *SearchQuery) Limit(limit int) *SearchQuery {
+	q.limit = limit
+	return q
+}
+
+// String returns the query as sent to GitHub
+func (q *SearchQuery) String() string {
+	return strings.Join(q.qualifiers, " ")
+}
+
+// Run sends the query and returns the items found. Pull requests are
+// returned as issues, their full data can be read with GetPullRequest.
+func (q *SearchQuery) Run(ctx context.Context) ([]*Issue, error) {
+	ctx, cancel := q.impl.operationContext(ctx)
+	defer cancel()
+
+	if q.limit < 0 {
+		return nil, errors.New("search limit cannot be negative")
+	}
+	return q.impl.searchIssues(ctx, q.String(), q.limit)
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"strings"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+)
+
+// searchIssues returns up to limit issues and pull requests matching
+// the query, all of them when limit is zero
+func (gau *githubAPIUser) searchIssues(ctx context.Context, query string, limit int) ([]*Issue, error) {
+	issues := []*Issue{}
+	opts := &gogithub.SearchOptions{ListOptions: gogithub.ListOptions{PerPage: 100}}
+	if limit > 0 && limit < opts.PerPage {
+		opts.PerPage = limit
+	}
+	for {
+		var result *gogithub.IssuesSearchResult
+		var resp *gogithub.Response
+		err := gau.doWithRetry(ctx, "search.Issues", func() (_ *gogithub.Response, err error) {
+			result, resp, err = gau.GitHubClient().Search.Issues(ctx, query, opts)
+			return resp, err
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "searching %q", query)
+		}
+		if result.GetIncompleteResults() {
+			gau.getLogger().Warnf("GitHub timed out searching %q, some results may be missing", query)
+		}
+		for _, ghissue := range result.Issues {
+			owner, repo := repoFromURL(ghissue.GetRepositoryURL())
+			issues = append(issues, gau.NewIssue(owner, repo, ghissue))
+			if limit > 0 && len(issues) >= limit {
+				return issues, nil
+			}
+		}
+
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return issues, nil
+}
+
+// repoFromURL returns the owner and name of a repository
+// from its API address, eg https://api.github.com/repos/o/r
+func repoFromURL(url string) (owner, repo string) {
+	parts := strings.Split(strings.TrimSuffix(url, "/"), "/")
+	if len(parts) < 2 {
+		return "", ""
+	}
+	return parts[len(parts)-2], parts[len(parts)-1]
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchRun(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	gh := &GitHub{impl: &defaultGithubImplementation{githubAPIUser: gau}, options: gau.options}
+	query := gh.Search().PullRequests().Org("mattermost").Label("needs-backport")
+	for i := 1; i <= 5; i++ {
+		fakes.search.Results[query.String()] = append(fakes.search.Results[query.String()], &gogithub.Issue{
+			Number:        gogithub.Int(i),
+			Title:         gogithub.String(fmt.Sprintf("PR %d", i)),
+			State:         gogithub.String("closed"),
+			RepositoryURL: gogithub.String("https://api.github.com/repos/mattermost/mattermost-server"),
+			Labels:        []*gogithub.Label{{Name: gogithub.String("needs-backport")}},
+		})
+	}
+	fakes.search.PerPage = 2
+	ctx := context.Background()
+
+	// All the pages are read
+	issues, err := query.Run(ctx)
+	require.Nil(t, err)
+	require.Len(t, issues, 5)
+	require.Len(t, fakes.search.Queries, 3)
+	require.Equal(t, "mattermost", issues[0].Owner)
+	require.Equal(t, "mattermost-server", issues[0].Repo)
+	require.Equal(t, 5, issues[4].Number)
+	require.Equal(t, []string{"needs-backport"}, issues[4].Labels)
+
+	// The limit stops paging
+	issues, err = query.Limit(3).Run(ctx)
+	require.Nil(t, err)
+	require.Len(t, issues, 3)
+	require.Len(t, fakes.search.Queries, 5)
+
+	issues, err = gh.Search().Issues().Label("nothing").Run(ctx)
+	require.Nil(t, err)
+	require.Empty(t, issues)
+
+	_, err = query.Limit(-1).Run(ctx)
+	require.NotNil(t, err)
+}
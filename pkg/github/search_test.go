@@ -0,0 +1,30 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchQuery(t *testing.T) {
+	search := &Search{}
+	since := time.Date(2021, 6, 1, 12, 0, 0, 0, time.FixedZone("CEST", 2*3600))
+
+	require.Equal(t,
+		"is:pr repo:mattermost/mattermost-server label:needs-backport is:merged updated:>=2021-06-01T10:00:00Z",
+		search.PullRequests().Repo("mattermost", "mattermost-server").Label("needs-backport").
+			State("merged").UpdatedSince(since).String(),
+	)
+	require.Equal(t,
+		`is:issue org:mattermost state:open label:"Help Wanted" -label:wontfix author:alice flaky test`,
+		search.Issues().Org("mattermost").State("open").Label("Help Wanted").WithoutLabel("wontfix").
+			Author("alice").Text("flaky test").String(),
+	)
+	require.Equal(t, "is:pr base:release-7.1 is:draft created:>=2021-06-01T10:00:00Z",
+		search.PullRequests().Base("release-7.1").State("draft").CreatedSince(since).String(),
+	)
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// coAuthorRegex matches the Co-authored-by trailers of commit messages
+var coAuthorRegex = regexp.MustCompile(`(?im)^co-authored-by:\s*(.*?)\s*<([^>]+)>\s*$`)
+
+// squashMessage builds the title and body of the commit squashing the
+// pull request commits, following the format GitHub uses. A single
+// commit keeps its message, otherwise the body lists the subjects of
+// the commits and credits their authors with Co-authored-by trailers,
+// keeping those already in the commit messages.
+func squashMessage(pr *PullRequest, commits []*Commit) (title, body string) {
+	if len(commits) == 1 {
+		subject, rest := splitCommitMessage(commits[0].Message)
+		return fmt.Sprintf("%s (#%d)", subject, pr.Number), rest
+	}
+
+	title = fmt.Sprintf("%s (#%d)", pr.Title, pr.Number)
+	lines := []string{}
+	for _, commit := range commits {
+		subject, _ := splitCommitMessage(commit.Message)
+		lines = append(lines, "* "+subject)
+	}
+
+	trailers := []string{}
+	seen := map[string]bool{}
+	addTrailer := func(name, email string) {
+		if email == "" || seen[strings.ToLower(email)] {
+			return
+		}
+		seen[strings.ToLower(email)] = true
+		trailers = append(trailers, fmt.Sprintf("Co-authored-by: %s <%s>", name, email))
+	}
+	for _, commit := range commits {
+		if commit.Author != nil {
+			addTrailer(commit.Author.Name, commit.Author.Email)
+		}
+		for _, match := range coAuthorRegex.FindAllStringSubmatch(commit.Message, -1) {
+			addTrailer(match[1], match[2])
+		}
+	}
+
+	body = strings.Join(lines, "\n")
+	if len(trailers) > 0 {
+		body += "\n\n" + strings.Join(trailers, "\n")
+	}
+	return title, body
+}
+
+// splitCommitMessage returns the first line of a commit
+// message and the rest of it without the blank separator
+func splitCommitMessage(message string) (subject, rest string) {
+	parts := strings.SplitN(strings.TrimSpace(message), "\n", 2)
+	subject = strings.TrimSpace(parts[0])
+	if len(parts) == 2 {
+		rest = strings.TrimSpace(parts[1])
+	}
+	return subject, rest
+}
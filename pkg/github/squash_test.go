@@ -0,0 +1,55 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"testing"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSquashMessage(t *testing.T) {
+	pr := &PullRequest{Number: 42, Title: "Add the frobnicator"}
+	jane := &CommitAuthor{Name: "Jane Doe", Email: "jane@example.com"}
+	john := &CommitAuthor{Name: "John Doe", Email: "john@example.com"}
+
+	title, body := squashMessage(pr, []*Commit{
+		{Message: "Add frobnicator API\n\nWith a long description", Author: jane},
+		{Message: "Fix tests\n\nco-authored-by: Ann Lee <ann@example.com>\nCo-authored-by: John <john@example.com>", Author: john},
+		{Message: "Address review comments", Author: &CommitAuthor{Name: "Jane", Email: "JANE@example.com"}},
+	})
+	require.Equal(t, "Add the frobnicator (#42)", title)
+	require.Equal(t,
+		"* Add frobnicator API\n* Fix tests\n* Address review comments\n\n"+
+			"Co-authored-by: Jane Doe <jane@example.com>\nCo-authored-by: John Doe <john@example.com>\n"+
+			"Co-authored-by: Ann Lee <ann@example.com>",
+		body,
+	)
+
+	// A single commit keeps its message
+	title, body = squashMessage(pr, []*Commit{{Message: "Frobnicate\n\nDetails here\n", Author: jane}})
+	require.Equal(t, "Frobnicate (#42)", title)
+	require.Equal(t, "Details here", body)
+}
+
+func TestBuildSquashMessage(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	pr := &PullRequest{
+		impl:      &defaultPRImplementation{githubAPIUser: gau},
+		RepoOwner: "mattermost",
+		RepoName:  "mattermost-server",
+		Number:    1,
+		Title:     "Fix the build",
+	}
+	fakes.pulls.Commits[1] = []*gogithub.RepositoryCommit{
+		fakes.addCommit("pr-1", "tree-1"), fakes.addCommit("pr-2", "tree-2"),
+	}
+
+	title, body, err := pr.BuildSquashMessage(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, "Fix the build (#1)", title)
+	require.Equal(t, "* Commit pr-1\n* Commit pr-2", body)
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+)
+
+const convertToDraftMutation = `mutation($pullRequestId: ID!) {
+  convertPullRequestToDraft(input: {pullRequestId: $pullRequestId}) {
+    clientMutationId
+  }
+}`
+
+const markReadyForReviewMutation = `mutation($pullRequestId: ID!) {
+  markPullRequestReadyForReview(input: {pullRequestId: $pullRequestId}) {
+    clientMutationId
+  }
+}`
+
+// setState opens or closes the pull request
+func (impl *defaultPRImplementation) setState(ctx context.Context, pr *PullRequest, state string) error {
+	if impl.getOptions().DryRun {
+		impl.log(pr).Infof("[dry-run] Would set the state of PR #%d to %s", pr.Number, state)
+		return nil
+	}
+
+	err := impl.doWithRetry(ctx, "pulls.Edit", func() (resp *gogithub.Response, err error) {
+		_, resp, err = impl.GitHubClient().PullRequests.Edit(
+			ctx, pr.RepoOwner, pr.RepoName, pr.Number, &gogithub.PullRequest{State: gogithub.String(state)},
+		)
+		return resp, err
+	})
+	if err != nil {
+		return err
+	}
+	impl.log(pr).Infof("Set the state of PR #%d to %s", pr.Number, state)
+	return nil
+}
+
+// setDraft converts the pull request to a draft or marks it ready for
+// review. The REST API cannot change it, so GraphQL mutations are used.
+func (impl *defaultPRImplementation) setDraft(ctx context.Context, pr *PullRequest, draft bool) error {
+	mutation, change := markReadyForReviewMutation, "ready for review"
+	if draft {
+		mutation, change = convertToDraftMutation, "draft"
+	}
+
+	if err := impl.loadNodeID(ctx, pr); err != nil {
+		return err
+	}
+
+	if impl.getOptions().DryRun {
+		impl.log(pr).Infof("[dry-run] Would mark PR #%d as %s", pr.Number, change)
+		return nil
+	}
+
+	err := impl.doWithRetry(ctx, "graphql.Do", func() (*gogithub.Response, error) {
+		return impl.GitHubClient().GraphQL.Do(ctx, mutation, map[string]interface{}{
+			"pullRequestId": pr.NodeID,
+		}, nil)
+	})
+	if err != nil {
+		return err
+	}
+	impl.log(pr).Infof("Marked PR #%d as %s", pr.Number, change)
+	return nil
+}
+
+// loadNodeID reads the GraphQL ID of the pull request when it is not known
+func (impl *defaultPRImplementation) loadNodeID(ctx context.Context, pr *PullRequest) error {
+	if pr.NodeID != "" {
+		return nil
+	}
+	var ghpr *gogithub.PullRequest
+	err := impl.doWithRetry(ctx, "pulls.Get", func() (resp *gogithub.Response, err error) {
+		ghpr, resp, err = impl.GitHubClient().PullRequests.Get(ctx, pr.RepoOwner, pr.RepoName, pr.Number)
+		return resp, err
+	})
+	if err != nil {
+		return errors.Wrapf(err, "fetching node ID of PR #%d", pr.Number)
+	}
+	pr.NodeID = ghpr.GetNodeID()
+	return nil
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"testing"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloseReopenPR(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	fakes.pulls.PullRequests[1] = &gogithub.PullRequest{Number: gogithub.Int(1), State: gogithub.String("open")}
+	pr := &PullRequest{
+		impl:      &defaultPRImplementation{githubAPIUser: gau},
+		RepoOwner: "mattermost",
+		RepoName:  "mattermost-server",
+		Number:    1,
+		State:     "open",
+	}
+
+	// Closing and reopening twice only calls the API once each
+	for i := 0; i < 2; i++ {
+		require.Nil(t, pr.ClosePR(context.Background()))
+		require.Equal(t, "closed", fakes.pulls.PullRequests[1].GetState())
+		require.False(t, pr.IsOpen())
+	}
+	require.Equal(t, 1, fakes.pulls.Edits)
+
+	for i := 0; i < 2; i++ {
+		require.Nil(t, pr.ReopenPR(context.Background()))
+		require.Equal(t, "open", fakes.pulls.PullRequests[1].GetState())
+		require.True(t, pr.IsOpen())
+	}
+	require.Equal(t, 2, fakes.pulls.Edits)
+
+	// Close and Reopen do the same
+	require.Nil(t, pr.Close(context.Background()))
+	require.False(t, pr.IsOpen())
+	require.Nil(t, pr.Reopen(context.Background()))
+	require.True(t, pr.IsOpen())
+	require.Equal(t, 4, fakes.pulls.Edits)
+
+	// Nothing is changed in dry-run mode
+	gau.options.DryRun = true
+	dryRun := &PullRequest{impl: &defaultPRImplementation{githubAPIUser: gau}, Number: 1, State: "open"}
+	require.Nil(t, dryRun.ClosePR(context.Background()))
+	require.Equal(t, 4, fakes.pulls.Edits)
+}
+
+func TestDraftState(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	fakes.pulls.PullRequests[1] = &gogithub.PullRequest{Number: gogithub.Int(1), NodeID: gogithub.String("PR_node")}
+	pr := &PullRequest{
+		impl:      &defaultPRImplementation{githubAPIUser: gau},
+		RepoOwner: "mattermost",
+		RepoName:  "mattermost-server",
+		Number:    1,
+		State:     "open",
+	}
+
+	// Converting twice only sends one mutation, the node ID is read once
+	for i := 0; i < 2; i++ {
+		require.Nil(t, pr.ConvertToDraft(context.Background()))
+		require.True(t, pr.IsDraft())
+	}
+	require.Len(t, fakes.graphql.Calls, 1)
+	require.Contains(t, fakes.graphql.Calls[0].Query, "convertPullRequestToDraft")
+	require.Equal(t, "PR_node", fakes.graphql.Calls[0].Variables["pullRequestId"])
+
+	for i := 0; i < 2; i++ {
+		require.Nil(t, pr.MarkReadyForReview(context.Background()))
+		require.False(t, pr.IsDraft())
+	}
+	require.Len(t, fakes.graphql.Calls, 2)
+	require.Contains(t, fakes.graphql.Calls[1].Query, "markPullRequestReadyForReview")
+
+	// Nothing is changed in dry-run mode
+	gau.options.DryRun = true
+	require.Nil(t, pr.ConvertToDraft(context.Background()))
+	require.Len(t, fakes.graphql.Calls, 2)
+}
+
+func TestDeleteBranch(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	fakes.git.Refs["heads/cherry-pick-1-release-7.1"] = &gogithub.Reference{
+		Ref: gogithub.String("refs/heads/cherry-pick-1-release-7.1"),
+	}
+	repo := gau.NewRepository(&gogithub.Repository{
+		Name: gogithub.String("mattermost-server"), Owner: &gogithub.User{Login: gogithub.String("mattermost")},
+	})
+
+	// Deleting a branch that is already gone is not an error
+	for i := 0; i < 2; i++ {
+		require.Nil(t, repo.DeleteBranch(context.Background(), "cherry-pick-1-release-7.1"))
+		require.NotContains(t, fakes.git.Refs, "heads/cherry-pick-1-release-7.1")
+	}
+}
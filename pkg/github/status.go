@@ -0,0 +1,104 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+// States of the CI of a pull request
+const (
+	CIStatusSuccess = "success" // All checks passed
+	CIStatusPending = "pending" // Some checks have not finished
+	CIStatusFailure = "failure" // At least one check failed
+	CIStatusNone    = "none"    // No checks were reported
+)
+
+// CheckRunsStatus summarizes the check runs reported on a commit
+type CheckRunsStatus struct {
+	State   string   // Aggregated state, one of the CIStatus constants
+	Total   int      // Number of check runs
+	Pending []string // Names of the check runs not completed yet
+	Failed  []string // Names of the check runs that did not succeed
+}
+
+// Sources of the CI contexts
+const (
+	CISourceStatus   = "status"    // A legacy commit status
+	CISourceCheckRun = "check_run" // A check run
+)
+
+// CIContext is the normalized result of a commit status or check run
+type CIContext struct {
+	Name        string // Context of the status or name of the check run
+	Source      string // One of the CISource constants
+	State       string // success, pending or failure
+	Description string
+	URL         string
+}
+
+// CIStatus merges the commit statuses and check runs of a commit
+type CIStatus struct {
+	SHA      string
+	State    string // Aggregated state, one of the CIStatus constants
+	Contexts []*CIContext
+}
+
+// Failed returns the names of the contexts that failed
+func (ci *CIStatus) Failed() []string {
+	return ci.namesInState(CIStatusFailure)
+}
+
+// Pending returns the names of the contexts that have not finished
+func (ci *CIStatus) Pending() []string {
+	return ci.namesInState(CIStatusPending)
+}
+
+func (ci *CIStatus) namesInState(state string) []string {
+	names := []string{}
+	for _, c := range ci.Contexts {
+		if c.State == state {
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
+
+// Statuses and conclusions of check runs
+const (
+	CheckRunQueued     = "queued"
+	CheckRunInProgress = "in_progress"
+	CheckRunCompleted  = "completed"
+
+	CheckConclusionSuccess = "success"
+	CheckConclusionFailure = "failure"
+	CheckConclusionNeutral = "neutral"
+)
+
+// CheckRun is a check reported on a commit. A run is created queued or
+// in progress and updated as completed with a conclusion once finished.
+type CheckRun struct {
+	Name        string
+	Status      string // One of the CheckRun status constants
+	Conclusion  string // Required when the run is completed
+	DetailsURL  string
+	Title       string // Title of the output, the name of the run if empty
+	Summary     string // Markdown summary of the output
+	Text        string // Markdown details of the output
+	Annotations []*CheckAnnotation
+}
+
+// CheckAnnotation flags a range of lines of a file in a check run
+type CheckAnnotation struct {
+	Path      string
+	StartLine int
+	EndLine   int
+	Level     string // notice, warning or failure
+	Title     string
+	Message   string
+}
+
+// CommitStatus is a status reported on a commit
+type CommitStatus struct {
+	State       string // error, failure, pending or success
+	Context     string // Name identifying the status
+	Description string
+	TargetURL   string
+}
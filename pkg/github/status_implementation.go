@@ -0,0 +1,242 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+)
+
+// getCombinedStatus returns the combined status state of a commit
+func (impl *defaultPRImplementation) getCombinedStatus(
+	ctx context.Context, pr *PullRequest, sha string,
+) (string, error) {
+	var status *gogithub.CombinedStatus
+	err := impl.doWithRetry(ctx, "repos.GetCombinedStatus", func() (resp *gogithub.Response, err error) {
+		status, resp, err = impl.GitHubClient().Repositories.GetCombinedStatus(
+			ctx, pr.RepoOwner, pr.RepoName, sha, &gogithub.ListOptions{},
+		)
+		return resp, err
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "querying GitHub for the status of %s", sha)
+	}
+
+	// GitHub reports a pending state when no statuses exist
+	if status.GetTotalCount() == 0 {
+		impl.log(pr).Infof("No statuses reported on %s (PR #%d)", sha, pr.Number)
+		return CIStatusNone, nil
+	}
+	impl.log(pr).Infof("Combined status of %s (PR #%d): %s", sha, pr.Number, status.GetState())
+	return status.GetState(), nil
+}
+
+// getCheckRuns reads all check runs of a commit and aggregates them
+func (impl *defaultPRImplementation) getCheckRuns(
+	ctx context.Context, pr *PullRequest, sha string,
+) (*CheckRunsStatus, error) {
+	runs, err := impl.listCheckRuns(ctx, pr, sha)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := summarizeCheckRuns(runs)
+	impl.log(pr).Infof("Check runs of %s (PR #%d): %s", sha, pr.Number, summary.State)
+	return summary, nil
+}
+
+// listCheckRuns fetches all pages of check runs reported on a commit
+func (impl *defaultPRImplementation) listCheckRuns(
+	ctx context.Context, pr *PullRequest, sha string,
+) ([]*gogithub.CheckRun, error) {
+	runs := []*gogithub.CheckRun{}
+	opts := &gogithub.ListCheckRunsOptions{}
+	for {
+		var results *gogithub.ListCheckRunsResults
+		var resp *gogithub.Response
+		err := impl.doWithRetry(ctx, "checks.ListCheckRunsForRef", func() (_ *gogithub.Response, err error) {
+			results, resp, err = impl.GitHubClient().Checks.ListCheckRunsForRef(
+				ctx, pr.RepoOwner, pr.RepoName, sha, opts,
+			)
+			return resp, err
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "querying GitHub for check runs of %s", sha)
+		}
+		runs = append(runs, results.CheckRuns...)
+
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return runs, nil
+}
+
+// listStatuses fetches all pages of the statuses of a commit. GitHub
+// only returns the latest status of each context.
+func (impl *defaultPRImplementation) listStatuses(
+	ctx context.Context, pr *PullRequest, sha string,
+) ([]*gogithub.RepoStatus, error) {
+	statuses := []*gogithub.RepoStatus{}
+	opts := &gogithub.ListOptions{PerPage: 100}
+	for {
+		var combined *gogithub.CombinedStatus
+		var resp *gogithub.Response
+		err := impl.doWithRetry(ctx, "repos.GetCombinedStatus", func() (_ *gogithub.Response, err error) {
+			combined, resp, err = impl.GitHubClient().Repositories.GetCombinedStatus(
+				ctx, pr.RepoOwner, pr.RepoName, sha, opts,
+			)
+			return resp, err
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "querying GitHub for the status of %s", sha)
+		}
+		statuses = append(statuses, combined.Statuses...)
+
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return statuses, nil
+}
+
+// getCIStatus reads the statuses and check runs of a commit and
+// normalizes them into a single CI summary
+func (impl *defaultPRImplementation) getCIStatus(
+	ctx context.Context, pr *PullRequest, sha string,
+) (*CIStatus, error) {
+	statuses, err := impl.listStatuses(ctx, pr, sha)
+	if err != nil {
+		return nil, err
+	}
+	runs, err := impl.listCheckRuns(ctx, pr, sha)
+	if err != nil {
+		return nil, err
+	}
+
+	status := summarizeCI(sha, statuses, runs)
+	impl.log(pr).Infof(
+		"CI status of %s (PR #%d): %s (%d contexts)", sha, pr.Number, status.State, len(status.Contexts),
+	)
+	return status, nil
+}
+
+// summarizeCI normalizes statuses and check runs into one context each.
+// When a check was run more than once only the latest run is considered.
+func summarizeCI(sha string, statuses []*gogithub.RepoStatus, runs []*gogithub.CheckRun) *CIStatus {
+	ci := &CIStatus{SHA: sha, State: CIStatusNone, Contexts: []*CIContext{}}
+
+	for _, status := range statuses {
+		ci.Contexts = append(ci.Contexts, &CIContext{
+			Name:        status.GetContext(),
+			Source:      CISourceStatus,
+			State:       normalizeStatusState(status.GetState()),
+			Description: status.GetDescription(),
+			URL:         status.GetTargetURL(),
+		})
+	}
+
+	latest := map[string]*gogithub.CheckRun{}
+	names := []string{}
+	for _, run := range runs {
+		prev, ok := latest[run.GetName()]
+		if !ok {
+			names = append(names, run.GetName())
+		}
+		if !ok || run.GetID() > prev.GetID() {
+			latest[run.GetName()] = run
+		}
+	}
+	for _, name := range names {
+		run := latest[name]
+		ci.Contexts = append(ci.Contexts, &CIContext{
+			Name:        name,
+			Source:      CISourceCheckRun,
+			State:       normalizeCheckRunState(run),
+			Description: run.GetOutput().GetTitle(),
+			URL:         run.GetDetailsURL(),
+		})
+	}
+
+	if len(ci.Contexts) == 0 {
+		return ci
+	}
+	ci.State = CIStatusSuccess
+	for _, c := range ci.Contexts {
+		if c.State == CIStatusFailure {
+			ci.State = CIStatusFailure
+			break
+		}
+		if c.State == CIStatusPending {
+			ci.State = CIStatusPending
+		}
+	}
+	return ci
+}
+
+// normalizeStatusState maps a commit status state to a CI state
+func normalizeStatusState(state string) string {
+	switch state {
+	case "success":
+		return CIStatusSuccess
+	case "pending":
+		return CIStatusPending
+	default:
+		return CIStatusFailure
+	}
+}
+
+// normalizeCheckRunState maps the status and conclusion of a check run
+// to a CI state. Neutral and skipped runs do not block.
+func normalizeCheckRunState(run *gogithub.CheckRun) string {
+	if run.GetStatus() != CheckRunCompleted {
+		return CIStatusPending
+	}
+	switch run.GetConclusion() {
+	case "success", "neutral", "skipped":
+		return CIStatusSuccess
+	default:
+		return CIStatusFailure
+	}
+}
+
+// summarizeCheckRuns aggregates check runs. A failed run makes the whole
+// set fail, otherwise any unfinished run leaves it pending.
+func summarizeCheckRuns(runs []*gogithub.CheckRun) *CheckRunsStatus {
+	summary := &CheckRunsStatus{
+		State:   CIStatusNone,
+		Total:   len(runs),
+		Pending: []string{},
+		Failed:  []string{},
+	}
+	if len(runs) == 0 {
+		return summary
+	}
+
+	for _, run := range runs {
+		if run.GetStatus() != "completed" {
+			summary.Pending = append(summary.Pending, run.GetName())
+			continue
+		}
+		switch run.GetConclusion() {
+		case "success", "neutral", "skipped":
+		default:
+			summary.Failed = append(summary.Failed, run.GetName())
+		}
+	}
+
+	switch {
+	case len(summary.Failed) > 0:
+		summary.State = CIStatusFailure
+	case len(summary.Pending) > 0:
+		summary.State = CIStatusPending
+	default:
+		summary.State = CIStatusSuccess
+	}
+	return summary
+}
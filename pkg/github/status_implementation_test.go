@@ -0,0 +1,206 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"testing"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/stretchr/testify/require"
+)
+
+func testCheckRun(name, status, conclusion string) *gogithub.CheckRun {
+	run := &gogithub.CheckRun{Name: gogithub.String(name), Status: gogithub.String(status)}
+	if conclusion != "" {
+		run.Conclusion = gogithub.String(conclusion)
+	}
+	return run
+}
+
+func TestGetCombinedStatus(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	impl := &defaultPRImplementation{githubAPIUser: gau}
+	pr := &PullRequest{impl: impl, RepoOwner: "mattermost", RepoName: "mattermost-server", Number: 1}
+	fakes.pulls.Commits[1] = []*gogithub.RepositoryCommit{
+		fakes.addCommit("pr-1", "tree-1"), fakes.addCommit("pr-2", "tree-2", "pr-1"),
+	}
+
+	// No statuses on the head commit
+	state, err := pr.GetCombinedStatus(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, CIStatusNone, state)
+
+	// Statuses are read from the last commit
+	fakes.repos.Statuses = map[string]*gogithub.CombinedStatus{
+		"pr-1": {State: gogithub.String("failure"), TotalCount: gogithub.Int(1)},
+		"pr-2": {State: gogithub.String("pending"), TotalCount: gogithub.Int(2)},
+	}
+	state, err = pr.GetCombinedStatus(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, CIStatusPending, state)
+}
+
+func TestSummarizeCheckRuns(t *testing.T) {
+	for _, tc := range []struct {
+		Runs     []*gogithub.CheckRun
+		Expected string
+	}{
+		{Runs: []*gogithub.CheckRun{}, Expected: CIStatusNone},
+		{
+			Runs: []*gogithub.CheckRun{
+				testCheckRun("test", "completed", "success"), testCheckRun("lint", "completed", "skipped"),
+			},
+			Expected: CIStatusSuccess,
+		},
+		{
+			Runs: []*gogithub.CheckRun{
+				testCheckRun("test", "in_progress", ""), testCheckRun("lint", "completed", "success"),
+			},
+			Expected: CIStatusPending,
+		},
+		{
+			Runs: []*gogithub.CheckRun{
+				testCheckRun("test", "in_progress", ""), testCheckRun("lint", "completed", "failure"),
+			},
+			Expected: CIStatusFailure,
+		},
+	} {
+		summary := summarizeCheckRuns(tc.Runs)
+		require.Equal(t, tc.Expected, summary.State)
+		require.Equal(t, len(tc.Runs), summary.Total)
+	}
+}
+
+func TestGetCheckRuns(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	impl := &defaultPRImplementation{githubAPIUser: gau}
+	pr := &PullRequest{impl: impl, RepoOwner: "mattermost", RepoName: "mattermost-server", Number: 1}
+	fakes.pulls.Commits[1] = []*gogithub.RepositoryCommit{fakes.addCommit("pr-1", "tree-1")}
+	fakes.checks.CheckRuns = map[string][]*gogithub.CheckRun{
+		"pr-1": {testCheckRun("test", "completed", "timed_out"), testCheckRun("lint", "queued", "")},
+	}
+
+	summary, err := pr.GetCheckRuns(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, CIStatusFailure, summary.State)
+	require.Equal(t, []string{"test"}, summary.Failed)
+	require.Equal(t, []string{"lint"}, summary.Pending)
+}
+
+func TestCheckRuns(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	repo := gau.NewRepository(&gogithub.Repository{
+		Name: gogithub.String("mattermost-server"), Owner: &gogithub.User{Login: gogithub.String("mattermost")},
+	})
+	ctx := context.Background()
+
+	_, err := repo.CreateCheckRun(ctx, "head", &CheckRun{Status: CheckRunInProgress})
+	require.NotNil(t, err)
+
+	id, err := repo.CreateCheckRun(ctx, "head", &CheckRun{Name: "changelog", Status: CheckRunInProgress})
+	require.Nil(t, err)
+	require.NotZero(t, id)
+	run := fakes.checks.CheckRuns["head"][0]
+	require.Equal(t, CheckRunInProgress, run.GetStatus())
+	require.Nil(t, run.Output)
+
+	// Annotations are sent in batches of the size GitHub accepts
+	annotations := []*CheckAnnotation{}
+	for i := 1; i <= 120; i++ {
+		annotations = append(annotations, &CheckAnnotation{
+			Path: "CHANGELOG.md", StartLine: i, Level: "warning", Message: "Missing entry",
+		})
+	}
+	require.Nil(t, repo.UpdateCheckRun(ctx, id, &CheckRun{
+		Name:        "changelog",
+		Status:      CheckRunCompleted,
+		Conclusion:  CheckConclusionFailure,
+		Summary:     "Changelog entries missing",
+		Annotations: annotations,
+	}))
+	require.Len(t, fakes.checks.Updates, 3)
+	require.Equal(t, CheckRunCompleted, run.GetStatus())
+	require.Equal(t, CheckConclusionFailure, run.GetConclusion())
+	require.Equal(t, "changelog", run.GetOutput().GetTitle())
+	require.Len(t, run.GetOutput().Annotations, 120)
+	require.Equal(t, 120, run.GetOutput().Annotations[119].GetEndLine())
+
+	// The new run is seen by the PR status checks
+	impl := &defaultPRImplementation{githubAPIUser: gau}
+	pr := &PullRequest{impl: impl, RepoOwner: "mattermost", RepoName: "mattermost-server"}
+	status, err := impl.getCheckRuns(ctx, pr, "head")
+	require.Nil(t, err)
+	require.Equal(t, []string{"changelog"}, status.Failed)
+}
+
+func TestSetCommitStatus(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	repo := gau.NewRepository(&gogithub.Repository{
+		Name: gogithub.String("mattermost-server"), Owner: &gogithub.User{Login: gogithub.String("mattermost")},
+	})
+	ctx := context.Background()
+
+	require.NotNil(t, repo.SetCommitStatus(ctx, "head", &CommitStatus{State: "pending"}))
+	require.Nil(t, repo.SetCommitStatus(ctx, "head", &CommitStatus{State: "pending", Context: "backport-eligible"}))
+	require.Nil(t, repo.SetCommitStatus(ctx, "head", &CommitStatus{
+		State: "success", Context: "backport-eligible", Description: "Can be backported",
+	}))
+	status := fakes.repos.Statuses["head"]
+	require.Equal(t, 1, status.GetTotalCount())
+	require.Equal(t, "success", status.GetState())
+	require.Equal(t, "Can be backported", status.Statuses[0].GetDescription())
+
+	// Nothing is reported in dry-run mode
+	gau.options.DryRun = true
+	_, err := repo.CreateCheckRun(ctx, "head", &CheckRun{Name: "changelog"})
+	require.Nil(t, err)
+	require.Empty(t, fakes.checks.CheckRuns)
+}
+
+func TestGetCIStatus(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	impl := &defaultPRImplementation{githubAPIUser: gau}
+	pr := &PullRequest{impl: impl, RepoOwner: "mattermost", RepoName: "mattermost-server", Number: 1}
+	fakes.pulls.Commits[1] = []*gogithub.RepositoryCommit{fakes.addCommit("pr-1", "tree-1")}
+
+	// Nothing reported yet
+	ci, err := pr.GetCIStatus(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, CIStatusNone, ci.State)
+	require.Empty(t, ci.Contexts)
+
+	fakes.repos.Statuses = map[string]*gogithub.CombinedStatus{
+		"pr-1": {
+			State:      gogithub.String("success"),
+			TotalCount: gogithub.Int(1),
+			Statuses: []*gogithub.RepoStatus{
+				{Context: gogithub.String("ci/circleci"), State: gogithub.String("success")},
+			},
+		},
+	}
+	rerun := testCheckRun("test", "in_progress", "")
+	rerun.ID = gogithub.Int64(3)
+	fakes.checks.CheckRuns = map[string][]*gogithub.CheckRun{
+		"pr-1": {testCheckRun("lint", "completed", "neutral"), testCheckRun("test", "completed", "failure"), rerun},
+	}
+	fakes.checks.CheckRuns["pr-1"][1].ID = gogithub.Int64(2)
+
+	// The failed test run was retried, only the latest one counts
+	ci, err = pr.GetCIStatus(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, "pr-1", ci.SHA)
+	require.Equal(t, CIStatusPending, ci.State)
+	require.Len(t, ci.Contexts, 3)
+	require.Equal(t, CISourceStatus, ci.Contexts[0].Source)
+	require.Equal(t, []string{"test"}, ci.Pending())
+	require.Empty(t, ci.Failed())
+
+	// A failed status fails everything
+	fakes.repos.Statuses["pr-1"].Statuses[0].State = gogithub.String("error")
+	ci, err = pr.GetCIStatus(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, CIStatusFailure, ci.State)
+	require.Equal(t, []string{"ci/circleci"}, ci.Failed())
+}
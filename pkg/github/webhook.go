@@ -0,0 +1,150 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+)
+
+// WebhookEvent is a webhook delivery from GitHub translated to the
+// types of the package
+type WebhookEvent struct {
+	Type   string // Event type, from the X-GitHub-Event header
+	Action string // Action that triggered the event, if any
+	Sender string // Login of the user that triggered the event
+
+	// DeliveryID identifies the delivery, it is kept when GitHub redelivers
+	// the event. Servers set it from the X-GitHub-Delivery header.
+	DeliveryID string
+
+	// PullRequest is set for events about pull requests. For comments,
+	// only the repository and number are known until it is fetched.
+	PullRequest *PullRequest
+
+	// Issue is set for issues events and comments on issues
+	Issue *Issue
+
+	// Comment is the body of the comment for issue_comment events
+	Comment string
+
+	// Ref and Commits are set for push events, the commits are
+	// listed oldest first and only have their metadata
+	Ref     string
+	Commits []*Commit
+
+	// Raw is the event as parsed by go-github
+	Raw interface{}
+}
+
+// NewPullRequestFromEvent builds a PullRequest from a webhook event
+// using the default options
+func NewPullRequestFromEvent(event *gogithub.PullRequestEvent) (*PullRequest, error) {
+	return New().NewPullRequestFromEvent(event)
+}
+
+// ParseWebhook parses a webhook payload using the default options
+func ParseWebhook(payload []byte, eventType string) (*WebhookEvent, error) {
+	return New().ParseWebhook(payload, eventType)
+}
+
+// NewPullRequestFromEvent builds a PullRequest from the data in a
+// pull_request webhook event. The returned object talks to the API
+// using the options of the GitHub object.
+func (gh *GitHub) NewPullRequestFromEvent(event *gogithub.PullRequestEvent) (*PullRequest, error) {
+	if event == nil || event.PullRequest == nil {
+		return nil, errors.Wrap(ErrInvalidEvent, "event has no pull request")
+	}
+
+	gau := gh.impl.apiUser()
+	pr := gau.NewPullRequest(event.PullRequest)
+
+	// The repository in the event is more reliable than the PR base
+	// data, which is missing in some payloads
+	if pr.RepoOwner == "" {
+		pr.RepoOwner = event.GetRepo().GetOwner().GetLogin()
+	}
+	if pr.RepoName == "" {
+		pr.RepoName = event.GetRepo().GetName()
+	}
+	if pr.Number == 0 {
+		pr.Number = event.GetNumber()
+	}
+
+	if pr.RepoOwner == "" || pr.RepoName == "" {
+		return nil, errors.Wrapf(ErrInvalidEvent, "event for PR #%d has no repository", pr.Number)
+	}
+	if pr.Number == 0 {
+		return nil, errors.Wrapf(ErrInvalidEvent, "event in %s/%s has no PR number", pr.RepoOwner, pr.RepoName)
+	}
+	return pr, nil
+}
+
+// ParseWebhook parses the payload of a webhook delivery. Pull request,
+// issue, issue comment and push events get their data translated.
+// Other event types are returned with only the raw go-github event.
+func (gh *GitHub) ParseWebhook(payload []byte, eventType string) (*WebhookEvent, error) {
+	raw, err := gogithub.ParseWebHook(eventType, payload)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing %s webhook payload", eventType)
+	}
+
+	gau := gh.impl.apiUser()
+	result := &WebhookEvent{Type: eventType, Raw: raw}
+	switch event := raw.(type) {
+	case *gogithub.PullRequestEvent:
+		result.Action = event.GetAction()
+		result.Sender = event.GetSender().GetLogin()
+		result.PullRequest, err = gh.NewPullRequestFromEvent(event)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading pull request from event")
+		}
+
+	case *gogithub.IssueCommentEvent:
+		result.Action = event.GetAction()
+		result.Sender = event.GetSender().GetLogin()
+		result.Comment = event.GetComment().GetBody()
+		if !event.GetIssue().IsPullRequest() {
+			result.Issue = gau.NewIssue(event.GetRepo().GetOwner().GetLogin(), event.GetRepo().GetName(), event.GetIssue())
+			break
+		}
+		result.PullRequest = &PullRequest{
+			impl:      &defaultPRImplementation{githubAPIUser: gau},
+			RepoOwner: event.GetRepo().GetOwner().GetLogin(),
+			RepoName:  event.GetRepo().GetName(),
+			Number:    event.GetIssue().GetNumber(),
+			Title:     event.GetIssue().GetTitle(),
+			State:     event.GetIssue().GetState(),
+		}
+
+	case *gogithub.IssuesEvent:
+		result.Action = event.GetAction()
+		result.Sender = event.GetSender().GetLogin()
+		result.Issue = gau.NewIssue(event.GetRepo().GetOwner().GetLogin(), event.GetRepo().GetName(), event.GetIssue())
+
+	case *gogithub.PushEvent:
+		result.Sender = event.GetSender().GetLogin()
+		result.Ref = event.GetRef()
+		result.Commits = []*Commit{}
+		for _, c := range event.Commits {
+			result.Commits = append(result.Commits, newPushCommit(c))
+		}
+	}
+	return result, nil
+}
+
+// newPushCommit builds a Commit from the summary sent in push events
+func newPushCommit(c *gogithub.HeadCommit) *Commit {
+	commit := NewCommit()
+	commit.SHA = c.GetID()
+	commit.TreeSHA = c.GetTreeID()
+	commit.Message = c.GetMessage()
+	commit.Author = &CommitAuthor{
+		Name: c.GetAuthor().GetName(), Email: c.GetAuthor().GetEmail(), Date: c.GetTimestamp().Time,
+	}
+	commit.Committer = &CommitAuthor{
+		Name: c.GetCommitter().GetName(), Email: c.GetCommitter().GetEmail(), Date: c.GetTimestamp().Time,
+	}
+	return commit
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"testing"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWebhook(t *testing.T) {
+	gh := NewWithOptions(&Options{})
+
+	// A merged pull request
+	event, err := gh.ParseWebhook([]byte(`{
+		"action": "closed",
+		"number": 1234,
+		"pull_request": {
+			"number": 1234, "state": "closed", "merged": true, "merge_commit_sha": "abc123",
+			"base": {"ref": "master", "repo": {"name": "mattermost-server", "owner": {"login": "mattermost"}}}
+		},
+		"repository": {"name": "mattermost-server", "owner": {"login": "mattermost"}}
+	}`), "pull_request")
+	require.Nil(t, err)
+	require.Equal(t, "closed", event.Action)
+	require.NotNil(t, event.PullRequest)
+	require.Equal(t, "mattermost", event.PullRequest.RepoOwner)
+	require.Equal(t, "mattermost-server", event.PullRequest.RepoName)
+	require.Equal(t, 1234, event.PullRequest.Number)
+	require.Equal(t, "abc123", event.PullRequest.MergeCommitSHA)
+	require.True(t, event.PullRequest.IsMerged())
+
+	// A comment on a pull request
+	event, err = gh.ParseWebhook([]byte(`{
+		"action": "created",
+		"issue": {"number": 1234, "state": "closed", "pull_request": {"url": "https://api.github.com/repos/mattermost/mattermost-server/pulls/1234"}},
+		"comment": {"body": "/cherry-pick release-7.1"},
+		"repository": {"name": "mattermost-server", "owner": {"login": "mattermost"}}
+	}`), "issue_comment")
+	require.Nil(t, err)
+	require.Equal(t, "/cherry-pick release-7.1", event.Comment)
+	require.Equal(t, 1234, event.PullRequest.Number)
+
+	// Comments on issues have no pull request
+	event, err = gh.ParseWebhook([]byte(`{
+		"action": "created", "issue": {"number": 1}, "comment": {"body": "hi"},
+		"repository": {"name": "mattermost-server", "owner": {"login": "mattermost"}}
+	}`), "issue_comment")
+	require.Nil(t, err)
+	require.Nil(t, event.PullRequest)
+	require.Equal(t, 1, event.Issue.Number)
+	require.Equal(t, "mattermost-server", event.Issue.Repo)
+
+	// Issues
+	event, err = gh.ParseWebhook([]byte(`{
+		"action": "labeled", "issue": {"number": 7, "labels": [{"name": "Bug"}]},
+		"sender": {"login": "alice"},
+		"repository": {"name": "mattermost-server", "owner": {"login": "mattermost"}}
+	}`), "issues")
+	require.Nil(t, err)
+	require.Equal(t, "labeled", event.Action)
+	require.Equal(t, "alice", event.Sender)
+	require.Equal(t, []string{"Bug"}, event.Issue.Labels)
+
+	// Pushes carry the commits
+	event, err = gh.ParseWebhook([]byte(`{
+		"ref": "refs/heads/master",
+		"commits": [{
+			"id": "abc123", "tree_id": "tree1", "message": "Fix the build",
+			"timestamp": "2021-06-01T10:00:00Z",
+			"author": {"name": "Alice", "email": "alice@example.com"},
+			"committer": {"name": "GitHub", "email": "noreply@github.com"}
+		}]
+	}`), "push")
+	require.Nil(t, err)
+	require.IsType(t, &gogithub.PushEvent{}, event.Raw)
+	require.Equal(t, "refs/heads/master", event.Ref)
+	require.Len(t, event.Commits, 1)
+	require.Equal(t, "abc123", event.Commits[0].SHA)
+	require.Equal(t, "tree1", event.Commits[0].TreeSHA)
+	require.Equal(t, "alice@example.com", event.Commits[0].Author.Email)
+	require.Equal(t, "GitHub", event.Commits[0].Committer.Name)
+
+	// Other events are returned raw
+	event, err = gh.ParseWebhook([]byte(`{"ref": "v7.1.0", "ref_type": "tag"}`), "create")
+	require.Nil(t, err)
+	require.IsType(t, &gogithub.CreateEvent{}, event.Raw)
+
+	// Events without the required data are rejected
+	_, err = gh.ParseWebhook([]byte(`{"action": "opened", "pull_request": {"number": 1}}`), "pull_request")
+	require.True(t, errors.Is(err, ErrInvalidEvent))
+	_, err = gh.NewPullRequestFromEvent(&gogithub.PullRequestEvent{})
+	require.True(t, errors.Is(err, ErrInvalidEvent))
+}
@@ -0,0 +1,36 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import "time"
+
+// maxWorkflowInputs is the number of inputs GitHub
+// accepts when dispatching a workflow
+const maxWorkflowInputs = 10
+
+// WorkflowRun is a run of a GitHub Actions workflow
+type WorkflowRun struct {
+	ID         int64
+	Name       string
+	HeadBranch string
+	HeadSHA    string
+	Event      string // Event that triggered the run, eg workflow_dispatch
+	Status     string // queued, in_progress or completed
+	Conclusion string // Set when the run is completed
+	URL        string
+	CreatedAt  time.Time
+}
+
+// State returns the state of the run as one of the CIStatus constants
+func (run *WorkflowRun) State() string {
+	if run.Status != CheckRunCompleted {
+		return CIStatusPending
+	}
+	switch run.Conclusion {
+	case "success", "neutral", "skipped":
+		return CIStatusSuccess
+	default:
+		return CIStatusFailure
+	}
+}
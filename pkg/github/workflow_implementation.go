@@ -0,0 +1,91 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+)
+
+// dispatchWorkflow triggers a workflow_dispatch event of the workflow
+func (di *defaultRepoImplementation) dispatchWorkflow(
+	ctx context.Context, owner, repo, workflow, ref string, inputs map[string]string,
+) error {
+	if di.getOptions().DryRun {
+		di.getLogger().Infof("[dry-run] Would run workflow %s on %s in %s/%s", workflow, ref, owner, repo)
+		return nil
+	}
+
+	event := gogithub.CreateWorkflowDispatchEventRequest{Ref: ref}
+	if len(inputs) > 0 {
+		event.Inputs = map[string]interface{}{}
+		for k, v := range inputs {
+			event.Inputs[k] = v
+		}
+	}
+	err := di.doWithRetry(ctx, "actions.CreateWorkflowDispatchEventByFileName", func() (*gogithub.Response, error) {
+		return di.GitHubClient().Actions.CreateWorkflowDispatchEventByFileName(ctx, owner, repo, workflow, event)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "dispatching workflow %s on %s", workflow, ref)
+	}
+	di.getLogger().Infof("Triggered workflow %s on %s in %s/%s", workflow, ref, owner, repo)
+	return nil
+}
+
+// listWorkflowRuns returns the latest runs of the workflow on the branch
+func (di *defaultRepoImplementation) listWorkflowRuns(
+	ctx context.Context, owner, repo, workflow, branch string,
+) ([]*WorkflowRun, error) {
+	var runs *gogithub.WorkflowRuns
+	err := di.doWithRetry(ctx, "actions.ListWorkflowRunsByFileName", func() (resp *gogithub.Response, err error) {
+		runs, resp, err = di.GitHubClient().Actions.ListWorkflowRunsByFileName(
+			ctx, owner, repo, workflow, &gogithub.ListWorkflowRunsOptions{
+				Branch:      branch,
+				ListOptions: gogithub.ListOptions{PerPage: 100},
+			},
+		)
+		return resp, err
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing runs of workflow %s", workflow)
+	}
+
+	result := make([]*WorkflowRun, 0, len(runs.WorkflowRuns))
+	for _, run := range runs.WorkflowRuns {
+		result = append(result, newWorkflowRun(run))
+	}
+	return result, nil
+}
+
+// getWorkflowRun reads a workflow run by ID
+func (di *defaultRepoImplementation) getWorkflowRun(
+	ctx context.Context, owner, repo string, id int64,
+) (*WorkflowRun, error) {
+	var run *gogithub.WorkflowRun
+	err := di.doWithRetry(ctx, "actions.GetWorkflowRunByID", func() (resp *gogithub.Response, err error) {
+		run, resp, err = di.GitHubClient().Actions.GetWorkflowRunByID(ctx, owner, repo, id)
+		return resp, err
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading workflow run %d", id)
+	}
+	return newWorkflowRun(run), nil
+}
+
+func newWorkflowRun(run *gogithub.WorkflowRun) *WorkflowRun {
+	return &WorkflowRun{
+		ID:         run.GetID(),
+		Name:       run.GetName(),
+		HeadBranch: run.GetHeadBranch(),
+		HeadSHA:    run.GetHeadSHA(),
+		Event:      run.GetEvent(),
+		Status:     run.GetStatus(),
+		Conclusion: run.GetConclusion(),
+		URL:        run.GetHTMLURL(),
+		CreatedAt:  run.GetCreatedAt().Time,
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package github
+
+import (
+	"context"
+	"testing"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/stretchr/testify/require"
+)
+
+func testWorkflowRun(id int64, branch, status, conclusion string) *gogithub.WorkflowRun {
+	run := &gogithub.WorkflowRun{
+		ID:         gogithub.Int64(id),
+		Name:       gogithub.String("E2E Tests"),
+		HeadBranch: gogithub.String(branch),
+		Status:     gogithub.String(status),
+		Event:      gogithub.String("workflow_dispatch"),
+	}
+	if conclusion != "" {
+		run.Conclusion = gogithub.String(conclusion)
+	}
+	return run
+}
+
+func TestWorkflows(t *testing.T) {
+	gau, fakes := newFakeAPIUser()
+	repo := gau.NewRepository(&gogithub.Repository{
+		Name: gogithub.String("mattermost-server"), Owner: &gogithub.User{Login: gogithub.String("mattermost")},
+	})
+	ctx := context.Background()
+
+	require.Nil(t, repo.DispatchWorkflow(ctx, "e2e-tests.yml", "release-7.8", map[string]string{"browser": "chrome"}))
+	require.Len(t, fakes.actions.Dispatches, 1)
+	require.Equal(t, "release-7.8", fakes.actions.Dispatches[0].Ref)
+	require.Equal(t, map[string]interface{}{"browser": "chrome"}, fakes.actions.Dispatches[0].Inputs)
+	require.NotNil(t, repo.DispatchWorkflow(ctx, "e2e-tests.yml", "", nil))
+	tooMany := map[string]string{}
+	for _, k := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k"} {
+		tooMany[k] = k
+	}
+	require.NotNil(t, repo.DispatchWorkflow(ctx, "e2e-tests.yml", "master", tooMany))
+	require.Len(t, fakes.actions.Dispatches, 1)
+
+	fakes.actions.Runs = map[string][]*gogithub.WorkflowRun{
+		"e2e-tests.yml": {
+			testWorkflowRun(3, "release-7.8", "in_progress", ""),
+			testWorkflowRun(2, "master", "completed", "success"),
+			testWorkflowRun(1, "release-7.8", "completed", "cancelled"),
+		},
+	}
+	runs, err := repo.ListWorkflowRuns(ctx, "e2e-tests.yml", "release-7.8")
+	require.Nil(t, err)
+	require.Len(t, runs, 2)
+	require.Equal(t, CIStatusPending, runs[0].State())
+	require.Equal(t, CIStatusFailure, runs[1].State())
+
+	run, err := repo.GetWorkflowRun(ctx, 2)
+	require.Nil(t, err)
+	require.Equal(t, "master", run.HeadBranch)
+	require.Equal(t, CIStatusSuccess, run.State())
+	_, err = repo.GetWorkflowRun(ctx, 4)
+	require.True(t, isNotFound(err))
+
+	// Workflows are not run in dry-run mode
+	gau.options.DryRun = true
+	require.Nil(t, repo.DispatchWorkflow(ctx, "e2e-tests.yml", "master", nil))
+	require.Len(t, fakes.actions.Dispatches, 1)
+}
@@ -0,0 +1,348 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package queue
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/puerco/mattermod-refactor/pkg/github"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrQueueFull is returned when enqueuing a job with all the slots taken
+	ErrQueueFull = errors.New("job queue is full")
+
+	// ErrQueueClosed is returned when enqueuing a job after Shutdown
+	ErrQueueClosed = errors.New("job queue is shut down")
+)
+
+// Job is a task processed by the workers of the queue. Jobs either have
+// a Run function or a Type registered in the queue with its Payload.
+// Only the latter can be persisted in a Store.
+type Job struct {
+	Name  string                          // Describes the job in the logs, eg "cherry-pick #123 to release-7.1"
+	Run   func(ctx context.Context) error // Does the work, it is called again if it fails
+	Retry *RetryPolicy                    // Overrides the retry policy of the queue
+
+	Type    string // Runner registered in the queue that does the work
+	Payload []byte // Data passed to the runner, usually JSON
+	Key     string // Idempotency key, jobs with the key of a pending one are skipped. Random if empty.
+}
+
+// Runner does the work of the jobs of a type
+type Runner func(ctx context.Context, payload []byte) error
+
+// RetryPolicy controls how failed jobs are retried. The wait between
+// attempts starts at InitialBackoff and doubles up to MaxBackoff.
+type RetryPolicy struct {
+	MaxAttempts    int // Total number of runs, including the first one
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+var defaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 5 * time.Second,
+	MaxBackoff:     time.Minute,
+}
+
+// Options configure a queue
+type Options struct {
+	Workers    int           // Jobs run in parallel, defaults to 4
+	Size       int           // Jobs waiting for a worker before Enqueue fails, defaults to 100
+	JobTimeout time.Duration // Limits each run of a job, no limit when zero
+	Retry      RetryPolicy   // Default retry policy of the jobs, three attempts if not set
+
+	// Store keeps the jobs with a Type until they finish, so they are run
+	// again after a restart (see Restore). Jobs are run at least once,
+	// their runners must cope with repeated runs. Not persisted when nil.
+	Store Store
+
+	// Logger receives the log messages of the queue. When nil,
+	// the standard logrus logger is used.
+	Logger github.Logger
+}
+
+// Queue runs jobs in a pool of workers, off the path of the requests
+// that create them. It is safe for concurrent use.
+type Queue struct {
+	options Options
+	jobs    chan *Job
+	ctx     context.Context // Canceled to abort the running jobs
+	cancel  context.CancelFunc
+	workers sync.WaitGroup
+
+	mtx     sync.RWMutex
+	closed  bool
+	runners map[string]Runner
+}
+
+// permanentError marks errors that are not worth retrying
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps an error returned by a job so it is not retried
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// New returns a queue with its workers started
+func New(opts Options) *Queue {
+	if opts.Workers <= 0 {
+		opts.Workers = 4
+	}
+	if opts.Size <= 0 {
+		opts.Size = 100
+	}
+	if opts.Retry.MaxAttempts <= 0 {
+		opts.Retry = defaultRetryPolicy
+	}
+	if opts.Logger == nil {
+		opts.Logger = github.NewLogrusLogger(logrus.StandardLogger())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	q := &Queue{
+		options: opts,
+		jobs:    make(chan *Job, opts.Size),
+		ctx:     ctx,
+		cancel:  cancel,
+		runners: map[string]Runner{},
+	}
+	for i := 0; i < opts.Workers; i++ {
+		q.workers.Add(1)
+		go q.work()
+	}
+	return q
+}
+
+// Register sets the runner of the jobs of a type. Runners have to be
+// registered before enqueuing or restoring their jobs.
+func (q *Queue) Register(jobType string, runner Runner) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	q.runners[jobType] = runner
+}
+
+// Enqueue adds a job to the queue, saving it in the store first if it has
+// a type. It does not block: when all the slots are taken it returns
+// ErrQueueFull. Jobs with the key of a pending one are skipped.
+func (q *Queue) Enqueue(ctx context.Context, job *Job) error {
+	q.mtx.RLock()
+	defer q.mtx.RUnlock()
+	if q.closed {
+		return ErrQueueClosed
+	}
+	if job == nil || (job.Run == nil && q.runners[job.Type] == nil) {
+		return errors.New("job has nothing to run")
+	}
+
+	if q.options.Store != nil && job.Type != "" {
+		if job.Key == "" {
+			key, err := randomKey()
+			if err != nil {
+				return errors.Wrap(err, "generating job key")
+			}
+			job.Key = key
+		}
+		added, err := q.options.Store.Add(ctx, &StoredJob{
+			Key: job.Key, Type: job.Type, Name: job.Name, Payload: job.Payload, CreatedAt: time.Now(),
+		})
+		if err != nil {
+			return errors.Wrapf(err, "storing job %s", job.Name)
+		}
+		if !added {
+			q.options.Logger.Debugf("Skipping job %s, job %s is already pending", job.Name, job.Key)
+			return nil
+		}
+	}
+
+	select {
+	case q.jobs <- q.bind(job):
+		return nil
+	default:
+		q.forget(ctx, job)
+		return errors.Wrapf(ErrQueueFull, "enqueuing %s", job.Name)
+	}
+}
+
+// Restore enqueues the jobs left in the store by a previous run, waiting
+// for free slots if needed. It returns the number of jobs restored.
+func (q *Queue) Restore(ctx context.Context) (int, error) {
+	if q.options.Store == nil {
+		return 0, nil
+	}
+	stored, err := q.options.Store.Pending(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "reading pending jobs")
+	}
+
+	q.mtx.RLock()
+	defer q.mtx.RUnlock()
+	if q.closed {
+		return 0, ErrQueueClosed
+	}
+	restored := 0
+	for _, sj := range stored {
+		if q.runners[sj.Type] == nil {
+			q.options.Logger.Warnf("Not restoring job %s, there is no runner for %s jobs", sj.Name, sj.Type)
+			continue
+		}
+		select {
+		case q.jobs <- q.bind(&Job{Name: sj.Name, Type: sj.Type, Payload: sj.Payload, Key: sj.Key}):
+			restored++
+		case <-ctx.Done():
+			return restored, errors.Wrap(ctx.Err(), "restoring pending jobs")
+		}
+	}
+	return restored, nil
+}
+
+// bind returns a copy of a job with a type that runs it with its runner,
+// so workers do not look up runners. Called with the lock held.
+func (q *Queue) bind(job *Job) *Job {
+	if job.Run != nil {
+		return job
+	}
+	bound := *job
+	runner := q.runners[job.Type]
+	bound.Run = func(ctx context.Context) error {
+		return runner(ctx, job.Payload)
+	}
+	return &bound
+}
+
+// forget removes a job from the store
+func (q *Queue) forget(ctx context.Context, job *Job) {
+	if q.options.Store == nil || job.Type == "" {
+		return
+	}
+	if err := q.options.Store.Remove(ctx, job.Key); err != nil {
+		q.options.Logger.Errorf("Unable to remove job %s from the store: %v", job.Name, err)
+	}
+}
+
+// Len returns the number of jobs waiting for a worker
+func (q *Queue) Len() int {
+	return len(q.jobs)
+}
+
+// Shutdown stops accepting jobs and waits for the workers to finish the
+// ones enqueued. If ctx ends first, the running jobs are canceled and
+// the ones still waiting are dropped.
+func (q *Queue) Shutdown(ctx context.Context) error {
+	q.mtx.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.jobs)
+	}
+	q.mtx.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		q.cancel()
+		return nil
+	case <-ctx.Done():
+		q.cancel()
+		<-done
+		return errors.Wrap(ctx.Err(), "waiting for jobs to finish")
+	}
+}
+
+// work runs jobs until the queue is shut down
+func (q *Queue) work() {
+	defer q.workers.Done()
+	for job := range q.jobs {
+		if q.ctx.Err() != nil {
+			q.options.Logger.Warnf("Dropping job %s, the queue is shutting down", job.Name)
+			continue
+		}
+		err := q.runWithRetry(job)
+		if err != nil {
+			q.options.Logger.Errorf("Job %s failed: %v", job.Name, err)
+		}
+		// Jobs interrupted by the shutdown stay in the store
+		// to be run again when the queue is restored
+		if err == nil || q.ctx.Err() == nil {
+			q.forget(context.Background(), job)
+		}
+	}
+}
+
+// runWithRetry runs a job until it succeeds, it returns a permanent
+// error or it runs out of attempts
+func (q *Queue) runWithRetry(job *Job) error {
+	policy := q.options.Retry
+	if job.Retry != nil {
+		policy = *job.Retry
+	}
+	backoff := policy.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := q.run(job)
+		if err == nil {
+			return nil
+		}
+		perr := &permanentError{}
+		if errors.As(err, &perr) || attempt >= policy.MaxAttempts {
+			return err
+		}
+
+		q.options.Logger.Warnf(
+			"Job %s failed (attempt %d/%d), retrying in %s: %v", job.Name, attempt, policy.MaxAttempts, backoff, err,
+		)
+		select {
+		case <-q.ctx.Done():
+			return errors.Wrap(err, "queue shut down before retrying")
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+	}
+}
+
+// run calls the job once, bounded by the job timeout. Panics are
+// returned as errors so they do not take down the worker.
+func (q *Queue) run(job *Job) (err error) {
+	ctx, cancel := q.ctx, context.CancelFunc(func() {})
+	if q.options.JobTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, q.options.JobTimeout)
+	}
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Errorf("job panicked: %v", r)
+		}
+	}()
+	return job.Run(ctx)
+}
+
+// randomKey returns a random idempotency key
+func randomKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package queue
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+var testRetry = RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+func TestQueue(t *testing.T) {
+	q := New(Options{Workers: 2, Retry: testRetry})
+	ctx := context.Background()
+
+	// Failed jobs are retried until they succeed or run out of attempts
+	var flaky, failing, permanent, panicking, done int32
+	jobs := []*Job{
+		{Name: "flaky", Run: func(ctx context.Context) error {
+			if atomic.AddInt32(&flaky, 1) < 2 {
+				return errors.New("temporary failure")
+			}
+			return nil
+		}},
+		{Name: "failing", Run: func(ctx context.Context) error {
+			atomic.AddInt32(&failing, 1)
+			return errors.New("always fails")
+		}},
+		{Name: "permanent", Run: func(ctx context.Context) error {
+			atomic.AddInt32(&permanent, 1)
+			return Permanent(errors.New("not worth retrying"))
+		}},
+		{Name: "panicking", Retry: &RetryPolicy{MaxAttempts: 1}, Run: func(ctx context.Context) error {
+			atomic.AddInt32(&panicking, 1)
+			panic("boom")
+		}},
+	}
+	for i := 0; i < 10; i++ {
+		jobs = append(jobs, &Job{Name: "counter", Run: func(ctx context.Context) error {
+			atomic.AddInt32(&done, 1)
+			return nil
+		}})
+	}
+	for _, job := range jobs {
+		require.Nil(t, q.Enqueue(ctx, job))
+	}
+
+	// Shutting down processes the jobs enqueued
+	require.Nil(t, q.Shutdown(context.Background()))
+	require.EqualValues(t, 2, flaky)
+	require.EqualValues(t, 3, failing)
+	require.EqualValues(t, 1, permanent)
+	require.EqualValues(t, 1, panicking)
+	require.EqualValues(t, 10, done)
+
+	err := q.Enqueue(ctx, &Job{Name: "late", Run: func(ctx context.Context) error { return nil }})
+	require.True(t, errors.Is(err, ErrQueueClosed))
+	require.NotNil(t, q.Enqueue(ctx, &Job{Name: "empty"}))
+}
+
+func TestQueueFullAndShutdownTimeout(t *testing.T) {
+	q := New(Options{Workers: 1, Size: 1, Retry: testRetry})
+	ctx := context.Background()
+	started := make(chan struct{})
+	blocking := &Job{Name: "blocking", Run: func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+	require.Nil(t, q.Enqueue(ctx, blocking))
+	<-started
+
+	// One job waits for the busy worker, the next one does not fit
+	var ran int32
+	waiting := &Job{Name: "waiting", Run: func(ctx context.Context) error {
+		atomic.AddInt32(&ran, 1)
+		return nil
+	}}
+	require.Nil(t, q.Enqueue(ctx, waiting))
+	require.Equal(t, 1, q.Len())
+	require.True(t, errors.Is(q.Enqueue(ctx, waiting), ErrQueueFull))
+
+	// Running jobs are canceled when the shutdown deadline passes
+	// and the waiting ones dropped
+	shutdownCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	err := q.Shutdown(shutdownCtx)
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+	require.EqualValues(t, 0, ran)
+}
+
+func TestQueuePersistence(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	q := New(Options{Workers: 1, Retry: testRetry, Store: store})
+
+	// The first job blocks the worker until the shutdown
+	started := make(chan struct{})
+	var runs []string
+	q.Register("cherry-pick", func(ctx context.Context, payload []byte) error {
+		runs = append(runs, string(payload))
+		if string(payload) == "#1" {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	})
+	require.Nil(t, q.Enqueue(ctx, &Job{Name: "first", Type: "cherry-pick", Payload: []byte("#1"), Key: "pr-1"}))
+	<-started
+
+	// Jobs with the key of a pending one are skipped
+	require.Nil(t, q.Enqueue(ctx, &Job{Name: "second", Type: "cherry-pick", Payload: []byte("#2"), Key: "pr-2"}))
+	require.Nil(t, q.Enqueue(ctx, &Job{Name: "again", Type: "cherry-pick", Payload: []byte("#2"), Key: "pr-2"}))
+	require.Equal(t, 1, q.Len())
+	require.NotNil(t, q.Enqueue(ctx, &Job{Name: "unknown", Type: "nothing"}))
+
+	// Jobs interrupted or dropped by the shutdown are kept
+	shutdownCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	require.NotNil(t, q.Shutdown(shutdownCtx))
+	pending, err := store.Pending(ctx)
+	require.Nil(t, err)
+	require.Len(t, pending, 2)
+
+	// And run by the next queue, which removes them when done
+	runs = nil
+	q = New(Options{Workers: 1, Retry: testRetry, Store: store})
+	q.Register("cherry-pick", func(ctx context.Context, payload []byte) error {
+		runs = append(runs, string(payload))
+		return nil
+	})
+	restored, err := q.Restore(ctx)
+	require.Nil(t, err)
+	require.Equal(t, 2, restored)
+	require.Nil(t, q.Shutdown(ctx))
+	require.Equal(t, []string{"#1", "#2"}, runs)
+	pending, err = store.Pending(ctx)
+	require.Nil(t, err)
+	require.Empty(t, pending)
+}
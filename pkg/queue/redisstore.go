@@ -0,0 +1,71 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package queue
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// RedisClient runs the Redis hash commands used by RedisStore. It is
+// satisfied by a thin adapter over any Redis client library.
+type RedisClient interface {
+	HSetNX(ctx context.Context, key, field string, value []byte) (bool, error)
+	HDel(ctx context.Context, key, field string) error
+	HGetAll(ctx context.Context, key string) (map[string][]byte, error)
+}
+
+// RedisStore keeps the jobs as JSON in a Redis hash, by key. HSETNX
+// makes adding a job with the key of a stored one a no-op.
+type RedisStore struct {
+	client RedisClient
+	hash   string
+}
+
+// NewRedisStore returns a store keeping the jobs in the hash.
+// It defaults to mattermod:jobs when empty.
+func NewRedisStore(client RedisClient, hash string) *RedisStore {
+	if hash == "" {
+		hash = "mattermod:jobs"
+	}
+	return &RedisStore{client: client, hash: hash}
+}
+
+// Add saves a job unless one with its key is stored
+func (rs *RedisStore) Add(ctx context.Context, job *StoredJob) (bool, error) {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return false, errors.Wrapf(err, "encoding job %s", job.Key)
+	}
+	added, err := rs.client.HSetNX(ctx, rs.hash, job.Key, data)
+	if err != nil {
+		return false, errors.Wrapf(err, "storing job %s", job.Key)
+	}
+	return added, nil
+}
+
+// Remove deletes a job
+func (rs *RedisStore) Remove(ctx context.Context, key string) error {
+	return errors.Wrapf(rs.client.HDel(ctx, rs.hash, key), "deleting job %s", key)
+}
+
+// Pending returns the stored jobs, oldest first
+func (rs *RedisStore) Pending(ctx context.Context) ([]*StoredJob, error) {
+	entries, err := rs.client.HGetAll(ctx, rs.hash)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading pending jobs")
+	}
+	jobs := []*StoredJob{}
+	for key, data := range entries {
+		job := &StoredJob{}
+		if err := json.Unmarshal(data, job); err != nil {
+			return nil, errors.Wrapf(err, "decoding job %s", key)
+		}
+		jobs = append(jobs, job)
+	}
+	sortJobs(jobs)
+	return jobs, nil
+}
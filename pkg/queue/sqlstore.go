@@ -0,0 +1,129 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// SQLSchema creates the table used by SQLStore with its default name.
+// The statement works in PostgreSQL, MySQL and SQLite.
+const SQLSchema = `CREATE TABLE IF NOT EXISTS jobs (
+	job_key VARCHAR(255) NOT NULL PRIMARY KEY,
+	job_type VARCHAR(255) NOT NULL,
+	name TEXT NOT NULL,
+	payload TEXT NOT NULL,
+	created_at BIGINT NOT NULL
+)`
+
+// SQLOptions configure a SQLStore
+type SQLOptions struct {
+	Table string // Name of the table, defaults to jobs
+
+	// NumberedParams writes the parameters of the statements as
+	// $1, $2... as PostgreSQL expects, instead of ?
+	NumberedParams bool
+}
+
+// SQLStore keeps the jobs in a table of a SQL database, see SQLSchema.
+// Any database/sql driver can be used. Payloads are stored base64
+// encoded so any text column can hold them.
+type SQLStore struct {
+	db      *sql.DB
+	options SQLOptions
+}
+
+// NewSQLStore returns a store keeping the jobs in db
+func NewSQLStore(db *sql.DB, opts SQLOptions) *SQLStore {
+	if opts.Table == "" {
+		opts.Table = "jobs"
+	}
+	return &SQLStore{db: db, options: opts}
+}
+
+// query replaces the {table} and ? placeholders of a statement
+func (ss *SQLStore) query(statement string) string {
+	statement = strings.ReplaceAll(statement, "{table}", ss.options.Table)
+	if !ss.options.NumberedParams {
+		return statement
+	}
+	for n := 1; strings.Contains(statement, "?"); n++ {
+		statement = strings.Replace(statement, "?", fmt.Sprintf("$%d", n), 1)
+	}
+	return statement
+}
+
+// Add saves a job unless one with its key is stored. The check and the
+// insert run in a transaction, concurrent adds of a key may still make
+// the insert fail with a primary key violation.
+func (ss *SQLStore) Add(ctx context.Context, job *StoredJob) (bool, error) {
+	tx, err := ss.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, errors.Wrap(err, "starting transaction")
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	var count int
+	if err := tx.QueryRowContext(
+		ctx, ss.query("SELECT COUNT(*) FROM {table} WHERE job_key = ?"), job.Key,
+	).Scan(&count); err != nil {
+		return false, errors.Wrapf(err, "looking up job %s", job.Key)
+	}
+	if count > 0 {
+		return false, nil
+	}
+
+	if _, err := tx.ExecContext(
+		ctx, ss.query("INSERT INTO {table} (job_key, job_type, name, payload, created_at) VALUES (?, ?, ?, ?, ?)"),
+		job.Key, job.Type, job.Name, base64.StdEncoding.EncodeToString(job.Payload), job.CreatedAt.UnixNano(),
+	); err != nil {
+		return false, errors.Wrapf(err, "inserting job %s", job.Key)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, errors.Wrapf(err, "committing job %s", job.Key)
+	}
+	return true, nil
+}
+
+// Remove deletes a job
+func (ss *SQLStore) Remove(ctx context.Context, key string) error {
+	if _, err := ss.db.ExecContext(ctx, ss.query("DELETE FROM {table} WHERE job_key = ?"), key); err != nil {
+		return errors.Wrapf(err, "deleting job %s", key)
+	}
+	return nil
+}
+
+// Pending returns the stored jobs, oldest first
+func (ss *SQLStore) Pending(ctx context.Context) ([]*StoredJob, error) {
+	rows, err := ss.db.QueryContext(
+		ctx, ss.query("SELECT job_key, job_type, name, payload, created_at FROM {table} ORDER BY created_at, job_key"),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying pending jobs")
+	}
+	defer rows.Close()
+
+	jobs := []*StoredJob{}
+	for rows.Next() {
+		job := &StoredJob{}
+		var payload string
+		var created int64
+		if err := rows.Scan(&job.Key, &job.Type, &job.Name, &payload, &created); err != nil {
+			return nil, errors.Wrap(err, "reading pending job")
+		}
+		if job.Payload, err = base64.StdEncoding.DecodeString(payload); err != nil {
+			return nil, errors.Wrapf(err, "decoding payload of job %s", job.Key)
+		}
+		job.CreatedAt = time.Unix(0, created)
+		jobs = append(jobs, job)
+	}
+	return jobs, errors.Wrap(rows.Err(), "reading pending jobs")
+}
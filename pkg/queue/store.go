@@ -0,0 +1,86 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package queue
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// StoredJob is a job saved in a Store until it finishes
+type StoredJob struct {
+	Key       string
+	Type      string
+	Name      string
+	Payload   []byte
+	CreatedAt time.Time
+}
+
+// Store persists the jobs of a queue so they survive restarts
+type Store interface {
+	// Add saves a job. If a job with the same key is stored,
+	// it is kept and Add returns false.
+	Add(ctx context.Context, job *StoredJob) (bool, error)
+
+	// Remove deletes a job, removing a missing job is not an error
+	Remove(ctx context.Context, key string) error
+
+	// Pending returns the stored jobs, oldest first
+	Pending(ctx context.Context) ([]*StoredJob, error)
+}
+
+// MemoryStore keeps jobs in memory. They do not survive restarts, it is
+// meant for tests. It is safe for concurrent use.
+type MemoryStore struct {
+	mtx  sync.Mutex
+	jobs map[string]*StoredJob
+}
+
+// NewMemoryStore returns an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{jobs: map[string]*StoredJob{}}
+}
+
+// Add saves a job unless one with its key is stored
+func (ms *MemoryStore) Add(ctx context.Context, job *StoredJob) (bool, error) {
+	ms.mtx.Lock()
+	defer ms.mtx.Unlock()
+	if _, ok := ms.jobs[job.Key]; ok {
+		return false, nil
+	}
+	ms.jobs[job.Key] = job
+	return true, nil
+}
+
+// Remove deletes a job
+func (ms *MemoryStore) Remove(ctx context.Context, key string) error {
+	ms.mtx.Lock()
+	defer ms.mtx.Unlock()
+	delete(ms.jobs, key)
+	return nil
+}
+
+// Pending returns the stored jobs, oldest first
+func (ms *MemoryStore) Pending(ctx context.Context) ([]*StoredJob, error) {
+	ms.mtx.Lock()
+	defer ms.mtx.Unlock()
+	jobs := []*StoredJob{}
+	for _, job := range ms.jobs {
+		jobs = append(jobs, job)
+	}
+	sortJobs(jobs)
+	return jobs, nil
+}
+
+// sortJobs sorts jobs oldest first, ties are sorted by key
+func sortJobs(jobs []*StoredJob) {
+	sort.Slice(jobs, func(i, j int) bool {
+		if jobs[i].CreatedAt.Equal(jobs[j].CreatedAt) {
+			return jobs[i].Key < jobs[j].Key
+		}
+		return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+	})
+}
@@ -0,0 +1,194 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// testStore checks the behavior shared by all stores
+func testStore(t *testing.T, store Store) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Millisecond)
+	newer := &StoredJob{Key: "b", Type: "cherry-pick", Name: "newer", Payload: []byte{0, 1, 2}, CreatedAt: now}
+	older := &StoredJob{Key: "a", Type: "ci", Name: "older", Payload: []byte(`{"pr": 1}`), CreatedAt: now.Add(-time.Minute)}
+
+	for _, job := range []*StoredJob{newer, older} {
+		added, err := store.Add(ctx, job)
+		require.Nil(t, err)
+		require.True(t, added)
+	}
+
+	// Jobs with the key of a stored one are not added
+	added, err := store.Add(ctx, &StoredJob{Key: "a", Type: "other", CreatedAt: now})
+	require.Nil(t, err)
+	require.False(t, added)
+
+	pending, err := store.Pending(ctx)
+	require.Nil(t, err)
+	require.Len(t, pending, 2)
+	require.Equal(t, "a", pending[0].Key)
+	require.Equal(t, "ci", pending[0].Type)
+	require.Equal(t, "older", pending[0].Name)
+	require.Equal(t, []byte(`{"pr": 1}`), pending[0].Payload)
+	require.True(t, older.CreatedAt.Equal(pending[0].CreatedAt))
+	require.Equal(t, []byte{0, 1, 2}, pending[1].Payload)
+
+	require.Nil(t, store.Remove(ctx, "a"))
+	require.Nil(t, store.Remove(ctx, "a"))
+	pending, err = store.Pending(ctx)
+	require.Nil(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, "b", pending[0].Key)
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+// fakeRedis implements the hash commands of RedisClient in memory
+type fakeRedis struct {
+	mtx    sync.Mutex
+	hashes map[string]map[string][]byte
+}
+
+func (f *fakeRedis) HSetNX(ctx context.Context, key, field string, value []byte) (bool, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if f.hashes[key] == nil {
+		f.hashes[key] = map[string][]byte{}
+	}
+	if _, ok := f.hashes[key][field]; ok {
+		return false, nil
+	}
+	f.hashes[key][field] = value
+	return true, nil
+}
+
+func (f *fakeRedis) HDel(ctx context.Context, key, field string) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	delete(f.hashes[key], field)
+	return nil
+}
+
+func (f *fakeRedis) HGetAll(ctx context.Context, key string) (map[string][]byte, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	result := map[string][]byte{}
+	for field, value := range f.hashes[key] {
+		result[field] = value
+	}
+	return result, nil
+}
+
+func TestRedisStore(t *testing.T) {
+	redis := &fakeRedis{hashes: map[string]map[string][]byte{}}
+	testStore(t, NewRedisStore(redis, ""))
+	require.Len(t, redis.hashes["mattermod:jobs"], 1)
+}
+
+func TestSQLStore(t *testing.T) {
+	db := sql.OpenDB(&fakeConnector{table: map[string][]driver.Value{}})
+	defer db.Close()
+	store := NewSQLStore(db, SQLOptions{})
+	testStore(t, store)
+
+	require.Equal(t, "DELETE FROM queue WHERE job_key = $1 AND job_type = $2",
+		NewSQLStore(db, SQLOptions{Table: "queue", NumberedParams: true}).query(
+			"DELETE FROM {table} WHERE job_key = ? AND job_type = ?",
+		),
+	)
+}
+
+// fakeConnector is a database/sql driver that only understands the
+// statements of SQLStore, keeping the rows in memory by key
+type fakeConnector struct {
+	mtx   sync.Mutex
+	table map[string][]driver.Value
+}
+
+func (c *fakeConnector) Connect(context.Context) (driver.Conn, error) { return &fakeConn{c}, nil }
+func (c *fakeConnector) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ c *fakeConnector }
+
+func (fc *fakeConn) Prepare(query string) (driver.Stmt, error) { return &fakeStmt{fc.c, query}, nil }
+func (fc *fakeConn) Close() error                              { return nil }
+func (fc *fakeConn) Begin() (driver.Tx, error)                 { return fc, nil }
+func (fc *fakeConn) Commit() error                             { return nil }
+func (fc *fakeConn) Rollback() error                           { return nil }
+
+type fakeStmt struct {
+	c     *fakeConnector
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.c.mtx.Lock()
+	defer s.c.mtx.Unlock()
+	switch {
+	case strings.HasPrefix(s.query, "INSERT INTO jobs "):
+		s.c.table[args[0].(string)] = args
+	case strings.HasPrefix(s.query, "DELETE FROM jobs "):
+		delete(s.c.table, args[0].(string))
+	default:
+		return nil, errors.Errorf("unexpected statement %q", s.query)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.c.mtx.Lock()
+	defer s.c.mtx.Unlock()
+	switch {
+	case strings.HasPrefix(s.query, "SELECT COUNT(*) FROM jobs "):
+		_, ok := s.c.table[args[0].(string)]
+		count := int64(0)
+		if ok {
+			count = 1
+		}
+		return &fakeRows{columns: 1, rows: [][]driver.Value{{count}}}, nil
+	case strings.HasPrefix(s.query, "SELECT job_key, "):
+		rows := [][]driver.Value{}
+		for _, row := range s.c.table {
+			rows = append(rows, row)
+		}
+		sort.Slice(rows, func(i, j int) bool { return rows[i][4].(int64) < rows[j][4].(int64) })
+		return &fakeRows{columns: 5, rows: rows}, nil
+	default:
+		return nil, errors.Errorf("unexpected query %q", s.query)
+	}
+}
+
+type fakeRows struct {
+	columns int
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return make([]string, r.columns) }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
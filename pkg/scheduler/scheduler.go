@@ -0,0 +1,191 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package scheduler
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/puerco/mattermod-refactor/pkg/github"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrTaskRunning is returned when running a task that has not finished
+// its previous run
+var ErrTaskRunning = errors.New("task is already running")
+
+// Task is a job run periodically, eg a sweep of stale pull requests
+type Task struct {
+	Name       string                          // Identifies the task in the logs
+	Interval   time.Duration                   // Time between the end of a run and the start of the next one
+	Timeout    time.Duration                   // Limits each run, defaults to the interval
+	RunOnStart bool                            // Run the task when the scheduler starts instead of after the first interval
+	Run        func(ctx context.Context) error // Does the work
+}
+
+// Options configure a scheduler
+type Options struct {
+	// Jitter is the fraction of the interval randomly added to or taken
+	// from each wait, so tasks started together do not hit the API at
+	// the same time. Zero disables it.
+	Jitter float64
+
+	// Logger receives the log messages of the scheduler. When nil,
+	// the standard logrus logger is used.
+	Logger github.Logger
+}
+
+// Scheduler runs tasks on intervals. The runs of a task never overlap:
+// the next one is scheduled when the previous one ends.
+type Scheduler struct {
+	options Options
+	mtx     sync.Mutex
+	tasks   map[string]*task
+	cancel  context.CancelFunc
+	running sync.WaitGroup
+}
+
+// task is a registered task with its state
+type task struct {
+	Task
+	busy int32 // Set while the task runs
+}
+
+// New returns a scheduler without tasks
+func New(opts Options) *Scheduler {
+	if opts.Logger == nil {
+		opts.Logger = github.NewLogrusLogger(logrus.StandardLogger())
+	}
+	return &Scheduler{options: opts, tasks: map[string]*task{}}
+}
+
+// Add registers a task. Tasks have to be added before starting the scheduler.
+func (s *Scheduler) Add(t Task) error {
+	if t.Name == "" || t.Run == nil {
+		return errors.New("tasks need a name and a function to run")
+	}
+	if t.Interval <= 0 {
+		return errors.Errorf("task %s needs a positive interval", t.Name)
+	}
+	if t.Timeout <= 0 {
+		t.Timeout = t.Interval
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.cancel != nil {
+		return errors.Errorf("unable to add task %s, the scheduler is running", t.Name)
+	}
+	if _, ok := s.tasks[t.Name]; ok {
+		return errors.Errorf("task %s is already registered", t.Name)
+	}
+	s.tasks[t.Name] = &task{Task: t}
+	return nil
+}
+
+// Start runs the tasks in the background until Stop is called
+// or ctx is canceled
+func (s *Scheduler) Start(ctx context.Context) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.cancel != nil {
+		return errors.New("the scheduler is already running")
+	}
+	ctx, s.cancel = context.WithCancel(ctx)
+	for _, t := range s.tasks {
+		s.running.Add(1)
+		go s.loop(ctx, t)
+	}
+	return nil
+}
+
+// Stop cancels the running tasks and waits for them to return
+func (s *Scheduler) Stop() {
+	s.mtx.Lock()
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.mtx.Unlock()
+	s.running.Wait()
+}
+
+// RunNow runs a task right away, outside of its schedule. It returns
+// ErrTaskRunning if the task is running.
+func (s *Scheduler) RunNow(ctx context.Context, name string) error {
+	s.mtx.Lock()
+	t, ok := s.tasks[name]
+	s.mtx.Unlock()
+	if !ok {
+		return errors.Errorf("task %s is not registered", name)
+	}
+	return s.run(ctx, t)
+}
+
+// loop runs a task on its interval until ctx is canceled
+func (s *Scheduler) loop(ctx context.Context, t *task) {
+	defer s.running.Done()
+	if !t.RunOnStart && !s.wait(ctx, t) {
+		return
+	}
+	for {
+		err := s.run(ctx, t)
+		switch {
+		case errors.Is(err, ErrTaskRunning):
+			s.options.Logger.Infof("Skipping scheduled run of %s, it is still running", t.Name)
+		case err != nil:
+			s.options.Logger.Errorf("Scheduled task %s failed: %v", t.Name, err)
+		}
+		if !s.wait(ctx, t) {
+			return
+		}
+	}
+}
+
+// wait blocks for the interval of the task with jitter applied.
+// It returns false if ctx is canceled first.
+func (s *Scheduler) wait(ctx context.Context, t *task) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(jitter(t.Interval, s.options.Jitter)):
+		return true
+	}
+}
+
+// run calls a task bounded by its timeout, unless it is running.
+// Panics are returned as errors.
+func (s *Scheduler) run(ctx context.Context, t *task) (err error) {
+	if !atomic.CompareAndSwapInt32(&t.busy, 0, 1) {
+		return errors.Wrap(ErrTaskRunning, t.Name)
+	}
+	defer atomic.StoreInt32(&t.busy, 0)
+
+	ctx, cancel := context.WithTimeout(ctx, t.Timeout)
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Errorf("task %s panicked: %v", t.Name, r)
+		}
+	}()
+
+	start := time.Now()
+	if err := t.Run(ctx); err != nil {
+		return errors.Wrapf(err, "running %s", t.Name)
+	}
+	s.options.Logger.Debugf("Task %s finished in %s", t.Name, time.Since(start))
+	return nil
+}
+
+// jitter spreads d randomly by up to the fraction in both directions
+func jitter(d time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 {
+		return d
+	}
+	spread := float64(d) * fraction
+	return d + time.Duration(spread*(2*rand.Float64()-1)) // nolint: gosec
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package scheduler
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduler(t *testing.T) {
+	s := New(Options{Jitter: 0.1})
+	ctx := context.Background()
+
+	var sweeps, slow, panics int32
+	require.Nil(t, s.Add(Task{Name: "sweep", Interval: 5 * time.Millisecond, RunOnStart: true, Run: func(ctx context.Context) error {
+		atomic.AddInt32(&sweeps, 1)
+		return errors.New("errors are logged")
+	}}))
+	release := make(chan struct{})
+	require.Nil(t, s.Add(Task{Name: "slow", Interval: time.Millisecond, RunOnStart: true, Run: func(ctx context.Context) error {
+		atomic.AddInt32(&slow, 1)
+		<-release
+		return nil
+	}}))
+	require.Nil(t, s.Add(Task{Name: "panic", Interval: time.Millisecond, Run: func(ctx context.Context) error {
+		atomic.AddInt32(&panics, 1)
+		panic("boom")
+	}}))
+
+	// Invalid and duplicate tasks are rejected
+	require.NotNil(t, s.Add(Task{Name: "sweep", Interval: time.Second, Run: func(ctx context.Context) error { return nil }}))
+	require.NotNil(t, s.Add(Task{Name: "no-interval", Run: func(ctx context.Context) error { return nil }}))
+	require.NotNil(t, s.Add(Task{Name: "nothing", Interval: time.Second}))
+
+	require.Nil(t, s.Start(ctx))
+	require.NotNil(t, s.Start(ctx))
+	require.NotNil(t, s.Add(Task{Name: "late", Interval: time.Second, Run: func(ctx context.Context) error { return nil }}))
+
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&sweeps) >= 3 && atomic.LoadInt32(&panics) >= 3
+	}, time.Second, time.Millisecond)
+
+	// Runs never overlap, not even when started by hand
+	require.True(t, errors.Is(s.RunNow(ctx, "slow"), ErrTaskRunning))
+	require.EqualValues(t, 1, atomic.LoadInt32(&slow))
+	require.NotNil(t, s.RunNow(ctx, "unknown"))
+
+	close(release)
+	s.Stop()
+	require.Nil(t, s.RunNow(ctx, "slow"))
+}
+
+func TestTaskTimeout(t *testing.T) {
+	s := New(Options{})
+	require.Nil(t, s.Add(Task{Name: "stuck", Interval: time.Hour, Timeout: 10 * time.Millisecond, Run: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}))
+	err := s.RunNow(context.Background(), "stuck")
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestJitter(t *testing.T) {
+	require.Equal(t, time.Second, jitter(time.Second, 0))
+	for i := 0; i < 100; i++ {
+		d := jitter(time.Second, 0.2)
+		require.GreaterOrEqual(t, int64(d), int64(800*time.Millisecond))
+		require.LessOrEqual(t, int64(d), int64(1200*time.Millisecond))
+	}
+}
@@ -0,0 +1,313 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrDeliveryNotFound is returned when a delivery is not in the store
+var ErrDeliveryNotFound = errors.New("delivery not found")
+
+// deliveryIDRegex matches the delivery IDs GitHub sends, which are GUIDs
+var deliveryIDRegex = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+
+// Delivery is a webhook delivery as received from GitHub
+type Delivery struct {
+	ID         string    // From the X-GitHub-Delivery header
+	Event      string    // From the X-GitHub-Event header
+	Payload    []byte    // Raw body of the request
+	ReceivedAt time.Time // Time when the delivery was received
+	Failed     bool      // Set when a handler failed, the delivery is in the dead-letter bucket
+	Error      string    // Error of the last handler that failed
+}
+
+// DeliveryStore keeps webhook deliveries so they can be replayed. Failed
+// deliveries form the dead-letter bucket, kept until they are replayed.
+type DeliveryStore interface {
+	// Save stores a delivery, replacing one with the same ID
+	Save(ctx context.Context, delivery *Delivery) error
+
+	// Get returns a delivery or ErrDeliveryNotFound
+	Get(ctx context.Context, id string) (*Delivery, error)
+
+	// MarkFailed moves a delivery to the dead-letter bucket
+	MarkFailed(ctx context.Context, id, reason string) error
+
+	// ClearFailed takes a delivery out of the dead-letter bucket
+	ClearFailed(ctx context.Context, id string) error
+
+	// Failed returns the deliveries in the dead-letter bucket, oldest first
+	Failed(ctx context.Context) ([]*Delivery, error)
+
+	// Prune removes the deliveries received before t that did not
+	// fail and returns how many were removed
+	Prune(ctx context.Context, t time.Time) (int, error)
+}
+
+// sortDeliveries sorts deliveries oldest first
+func sortDeliveries(deliveries []*Delivery) {
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].ReceivedAt.Before(deliveries[j].ReceivedAt)
+	})
+}
+
+// MemoryDeliveryStore keeps deliveries in memory. They are lost on
+// restart. It is safe for concurrent use.
+type MemoryDeliveryStore struct {
+	mtx        sync.Mutex
+	deliveries map[string]*Delivery
+}
+
+// NewMemoryDeliveryStore returns an empty MemoryDeliveryStore
+func NewMemoryDeliveryStore() *MemoryDeliveryStore {
+	return &MemoryDeliveryStore{deliveries: map[string]*Delivery{}}
+}
+
+// Save stores a copy of a delivery
+func (ms *MemoryDeliveryStore) Save(ctx context.Context, delivery *Delivery) error {
+	ms.mtx.Lock()
+	defer ms.mtx.Unlock()
+	d := *delivery
+	ms.deliveries[d.ID] = &d
+	return nil
+}
+
+// Get returns a copy of a delivery
+func (ms *MemoryDeliveryStore) Get(ctx context.Context, id string) (*Delivery, error) {
+	ms.mtx.Lock()
+	defer ms.mtx.Unlock()
+	d, ok := ms.deliveries[id]
+	if !ok {
+		return nil, errors.Wrap(ErrDeliveryNotFound, id)
+	}
+	c := *d
+	return &c, nil
+}
+
+// MarkFailed moves a delivery to the dead-letter bucket
+func (ms *MemoryDeliveryStore) MarkFailed(ctx context.Context, id, reason string) error {
+	ms.mtx.Lock()
+	defer ms.mtx.Unlock()
+	d, ok := ms.deliveries[id]
+	if !ok {
+		return errors.Wrap(ErrDeliveryNotFound, id)
+	}
+	d.Failed, d.Error = true, reason
+	return nil
+}
+
+// ClearFailed takes a delivery out of the dead-letter bucket
+func (ms *MemoryDeliveryStore) ClearFailed(ctx context.Context, id string) error {
+	ms.mtx.Lock()
+	defer ms.mtx.Unlock()
+	d, ok := ms.deliveries[id]
+	if !ok {
+		return errors.Wrap(ErrDeliveryNotFound, id)
+	}
+	d.Failed, d.Error = false, ""
+	return nil
+}
+
+// Failed returns the deliveries in the dead-letter bucket
+func (ms *MemoryDeliveryStore) Failed(ctx context.Context) ([]*Delivery, error) {
+	ms.mtx.Lock()
+	defer ms.mtx.Unlock()
+	failed := []*Delivery{}
+	for _, d := range ms.deliveries {
+		if d.Failed {
+			c := *d
+			failed = append(failed, &c)
+		}
+	}
+	sortDeliveries(failed)
+	return failed, nil
+}
+
+// Prune removes the deliveries received before t that did not fail
+func (ms *MemoryDeliveryStore) Prune(ctx context.Context, t time.Time) (int, error) {
+	ms.mtx.Lock()
+	defer ms.mtx.Unlock()
+	pruned := 0
+	for id, d := range ms.deliveries {
+		if !d.Failed && d.ReceivedAt.Before(t) {
+			delete(ms.deliveries, id)
+			pruned++
+		}
+	}
+	return pruned, nil
+}
+
+// FileDeliveryStore keeps each delivery as a JSON file in a directory,
+// so they survive restarts. It is safe for concurrent use in a process.
+type FileDeliveryStore struct {
+	mtx sync.Mutex
+	dir string
+}
+
+// NewFileDeliveryStore returns a store keeping the deliveries in dir,
+// which is created if it does not exist
+func NewFileDeliveryStore(dir string) (*FileDeliveryStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, errors.Wrapf(err, "creating deliveries directory %s", dir)
+	}
+	return &FileDeliveryStore{dir: dir}, nil
+}
+
+// path returns the file of a delivery. IDs are checked as they
+// come from the requests.
+func (fs *FileDeliveryStore) path(id string) (string, error) {
+	if !deliveryIDRegex.MatchString(id) {
+		return "", errors.Errorf("invalid delivery ID %q", id)
+	}
+	return filepath.Join(fs.dir, id+".json"), nil
+}
+
+// read loads a delivery from its file
+func (fs *FileDeliveryStore) read(id string) (*Delivery, error) {
+	path, err := fs.path(id)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.Wrap(ErrDeliveryNotFound, id)
+		}
+		return nil, errors.Wrapf(err, "reading delivery %s", id)
+	}
+	d := &Delivery{}
+	if err := json.Unmarshal(data, d); err != nil {
+		return nil, errors.Wrapf(err, "decoding delivery %s", id)
+	}
+	return d, nil
+}
+
+// write saves a delivery to its file, replacing it atomically
+func (fs *FileDeliveryStore) write(d *Delivery) error {
+	path, err := fs.path(d.ID)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(d)
+	if err != nil {
+		return errors.Wrapf(err, "encoding delivery %s", d.ID)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return errors.Wrapf(err, "writing delivery %s", d.ID)
+	}
+	return errors.Wrapf(os.Rename(tmp, path), "writing delivery %s", d.ID)
+}
+
+// all reads all the deliveries in the directory
+func (fs *FileDeliveryStore) all() ([]*Delivery, error) {
+	entries, err := os.ReadDir(fs.dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing deliveries in %s", fs.dir)
+	}
+	deliveries := []*Delivery{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		d, err := fs.read(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	sortDeliveries(deliveries)
+	return deliveries, nil
+}
+
+// Save stores a delivery
+func (fs *FileDeliveryStore) Save(ctx context.Context, delivery *Delivery) error {
+	fs.mtx.Lock()
+	defer fs.mtx.Unlock()
+	return fs.write(delivery)
+}
+
+// Get returns a delivery
+func (fs *FileDeliveryStore) Get(ctx context.Context, id string) (*Delivery, error) {
+	fs.mtx.Lock()
+	defer fs.mtx.Unlock()
+	return fs.read(id)
+}
+
+// MarkFailed moves a delivery to the dead-letter bucket
+func (fs *FileDeliveryStore) MarkFailed(ctx context.Context, id, reason string) error {
+	fs.mtx.Lock()
+	defer fs.mtx.Unlock()
+	d, err := fs.read(id)
+	if err != nil {
+		return err
+	}
+	d.Failed, d.Error = true, reason
+	return fs.write(d)
+}
+
+// ClearFailed takes a delivery out of the dead-letter bucket
+func (fs *FileDeliveryStore) ClearFailed(ctx context.Context, id string) error {
+	fs.mtx.Lock()
+	defer fs.mtx.Unlock()
+	d, err := fs.read(id)
+	if err != nil {
+		return err
+	}
+	d.Failed, d.Error = false, ""
+	return fs.write(d)
+}
+
+// Failed returns the deliveries in the dead-letter bucket
+func (fs *FileDeliveryStore) Failed(ctx context.Context) ([]*Delivery, error) {
+	fs.mtx.Lock()
+	defer fs.mtx.Unlock()
+	deliveries, err := fs.all()
+	if err != nil {
+		return nil, err
+	}
+	failed := []*Delivery{}
+	for _, d := range deliveries {
+		if d.Failed {
+			failed = append(failed, d)
+		}
+	}
+	return failed, nil
+}
+
+// Prune removes the deliveries received before t that did not fail
+func (fs *FileDeliveryStore) Prune(ctx context.Context, t time.Time) (int, error) {
+	fs.mtx.Lock()
+	defer fs.mtx.Unlock()
+	deliveries, err := fs.all()
+	if err != nil {
+		return 0, err
+	}
+	pruned := 0
+	for _, d := range deliveries {
+		if d.Failed || !d.ReceivedAt.Before(t) {
+			continue
+		}
+		path, err := fs.path(d.ID)
+		if err != nil {
+			return pruned, err
+		}
+		if err := os.Remove(path); err != nil {
+			return pruned, errors.Wrapf(err, "removing delivery %s", d.ID)
+		}
+		pruned++
+	}
+	return pruned, nil
+}
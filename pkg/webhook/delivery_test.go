@@ -0,0 +1,69 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package webhook
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// testDeliveryStore checks the behavior shared by all delivery stores
+func testDeliveryStore(t *testing.T, store DeliveryStore) {
+	ctx := context.Background()
+	now := time.Now()
+	for i, id := range []string{"old-ok", "old-failed", "new"} {
+		require.Nil(t, store.Save(ctx, &Delivery{
+			ID: id, Event: "push", Payload: []byte(`{"ref": "refs/heads/master"}`),
+			ReceivedAt: now.Add(time.Duration(i-2) * time.Hour),
+		}))
+	}
+
+	d, err := store.Get(ctx, "new")
+	require.Nil(t, err)
+	require.Equal(t, "push", d.Event)
+	require.Equal(t, []byte(`{"ref": "refs/heads/master"}`), d.Payload)
+	_, err = store.Get(ctx, "missing")
+	require.True(t, errors.Is(err, ErrDeliveryNotFound))
+
+	// Failed deliveries are kept in the dead-letter bucket
+	require.Nil(t, store.MarkFailed(ctx, "old-failed", "boom"))
+	require.Nil(t, store.MarkFailed(ctx, "new", "bang"))
+	require.True(t, errors.Is(store.MarkFailed(ctx, "missing", "boom"), ErrDeliveryNotFound))
+	failed, err := store.Failed(ctx)
+	require.Nil(t, err)
+	require.Len(t, failed, 2)
+	require.Equal(t, "old-failed", failed[0].ID)
+	require.Equal(t, "boom", failed[0].Error)
+
+	require.Nil(t, store.ClearFailed(ctx, "new"))
+	failed, err = store.Failed(ctx)
+	require.Nil(t, err)
+	require.Len(t, failed, 1)
+
+	// Pruning keeps the dead letters
+	pruned, err := store.Prune(ctx, now.Add(-30*time.Minute))
+	require.Nil(t, err)
+	require.Equal(t, 1, pruned)
+	_, err = store.Get(ctx, "old-ok")
+	require.True(t, errors.Is(err, ErrDeliveryNotFound))
+	_, err = store.Get(ctx, "old-failed")
+	require.Nil(t, err)
+}
+
+func TestMemoryDeliveryStore(t *testing.T) {
+	testDeliveryStore(t, NewMemoryDeliveryStore())
+}
+
+func TestFileDeliveryStore(t *testing.T) {
+	store, err := NewFileDeliveryStore(t.TempDir())
+	require.Nil(t, err)
+	testDeliveryStore(t, store)
+
+	// IDs cannot escape the directory
+	require.NotNil(t, store.Save(context.Background(), &Delivery{ID: "../../etc/passwd"}))
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package webhook
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/puerco/mattermod-refactor/pkg/github"
+)
+
+// Logging logs when handlers start and finish, with the time they took
+func Logging(logger github.Logger) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, event *github.WebhookEvent) error {
+			log := logger.WithFields(map[string]interface{}{
+				"delivery": event.DeliveryID,
+				"event":    event.Type,
+				"action":   event.Action,
+			})
+			log.Debugf("Handling %s event (%s) from %s", event.Type, event.Action, event.Sender)
+			start := time.Now()
+			err := next(ctx, event)
+			if err != nil {
+				log.Warnf("Handler failed after %s: %v", time.Since(start), err)
+				return err
+			}
+			log.Infof("Handled %s event (%s) in %s", event.Type, event.Action, time.Since(start))
+			return nil
+		}
+	}
+}
+
+// OrgMembers only runs handlers for events triggered by members of the
+// organization. Memberships are cached as set in the options of gh.
+func OrgMembers(gh *github.GitHub, org string) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, event *github.WebhookEvent) error {
+			if event.Sender == "" {
+				return nil
+			}
+			member, err := gh.IsOrgMember(ctx, org, event.Sender)
+			if err != nil {
+				return errors.Wrapf(err, "checking if %s is a member of %s", event.Sender, org)
+			}
+			if !member {
+				return nil
+			}
+			return next(ctx, event)
+		}
+	}
+}
+
+// Dedup skips deliveries already handled in the last ttl, as happens
+// when they are redelivered from GitHub. Events without a delivery ID
+// and replays are always handled. Deliveries whose handler fails are
+// forgotten, so they run again when redelivered.
+func Dedup(ttl time.Duration) Middleware {
+	return func(next Handler) Handler {
+		var mtx sync.Mutex
+		seen := map[string]time.Time{} // Expiration of the deliveries by ID
+		return func(ctx context.Context, event *github.WebhookEvent) error {
+			if event.DeliveryID == "" || IsReplay(ctx) {
+				return next(ctx, event)
+			}
+			mtx.Lock()
+			now := time.Now()
+			for id, expires := range seen {
+				if now.After(expires) {
+					delete(seen, id)
+				}
+			}
+			_, dup := seen[event.DeliveryID]
+			if !dup {
+				seen[event.DeliveryID] = now.Add(ttl)
+			}
+			mtx.Unlock()
+			if dup {
+				return nil
+			}
+			if err := next(ctx, event); err != nil {
+				mtx.Lock()
+				delete(seen, event.DeliveryID)
+				mtx.Unlock()
+				return err
+			}
+			return nil
+		}
+	}
+}
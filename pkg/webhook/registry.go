@@ -0,0 +1,73 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package webhook
+
+import (
+	"strings"
+	"sync"
+)
+
+// Middleware wraps a handler to run code before or after it, or
+// to skip it, eg to only handle events from organization members
+type Middleware func(next Handler) Handler
+
+// Registry keeps the handlers of webhook events. Handlers are registered
+// by event type, eg "pull_request", or by event and action, eg
+// "pull_request.opened". It is safe for concurrent use.
+type Registry struct {
+	mtx        sync.RWMutex
+	handlers   []registration
+	middleware []Middleware
+}
+
+// registration is a handler of an event, for all its
+// actions when action is empty
+type registration struct {
+	event   string
+	action  string
+	handler Handler
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Use attaches middleware to the handlers registered from then on. The
+// first middleware attached runs first. Each handler gets its own copy
+// of the middleware, so the state they keep is not shared.
+func (r *Registry) Use(middleware ...Middleware) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.middleware = append(r.middleware, middleware...)
+}
+
+// On registers a handler for an event type or for an action of it,
+// written as "event.action", eg "issue_comment.created"
+func (r *Registry) On(key string, handler Handler) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	for i := len(r.middleware) - 1; i >= 0; i-- {
+		handler = r.middleware[i](handler)
+	}
+	event, action := key, ""
+	if i := strings.Index(key, "."); i >= 0 {
+		event, action = key[:i], key[i+1:]
+	}
+	r.handlers = append(r.handlers, registration{event: event, action: action, handler: handler})
+}
+
+// Handlers returns the handlers of an event action,
+// in the order they were registered
+func (r *Registry) Handlers(event, action string) []Handler {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	handlers := []Handler{}
+	for _, reg := range r.handlers {
+		if reg.event == event && (reg.action == "" || reg.action == action) {
+			handlers = append(handlers, reg.handler)
+		}
+	}
+	return handlers
+}
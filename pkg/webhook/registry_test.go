@@ -0,0 +1,100 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package webhook
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/puerco/mattermod-refactor/pkg/github"
+	"github.com/puerco/mattermod-refactor/pkg/github/githubtest"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+// run calls the handlers of an event as the server would
+func run(r *Registry, event *github.WebhookEvent) {
+	for _, handler := range r.Handlers(event.Type, event.Action) {
+		handler(context.Background(), event) // nolint: errcheck
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	calls := []string{}
+	record := func(name string) Handler {
+		return func(ctx context.Context, event *github.WebhookEvent) error {
+			calls = append(calls, name)
+			return nil
+		}
+	}
+	trace := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, event *github.WebhookEvent) error {
+				calls = append(calls, name)
+				return next(ctx, event)
+			}
+		}
+	}
+
+	// Middleware only wraps the handlers registered after it, in order
+	r.On("pull_request", record("all"))
+	r.Use(trace("first"), trace("second"))
+	r.On("pull_request.opened", record("opened"))
+	r.On("issue_comment.created", record("comment"))
+
+	run(r, &github.WebhookEvent{Type: "pull_request", Action: "opened"})
+	require.Equal(t, []string{"all", "first", "second", "opened"}, calls)
+
+	calls = []string{}
+	run(r, &github.WebhookEvent{Type: "pull_request", Action: "closed"})
+	require.Equal(t, []string{"all"}, calls)
+
+	calls = []string{}
+	run(r, &github.WebhookEvent{Type: "issue_comment", Action: "edited"})
+	require.Empty(t, calls)
+}
+
+func TestMiddleware(t *testing.T) {
+	fake := githubtest.New()
+	fake.Organizations.Members["mattermost"] = []string{"alice"}
+	gh := fake.GitHub(nil)
+
+	r := NewRegistry()
+	calls := 0
+	var failure error
+	r.Use(Logging(github.NewLogrusLogger(logrus.StandardLogger())), OrgMembers(gh, "mattermost"), Dedup(time.Hour))
+	r.On("issue_comment.created", func(ctx context.Context, event *github.WebhookEvent) error {
+		calls++
+		return failure
+	})
+
+	// Events from outsiders are skipped
+	run(r, &github.WebhookEvent{Type: "issue_comment", Action: "created", Sender: "mallory", DeliveryID: "1"})
+	run(r, &github.WebhookEvent{Type: "issue_comment", Action: "created", DeliveryID: "2"})
+	require.Equal(t, 0, calls)
+
+	// Redeliveries are only handled once
+	for i := 0; i < 2; i++ {
+		run(r, &github.WebhookEvent{Type: "issue_comment", Action: "created", Sender: "alice", DeliveryID: "3"})
+	}
+	require.Equal(t, 1, calls)
+
+	// Events without an ID are always handled
+	for i := 0; i < 2; i++ {
+		run(r, &github.WebhookEvent{Type: "issue_comment", Action: "created", Sender: "alice"})
+	}
+	require.Equal(t, 3, calls)
+
+	// Failed deliveries are handled again when redelivered
+	failure = errors.New("handler failed")
+	run(r, &github.WebhookEvent{Type: "issue_comment", Action: "created", Sender: "alice", DeliveryID: "4"})
+	failure = nil
+	for i := 0; i < 2; i++ {
+		run(r, &github.WebhookEvent{Type: "issue_comment", Action: "created", Sender: "alice", DeliveryID: "4"})
+	}
+	require.Equal(t, 5, calls)
+}
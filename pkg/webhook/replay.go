@@ -0,0 +1,125 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package webhook
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// replayKey marks the contexts of replayed deliveries
+type replayKey struct{}
+
+// IsReplay returns true if the handler runs for a replayed delivery
+func IsReplay(ctx context.Context) bool {
+	replay, _ := ctx.Value(replayKey{}).(bool)
+	return replay
+}
+
+// Replay runs the handlers of a stored delivery again and waits for
+// them. If all of them succeed, the delivery leaves the dead-letter
+// bucket, otherwise it is kept there with the new error.
+func (s *Server) Replay(ctx context.Context, id string) error {
+	if s.options.Deliveries == nil {
+		return errors.New("the server does not store deliveries")
+	}
+	delivery, err := s.options.Deliveries.Get(ctx, id)
+	if err != nil {
+		return errors.Wrapf(err, "reading delivery %s", id)
+	}
+	log := s.options.Logger.WithFields(map[string]interface{}{"delivery": id, "event": delivery.Event})
+
+	event, err := s.options.GitHub.ParseWebhook(delivery.Payload, delivery.Event)
+	if err != nil {
+		s.markFailed(log, id, err)
+		return errors.Wrapf(err, "parsing delivery %s", id)
+	}
+	event.DeliveryID = id
+
+	ctx = context.WithValue(ctx, replayKey{}, true)
+	var failed error
+	for _, handler := range s.Handlers(event.Type, event.Action) {
+		hctx, cancel := context.WithTimeout(ctx, s.options.HandlerTimeout)
+		err := handler(hctx, event)
+		cancel()
+		if err != nil {
+			log.Errorf("Replaying %s event (%s): %v", event.Type, event.Action, err)
+			failed = err
+		}
+	}
+	if failed != nil {
+		s.markFailed(log, id, failed)
+		return errors.Wrapf(failed, "replaying delivery %s", id)
+	}
+	if err := s.options.Deliveries.ClearFailed(ctx, id); err != nil {
+		return errors.Wrapf(err, "clearing delivery %s from the dead-letter bucket", id)
+	}
+	log.Infof("Replayed delivery %s", id)
+	return nil
+}
+
+// FailedDelivery describes a delivery in the dead-letter bucket
+type FailedDelivery struct {
+	ID         string    `json:"id"`
+	Event      string    `json:"event"`
+	ReceivedAt time.Time `json:"received_at"`
+	Error      string    `json:"error"`
+}
+
+// ReplayAPI returns an HTTP API to manage the dead-letter bucket. It
+// serves GET /failed, listing the failed deliveries, and POST
+// /replay/{id}, replaying a delivery. Requests need the token as a
+// bearer token. Mount it out of reach of the public webhook endpoint.
+func (s *Server) ReplayAPI(token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/failed":
+			s.serveFailed(w, r)
+		case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/replay/"):
+			id := strings.TrimPrefix(r.URL.Path, "/replay/")
+			if err := s.Replay(r.Context(), id); err != nil {
+				status := http.StatusInternalServerError
+				if errors.Is(err, ErrDeliveryNotFound) {
+					status = http.StatusNotFound
+				}
+				http.Error(w, err.Error(), status)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// serveFailed writes the deliveries in the dead-letter bucket as JSON
+func (s *Server) serveFailed(w http.ResponseWriter, r *http.Request) {
+	if s.options.Deliveries == nil {
+		http.Error(w, "the server does not store deliveries", http.StatusNotFound)
+		return
+	}
+	deliveries, err := s.options.Deliveries.Failed(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	failed := []FailedDelivery{}
+	for _, d := range deliveries {
+		failed = append(failed, FailedDelivery{ID: d.ID, Event: d.Event, ReceivedAt: d.ReceivedAt, Error: d.Error})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(failed) // nolint: errcheck
+}
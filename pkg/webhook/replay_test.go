@@ -0,0 +1,81 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/puerco/mattermod-refactor/pkg/github"
+	"github.com/puerco/mattermod-refactor/pkg/github/githubtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplay(t *testing.T) {
+	store := NewMemoryDeliveryStore()
+	s, err := New(Options{Secret: []byte(testSecret), GitHub: githubtest.New().GitHub(nil), Deliveries: store})
+	require.Nil(t, err)
+
+	// The handler fails until the bug is fixed
+	fixed := false
+	replays := 0
+	s.Use(Dedup(time.Hour))
+	s.On("pull_request.labeled", func(ctx context.Context, event *github.WebhookEvent) error {
+		if IsReplay(ctx) {
+			replays++
+		}
+		if !fixed {
+			return errors.New("bug")
+		}
+		return nil
+	})
+
+	rec := deliver(s, "pull_request", testPullRequestPayload, testSecret)
+	s.Wait()
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	failed, err := store.Failed(context.Background())
+	require.Nil(t, err)
+	require.Len(t, failed, 1)
+	require.Equal(t, "bug", failed[0].Error)
+	id := failed[0].ID
+
+	api := s.ReplayAPI("token")
+	call := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		api.ServeHTTP(rec, req)
+		return rec
+	}
+	require.Equal(t, http.StatusUnauthorized, call(http.MethodGet, "/failed", "wrong").Code)
+
+	rec = call(http.MethodGet, "/failed", "token")
+	require.Equal(t, http.StatusOK, rec.Code)
+	listed := []FailedDelivery{}
+	require.Nil(t, json.Unmarshal(rec.Body.Bytes(), &listed))
+	require.Len(t, listed, 1)
+	require.Equal(t, id, listed[0].ID)
+
+	// Failed replays stay in the dead-letter bucket
+	require.Equal(t, http.StatusInternalServerError, call(http.MethodPost, "/replay/"+id, "token").Code)
+	failed, err = store.Failed(context.Background())
+	require.Nil(t, err)
+	require.Len(t, failed, 1)
+
+	// Replays are not deduplicated and leave the bucket when they work
+	fixed = true
+	require.Equal(t, http.StatusNoContent, call(http.MethodPost, "/replay/"+id, "token").Code)
+	require.Equal(t, 2, replays)
+	failed, err = store.Failed(context.Background())
+	require.Nil(t, err)
+	require.Empty(t, failed)
+
+	require.Equal(t, http.StatusNotFound, call(http.MethodPost, "/replay/missing", "token").Code)
+	require.Equal(t, http.StatusNotFound, call(http.MethodGet, "/other", "token").Code)
+}
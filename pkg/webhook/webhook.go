@@ -0,0 +1,202 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	gogithub "github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+	"github.com/puerco/mattermod-refactor/pkg/github"
+	"github.com/sirupsen/logrus"
+)
+
+// maxPayloadSize is the largest payload GitHub sends in webhook deliveries
+const maxPayloadSize = 25 << 20
+
+// Handler processes a webhook event. Errors are logged by the server.
+type Handler func(ctx context.Context, event *github.WebhookEvent) error
+
+// Server receives webhook deliveries from GitHub, checks their signature
+// and dispatches them to the handlers registered for the event and action.
+// It implements http.Handler, so it can be mounted in any HTTP server.
+type Server struct {
+	*Registry
+	options Options
+	running sync.WaitGroup
+}
+
+// Options configure the webhook server
+type Options struct {
+	// Secret is the secret set in the webhook on GitHub. Deliveries
+	// without a valid signature for it are rejected. Required.
+	Secret []byte
+
+	// GitHub parses the payloads. The objects in the events talk to
+	// the API with its options. When nil, the default options are used.
+	GitHub *github.GitHub
+
+	// HandlerTimeout limits the time each handler can take. Handlers run
+	// after GitHub gets its response, so they are not bound by the ten
+	// seconds GitHub waits for it. Defaults to five minutes.
+	HandlerTimeout time.Duration
+
+	// Deliveries stores the raw deliveries so they can be replayed, and
+	// the ones with failed handlers in its dead-letter bucket. Deliveries
+	// are not stored when nil.
+	Deliveries DeliveryStore
+
+	// Logger receives the log messages of the server. When nil, the
+	// standard logrus logger is used.
+	Logger github.Logger
+}
+
+// New returns a webhook server with no handlers
+func New(opts Options) (*Server, error) {
+	if len(opts.Secret) == 0 {
+		return nil, errors.New("a webhook secret is required to check the signature of deliveries")
+	}
+	if opts.GitHub == nil {
+		opts.GitHub = github.New()
+	}
+	if opts.HandlerTimeout == 0 {
+		opts.HandlerTimeout = 5 * time.Minute
+	}
+	if opts.Logger == nil {
+		opts.Logger = github.NewLogrusLogger(logrus.StandardLogger())
+	}
+	return &Server{Registry: NewRegistry(), options: opts}, nil
+}
+
+// Handle registers a handler for an event type, eg pull_request. When
+// action is empty, the handler receives the event for all actions.
+func (s *Server) Handle(event, action string, handler Handler) {
+	key := event
+	if action != "" {
+		key += "." + action
+	}
+	s.On(key, handler)
+}
+
+// ServeHTTP checks and parses a webhook delivery, answers GitHub and
+// runs the handlers of the event in the background
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "webhook deliveries must be POSTed", http.StatusMethodNotAllowed)
+		return
+	}
+	log := s.options.Logger.WithFields(map[string]interface{}{
+		"delivery": gogithub.DeliveryID(r),
+		"event":    gogithub.WebHookType(r),
+	})
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxPayloadSize)
+	payload, err := gogithub.ValidatePayload(r, s.options.Secret)
+	if err != nil {
+		log.Warnf("Rejecting webhook delivery: %v", err)
+		http.Error(w, "invalid webhook signature", http.StatusUnauthorized)
+		return
+	}
+
+	eventType := gogithub.WebHookType(r)
+	if eventType == "" {
+		http.Error(w, "missing event type", http.StatusBadRequest)
+		return
+	}
+	if eventType == "ping" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	deliveryID := gogithub.DeliveryID(r)
+	s.saveDelivery(r.Context(), log, &Delivery{
+		ID: deliveryID, Event: eventType, Payload: payload, ReceivedAt: time.Now(),
+	})
+
+	event, err := s.options.GitHub.ParseWebhook(payload, eventType)
+	if err != nil {
+		log.Warnf("Unable to parse webhook delivery: %v", err)
+		s.markFailed(log, deliveryID, err)
+		http.Error(w, "invalid webhook payload", http.StatusBadRequest)
+		return
+	}
+	event.DeliveryID = deliveryID
+
+	handlers := s.Handlers(event.Type, event.Action)
+	if len(handlers) == 0 {
+		log.Debugf("No handlers for %s event (%s)", event.Type, event.Action)
+	}
+	for _, handler := range handlers {
+		s.dispatch(log, handler, event)
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// dispatch runs a handler in the background with its own context,
+// as the one of the request ends when GitHub gets its response
+func (s *Server) dispatch(log github.Logger, handler Handler, event *github.WebhookEvent) {
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), s.options.HandlerTimeout)
+		defer cancel()
+		if err := handler(ctx, event); err != nil {
+			log.Errorf("Handling %s event (%s): %v", event.Type, event.Action, err)
+			s.markFailed(log, event.DeliveryID, err)
+		}
+	}()
+}
+
+// saveDelivery stores a delivery if there is a store. Deliveries
+// without an ID cannot be replayed and are not stored.
+func (s *Server) saveDelivery(ctx context.Context, log github.Logger, delivery *Delivery) {
+	if s.options.Deliveries == nil || delivery.ID == "" {
+		return
+	}
+	if err := s.options.Deliveries.Save(ctx, delivery); err != nil {
+		log.Errorf("Unable to store delivery: %v", err)
+	}
+}
+
+// markFailed moves a delivery to the dead-letter bucket
+func (s *Server) markFailed(log github.Logger, id string, reason error) {
+	if s.options.Deliveries == nil || id == "" {
+		return
+	}
+	if err := s.options.Deliveries.MarkFailed(context.Background(), id, reason.Error()); err != nil {
+		log.Errorf("Unable to move delivery to the dead-letter bucket: %v", err)
+	}
+}
+
+// Wait blocks until the handlers running finish
+func (s *Server) Wait() {
+	s.running.Wait()
+}
+
+// ListenAndServe receives webhooks on addr until ctx is canceled. The
+// server then stops accepting deliveries and waits for the handlers.
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	srv := &http.Server{Addr: addr, Handler: s, ReadHeaderTimeout: 10 * time.Second}
+	errc := make(chan error, 1)
+	go func() {
+		errc <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errc:
+		return errors.Wrap(err, "serving webhooks")
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return errors.Wrap(err, "shutting down webhook server")
+	}
+	s.Wait()
+	return nil
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2021-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/puerco/mattermod-refactor/pkg/github"
+	"github.com/puerco/mattermod-refactor/pkg/github/githubtest"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "s3cr3t"
+
+const testPullRequestPayload = `{
+	"action": "labeled",
+	"number": 1234,
+	"pull_request": {
+		"number": 1234, "state": "open",
+		"base": {"ref": "master", "repo": {"name": "mattermost-server", "owner": {"login": "mattermost"}}}
+	},
+	"sender": {"login": "alice"},
+	"repository": {"name": "mattermost-server", "owner": {"login": "mattermost"}}
+}`
+
+// deliver sends a webhook delivery to the server, signed with secret
+func deliver(s *Server, eventType, payload, secret string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/hooks", strings.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Event", eventType)
+	req.Header.Set("X-GitHub-Delivery", "72d3162e-cc78-11e3-81ab-4c9367dc0958")
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestServer(t *testing.T) {
+	_, err := New(Options{})
+	require.NotNil(t, err)
+
+	s, err := New(Options{Secret: []byte(testSecret), GitHub: githubtest.New().GitHub(nil)})
+	require.Nil(t, err)
+
+	var mtx sync.Mutex
+	calls := []string{}
+	var received *github.WebhookEvent
+	record := func(name string) Handler {
+		return func(ctx context.Context, event *github.WebhookEvent) error {
+			mtx.Lock()
+			defer mtx.Unlock()
+			calls = append(calls, name)
+			received = event
+			return nil
+		}
+	}
+	s.Handle("pull_request", "", record("all"))
+	s.Handle("pull_request", "labeled", record("labeled"))
+	s.Handle("pull_request", "closed", record("closed"))
+	s.Handle("issues", "", record("issues"))
+	s.Handle("pull_request", "labeled", func(ctx context.Context, event *github.WebhookEvent) error {
+		return errors.New("handler errors are only logged")
+	})
+
+	// Only the handlers of the event action are called
+	rec := deliver(s, "pull_request", testPullRequestPayload, testSecret)
+	s.Wait()
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	require.ElementsMatch(t, []string{"all", "labeled"}, calls)
+	require.Equal(t, 1234, received.PullRequest.Number)
+	require.Equal(t, "alice", received.Sender)
+
+	// Deliveries with a bad signature are rejected
+	calls = []string{}
+	rec = deliver(s, "pull_request", testPullRequestPayload, "wrong")
+	s.Wait()
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	require.Empty(t, calls)
+
+	// Pings are answered without handlers
+	rec = deliver(s, "ping", `{"zen": "Keep it logically awesome."}`, testSecret)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	// Invalid payloads are rejected
+	rec = deliver(s, "pull_request", `{"action": "opened", "pull_request": {"number": 1}}`, testSecret)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/hooks", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}